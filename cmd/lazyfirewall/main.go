@@ -10,11 +10,18 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
+	"lazyfirewall/internal/cli"
 	"lazyfirewall/internal/config"
 	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/keymap"
 	"lazyfirewall/internal/logger"
+	"lazyfirewall/internal/offline"
+	"lazyfirewall/internal/templates"
+	"lazyfirewall/internal/theme"
 	"lazyfirewall/internal/ui"
 	"lazyfirewall/internal/version"
 )
@@ -24,14 +31,33 @@ func main() {
 	var showVersion bool
 	var logLevel string
 	var noColor bool
+	var offlineMode bool
+	var offlineRoot string
 	flag.BoolVar(&dryRun, "dry-run", false, "show changes without applying")
 	flag.BoolVar(&dryRun, "n", false, "alias for --dry-run")
 	flag.BoolVar(&showVersion, "version", false, "print version and exit")
 	flag.BoolVar(&showVersion, "v", false, "alias for --version")
 	flag.StringVar(&logLevel, "log-level", "", "set log level (debug|info|warn|error)")
 	flag.BoolVar(&noColor, "no-color", false, "disable color output")
+	flag.BoolVar(&offlineMode, "offline", false, "edit a firewalld config directory without a running daemon")
+	flag.StringVar(&offlineRoot, "root", "/etc/firewalld", "config directory for --offline")
 	flag.Parse()
 
+	rootSet := false
+	flag.Visit(func(f *flag.Flag) {
+		rootSet = rootSet || f.Name == "root"
+	})
+	if rootSet && !offlineMode {
+		fmt.Fprintln(os.Stderr, "Error: --root requires --offline")
+		os.Exit(2)
+	}
+
+	if flag.Arg(0) == "config" {
+		// Runs before the config is loaded so it can report what is wrong
+		// with it.
+		os.Exit(cli.Run(flag.Args(), cli.Options{Stdout: os.Stdout, Stderr: os.Stderr}))
+	}
+
 	cfg, warnings, configPath, configFound, err := config.Load()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -58,11 +84,41 @@ func main() {
 		return
 	}
 
-	client, err := firewalld.NewClient()
+	connect := connectFirewalld
+	if offlineMode {
+		connect = func() (firewalld.Backend, error) {
+			return openOffline(offlineRoot)
+		}
+	}
+
+	if flag.NArg() > 0 {
+		os.Exit(cli.Run(flag.Args(), cli.Options{
+			DryRun:  dryRun,
+			Offline: offlineMode,
+			Stdout:  os.Stdout,
+			Stderr:  os.Stderr,
+			Connect: connect,
+		}))
+	}
+
+	// Conflicting keys would leave an action unreachable; refuse to start.
+	keys, err := keymap.New(cfg.KeyBindings())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
-		fmt.Fprintln(os.Stderr, "Make sure firewalld is running:")
-		fmt.Fprintln(os.Stderr, "  sudo systemctl start firewalld")
+		fmt.Fprintf(os.Stderr, "Error: invalid [keys] in %s:\n", configPath)
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "  %s\n", line)
+		}
+		os.Exit(2)
+	}
+
+	client, err := connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if !offlineMode {
+			fmt.Fprintln(os.Stderr, "\nMake sure firewalld is running:")
+			fmt.Fprintln(os.Stderr, "  sudo systemctl start firewalld")
+			fmt.Fprintln(os.Stderr, "or edit its configuration directly with --offline [--root DIR].")
+		}
 		os.Exit(1)
 	}
 	defer func() {
@@ -77,7 +133,15 @@ func main() {
 		DryRun:           dryRun,
 		NoColor:          noColor,
 		DefaultPermanent: cfg.Behavior.DefaultPermanent,
+		ConfirmTimeout:   time.Duration(cfg.Behavior.ConfirmTimeoutSeconds) * time.Second,
+		AutoRefresh:      time.Duration(cfg.Behavior.AutoRefreshSeconds) * time.Second,
+		Keymap:           keys,
 	}
+	if b, ok := client.(*offline.Backend); ok {
+		opts.OfflineRoot = b.Root()
+	}
+	opts.Theme = loadTheme(cfg, configPath)
+	opts.Templates = loadTemplates(cfg, configPath)
 	if err := ui.RunWithContext(ctx, client, opts); err != nil {
 		if err == context.Canceled {
 			return
@@ -86,3 +150,44 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// loadTheme resolves the configured theme, looking for user themes in the
+// config file and next to it. Problems are logged and the default theme is
+// used.
+func loadTheme(cfg config.Config, configPath string) theme.Theme {
+	t, err := cfg.Theme(configPath)
+	if err != nil {
+		logger.WarnConfig(fmt.Sprintf("ui.theme: %v; using default", err))
+		t, _ = theme.Builtin(theme.Default)
+	}
+	return t
+}
+
+// loadTemplates returns the built-in templates merged with those of the
+// config file and the template files next to it, which win in that order.
+// Files that do not load are logged and skipped.
+func loadTemplates(cfg config.Config, configPath string) []templates.Template {
+	files, errs := templates.LoadDir(templates.Dir(configPath))
+	for _, err := range errs {
+		logger.WarnConfig(err.Error())
+	}
+	return templates.Merge(templates.Builtin(), cfg.Templates, files)
+}
+
+// connectFirewalld adapts firewalld.NewClient to the backend the command line
+// expects, keeping a failed connection a nil interface.
+func connectFirewalld() (firewalld.Backend, error) {
+	client, err := firewalld.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func openOffline(root string) (firewalld.Backend, error) {
+	b, err := offline.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
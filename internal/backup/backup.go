@@ -214,6 +214,49 @@ func RestoreZoneBackup(zone string, b Backup) error {
 	return nil
 }
 
+// RestoreZoneBackupAndReload restores b, reloads firewalld, and puts the
+// previous zone file back if the reload fails.
+func RestoreZoneBackupAndReload(zone string, b Backup, reload func() error) error {
+	if err := validation.IsValidZoneName(zone); err != nil {
+		return fmt.Errorf("invalid zone name: %w", err)
+	}
+
+	slog.Info("restoring backup", "zone", zone, "backup", b.Path)
+	if err := RestoreZoneBackup(zone, b); err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+	if err := reload(); err != nil {
+		slog.Error("reload failed after restore, attempting rollback", "zone", zone, "error", err)
+
+		preRestorePath, pathErr := GetPreRestoreBackupPath(zone)
+		if pathErr != nil {
+			return fmt.Errorf("restore failed and rollback path lookup failed: %w (path error: %v)", err, pathErr)
+		}
+		if preRestorePath == "" {
+			return fmt.Errorf("restore failed: %w", err)
+		}
+
+		destPath, destErr := ZoneDestinationPath(zone)
+		if destErr != nil {
+			return fmt.Errorf("restore failed and rollback destination failed: %w (destination error: %v)", err, destErr)
+		}
+		if rollbackErr := CopyFile(preRestorePath, destPath); rollbackErr != nil {
+			slog.Error("critical rollback failure", "zone", zone, "error", rollbackErr)
+			return fmt.Errorf("restore failed and rollback failed: %w (rollback: %v)", err, rollbackErr)
+		}
+
+		if reloadErr := reload(); reloadErr != nil {
+			slog.Error("reload failed after rollback", "zone", zone, "error", reloadErr)
+		}
+		return fmt.Errorf("restore failed, previous state restored: %w", err)
+	}
+
+	if cleanupErr := CleanupPreRestoreBackup(zone); cleanupErr != nil {
+		slog.Warn("failed to cleanup pre-restore backup", "zone", zone, "error", cleanupErr)
+	}
+	return nil
+}
+
 func zoneFilePath(zone string) (string, error) {
 	if err := validation.IsValidZoneName(zone); err != nil {
 		return "", fmt.Errorf("invalid zone name: %w", err)
@@ -4,6 +4,7 @@
 package backup
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -115,6 +116,68 @@ func TestRestoreZoneBackup_Transactional(t *testing.T) {
 	}
 }
 
+func TestRestoreZoneBackupAndReload(t *testing.T) {
+	setup := func(t *testing.T) (string, Backup) {
+		t.Helper()
+		tempDir := t.TempDir()
+		withZoneDirs(t, filepath.Join(tempDir, "etc-zones"), filepath.Join(tempDir, "usr-zones"))
+		if err := os.MkdirAll(zoneConfigDir, 0o755); err != nil {
+			t.Fatalf("mkdir zone dir: %v", err)
+		}
+		dest := filepath.Join(zoneConfigDir, "public.xml")
+		if err := os.WriteFile(dest, []byte("old"), 0o644); err != nil {
+			t.Fatalf("write old zone file: %v", err)
+		}
+		backupPath := filepath.Join(tempDir, "backup.xml")
+		if err := os.WriteFile(backupPath, []byte("new"), 0o644); err != nil {
+			t.Fatalf("write backup file: %v", err)
+		}
+		return dest, Backup{Path: backupPath}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		dest, b := setup(t)
+		reloads := 0
+		if err := RestoreZoneBackupAndReload("public", b, func() error { reloads++; return nil }); err != nil {
+			t.Fatalf("RestoreZoneBackupAndReload() error = %v", err)
+		}
+		got, _ := os.ReadFile(dest)
+		if string(got) != "new" || reloads != 1 {
+			t.Fatalf("content = %q, reloads = %d, want new, 1", got, reloads)
+		}
+		if path, _ := GetPreRestoreBackupPath("public"); path != "" {
+			t.Fatalf("pre-restore backup not cleaned up: %s", path)
+		}
+	})
+
+	t.Run("reload failure rolls back", func(t *testing.T) {
+		dest, b := setup(t)
+		reloadErr := errors.New("reload failed")
+		reloads := 0
+		err := RestoreZoneBackupAndReload("public", b, func() error {
+			reloads++
+			if reloads == 1 {
+				return reloadErr
+			}
+			return nil
+		})
+		if !errors.Is(err, reloadErr) {
+			t.Fatalf("RestoreZoneBackupAndReload() error = %v, want %v", err, reloadErr)
+		}
+		got, _ := os.ReadFile(dest)
+		if string(got) != "old" || reloads != 2 {
+			t.Fatalf("content = %q, reloads = %d, want old, 2", got, reloads)
+		}
+	})
+
+	t.Run("invalid zone", func(t *testing.T) {
+		err := RestoreZoneBackupAndReload("../bad", Backup{Path: "/tmp/x.xml"}, func() error { return nil })
+		if err == nil {
+			t.Fatalf("expected validation error for invalid zone")
+		}
+	})
+}
+
 func TestListBackups_SortedAndDecodedDescription(t *testing.T) {
 	tempDir := t.TempDir()
 	oldHome := os.Getenv("HOME")
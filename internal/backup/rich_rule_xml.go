@@ -0,0 +1,254 @@
+//go:build linux
+// +build linux
+
+package backup
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"lazyfirewall/internal/richrule"
+)
+
+type ruleXML struct {
+	Family      string          `xml:"family,attr,omitempty"`
+	Priority    string          `xml:"priority,attr,omitempty"`
+	Source      *ruleAddrXML    `xml:"source"`
+	Destination *ruleAddrXML    `xml:"destination"`
+	Service     *serviceXML     `xml:"service"`
+	Port        *portXML        `xml:"port"`
+	Protocol    *protocolXML    `xml:"protocol"`
+	IcmpBlock   *icmpXML        `xml:"icmp-block"`
+	IcmpType    *icmpXML        `xml:"icmp-type"`
+	Masquerade  *struct{}       `xml:"masquerade"`
+	ForwardPort *forwardPortXML `xml:"forward-port"`
+	SourcePort  *portXML        `xml:"source-port"`
+	TcpMssClamp *tcpMssClampXML `xml:"tcp-mss-clamp"`
+	Log         *ruleLogXML     `xml:"log"`
+	NFLog       *ruleNFLogXML   `xml:"nflog"`
+	Audit       *ruleLimitXML   `xml:"audit"`
+	Accept      *ruleLimitXML   `xml:"accept"`
+	Reject      *ruleRejectXML  `xml:"reject"`
+	Drop        *ruleLimitXML   `xml:"drop"`
+	Mark        *ruleMarkXML    `xml:"mark"`
+
+	Unknown      []unknownXML `xml:",any"`
+	UnknownAttrs []xml.Attr   `xml:",any,attr"`
+}
+
+type tcpMssClampXML struct {
+	Value string `xml:"value,attr,omitempty"`
+}
+
+type ruleAddrXML struct {
+	Address string `xml:"address,attr,omitempty"`
+	Mac     string `xml:"mac,attr,omitempty"`
+	IPSet   string `xml:"ipset,attr,omitempty"`
+	Invert  string `xml:"invert,attr,omitempty"`
+}
+
+type limitXML struct {
+	Value string `xml:"value,attr"`
+	Burst string `xml:"burst,attr,omitempty"`
+}
+
+type ruleLimitXML struct {
+	Limit *limitXML `xml:"limit"`
+}
+
+type ruleLogXML struct {
+	Prefix string    `xml:"prefix,attr,omitempty"`
+	Level  string    `xml:"level,attr,omitempty"`
+	Limit  *limitXML `xml:"limit"`
+}
+
+type ruleNFLogXML struct {
+	Group     string    `xml:"group,attr,omitempty"`
+	Prefix    string    `xml:"prefix,attr,omitempty"`
+	QueueSize string    `xml:"queue-size,attr,omitempty"`
+	Limit     *limitXML `xml:"limit"`
+}
+
+type ruleRejectXML struct {
+	Type  string    `xml:"type,attr,omitempty"`
+	Limit *limitXML `xml:"limit"`
+}
+
+type ruleMarkXML struct {
+	Set   string    `xml:"set,attr"`
+	Limit *limitXML `xml:"limit"`
+}
+
+// ruleXMLFromString converts a rich rule in firewalld's rule language into
+// the structured <rule> element used by zone XML files.
+func ruleXMLFromString(rule string) (ruleXML, error) {
+	r, err := richrule.Parse(rule)
+	if err != nil {
+		return ruleXML{}, err
+	}
+	return ruleXMLFromRule(r), nil
+}
+
+func ruleXMLFromRule(r *richrule.Rule) ruleXML {
+	rx := ruleXML{
+		Family:      r.Family,
+		Source:      addrXMLFromRule(r.Source),
+		Destination: addrXMLFromRule(r.Destination),
+	}
+	if r.Priority != 0 {
+		rx.Priority = strconv.Itoa(r.Priority)
+	}
+	if e := r.Element; e != nil {
+		switch e.Kind {
+		case "service":
+			rx.Service = &serviceXML{Name: e.Name}
+		case "port":
+			rx.Port = &portXML{Port: e.Port, Protocol: e.Protocol}
+		case "protocol":
+			rx.Protocol = &protocolXML{Value: e.Protocol}
+		case "icmp-block":
+			rx.IcmpBlock = &icmpXML{Name: e.Name}
+		case "icmp-type":
+			rx.IcmpType = &icmpXML{Name: e.Name}
+		case "masquerade":
+			rx.Masquerade = &struct{}{}
+		case "forward-port":
+			rx.ForwardPort = &forwardPortXML{Port: e.Port, Protocol: e.Protocol, ToPort: e.ToPort, ToAddr: e.ToAddr}
+		case "source-port":
+			rx.SourcePort = &portXML{Port: e.Port, Protocol: e.Protocol}
+		case "tcp-mss-clamp":
+			rx.TcpMssClamp = &tcpMssClampXML{Value: e.Value}
+		}
+	}
+	if l := r.Log; l != nil {
+		if l.NFLog {
+			rx.NFLog = &ruleNFLogXML{Group: l.Group, Prefix: l.Prefix, QueueSize: l.QueueSize, Limit: limitXMLFromRule(l.Limit)}
+		} else {
+			rx.Log = &ruleLogXML{Prefix: l.Prefix, Level: l.Level, Limit: limitXMLFromRule(l.Limit)}
+		}
+	}
+	if r.Audit != nil {
+		rx.Audit = &ruleLimitXML{Limit: limitXMLFromRule(r.Audit.Limit)}
+	}
+	if a := r.Action; a != nil {
+		limit := limitXMLFromRule(a.Limit)
+		switch a.Kind {
+		case "accept":
+			rx.Accept = &ruleLimitXML{Limit: limit}
+		case "reject":
+			rx.Reject = &ruleRejectXML{Type: a.Type, Limit: limit}
+		case "drop":
+			rx.Drop = &ruleLimitXML{Limit: limit}
+		case "mark":
+			rx.Mark = &ruleMarkXML{Set: a.Set, Limit: limit}
+		}
+	}
+	return rx
+}
+
+func addrXMLFromRule(a *richrule.Address) *ruleAddrXML {
+	if a == nil {
+		return nil
+	}
+	addr := &ruleAddrXML{Address: a.Address, Mac: a.MAC, IPSet: a.IPSet}
+	if a.Invert {
+		addr.Invert = "True"
+	}
+	return addr
+}
+
+func limitXMLFromRule(l *richrule.Limit) *limitXML {
+	if l == nil {
+		return nil
+	}
+	limit := &limitXML{Value: l.Value}
+	if l.Burst > 0 {
+		limit.Burst = strconv.Itoa(l.Burst)
+	}
+	return limit
+}
+
+// String renders the rule in firewalld's rich rule language. A <rule> with
+// several elements or actions keeps only the first of each;
+// CheckZoneXMLRoundTrip reports what that drops.
+func (rx ruleXML) String() string {
+	return rx.rule().String()
+}
+
+func (rx ruleXML) rule() *richrule.Rule {
+	r := &richrule.Rule{
+		Family:      rx.Family,
+		Source:      rx.Source.rule(),
+		Destination: rx.Destination.rule(),
+	}
+	r.Priority, _ = strconv.Atoi(rx.Priority)
+
+	switch {
+	case rx.Service != nil:
+		r.Element = &richrule.Element{Kind: "service", Name: rx.Service.Name}
+	case rx.Port != nil:
+		r.Element = &richrule.Element{Kind: "port", Port: rx.Port.Port, Protocol: rx.Port.Protocol}
+	case rx.Protocol != nil:
+		r.Element = &richrule.Element{Kind: "protocol", Protocol: rx.Protocol.Value}
+	case rx.IcmpBlock != nil:
+		r.Element = &richrule.Element{Kind: "icmp-block", Name: rx.IcmpBlock.Name}
+	case rx.IcmpType != nil:
+		r.Element = &richrule.Element{Kind: "icmp-type", Name: rx.IcmpType.Name}
+	case rx.Masquerade != nil:
+		r.Element = &richrule.Element{Kind: "masquerade"}
+	case rx.ForwardPort != nil:
+		fp := rx.ForwardPort
+		r.Element = &richrule.Element{Kind: "forward-port", Port: fp.Port, Protocol: fp.Protocol, ToPort: fp.ToPort, ToAddr: fp.ToAddr}
+	case rx.SourcePort != nil:
+		r.Element = &richrule.Element{Kind: "source-port", Port: rx.SourcePort.Port, Protocol: rx.SourcePort.Protocol}
+	case rx.TcpMssClamp != nil:
+		r.Element = &richrule.Element{Kind: "tcp-mss-clamp", Value: rx.TcpMssClamp.Value}
+	}
+
+	switch {
+	case rx.Log != nil:
+		r.Log = &richrule.Log{Prefix: rx.Log.Prefix, Level: rx.Log.Level, Limit: rx.Log.Limit.rule()}
+	case rx.NFLog != nil:
+		r.Log = &richrule.Log{NFLog: true, Group: rx.NFLog.Group, Prefix: rx.NFLog.Prefix, QueueSize: rx.NFLog.QueueSize, Limit: rx.NFLog.Limit.rule()}
+	}
+	if rx.Audit != nil {
+		r.Audit = &richrule.Audit{Limit: rx.Audit.Limit.rule()}
+	}
+
+	switch {
+	case rx.Accept != nil:
+		r.Action = &richrule.Action{Kind: "accept", Limit: rx.Accept.Limit.rule()}
+	case rx.Reject != nil:
+		r.Action = &richrule.Action{Kind: "reject", Type: rx.Reject.Type, Limit: rx.Reject.Limit.rule()}
+	case rx.Drop != nil:
+		r.Action = &richrule.Action{Kind: "drop", Limit: rx.Drop.Limit.rule()}
+	case rx.Mark != nil:
+		r.Action = &richrule.Action{Kind: "mark", Set: rx.Mark.Set, Limit: rx.Mark.Limit.rule()}
+	}
+	return r
+}
+
+func (a *ruleAddrXML) rule() *richrule.Address {
+	if a == nil {
+		return nil
+	}
+	return &richrule.Address{Invert: isXMLTrue(a.Invert), Address: a.Address, MAC: a.Mac, IPSet: a.IPSet}
+}
+
+func (l *limitXML) rule() *richrule.Limit {
+	if l == nil {
+		return nil
+	}
+	burst, _ := strconv.Atoi(l.Burst)
+	return &richrule.Limit{Value: l.Value, Burst: burst}
+}
+
+func isXMLTrue(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "1":
+		return true
+	default:
+		return false
+	}
+}
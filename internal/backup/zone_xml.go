@@ -7,8 +7,10 @@ import (
 	"bytes"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -23,17 +25,57 @@ const (
 )
 
 type zoneXML struct {
-	XMLName            xml.Name     `xml:"zone"`
-	Target             string       `xml:"target,attr"`
-	Short              string       `xml:"short"`
-	Description        string       `xml:"description"`
-	Services           []serviceXML `xml:"service"`
-	Ports              []portXML    `xml:"port"`
-	Interfaces         []ifaceXML   `xml:"interface"`
-	Sources            []sourceXML  `xml:"source"`
-	IcmpBlocks         []icmpXML    `xml:"icmp-block"`
-	IcmpBlockInversion *struct{}    `xml:"icmp-block-inversion"`
-	Masquerade         *struct{}    `xml:"masquerade"`
+	XMLName            xml.Name         `xml:"zone"`
+	Version            string           `xml:"version,attr,omitempty"`
+	Target             string           `xml:"target,attr"`
+	IngressPriority    int              `xml:"ingress-priority,attr,omitempty"`
+	EgressPriority     int              `xml:"egress-priority,attr,omitempty"`
+	Short              string           `xml:"short"`
+	Description        string           `xml:"description"`
+	Services           []serviceXML     `xml:"service"`
+	Ports              []portXML        `xml:"port"`
+	Protocols          []protocolXML    `xml:"protocol"`
+	Interfaces         []ifaceXML       `xml:"interface"`
+	Sources            []sourceXML      `xml:"source"`
+	IcmpBlocks         []icmpXML        `xml:"icmp-block"`
+	IcmpBlockInversion *struct{}        `xml:"icmp-block-inversion"`
+	Forward            *struct{}        `xml:"forward"`
+	Masquerade         *struct{}        `xml:"masquerade"`
+	ForwardPorts       []forwardPortXML `xml:"forward-port"`
+	SourcePorts        []portXML        `xml:"source-port"`
+	Rules              []ruleXML        `xml:"rule"`
+	Unknown            []unknownXML     `xml:",any"`
+	UnknownAttrs       []xml.Attr       `xml:",any,attr"`
+}
+
+// unknownXML catches elements the codec does not model, so parsing can
+// refuse a file instead of silently dropping part of it.
+type unknownXML struct {
+	XMLName xml.Name
+}
+
+// unsupported reports the first element or attribute of the zone or its
+// rules that the codec would drop.
+func (zx zoneXML) unsupported() error {
+	if err := unsupportedXML("zone", zx.Unknown, zx.UnknownAttrs); err != nil {
+		return err
+	}
+	for _, r := range zx.Rules {
+		if err := unsupportedXML("rule", r.Unknown, r.UnknownAttrs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unsupportedXML(parent string, elements []unknownXML, attrs []xml.Attr) error {
+	if len(elements) > 0 {
+		return fmt.Errorf("unsupported element <%s> in <%s>", elements[0].XMLName.Local, parent)
+	}
+	if len(attrs) > 0 {
+		return fmt.Errorf("unsupported attribute %s= on <%s>", attrs[0].Name.Local, parent)
+	}
+	return nil
 }
 
 type serviceXML struct {
@@ -45,14 +87,25 @@ type portXML struct {
 	Protocol string `xml:"protocol,attr"`
 }
 
+type protocolXML struct {
+	Value string `xml:"value,attr"`
+}
+
+type forwardPortXML struct {
+	Port     string `xml:"port,attr"`
+	Protocol string `xml:"protocol,attr"`
+	ToPort   string `xml:"to-port,attr,omitempty"`
+	ToAddr   string `xml:"to-addr,attr,omitempty"`
+}
+
 type ifaceXML struct {
 	Name string `xml:"name,attr"`
 }
 
 type sourceXML struct {
-	Address string `xml:"address,attr"`
-	Mac     string `xml:"mac,attr"`
-	IPSet   string `xml:"ipset,attr"`
+	Address string `xml:"address,attr,omitempty"`
+	Mac     string `xml:"mac,attr,omitempty"`
+	IPSet   string `xml:"ipset,attr,omitempty"`
 }
 
 type icmpXML struct {
@@ -87,13 +140,20 @@ func ParseZoneXML(data []byte) (*firewalld.Zone, error) {
 	if err := decoder.Decode(&zx); err != nil {
 		return nil, fmt.Errorf("failed to parse zone XML: %w", err)
 	}
+	if err := zx.unsupported(); err != nil {
+		return nil, fmt.Errorf("failed to parse zone XML: %w", err)
+	}
 
 	z := &firewalld.Zone{
-		Target:      zx.Target,
-		Short:       zx.Short,
-		Description: zx.Description,
-		Masquerade:  zx.Masquerade != nil,
-		IcmpInvert:  zx.IcmpBlockInversion != nil,
+		Target:          zx.Target,
+		Short:           zx.Short,
+		Description:     zx.Description,
+		Masquerade:      zx.Masquerade != nil,
+		Forward:         zx.Forward != nil,
+		IcmpInvert:      zx.IcmpBlockInversion != nil,
+		Version:         zx.Version,
+		IngressPriority: zx.IngressPriority,
+		EgressPriority:  zx.EgressPriority,
 	}
 
 	for _, s := range zx.Services {
@@ -106,6 +166,29 @@ func ParseZoneXML(data []byte) (*firewalld.Zone, error) {
 			z.Ports = append(z.Ports, firewalld.Port{Port: p.Port, Protocol: p.Protocol})
 		}
 	}
+	for _, p := range zx.Protocols {
+		if p.Value != "" {
+			z.Protocols = append(z.Protocols, p.Value)
+		}
+	}
+	for _, p := range zx.SourcePorts {
+		if p.Port != "" && p.Protocol != "" {
+			z.SourcePorts = append(z.SourcePorts, firewalld.Port{Port: p.Port, Protocol: p.Protocol})
+		}
+	}
+	for _, fp := range zx.ForwardPorts {
+		if fp.Port != "" && fp.Protocol != "" {
+			z.ForwardPorts = append(z.ForwardPorts, firewalld.ForwardPort{
+				Port:     fp.Port,
+				Protocol: fp.Protocol,
+				ToPort:   fp.ToPort,
+				ToAddr:   fp.ToAddr,
+			})
+		}
+	}
+	for _, r := range zx.Rules {
+		z.RichRules = append(z.RichRules, r.String())
+	}
 	for _, i := range zx.Interfaces {
 		if i.Name != "" {
 			z.Interfaces = append(z.Interfaces, i.Name)
@@ -134,13 +217,19 @@ func MarshalZoneXML(z *firewalld.Zone) ([]byte, error) {
 		return nil, fmt.Errorf("zone is nil")
 	}
 	zx := zoneXML{
-		Target:      z.Target,
-		Short:       z.Short,
-		Description: z.Description,
+		Version:         z.Version,
+		Target:          z.Target,
+		IngressPriority: z.IngressPriority,
+		EgressPriority:  z.EgressPriority,
+		Short:           z.Short,
+		Description:     z.Description,
 	}
 	if z.Masquerade {
 		zx.Masquerade = &struct{}{}
 	}
+	if z.Forward {
+		zx.Forward = &struct{}{}
+	}
 	if z.IcmpInvert {
 		zx.IcmpBlockInversion = &struct{}{}
 	}
@@ -154,6 +243,36 @@ func MarshalZoneXML(z *firewalld.Zone) ([]byte, error) {
 			zx.Ports = append(zx.Ports, portXML{Port: p.Port, Protocol: p.Protocol})
 		}
 	}
+	for _, p := range z.Protocols {
+		if p != "" {
+			zx.Protocols = append(zx.Protocols, protocolXML{Value: p})
+		}
+	}
+	for _, p := range z.SourcePorts {
+		if p.Port != "" && p.Protocol != "" {
+			zx.SourcePorts = append(zx.SourcePorts, portXML{Port: p.Port, Protocol: p.Protocol})
+		}
+	}
+	for _, fp := range z.ForwardPorts {
+		if fp.Port != "" && fp.Protocol != "" {
+			zx.ForwardPorts = append(zx.ForwardPorts, forwardPortXML{
+				Port:     fp.Port,
+				Protocol: fp.Protocol,
+				ToPort:   fp.ToPort,
+				ToAddr:   fp.ToAddr,
+			})
+		}
+	}
+	for _, r := range z.RichRules {
+		if strings.TrimSpace(r) == "" {
+			continue
+		}
+		rx, err := ruleXMLFromString(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rich rule %q: %w", r, err)
+		}
+		zx.Rules = append(zx.Rules, rx)
+	}
 	for _, i := range z.Interfaces {
 		if i != "" {
 			zx.Interfaces = append(zx.Interfaces, ifaceXML{Name: i})
@@ -212,3 +331,130 @@ func WriteZoneXMLFile(zone string, z *firewalld.Zone) (string, error) {
 	}
 	return dest, nil
 }
+
+// CheckZoneXMLRoundTrip reports an error unless data survives ParseZoneXML
+// and MarshalZoneXML unchanged. Formatting, comments, attribute order, empty
+// attributes and the order of differently named elements are ignored.
+// Callers that rewrite a zone file use it to refuse edits that would lose
+// content the codec does not model.
+func CheckZoneXMLRoundTrip(data []byte) error {
+	z, err := ParseZoneXML(data)
+	if err != nil {
+		return err
+	}
+	out, err := MarshalZoneXML(z)
+	if err != nil {
+		return err
+	}
+	want, err := parseXMLTree(data)
+	if err != nil {
+		return err
+	}
+	got, err := parseXMLTree(out)
+	if err != nil {
+		return err
+	}
+	if want.canonical() == got.canonical() {
+		return nil
+	}
+	if want.attrString() != got.attrString() {
+		return fmt.Errorf("zone XML does not round-trip: <%s> attributes would change", want.name)
+	}
+	for _, child := range want.children {
+		if child.emptyText() {
+			continue
+		}
+		if !slices.ContainsFunc(got.children, func(n *xmlNode) bool { return n.canonical() == child.canonical() }) {
+			return fmt.Errorf("zone XML does not round-trip: %s would change", child.canonical())
+		}
+	}
+	return fmt.Errorf("zone XML does not round-trip")
+}
+
+// xmlNode is a generic element tree used to compare documents.
+type xmlNode struct {
+	name     string
+	attrs    []string
+	text     string
+	children []*xmlNode
+}
+
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+	var root *xmlNode
+	var stack []*xmlNode
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse zone XML: %w", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: tok.Name.Local}
+			for _, a := range tok.Attr {
+				if a.Value != "" {
+					n.attrs = append(n.attrs, a.Name.Local+"="+strconv.Quote(a.Value))
+				}
+			}
+			slices.Sort(n.attrs)
+			if len(stack) == 0 {
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(tok)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("failed to parse zone XML: no root element")
+	}
+	return root, nil
+}
+
+// emptyText reports an empty <short> or <description>, which the codec
+// treats as absent.
+func (n *xmlNode) emptyText() bool {
+	return (n.name == "short" || n.name == "description") &&
+		len(n.attrs) == 0 && len(n.children) == 0 && strings.TrimSpace(n.text) == ""
+}
+
+func (n *xmlNode) attrString() string {
+	return strings.Join(n.attrs, " ")
+}
+
+// canonical renders n with its children stably sorted by name, so elements
+// of the same kind keep their relative order.
+func (n *xmlNode) canonical() string {
+	var b strings.Builder
+	b.WriteString("<" + n.name)
+	if len(n.attrs) > 0 {
+		b.WriteString(" " + n.attrString())
+	}
+	b.WriteString(">")
+	if text := strings.TrimSpace(n.text); text != "" {
+		b.WriteString(text)
+	}
+	children := slices.Clone(n.children)
+	slices.SortStableFunc(children, func(a, b *xmlNode) int {
+		return strings.Compare(a.name, b.name)
+	})
+	for _, c := range children {
+		if c.emptyText() {
+			continue
+		}
+		b.WriteString(c.canonical())
+	}
+	b.WriteString("</" + n.name + ">")
+	return b.String()
+}
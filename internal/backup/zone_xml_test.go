@@ -160,3 +160,208 @@ func TestMarshalAndParseZoneXML_RoundTrip(t *testing.T) {
 		t.Fatalf("boolean fields mismatch: parsed masquerade=%v invert=%v", parsed.Masquerade, parsed.IcmpInvert)
 	}
 }
+
+func TestMarshalAndParseZoneXML_RoundTripForwarding(t *testing.T) {
+	orig := &firewalld.Zone{
+		Protocols:   []string{"gre", "icmp"},
+		SourcePorts: []firewalld.Port{{Port: "1024-2048", Protocol: "udp"}},
+		ForwardPorts: []firewalld.ForwardPort{
+			{Port: "80", Protocol: "tcp", ToPort: "8080"},
+			{Port: "443", Protocol: "tcp", ToPort: "8443", ToAddr: "192.168.1.10"},
+		},
+		RichRules: []string{
+			`rule family="ipv4" source address="10.0.0.0/8" service name="ssh" accept`,
+			`rule family="ipv6" source NOT address="fd00::/8" port port="8080" protocol="tcp" log prefix="web" level="info" limit value="1/m" reject type="icmp6-adm-prohibited"`,
+			`rule priority="-10" source ipset="blocklist" drop`,
+			`rule family="ipv4" forward-port port="22" protocol="tcp" to-port="2222" to-addr="10.0.0.5"`,
+			`rule protocol value="icmp" audit limit value="3/s" drop`,
+			`rule family="ipv4" source address="192.168.0.0/16" masquerade`,
+		},
+		Forward: true,
+	}
+
+	data, err := MarshalZoneXML(orig)
+	if err != nil {
+		t.Fatalf("MarshalZoneXML() error = %v", err)
+	}
+	if !strings.Contains(string(data), `<rule family="ipv4">`) {
+		t.Fatalf("expected structured rule element, got:\n%s", data)
+	}
+
+	parsed, err := ParseZoneXML(data)
+	if err != nil {
+		t.Fatalf("ParseZoneXML() error = %v", err)
+	}
+	if !parsed.Forward {
+		t.Fatalf("Forward = false, want true")
+	}
+	if strings.Join(parsed.Protocols, ",") != "gre,icmp" {
+		t.Fatalf("Protocols = %v, want %v", parsed.Protocols, orig.Protocols)
+	}
+	if len(parsed.SourcePorts) != 1 || parsed.SourcePorts[0] != orig.SourcePorts[0] {
+		t.Fatalf("SourcePorts = %v, want %v", parsed.SourcePorts, orig.SourcePorts)
+	}
+	if len(parsed.ForwardPorts) != len(orig.ForwardPorts) {
+		t.Fatalf("ForwardPorts = %v, want %v", parsed.ForwardPorts, orig.ForwardPorts)
+	}
+	for i := range orig.ForwardPorts {
+		if parsed.ForwardPorts[i] != orig.ForwardPorts[i] {
+			t.Fatalf("ForwardPorts[%d] = %v, want %v", i, parsed.ForwardPorts[i], orig.ForwardPorts[i])
+		}
+	}
+	if len(parsed.RichRules) != len(orig.RichRules) {
+		t.Fatalf("RichRules = %v, want %v", parsed.RichRules, orig.RichRules)
+	}
+	for i := range orig.RichRules {
+		if parsed.RichRules[i] != orig.RichRules[i] {
+			t.Fatalf("RichRules[%d] = %q, want %q", i, parsed.RichRules[i], orig.RichRules[i])
+		}
+	}
+}
+
+func TestParseZoneXML_FirewalldRule(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<zone target="default">
+  <short>Work</short>
+  <rule family="ipv4" priority="5">
+    <source address="10.1.0.0/16" invert="True"/>
+    <service name="http"/>
+    <nflog group="2" prefix="web"/>
+    <accept>
+      <limit value="10/m"/>
+    </accept>
+  </rule>
+</zone>`)
+
+	z, err := ParseZoneXML(data)
+	if err != nil {
+		t.Fatalf("ParseZoneXML() error = %v", err)
+	}
+	want := `rule family="ipv4" priority="5" source NOT address="10.1.0.0/16" service name="http" nflog group="2" prefix="web" accept limit value="10/m"`
+	if len(z.RichRules) != 1 || z.RichRules[0] != want {
+		t.Fatalf("RichRules = %v, want [%s]", z.RichRules, want)
+	}
+}
+
+func TestMarshalZoneXML_InvalidRichRule(t *testing.T) {
+	tests := []string{
+		`service name="ssh" accept`,
+		`rule service name="ssh" port port="22" protocol="tcp" accept`,
+		`rule source address="10.0.0.1 accept`,
+		`rule bogus accept`,
+		`rule accept drop`,
+	}
+	for _, rule := range tests {
+		if _, err := MarshalZoneXML(&firewalld.Zone{RichRules: []string{rule}}); err == nil {
+			t.Fatalf("MarshalZoneXML(%q) expected error", rule)
+		}
+	}
+}
+
+func TestParseZoneXML_RoundTripLossless(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<zone version="1.0" target="default" ingress-priority="-10" egress-priority="5">
+  <short>Edge</short>
+  <rule>
+    <service name="http"/>
+    <accept>
+      <limit value="1/m" burst="5"/>
+    </accept>
+  </rule>
+  <rule>
+    <tcp-mss-clamp value="pmtu"/>
+  </rule>
+</zone>`)
+
+	z, err := ParseZoneXML(data)
+	if err != nil {
+		t.Fatalf("ParseZoneXML() error = %v", err)
+	}
+	if z.Version != "1.0" || z.IngressPriority != -10 || z.EgressPriority != 5 {
+		t.Fatalf("zone attributes = %q/%d/%d, want 1.0/-10/5", z.Version, z.IngressPriority, z.EgressPriority)
+	}
+	wantRules := []string{
+		`rule service name="http" accept limit value="1/m" burst="5"`,
+		`rule tcp-mss-clamp value="pmtu"`,
+	}
+	if strings.Join(z.RichRules, "\n") != strings.Join(wantRules, "\n") {
+		t.Fatalf("RichRules = %q, want %q", z.RichRules, wantRules)
+	}
+
+	out, err := MarshalZoneXML(z)
+	if err != nil {
+		t.Fatalf("MarshalZoneXML() error = %v", err)
+	}
+	for _, want := range []string{
+		`<zone version="1.0" target="default" ingress-priority="-10" egress-priority="5">`,
+		`<limit value="1/m" burst="5"></limit>`,
+		`<tcp-mss-clamp value="pmtu"></tcp-mss-clamp>`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("marshalled zone lacks %s:\n%s", want, out)
+		}
+	}
+	again, err := ParseZoneXML(out)
+	if err != nil {
+		t.Fatalf("ParseZoneXML(round trip) error = %v", err)
+	}
+	if again.Version != z.Version || again.IngressPriority != z.IngressPriority || again.EgressPriority != z.EgressPriority ||
+		strings.Join(again.RichRules, "\n") != strings.Join(z.RichRules, "\n") {
+		t.Fatalf("round trip = %+v, want %+v", again, z)
+	}
+}
+
+func TestParseZoneXML_UnsupportedContent(t *testing.T) {
+	tests := map[string]string{
+		"zone element":   `<zone><short>x</short><helper name="ftp"/></zone>`,
+		"zone attribute": `<zone target="default" future="1"/>`,
+		"rule element":   `<zone><rule><service name="ssh"/><future/><accept/></rule></zone>`,
+		"rule attribute": `<zone><rule future="1"><service name="ssh"/><accept/></rule></zone>`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseZoneXML([]byte(data)); err == nil || !strings.Contains(err.Error(), "unsupported") {
+				t.Fatalf("ParseZoneXML() error = %v, want unsupported content error", err)
+			}
+		})
+	}
+}
+
+func TestCheckZoneXMLRoundTrip(t *testing.T) {
+	ok := []string{
+		`<?xml version="1.0" encoding="utf-8"?>
+<zone target="DROP">
+  <short>Work</short>
+  <description></description>
+  <!-- comments are not content -->
+  <interface name="eth0"/>
+  <service name="ssh"/>
+  <port protocol="tcp" port="8080"/>
+  <rule family="ipv4">
+    <source address="10.0.0.0/8" invert="True"/>
+    <service name="http"/>
+    <accept/>
+  </rule>
+</zone>`,
+		`<zone><short>Public</short><service name="ssh"/><interface name="eth0"/></zone>`,
+	}
+	for _, data := range ok {
+		if err := CheckZoneXMLRoundTrip([]byte(data)); err != nil {
+			t.Fatalf("CheckZoneXMLRoundTrip(%s) error = %v", data, err)
+		}
+	}
+
+	lossy := map[string]string{
+		"port attribute":  `<zone><port port="80" protocol="tcp" comment="web"/></zone>`,
+		"limit attribute": `<zone><rule><service name="ssh"/><accept><limit value="1/m" extra="x"/></accept></rule></zone>`,
+		"zone element":    `<zone><helper name="ftp"/></zone>`,
+		"empty service":   `<zone><service name=""/></zone>`,
+	}
+	for name, data := range lossy {
+		t.Run(name, func(t *testing.T) {
+			if err := CheckZoneXMLRoundTrip([]byte(data)); err == nil {
+				t.Fatalf("CheckZoneXMLRoundTrip() = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+//go:build linux
+// +build linux
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"lazyfirewall/internal/state"
+)
+
+func runApply(e *env, args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ContinueOnError)
+	file := fs.String("f", "", "desired state file (YAML)")
+	planOnly := fs.Bool("plan", false, "print the plan without applying it")
+	noBackup := fs.Bool("no-backup", false, "skip the zone backups taken before applying")
+	force := fs.Bool("force", false, "apply even if a removal would lock out the current SSH session")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs("apply -f FILE [--plan]", rest); err != nil {
+		return err
+	}
+	if *file == "" {
+		return usagef("usage: lazyfirewall apply -f FILE [--plan]")
+	}
+
+	desired, err := state.LoadFile(*file)
+	if err != nil {
+		return err
+	}
+	client, err := e.firewalld()
+	if err != nil {
+		return err
+	}
+	current, err := state.Fetch(client, desired)
+	if err != nil {
+		return err
+	}
+	plan, err := state.BuildPlan(desired, current)
+	if err != nil {
+		return err
+	}
+	plan.Render(e.stdout)
+	if plan.Empty() || *planOnly || e.opts.DryRun {
+		return nil
+	}
+
+	lockoutFlags := &mutationFlags{permanent: true, force: *force}
+	for _, c := range plan.Changes {
+		change, ok := c.Lockout()
+		if !ok {
+			continue
+		}
+		if err := e.checkLockout(client, change, lockoutFlags); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(e.stdout, "\nApplying...")
+	opts := state.ApplyOptions{NoBackup: *noBackup || e.opts.Offline}
+	if err := state.Apply(client, plan, e.stdout, opts); err != nil {
+		return err
+	}
+	add, change, remove := plan.Counts()
+	fmt.Fprintf(e.stdout, "\nApply complete! %d added, %d changed, %d removed.\n", add, change, remove)
+	return nil
+}
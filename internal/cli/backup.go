@@ -0,0 +1,174 @@
+//go:build linux
+// +build linux
+
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"lazyfirewall/internal/backup"
+	"lazyfirewall/internal/validation"
+)
+
+type backupEntry struct {
+	Index       int       `json:"index"`
+	Zone        string    `json:"zone"`
+	Path        string    `json:"path"`
+	Time        time.Time `json:"time"`
+	Size        int64     `json:"size"`
+	Description string    `json:"description,omitempty"`
+}
+
+func runBackup(e *env, args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: lazyfirewall backup create|list|restore ZONE")
+	}
+	if e.opts.Offline {
+		return errors.New("backups are not available in offline mode")
+	}
+	switch args[0] {
+	case "create":
+		return runBackupCreate(e, args[1:])
+	case "list":
+		return runBackupList(e, args[1:])
+	case "restore":
+		return runBackupRestore(e, args[1:])
+	default:
+		return usagef("unknown backup command %q", args[0])
+	}
+}
+
+func runBackupCreate(e *env, args []string) error {
+	fs := flag.NewFlagSet("backup create", flag.ContinueOnError)
+	description := fs.String("description", "", "backup description")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs("backup create", rest, "ZONE"); err != nil {
+		return err
+	}
+	zone := rest[0]
+	if err := validation.IsValidZoneName(zone); err != nil {
+		return usagef("invalid zone name: %v", err)
+	}
+	if e.opts.DryRun {
+		fmt.Fprintf(e.stdout, "DRY RUN: would back up zone %s\n", zone)
+		return nil
+	}
+	b, err := backup.CreateZoneBackupWithDescription(zone, *description)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(e.stdout, b.Path)
+	return nil
+}
+
+func runBackupList(e *env, args []string) error {
+	fs := flag.NewFlagSet("backup list", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs("backup list", rest, "ZONE"); err != nil {
+		return err
+	}
+	zone := rest[0]
+	if err := validation.IsValidZoneName(zone); err != nil {
+		return usagef("invalid zone name: %v", err)
+	}
+	items, err := backup.ListBackups(zone)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]backupEntry, 0, len(items))
+	for i, b := range items {
+		entries = append(entries, backupEntry{
+			Index:       i + 1,
+			Zone:        b.Zone,
+			Path:        b.Path,
+			Time:        b.Time,
+			Size:        b.Size,
+			Description: b.Description,
+		})
+	}
+	if *asJSON {
+		return writeJSON(e.stdout, entries)
+	}
+	for _, b := range entries {
+		fmt.Fprintf(e.stdout, "%d\t%s\t%d\t%s\t%s\n", b.Index, b.Time.Format("2006-01-02 15:04:05"), b.Size, b.Description, b.Path)
+	}
+	return nil
+}
+
+func runBackupRestore(e *env, args []string) error {
+	fs := flag.NewFlagSet("backup restore", flag.ContinueOnError)
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 1 {
+		rest = append(rest, "latest")
+	}
+	if err := expectArgs("backup restore", rest, "ZONE", "[latest|N|PATH]"); err != nil {
+		return err
+	}
+	zone := rest[0]
+	if err := validation.IsValidZoneName(zone); err != nil {
+		return usagef("invalid zone name: %v", err)
+	}
+	item, err := selectBackup(zone, rest[1])
+	if err != nil {
+		return err
+	}
+	if e.opts.DryRun {
+		fmt.Fprintf(e.stdout, "DRY RUN: would restore zone %s from %s\n", zone, item.Path)
+		return nil
+	}
+
+	client, err := e.firewalld()
+	if err != nil {
+		return err
+	}
+	if err := backup.RestoreZoneBackupAndReload(zone, item, client.Reload); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "OK: restored zone %s from %s\n", zone, item.Path)
+	return nil
+}
+
+// selectBackup resolves "latest", a 1-based index from "backup list", or a
+// file path.
+func selectBackup(zone, ref string) (backup.Backup, error) {
+	if ref != "latest" {
+		if _, err := strconv.Atoi(ref); err != nil {
+			if _, statErr := os.Stat(ref); statErr != nil {
+				return backup.Backup{}, fmt.Errorf("%w: backup %s", errNotFound, ref)
+			}
+			return backup.Backup{Path: ref, Zone: zone}, nil
+		}
+	}
+
+	items, err := backup.ListBackups(zone)
+	if err != nil {
+		return backup.Backup{}, err
+	}
+	if len(items) == 0 {
+		return backup.Backup{}, fmt.Errorf("%w: no backups for zone %s", errNotFound, zone)
+	}
+	if ref == "latest" {
+		return items[0], nil
+	}
+	n, _ := strconv.Atoi(ref)
+	if n < 1 || n > len(items) {
+		return backup.Backup{}, fmt.Errorf("%w: backup %d (have %d)", errNotFound, n, len(items))
+	}
+	return items[n-1], nil
+}
@@ -0,0 +1,217 @@
+//go:build linux
+// +build linux
+
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+// Exit codes are part of the scripting interface; do not renumber them.
+const (
+	ExitOK          = 0
+	ExitFailure     = 1
+	ExitUsage       = 2
+	ExitPermission  = 3
+	ExitNotFound    = 4
+	ExitUnavailable = 5
+)
+
+var errNotFound = errors.New("not found")
+
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...any) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+type Options struct {
+	DryRun bool
+	// Offline marks Connect as returning a configuration directory rather
+	// than the running daemon.
+	Offline bool
+	Stdout  io.Writer
+	Stderr  io.Writer
+	Connect func() (firewalld.Backend, error)
+}
+
+type env struct {
+	opts   Options
+	stdout io.Writer
+	stderr io.Writer
+	client firewalld.Backend
+}
+
+func (e *env) firewalld() (firewalld.Backend, error) {
+	if e.client != nil {
+		return e.client, nil
+	}
+	if e.opts.Connect == nil {
+		return nil, fmt.Errorf("%w: no firewalld connection", firewalld.ErrNotRunning)
+	}
+	client, err := e.opts.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", firewalld.ErrNotRunning, err)
+	}
+	e.client = client
+	return client, nil
+}
+
+// IsCommand reports whether args start with a known subcommand.
+func IsCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	_, ok := commands[args[0]]
+	return ok
+}
+
+var commands = map[string]func(*env, []string) error{
+	"zone":    runZone,
+	"service": runService,
+	"port":    runPort,
+	"backup":  runBackup,
+	"apply":   runApply,
+	"config":  runConfig,
+	"help":    runHelp,
+}
+
+// Run executes a subcommand and returns the process exit code.
+func Run(args []string, opts Options) int {
+	e := &env{opts: opts, stdout: opts.Stdout, stderr: opts.Stderr}
+	if e.stdout == nil {
+		e.stdout = os.Stdout
+	}
+	if e.stderr == nil {
+		e.stderr = os.Stderr
+	}
+	defer func() {
+		if e.client != nil {
+			_ = e.client.Close()
+		}
+	}()
+
+	if len(args) == 0 {
+		printUsage(e.stderr)
+		return ExitUsage
+	}
+	fn, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "Error: unknown command %q\n\n", args[0])
+		printUsage(e.stderr)
+		return ExitUsage
+	}
+	err := fn(e, args[1:])
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	fmt.Fprintf(e.stderr, "Error: %v\n", err)
+	code := exitCode(err)
+	if code == ExitUsage {
+		fmt.Fprintln(e.stderr, "Run 'lazyfirewall help' for usage.")
+	}
+	return code
+}
+
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &usage):
+		return ExitUsage
+	case errors.Is(err, firewalld.ErrPermissionDenied):
+		return ExitPermission
+	case errors.Is(err, firewalld.ErrInvalidZone),
+		errors.Is(err, firewalld.ErrInvalidIPSet),
+		errors.Is(err, firewalld.ErrInvalidPolicy),
+		errors.Is(err, errNotFound),
+		errors.Is(err, os.ErrNotExist):
+		return ExitNotFound
+	case errors.Is(err, firewalld.ErrNotRunning),
+		errors.Is(err, firewalld.ErrUnsupportedAPI):
+		return ExitUnavailable
+	default:
+		return ExitFailure
+	}
+}
+
+// parseFlags parses fs allowing flags and positional arguments to be mixed,
+// e.g. "service add public http --permanent".
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, usagef("%s: %v", fs.Name(), err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func expectArgs(name string, args []string, names ...string) error {
+	if len(args) != len(names) {
+		return usagef("usage: lazyfirewall %s %s", name, strings.Join(names, " "))
+	}
+	return nil
+}
+
+func runHelp(e *env, _ []string) error {
+	printUsage(e.stdout)
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `Usage:
+  lazyfirewall [flags]                      start the terminal UI
+  lazyfirewall <command> [args] [flags]
+
+Commands:
+  zone list [--json]
+  zone show ZONE [--permanent] [--json]
+  service add|remove ZONE SERVICE [--permanent] [--timeout 30m] [--no-backup] [--force]
+  port add|remove ZONE PORT/PROTO [--permanent] [--timeout 30m] [--no-backup] [--force]
+  backup create ZONE [--description TEXT]
+  backup list ZONE [--json]
+  backup restore ZONE [latest|N|PATH]
+  apply -f STATE.yaml [--plan] [--no-backup] [--force]
+  config validate [FILE]
+  config dump-default
+
+Global flags (before the command):
+  --dry-run, -n   print the change instead of applying it
+  --offline       edit the config directory instead of the running firewalld
+  --root DIR      config directory for --offline (default /etc/firewalld)
+
+Exit codes:
+  0 success, 1 failure, 2 usage error, 3 permission denied,
+  4 zone/service/backup not found, 5 firewalld unavailable
+`)
+}
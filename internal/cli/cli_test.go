@@ -0,0 +1,221 @@
+//go:build linux
+// +build linux
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+func run(t *testing.T, opts Options, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	opts.Stdout = &stdout
+	opts.Stderr = &stderr
+	code := Run(args, opts)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunExitCodes(t *testing.T) {
+	unavailable := func() (firewalld.Backend, error) {
+		return nil, errors.New("no bus")
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		args []string
+		want int
+	}{
+		{name: "no args", args: nil, want: ExitUsage},
+		{name: "unknown command", args: []string{"bogus"}, want: ExitUsage},
+		{name: "help", args: []string{"help"}, want: ExitOK},
+		{name: "unknown subcommand", args: []string{"zone", "bogus"}, want: ExitUsage},
+		{name: "missing args", args: []string{"service", "add", "public"}, want: ExitUsage},
+		{name: "bad flag", args: []string{"zone", "list", "--bogus"}, want: ExitUsage},
+		{name: "invalid zone", args: []string{"zone", "show", "../bad"}, want: ExitUsage},
+		{name: "bad port", args: []string{"port", "add", "public", "80"}, want: ExitUsage},
+		{name: "permanent timeout", args: []string{"service", "add", "public", "http", "--permanent", "--timeout", "5m"}, want: ExitUsage},
+		{name: "firewalld unavailable", opts: Options{Connect: unavailable}, args: []string{"zone", "list"}, want: ExitUnavailable},
+		{name: "no connection", args: []string{"zone", "show", "public"}, want: ExitUnavailable},
+		{name: "dry run", opts: Options{DryRun: true, Connect: unavailable}, args: []string{"service", "add", "public", "http", "--permanent"}, want: ExitOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, stderr := run(t, tt.opts, tt.args...)
+			if got != tt.want {
+				t.Fatalf("Run(%q) = %d, want %d (stderr %q)", tt.args, got, tt.want, stderr)
+			}
+		})
+	}
+}
+
+func TestRunDryRunOutput(t *testing.T) {
+	code, stdout, _ := run(t, Options{DryRun: true}, "port", "remove", "public", "8080/TCP", "--permanent")
+	if code != ExitOK {
+		t.Fatalf("Run() = %d, want %d", code, ExitOK)
+	}
+	want := "DRY RUN: would remove port 8080/tcp from zone public (permanent)\n"
+	if stdout != want {
+		t.Fatalf("stdout = %q, want %q", stdout, want)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: nil, want: ExitOK},
+		{err: errors.New("boom"), want: ExitFailure},
+		{err: usagef("bad"), want: ExitUsage},
+		{err: fmt.Errorf("wrap: %w", firewalld.ErrPermissionDenied), want: ExitPermission},
+		{err: firewalld.ErrInvalidZone, want: ExitNotFound},
+		{err: fmt.Errorf("%w: x", errNotFound), want: ExitNotFound},
+		{err: os.ErrNotExist, want: ExitNotFound},
+		{err: firewalld.ErrUnsupportedAPI, want: ExitUnavailable},
+		{err: fmt.Errorf("%w: x", firewalld.ErrNotRunning), want: ExitUnavailable},
+	}
+
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Fatalf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestParseFlagsInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	permanent := fs.Bool("permanent", false, "")
+	asJSON := fs.Bool("json", false, "")
+	rest, err := parseFlags(fs, []string{"public", "--permanent", "http", "--json", "--", "--literal"})
+	if err != nil {
+		t.Fatalf("parseFlags() error = %v", err)
+	}
+	if !*permanent || !*asJSON {
+		t.Fatalf("flags = permanent %v json %v, want both true", *permanent, *asJSON)
+	}
+	if got := strings.Join(rest, " "); got != "public http --literal" {
+		t.Fatalf("positional = %q, want %q", got, "public http --literal")
+	}
+}
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    firewalld.Port
+		wantErr bool
+	}{
+		{input: "80/tcp", want: firewalld.Port{Port: "80", Protocol: "tcp"}},
+		{input: "6000-6010/UDP", want: firewalld.Port{Port: "6000-6010", Protocol: "udp"}},
+		{input: "80", wantErr: true},
+		{input: "0/tcp", wantErr: true},
+		{input: "10-5/tcp", wantErr: true},
+		{input: "80/icmp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePort(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parsePort(%q) error = %v, wantErr = %v", tt.input, err, tt.wantErr)
+		}
+		if err == nil && got != tt.want {
+			t.Fatalf("parsePort(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestWriteZoneList(t *testing.T) {
+	var b bytes.Buffer
+	writeZoneList(&b, []zoneSummary{
+		{Name: "block"},
+		{Name: "public", Default: true, Active: true, Bindings: []string{"eth0", "eth1"}},
+	})
+	want := "block\npublic\tdefault,active\teth0 eth1\n"
+	if b.String() != want {
+		t.Fatalf("writeZoneList() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestBackupListAndSelect(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SUDO_USER", "")
+	dir := filepath.Join(home, ".config/lazyfirewall/backups")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, name := range []string{"zone-public-20260101-100000.xml", "zone-public-20260102-100000__nightly.xml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("<zone/>"), 0o644); err != nil {
+			t.Fatalf("write backup: %v", err)
+		}
+	}
+
+	code, stdout, stderr := run(t, Options{}, "backup", "list", "public", "--json")
+	if code != ExitOK {
+		t.Fatalf("backup list = %d, stderr %q", code, stderr)
+	}
+	if !strings.Contains(stdout, `"description": "nightly"`) || !strings.Contains(stdout, `"index": 2`) {
+		t.Fatalf("backup list output = %s", stdout)
+	}
+
+	latest, err := selectBackup("public", "latest")
+	if err != nil || !strings.HasSuffix(latest.Path, "__nightly.xml") {
+		t.Fatalf("selectBackup(latest) = %+v, %v", latest, err)
+	}
+	second, err := selectBackup("public", "2")
+	if err != nil || !strings.HasSuffix(second.Path, "20260101-100000.xml") {
+		t.Fatalf("selectBackup(2) = %+v, %v", second, err)
+	}
+	if _, err := selectBackup("public", "3"); exitCode(err) != ExitNotFound {
+		t.Fatalf("selectBackup(3) error = %v, want not found", err)
+	}
+	if _, err := selectBackup("dmz", "latest"); exitCode(err) != ExitNotFound {
+		t.Fatalf("selectBackup(dmz) error = %v, want not found", err)
+	}
+
+	code, stdout, _ = run(t, Options{DryRun: true}, "backup", "restore", "public")
+	if code != ExitOK || !strings.Contains(stdout, "__nightly.xml") {
+		t.Fatalf("backup restore dry run = %d, %q", code, stdout)
+	}
+}
+
+func TestConfigCommands(t *testing.T) {
+	code, out, _ := run(t, Options{}, "config", "dump-default")
+	if code != ExitOK || !strings.Contains(out, "confirm_timeout = 30") {
+		t.Fatalf("config dump-default = %d, %q", code, out)
+	}
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.toml")
+	if err := os.WriteFile(good, []byte("[ui]\ntheme = \"nord\"\ncolour = \"x\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, out, _ = run(t, Options{}, "config", "validate", good)
+	if code != ExitOK || !strings.Contains(out, `line 3: unknown key "ui.colour"`) || !strings.Contains(out, "OK") {
+		t.Fatalf("config validate good = %d, %q", code, out)
+	}
+
+	bad := filepath.Join(dir, "bad.toml")
+	if err := os.WriteFile(bad, []byte("[keys]\nimport = \"q\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, _, stderr := run(t, Options{}, "config", "validate", bad)
+	if code != ExitFailure || !strings.Contains(stderr, `keys: key "q" is bound to both quit and import`) {
+		t.Fatalf("config validate bad = %d, %q", code, stderr)
+	}
+
+	if code, _, _ := run(t, Options{}, "config", "validate", filepath.Join(dir, "missing.toml")); code != ExitNotFound {
+		t.Fatalf("config validate missing = %d, want %d", code, ExitNotFound)
+	}
+}
@@ -0,0 +1,88 @@
+//go:build linux
+// +build linux
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"lazyfirewall/internal/config"
+	"lazyfirewall/internal/keymap"
+	"lazyfirewall/internal/templates"
+)
+
+var errInvalidConfig = errors.New("invalid configuration")
+
+func runConfig(e *env, args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: lazyfirewall config validate|dump-default")
+	}
+	switch args[0] {
+	case "validate":
+		return runConfigValidate(e, args[1:])
+	case "dump-default":
+		if err := expectArgs("config dump-default", args[1:]); err != nil {
+			return err
+		}
+		fmt.Fprint(e.stdout, config.DefaultFile())
+		return nil
+	default:
+		return usagef("unknown config command %q", args[0])
+	}
+}
+
+// runConfigValidate checks a config file the way startup would: syntax,
+// types and ranges, unknown keys, the keymap, the selected theme and the
+// template files next to it.
+// Warnings are printed but only errors fail.
+func runConfigValidate(e *env, args []string) error {
+	if len(args) > 1 {
+		return usagef("usage: lazyfirewall config validate [FILE]")
+	}
+	var path string
+	if len(args) == 1 {
+		path = args[0]
+	} else {
+		found, ok, err := config.Find()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintf(e.stdout, "%s: not found; defaults apply\n", found)
+			return nil
+		}
+		path = found
+	}
+
+	cfg, warnings, err := config.LoadFile(path)
+	if err != nil {
+		return err
+	}
+	var problems []string
+	if _, err := keymap.New(cfg.KeyBindings()); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			problems = append(problems, "keys: "+line)
+		}
+	}
+	if _, err := cfg.Theme(path); err != nil {
+		problems = append(problems, "ui.theme: "+err.Error())
+	}
+	_, errs := templates.LoadDir(templates.Dir(path))
+	for _, err := range errs {
+		problems = append(problems, err.Error())
+	}
+
+	for _, w := range warnings {
+		fmt.Fprintf(e.stdout, "%s: warning: %s\n", path, w)
+	}
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintf(e.stderr, "%s: %s\n", path, p)
+		}
+		return fmt.Errorf("%s: %w", path, errInvalidConfig)
+	}
+	fmt.Fprintf(e.stdout, "%s: OK\n", path)
+	return nil
+}
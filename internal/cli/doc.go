@@ -0,0 +1,5 @@
+//go:build linux
+// +build linux
+
+// Package cli implements the non-interactive LazyFirewall subcommands.
+package cli
@@ -0,0 +1,252 @@
+//go:build linux
+// +build linux
+
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"lazyfirewall/internal/backup"
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/lockout"
+	"lazyfirewall/internal/validation"
+)
+
+type mutationFlags struct {
+	permanent bool
+	timeout   time.Duration
+	noBackup  bool
+	force     bool
+}
+
+func newMutationFlagSet(name string) (*flag.FlagSet, *mutationFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	mf := &mutationFlags{}
+	fs.BoolVar(&mf.permanent, "permanent", false, "change the permanent configuration")
+	fs.DurationVar(&mf.timeout, "timeout", 0, "runtime lifetime (e.g. 30m)")
+	fs.BoolVar(&mf.noBackup, "no-backup", false, "skip the zone backup taken before the change")
+	fs.BoolVar(&mf.force, "force", false, "apply even if the change would lock out the current SSH session")
+	return fs, mf
+}
+
+// validate checks the flags; offline there is only the permanent
+// configuration and backups of the host's zones would be meaningless.
+func (mf *mutationFlags) validate(offline bool) error {
+	if offline {
+		if mf.timeout > 0 {
+			return usagef("--timeout needs a running firewalld")
+		}
+		mf.permanent = true
+		mf.noBackup = true
+	}
+	if mf.timeout < 0 || mf.timeout > firewalld.MaxRuntimeTimeout {
+		return usagef("invalid timeout: %s", mf.timeout)
+	}
+	if mf.timeout > 0 && mf.permanent {
+		return usagef("--timeout applies to runtime only")
+	}
+	return nil
+}
+
+func (mf *mutationFlags) modeLabel() string {
+	if mf.timeout > 0 {
+		return "runtime, expires in " + mf.timeout.String()
+	}
+	if mf.permanent {
+		return "permanent"
+	}
+	return "runtime"
+}
+
+func runService(e *env, args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: lazyfirewall service add|remove ZONE SERVICE")
+	}
+	action := args[0]
+	if action != "add" && action != "remove" {
+		return usagef("unknown service command %q", action)
+	}
+	fs, mf := newMutationFlagSet("service " + action)
+	rest, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if err := expectArgs("service "+action, rest, "ZONE", "SERVICE"); err != nil {
+		return err
+	}
+	if err := mf.validate(e.opts.Offline); err != nil {
+		return err
+	}
+	zone, service := rest[0], rest[1]
+	if err := validation.IsValidZoneName(zone); err != nil {
+		return usagef("invalid zone name: %v", err)
+	}
+
+	desc := fmt.Sprintf("%s service %s %s zone %s (%s)", action, service, toFrom(action), zone, mf.modeLabel())
+	if e.opts.DryRun {
+		fmt.Fprintln(e.stdout, "DRY RUN: would "+desc)
+		return nil
+	}
+
+	client, err := e.firewalld()
+	if err != nil {
+		return err
+	}
+	if action == "add" {
+		if names, err := client.ListServiceNames(); err == nil && len(names) > 0 && !slices.Contains(names, service) {
+			return fmt.Errorf("%w: unknown service %s", errNotFound, service)
+		}
+	} else if err := e.checkLockout(client, lockout.Change{Kind: lockout.RemoveService, Zone: zone, Value: service}, mf); err != nil {
+		return err
+	}
+	if err := e.backupBeforeChange(zone, mf); err != nil {
+		return err
+	}
+
+	switch {
+	case action == "add" && mf.permanent:
+		err = client.AddServicePermanent(zone, service)
+	case action == "add":
+		err = client.AddServiceRuntimeTimeout(zone, service, mf.timeout)
+	case mf.permanent:
+		err = client.RemoveServicePermanent(zone, service)
+	default:
+		err = client.RemoveServiceRuntime(zone, service)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(e.stdout, "OK: "+desc)
+	return nil
+}
+
+func runPort(e *env, args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: lazyfirewall port add|remove ZONE PORT/PROTO")
+	}
+	action := args[0]
+	if action != "add" && action != "remove" {
+		return usagef("unknown port command %q", action)
+	}
+	fs, mf := newMutationFlagSet("port " + action)
+	rest, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if err := expectArgs("port "+action, rest, "ZONE", "PORT/PROTO"); err != nil {
+		return err
+	}
+	if err := mf.validate(e.opts.Offline); err != nil {
+		return err
+	}
+	zone := rest[0]
+	if err := validation.IsValidZoneName(zone); err != nil {
+		return usagef("invalid zone name: %v", err)
+	}
+	port, err := parsePort(rest[1])
+	if err != nil {
+		return err
+	}
+
+	desc := fmt.Sprintf("%s port %s/%s %s zone %s (%s)", action, port.Port, port.Protocol, toFrom(action), zone, mf.modeLabel())
+	if e.opts.DryRun {
+		fmt.Fprintln(e.stdout, "DRY RUN: would "+desc)
+		return nil
+	}
+
+	client, err := e.firewalld()
+	if err != nil {
+		return err
+	}
+	if action == "remove" {
+		if err := e.checkLockout(client, lockout.Change{Kind: lockout.RemovePort, Zone: zone, Port: port}, mf); err != nil {
+			return err
+		}
+	}
+	if err := e.backupBeforeChange(zone, mf); err != nil {
+		return err
+	}
+
+	switch {
+	case action == "add" && mf.permanent:
+		err = client.AddPortPermanent(zone, port)
+	case action == "add":
+		err = client.AddPortRuntimeTimeout(zone, port, mf.timeout)
+	case mf.permanent:
+		err = client.RemovePortPermanent(zone, port)
+	default:
+		err = client.RemovePortRuntime(zone, port)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(e.stdout, "OK: "+desc)
+	return nil
+}
+
+// backupBeforeChange mirrors the TUI: a missing zone XML only warns, any
+// other backup failure aborts the change.
+func (e *env) backupBeforeChange(zone string, mf *mutationFlags) error {
+	if mf.noBackup {
+		return nil
+	}
+	b, err := backup.CreateZoneBackup(zone)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintln(e.stderr, "Warning: backup skipped: zone XML not found")
+			return nil
+		}
+		return fmt.Errorf("backup failed (use --no-backup to skip): %w", err)
+	}
+	fmt.Fprintln(e.stderr, "Backup: "+b.Path)
+	return nil
+}
+
+// checkLockout refuses a change that would block the SSH session the command
+// runs in, or whose effect on it could not be checked, unless --force is
+// given.
+func (e *env) checkLockout(client firewalld.Backend, change lockout.Change, mf *mutationFlags) error {
+	if e.opts.Offline {
+		return nil
+	}
+	session, err := lockout.DetectSession()
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Warning: SSH session not detected, lockout check skipped: %v\n", err)
+	}
+	if session == nil {
+		return nil
+	}
+	res, err := lockout.Check(client, session, change, mf.permanent)
+	reason := res.Reason
+	switch {
+	case err != nil:
+		reason = "lockout check failed: " + err.Error()
+	case !res.Blocked:
+		return nil
+	}
+	if mf.force {
+		fmt.Fprintln(e.stderr, "Warning: "+reason)
+		return nil
+	}
+	return fmt.Errorf("refusing change: %s (use --force to apply anyway)", reason)
+}
+
+func toFrom(action string) string {
+	if action == "add" {
+		return "to"
+	}
+	return "from"
+}
+
+func parsePort(value string) (firewalld.Port, error) {
+	port, err := firewalld.ParsePort(value)
+	if err != nil {
+		return firewalld.Port{}, usagef("%v", err)
+	}
+	return port, nil
+}
@@ -0,0 +1,173 @@
+//go:build linux
+// +build linux
+
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/validation"
+)
+
+type zoneSummary struct {
+	Name     string   `json:"name"`
+	Default  bool     `json:"default"`
+	Active   bool     `json:"active"`
+	Bindings []string `json:"bindings,omitempty"`
+}
+
+func runZone(e *env, args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: lazyfirewall zone list|show")
+	}
+	switch args[0] {
+	case "list":
+		return runZoneList(e, args[1:])
+	case "show":
+		return runZoneShow(e, args[1:])
+	default:
+		return usagef("unknown zone command %q", args[0])
+	}
+}
+
+func runZoneList(e *env, args []string) error {
+	fs := flag.NewFlagSet("zone list", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs("zone list", rest); err != nil {
+		return err
+	}
+
+	client, err := e.firewalld()
+	if err != nil {
+		return err
+	}
+	names, err := client.ListZones()
+	if err != nil {
+		return err
+	}
+	defaultZone, err := client.GetDefaultZone()
+	if err != nil {
+		return err
+	}
+	active, err := client.GetActiveZones()
+	if err != nil {
+		return err
+	}
+
+	zones := make([]zoneSummary, 0, len(names))
+	for _, name := range names {
+		bindings, isActive := active[name]
+		zones = append(zones, zoneSummary{
+			Name:     name,
+			Default:  name == defaultZone,
+			Active:   isActive,
+			Bindings: bindings,
+		})
+	}
+	if *asJSON {
+		return writeJSON(e.stdout, zones)
+	}
+	writeZoneList(e.stdout, zones)
+	return nil
+}
+
+func writeZoneList(w io.Writer, zones []zoneSummary) {
+	for _, z := range zones {
+		var flags []string
+		if z.Default {
+			flags = append(flags, "default")
+		}
+		if z.Active {
+			flags = append(flags, "active")
+		}
+		line := z.Name
+		if len(flags) > 0 {
+			line += "\t" + strings.Join(flags, ",")
+		}
+		if len(z.Bindings) > 0 {
+			line += "\t" + strings.Join(z.Bindings, " ")
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func runZoneShow(e *env, args []string) error {
+	fs := flag.NewFlagSet("zone show", flag.ContinueOnError)
+	permanent := fs.Bool("permanent", false, "show permanent configuration")
+	asJSON := fs.Bool("json", false, "print JSON")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := expectArgs("zone show", rest, "ZONE"); err != nil {
+		return err
+	}
+	zone := rest[0]
+	if err := validation.IsValidZoneName(zone); err != nil {
+		return usagef("invalid zone name: %v", err)
+	}
+
+	client, err := e.firewalld()
+	if err != nil {
+		return err
+	}
+	z, err := client.GetZoneSettings(zone, *permanent)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(e.stdout, z)
+	}
+	writeZone(e.stdout, z)
+	return nil
+}
+
+func writeZone(w io.Writer, z *firewalld.Zone) {
+	ports := make([]string, 0, len(z.Ports))
+	for _, p := range z.Ports {
+		ports = append(ports, p.Port+"/"+p.Protocol)
+	}
+	forwards := make([]string, 0, len(z.ForwardPorts))
+	for _, fp := range z.ForwardPorts {
+		forwards = append(forwards, firewalld.FormatForwardPort(fp))
+	}
+	fmt.Fprintf(w, "%s\n", z.Name)
+	fmt.Fprintf(w, "  target: %s\n", z.Target)
+	fmt.Fprintf(w, "  interfaces: %s\n", strings.Join(z.Interfaces, " "))
+	fmt.Fprintf(w, "  sources: %s\n", strings.Join(z.Sources, " "))
+	fmt.Fprintf(w, "  services: %s\n", strings.Join(z.Services, " "))
+	fmt.Fprintf(w, "  ports: %s\n", strings.Join(ports, " "))
+	fmt.Fprintf(w, "  protocols: %s\n", strings.Join(z.Protocols, " "))
+	fmt.Fprintf(w, "  masquerade: %s\n", yesNo(z.Masquerade))
+	fmt.Fprintf(w, "  forward-ports: %s\n", strings.Join(forwards, " "))
+	fmt.Fprintf(w, "  icmp-blocks: %s\n", strings.Join(z.IcmpBlocks, " "))
+	fmt.Fprintf(w, "  rich rules:\n")
+	for _, rule := range z.RichRules {
+		fmt.Fprintf(w, "\t%s\n", rule)
+	}
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
+
+func writeJSON(w io.Writer, v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", out)
+	return err
+}
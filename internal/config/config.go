@@ -1,41 +1,119 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
+
+	"lazyfirewall/internal/logger"
+	"lazyfirewall/internal/templates"
+	"lazyfirewall/internal/theme"
+
+	"github.com/BurntSushi/toml"
 )
 
+// maxConfirmTimeout caps behavior.confirm_timeout so a typo cannot leave a
+// risky change unconfirmed for hours.
+const maxConfirmTimeout = 600
+
+// Config is the layout of config.toml. Unknown keys are reported as
+// warnings; values of the wrong type are errors.
 type Config struct {
-	UI       UIConfig
-	Behavior BehaviorConfig
-	Advanced AdvancedConfig
+	UI       UIConfig       `toml:"ui"`
+	Behavior BehaviorConfig `toml:"behavior"`
+	Advanced AdvancedConfig `toml:"advanced"`
+	// Keys maps action names to the keys bound to them; see the keymap
+	// package. Only actions listed in [keys] are set.
+	Keys map[string]KeyList `toml:"keys"`
+	// Themes are user themes defined inline as [themes.<name>] tables,
+	// with the keys of a theme file.
+	Themes map[string]map[string]string `toml:"themes"`
+	// Templates are zone templates defined as [[templates]] tables; they
+	// replace built-in templates of the same name.
+	Templates []templates.Template `toml:"templates"`
 }
 
 type UIConfig struct {
-	Theme string
+	Theme string `toml:"theme"`
 }
 
 type BehaviorConfig struct {
-	DefaultPermanent   bool
-	AutoRefreshSeconds int
+	DefaultPermanent      bool `toml:"default_permanent"`
+	AutoRefreshSeconds    int  `toml:"auto_refresh_interval"`
+	ConfirmTimeoutSeconds int  `toml:"confirm_timeout"`
 }
 
 type AdvancedConfig struct {
-	LogLevel string
+	LogLevel string `toml:"log_level"`
+}
+
+// KeyList is the value of a [keys] entry: one key ("x") or a list of
+// them (["x", "ctrl+x"]).
+type KeyList []string
+
+func (k *KeyList) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*k = KeyList{v}
+	case []any:
+		list := make(KeyList, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("keys must be strings, got %T", item)
+			}
+			list = append(list, s)
+		}
+		*k = list
+	default:
+		return fmt.Errorf("expected a key or a list of keys, got %T", v)
+	}
+	return nil
+}
+
+// KeyBindings returns Keys in the form keymap.New takes.
+func (c Config) KeyBindings() map[string][]string {
+	if len(c.Keys) == 0 {
+		return nil
+	}
+	keys := make(map[string][]string, len(c.Keys))
+	for action, list := range c.Keys {
+		keys[action] = list
+	}
+	return keys
+}
+
+// Theme resolves ui.theme: a [themes.<name>] table first, then a theme
+// file next to the config file at configPath, then the built-in themes.
+func (c Config) Theme(configPath string) (theme.Theme, error) {
+	if values, ok := c.Themes[c.UI.Theme]; ok {
+		t, err := theme.FromValues(c.UI.Theme, values)
+		if err != nil {
+			return theme.Theme{}, fmt.Errorf("themes.%s: %w", c.UI.Theme, err)
+		}
+		return t, nil
+	}
+	dir := ""
+	if configPath != "" {
+		dir = theme.Dir(configPath)
+	}
+	return theme.Load(c.UI.Theme, dir)
 }
 
 func Default() Config {
 	return Config{
 		UI: UIConfig{
-			Theme: "default",
+			Theme: theme.Default,
 		},
 		Behavior: BehaviorConfig{
-			DefaultPermanent:   false,
-			AutoRefreshSeconds: 0,
+			DefaultPermanent:      false,
+			AutoRefreshSeconds:    0,
+			ConfirmTimeoutSeconds: 30,
 		},
 		Advanced: AdvancedConfig{
 			LogLevel: "",
@@ -55,128 +133,183 @@ func ResolvePath() (string, error) {
 }
 
 func Load() (Config, []string, string, bool, error) {
-	paths, err := candidatePaths()
+	path, found, err := Find()
+	if err != nil || !found {
+		return Default(), nil, path, false, err
+	}
+	cfg, warnings, err := LoadFile(path)
 	if err != nil {
 		return Default(), nil, "", false, err
 	}
+	return cfg, warnings, path, true, nil
+}
+
+// Find returns the config file Load reads: the first candidate path that
+// exists. When none does, it returns the default path and false.
+func Find() (string, bool, error) {
+	paths, err := candidatePaths()
+	if err != nil {
+		return "", false, err
+	}
 	for _, path := range paths {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			if os.IsNotExist(err) {
-				continue
-			}
-			return Default(), nil, "", false, err
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, true, nil
 		}
-		cfg := Default()
-		warnings, err := parse(string(data), &cfg)
-		if err != nil {
-			return Default(), nil, "", false, fmt.Errorf("parse %s: %w", path, err)
+		if !os.IsNotExist(err) {
+			return "", false, err
 		}
-		warnings = append(warnings, normalizeConfig(&cfg)...)
-		return cfg, warnings, path, true, nil
 	}
-	return Default(), nil, "", false, nil
+	return paths[0], false, nil
+}
+
+// LoadFile reads and validates the config file at path.
+func LoadFile(path string) (Config, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Default(), nil, err
+	}
+	cfg := Default()
+	warnings, err := parse(string(data), &cfg)
+	if err != nil {
+		return Default(), nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	warnings = append(warnings, normalizeConfig(&cfg)...)
+	return cfg, warnings, nil
 }
 
 func normalizeConfig(cfg *Config) []string {
 	warnings := make([]string, 0)
-	if cfg.UI.Theme != "" && cfg.UI.Theme != "default" {
-		warnings = append(warnings, fmt.Sprintf("ui.theme %q is not supported; using default", cfg.UI.Theme))
-		cfg.UI.Theme = "default"
+	if cfg.UI.Theme == "" {
+		cfg.UI.Theme = theme.Default
+	} else if !theme.ValidName(cfg.UI.Theme) {
+		warnings = append(warnings, fmt.Sprintf("ui.theme %q is not a valid theme name; using default", cfg.UI.Theme))
+		cfg.UI.Theme = theme.Default
 	}
-	if cfg.Behavior.AutoRefreshSeconds > 0 {
-		warnings = append(warnings, "behavior.auto_refresh_interval is currently disabled; set to 0")
+	if cfg.Behavior.ConfirmTimeoutSeconds > maxConfirmTimeout {
+		warnings = append(warnings, fmt.Sprintf("behavior.confirm_timeout %d is too long; using %d", cfg.Behavior.ConfirmTimeoutSeconds, maxConfirmTimeout))
+		cfg.Behavior.ConfirmTimeoutSeconds = maxConfirmTimeout
 	}
 	return warnings
 }
 
+// parse decodes raw over cfg. Syntax errors, values of the wrong type and
+// values out of range are errors; unknown sections and keys are warnings.
+// Both carry the line number.
 func parse(raw string, cfg *Config) ([]string, error) {
-	section := ""
+	md, err := toml.Decode(raw, cfg)
+	if err != nil {
+		// Drop the "toml: " prefix; the error starts with the line.
+		return nil, errors.New(strings.TrimPrefix(err.Error(), "toml: "))
+	}
+
 	var warnings []string
-	lineNo := 0
-	start := 0
-	for {
-		end := start
-		for end < len(raw) && raw[end] != '\n' {
-			end++
-		}
-		lineNo++
-		line := raw[start:end]
-		line = stripComment(line)
-		line = strings.TrimSpace(line)
-		if line == "" {
-			if end == len(raw) {
-				break
-			}
-			start = end + 1
+	undecoded := make(map[string]bool)
+	for _, key := range md.Undecoded() {
+		undecoded[key.String()] = true
+		if len(key) > 1 && undecoded[key[:len(key)-1].String()] {
+			// Reported with its table.
 			continue
 		}
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
-			if end == len(raw) {
-				break
-			}
-			start = end + 1
-			continue
+		what := "key"
+		if md.Type(key...) == "Hash" {
+			what = "section"
 		}
-		key, value, ok := strings.Cut(line, "=")
-		if !ok {
-			return warnings, fmt.Errorf("line %d: expected key = value", lineNo)
-		}
-		key = strings.ToLower(strings.TrimSpace(key))
-		value = strings.TrimSpace(value)
-		if key == "" {
-			return warnings, fmt.Errorf("line %d: empty key", lineNo)
+		warnings = append(warnings, fmt.Sprintf("%sunknown %s %q", linePrefix(md, raw, key), what, key.String()))
+	}
+
+	if err := validate(md, raw, cfg); err != nil {
+		return warnings, err
+	}
+	valid := cfg.Templates[:0]
+	for i, t := range cfg.Templates {
+		if err := t.Validate(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("templates[%d] %q: %v; skipped", i, t.Name, err))
+			continue
 		}
+		t.Origin = "config"
+		valid = append(valid, t)
+	}
+	cfg.Templates = valid
+	return warnings, nil
+}
 
-		switch section {
-		case "ui":
-			if key == "theme" {
-				val, err := parseString(value)
-				if err != nil {
-					return warnings, fmt.Errorf("line %d: %w", lineNo, err)
-				}
-				cfg.UI.Theme = val
-			} else {
-				warnings = append(warnings, fmt.Sprintf("line %d: unknown ui key %q", lineNo, key))
-			}
-		case "behavior":
-			switch key {
-			case "default_permanent":
-				val, err := parseBool(value)
-				if err != nil {
-					return warnings, fmt.Errorf("line %d: %w", lineNo, err)
-				}
-				cfg.Behavior.DefaultPermanent = val
-			case "auto_refresh_interval":
-				val, err := parseInt(value)
-				if err != nil {
-					return warnings, fmt.Errorf("line %d: %w", lineNo, err)
-				}
-				cfg.Behavior.AutoRefreshSeconds = val
-			default:
-				warnings = append(warnings, fmt.Sprintf("line %d: unknown behavior key %q", lineNo, key))
-			}
-		case "advanced":
-			if key == "log_level" {
-				val, err := parseString(value)
-				if err != nil {
-					return warnings, fmt.Errorf("line %d: %w", lineNo, err)
-				}
-				cfg.Advanced.LogLevel = val
-			} else {
-				warnings = append(warnings, fmt.Sprintf("line %d: unknown advanced key %q", lineNo, key))
-			}
-		default:
-			warnings = append(warnings, fmt.Sprintf("line %d: unknown section %q", lineNo, section))
+// validate checks values the types alone do not.
+func validate(md toml.MetaData, raw string, cfg *Config) error {
+	checks := []struct {
+		key toml.Key
+		err error
+	}{
+		{toml.Key{"behavior", "auto_refresh_interval"}, nonNegative(cfg.Behavior.AutoRefreshSeconds)},
+		{toml.Key{"behavior", "confirm_timeout"}, nonNegative(cfg.Behavior.ConfirmTimeoutSeconds)},
+	}
+	if _, err := logger.ParseLevel(cfg.Advanced.LogLevel); err != nil {
+		checks = append(checks, struct {
+			key toml.Key
+			err error
+		}{toml.Key{"advanced", "log_level"}, err})
+	}
+	for _, c := range checks {
+		if c.err != nil {
+			return fmt.Errorf("%s%s: %w", linePrefix(md, raw, c.key), c.key.String(), c.err)
 		}
+	}
+	return nil
+}
+
+func nonNegative(n int) error {
+	if n < 0 {
+		return fmt.Errorf("must be >= 0")
+	}
+	return nil
+}
+
+// linePrefix returns "line N: " for the line defining key, or "" when the
+// decoder does not know its position.
+func linePrefix(md toml.MetaData, raw string, key toml.Key) string {
+	if n := keyLine(md, raw, key); n > 0 {
+		return fmt.Sprintf("line %d: ", n)
+	}
+	return ""
+}
+
+// positionProbe fails as soon as the decoder reaches it, so the
+// toml.ParseError it causes carries the position of the key it sits at.
+type positionProbe struct{}
+
+func (positionProbe) UnmarshalTOML(any) error { return errors.New("position probe") }
+
+var positionProbeType = reflect.TypeOf(positionProbe{})
 
-		if end == len(raw) {
-			break
+// keyLine returns the line defining key, or 0 when it is unknown. MetaData
+// does not export key positions, so raw is decoded again into a type with
+// a positionProbe at key and the position is read from the resulting
+// toml.ParseError.
+func keyLine(md toml.MetaData, raw string, key toml.Key) int {
+	if len(key) == 0 {
+		return 0
+	}
+	t := positionProbeType
+	for i := len(key) - 1; i >= 0; i-- {
+		part := key[i]
+		// Struct tags cannot name these keys.
+		if part == "" || part == "-" || strings.Contains(part, ",") {
+			return 0
+		}
+		if i < len(key)-1 && md.Type(key[:i+1]...) == "ArrayHash" {
+			t = reflect.SliceOf(t)
 		}
-		start = end + 1
+		t = reflect.StructOf([]reflect.StructField{{
+			Name: "F",
+			Type: t,
+			Tag:  reflect.StructTag("toml:" + strconv.Quote(part)),
+		}})
 	}
-	return warnings, nil
+	var pe toml.ParseError
+	if _, err := toml.Decode(raw, reflect.New(t).Interface()); errors.As(err, &pe) && pe.LastKey == key.String() {
+		return pe.Position.Line
+	}
+	return 0
 }
 
 func candidatePaths() ([]string, error) {
@@ -238,48 +371,3 @@ func stripComment(line string) string {
 
 	return line
 }
-
-func parseString(value string) (string, error) {
-	if value == "" {
-		return "", fmt.Errorf("empty string value")
-	}
-	value = strings.TrimSpace(value)
-	if strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") && len(value) >= 2 {
-		unquotedFast := value[1 : len(value)-1]
-		if !strings.Contains(unquotedFast, `\`) && !strings.Contains(unquotedFast, `"`) {
-			return unquotedFast, nil
-		}
-		unquoted, err := strconv.Unquote(value)
-		if err != nil {
-			return "", fmt.Errorf("invalid string %q", value)
-		}
-		return unquoted, nil
-	}
-	return "", fmt.Errorf("string must be quoted")
-}
-
-func parseBool(value string) (bool, error) {
-	switch strings.ToLower(strings.TrimSpace(value)) {
-	case "true":
-		return true, nil
-	case "false":
-		return false, nil
-	default:
-		return false, fmt.Errorf("invalid bool %q", value)
-	}
-}
-
-func parseInt(value string) (int, error) {
-	value = strings.TrimSpace(value)
-	if value == "" {
-		return 0, fmt.Errorf("empty number")
-	}
-	n, err := strconv.Atoi(value)
-	if err != nil {
-		return 0, fmt.Errorf("invalid number %q", value)
-	}
-	if n < 0 {
-		return 0, fmt.Errorf("number must be >= 0")
-	}
-	return n, nil
-}
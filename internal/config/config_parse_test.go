@@ -2,84 +2,16 @@ package config
 
 import (
 	"os"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
-func TestParseString(t *testing.T) {
-	tests := []struct {
-		name    string
-		input   string
-		want    string
-		wantErr bool
-	}{
-		{name: "quoted value", input: `"debug"`, want: "debug", wantErr: false},
-		{name: "escaped chars", input: `"val\"ue"`, want: `val"ue`, wantErr: false},
-		{name: "unquoted", input: "debug", wantErr: true},
-		{name: "empty", input: "", wantErr: true},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseString(tt.input)
-			if (err != nil) != tt.wantErr {
-				t.Fatalf("parseString(%q) error = %v, wantErr = %v", tt.input, err, tt.wantErr)
-			}
-			if err == nil && got != tt.want {
-				t.Fatalf("parseString(%q) = %q, want %q", tt.input, got, tt.want)
-			}
-		})
-	}
-}
-
-func TestParseBool(t *testing.T) {
-	tests := []struct {
-		input   string
-		want    bool
-		wantErr bool
-	}{
-		{input: "true", want: true, wantErr: false},
-		{input: "FALSE", want: false, wantErr: false},
-		{input: "yes", wantErr: true},
-	}
-
-	for _, tt := range tests {
-		got, err := parseBool(tt.input)
-		if (err != nil) != tt.wantErr {
-			t.Fatalf("parseBool(%q) error = %v, wantErr = %v", tt.input, err, tt.wantErr)
-		}
-		if err == nil && got != tt.want {
-			t.Fatalf("parseBool(%q) = %v, want %v", tt.input, got, tt.want)
-		}
-	}
-}
-
-func TestParseInt(t *testing.T) {
-	tests := []struct {
-		input   string
-		want    int
-		wantErr bool
-	}{
-		{input: "0", want: 0, wantErr: false},
-		{input: "15", want: 15, wantErr: false},
-		{input: "-1", wantErr: true},
-		{input: "abc", wantErr: true},
-	}
-
-	for _, tt := range tests {
-		got, err := parseInt(tt.input)
-		if (err != nil) != tt.wantErr {
-			t.Fatalf("parseInt(%q) error = %v, wantErr = %v", tt.input, err, tt.wantErr)
-		}
-		if err == nil && got != tt.want {
-			t.Fatalf("parseInt(%q) = %d, want %d", tt.input, got, tt.want)
-		}
-	}
-}
-
 func TestNormalizeConfig(t *testing.T) {
 	cfg := Config{
 		UI: UIConfig{
-			Theme: "custom",
+			Theme: "../custom",
 		},
 		Behavior: BehaviorConfig{
 			AutoRefreshSeconds: 5,
@@ -93,6 +25,15 @@ func TestNormalizeConfig(t *testing.T) {
 	if len(warnings) == 0 {
 		t.Fatalf("expected warnings, got none")
 	}
+	if cfg.Behavior.AutoRefreshSeconds != 5 {
+		t.Fatalf("auto_refresh_interval = %d, want 5", cfg.Behavior.AutoRefreshSeconds)
+	}
+
+	cfg = Default()
+	cfg.UI.Theme = "projector"
+	if warnings := normalizeConfig(&cfg); len(warnings) != 0 || cfg.UI.Theme != "projector" {
+		t.Fatalf("user theme names should be kept, theme = %q, warnings = %v", cfg.UI.Theme, warnings)
+	}
 }
 
 func TestNormalizeConfig_NoWarningsForDefaults(t *testing.T) {
@@ -111,24 +52,67 @@ theme = "default"
 [behavior]
 default_permanent = true
 auto_refresh_interval = 0
+confirm_timeout = 45
 
 [advanced]
 log_level = "debug"
+
+[keys]
+import = ["ctrl+o"]
+help = "f1"
+
+[themes.projector]
+base = "solarized-light"
+added = "#008700"
+
+[[templates]]
+name = "Bastion"
+sources = ["${ADMIN_NET}"]
+params = [{ name = "ADMIN_NET", prompt = "Admin network" }]
+
+[[templates]]
+name = "Broken"
+ports = ["http"]
 `
 	cfg := Default()
 	warnings, err := parse(raw, &cfg)
 	if err != nil {
 		t.Fatalf("parse() error = %v", err)
 	}
-	if len(warnings) != 0 {
-		t.Fatalf("expected no warnings, got %v", warnings)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `templates[1] "Broken": ports[0]`) {
+		t.Fatalf("expected a warning for the broken template, got %v", warnings)
+	}
+	if len(cfg.Templates) != 1 || cfg.Templates[0].Params[0].Prompt != "Admin network" || cfg.Templates[0].Origin != "config" {
+		t.Fatalf("templates = %+v", cfg.Templates)
 	}
 	if !cfg.Behavior.DefaultPermanent {
 		t.Fatalf("default_permanent was not parsed")
 	}
+	if cfg.Behavior.ConfirmTimeoutSeconds != 45 {
+		t.Fatalf("confirm_timeout = %d, want 45", cfg.Behavior.ConfirmTimeoutSeconds)
+	}
 	if cfg.Advanced.LogLevel != "debug" {
 		t.Fatalf("log_level = %q, want debug", cfg.Advanced.LogLevel)
 	}
+	keys := cfg.KeyBindings()
+	if !slices.Equal(keys["import"], []string{"ctrl+o"}) || !slices.Equal(keys["help"], []string{"f1"}) {
+		t.Fatalf("keys = %v", keys)
+	}
+	if cfg.Themes["projector"]["added"] != "#008700" {
+		t.Fatalf("themes = %v", cfg.Themes)
+	}
+}
+
+func TestNormalizeConfig_ClampsConfirmTimeout(t *testing.T) {
+	cfg := Default()
+	cfg.Behavior.ConfirmTimeoutSeconds = 3600
+	warnings := normalizeConfig(&cfg)
+	if cfg.Behavior.ConfirmTimeoutSeconds != maxConfirmTimeout {
+		t.Fatalf("confirm_timeout = %d, want %d", cfg.Behavior.ConfirmTimeoutSeconds, maxConfirmTimeout)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want one", warnings)
+	}
 }
 
 func TestParse_UnknownKeysProduceWarnings(t *testing.T) {
@@ -144,8 +128,85 @@ foo = "bar"
 	if err != nil {
 		t.Fatalf("parse() error = %v", err)
 	}
-	if len(warnings) < 2 {
-		t.Fatalf("expected warnings for unknown keys/section, got %v", warnings)
+	want := []string{
+		`line 3: unknown key "ui.unknown"`,
+		`line 5: unknown section "unknown_section"`,
+	}
+	if !slices.Equal(warnings, want) {
+		t.Fatalf("warnings = %q, want %q", warnings, want)
+	}
+}
+
+func TestParse_WarningLines(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "quoted key", raw: "[ui]\n\"the me\" = 1", want: `line 2: unknown key "ui.\"the me\""`},
+		{name: "dotted key", raw: "# top\n\nbehavior.foo = 1", want: `line 3: unknown key "behavior.foo"`},
+		{name: "after multi-line string", raw: "[ui]\ntheme = \"\"\"\nfoo = 1\n\"\"\"\nfoo = 2", want: `line 5: unknown key "ui.foo"`},
+		{name: "array of tables", raw: "[[templates]]\nname = \"a\"\n\n[[templates]]\nname = \"b\"\ncolour = 1", want: `line 6: unknown key "templates.colour"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			warnings, _ := parse(tt.raw, &cfg)
+			if !slices.Contains(warnings, tt.want) {
+				t.Fatalf("warnings = %q, want %q", warnings, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "[behavior]\nconfirm_timeout = \"30\"", want: "line 2"},
+		{raw: "[behavior]\n\nauto_refresh_interval = -1", want: "line 3: behavior.auto_refresh_interval: must be >= 0"},
+		{raw: "[advanced]\nlog_level = \"loud\"", want: "line 2: advanced.log_level: invalid log level"},
+		{raw: "[ui\ntheme = \"x\"", want: "to end table name"},
+		{raw: "[keys]\nhelp = 1", want: "expected a key or a list of keys"},
+	}
+
+	for _, tt := range tests {
+		cfg := Default()
+		_, err := parse(tt.raw, &cfg)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("parse(%q) error = %v, want %q", tt.raw, err, tt.want)
+		}
+	}
+}
+
+func TestDefaultFile(t *testing.T) {
+	cfg := Config{}
+	warnings, err := parse(DefaultFile(), &cfg)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("parse(DefaultFile()) = %v, %v", warnings, err)
+	}
+	if len(cfg.Keys) != 0 || len(cfg.Themes) != 0 {
+		t.Fatalf("DefaultFile() should only bind keys and themes in comments")
+	}
+	cfg.Keys, cfg.Themes = nil, nil
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Fatalf("DefaultFile() = %+v, want %+v", cfg, Default())
+	}
+}
+
+func TestConfigTheme(t *testing.T) {
+	cfg := Default()
+	cfg.UI.Theme = "projector"
+	cfg.Themes = map[string]map[string]string{"projector": {"base": "nord", "match": "1"}}
+	th, err := cfg.Theme("")
+	if err != nil || th.Match != "1" || th.Name != "projector" {
+		t.Fatalf("Theme() = %+v, %v", th, err)
+	}
+	cfg.Themes["projector"]["match"] = "red"
+	if _, err := cfg.Theme(""); err == nil || !strings.Contains(err.Error(), "themes.projector") {
+		t.Fatalf("Theme() error = %v", err)
 	}
 }
 
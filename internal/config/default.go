@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"lazyfirewall/internal/theme"
+)
+
+// DefaultFile returns a config.toml with every setting at its default and a
+// comment on each, as printed by `lazyfirewall config dump-default`.
+func DefaultFile() string {
+	d := Default()
+	return fmt.Sprintf(`# lazyfirewall configuration (~/.config/lazyfirewall/config.toml)
+
+[ui]
+# Built-in: %s.
+# Other names use [themes.<name>] below or themes/<name>.toml next to this file.
+theme = %q
+
+[behavior]
+# Start in permanent mode instead of runtime.
+default_permanent = %t
+# Seconds between refreshes; 0 follows firewalld's D-Bus signals.
+auto_refresh_interval = %d
+# Seconds to confirm changes that could cut off an SSH session; 0 disables, max %d.
+confirm_timeout = %d
+
+[advanced]
+# debug, info, warn or error; empty means info.
+log_level = %q
+
+# Keys of the main screen by action; the help screen (?) lists the actions.
+# An empty list unbinds an action.
+[keys]
+# import = "ctrl+o"
+# down = ["j", "down"]
+
+# Themes defined in place, selected with ui.theme = "<name>".
+# [themes.projector]
+# base = "solarized-light"
+# added = "#008700"
+
+# Zone templates (t in the UI), next to the built-in ones; a template of a
+# built-in name replaces it. ${NAME} parameters are asked for when applied.
+# [[templates]]
+# name = "Bastion"
+# description = "SSH from the admin network only"
+# params = [{ name = "ADMIN_NET", prompt = "Admin network (CIDR)", default = "10.0.0.0/8" }]
+# services = ["ssh"]
+# rich_rules = ['rule family="ipv4" source address="${ADMIN_NET}" service name="ssh" accept']
+# sources = []
+# ipsets = []
+# masquerade = false
+# target = "DROP"
+`, strings.Join(theme.Names(), ", "), d.UI.Theme,
+		d.Behavior.DefaultPermanent, d.Behavior.AutoRefreshSeconds, maxConfirmTimeout, d.Behavior.ConfirmTimeoutSeconds,
+		d.Advanced.LogLevel)
+}
@@ -0,0 +1,147 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import "time"
+
+// Backend is the firewall state the UI and command line operate on. Client
+// implements it over D-Bus; other implementations may edit configuration
+// offline or talk to a remote agent. Methods follow Client's semantics:
+// runtime and permanent variants are separate, and errors wrap the package's
+// sentinel errors where one applies.
+type Backend interface {
+	ReadOnly() bool
+	Close() error
+
+	// Zones.
+	ListZones() ([]string, error)
+	GetDefaultZone() (string, error)
+	SetDefaultZone(zone string) error
+	GetActiveZones() (map[string][]string, error)
+	GetZoneSettings(zone string, permanent bool) (*Zone, error)
+	AddZonePermanent(zone string) error
+	RemoveZonePermanent(zone string) error
+
+	// Zone elements.
+	AddServiceRuntime(zone, service string) error
+	AddServiceRuntimeTimeout(zone, service string, timeout time.Duration) error
+	AddServicePermanent(zone, service string) error
+	RemoveServiceRuntime(zone, service string) error
+	RemoveServicePermanent(zone, service string) error
+	AddPortRuntime(zone string, port Port) error
+	AddPortRuntimeTimeout(zone string, port Port, timeout time.Duration) error
+	AddPortPermanent(zone string, port Port) error
+	RemovePortRuntime(zone string, port Port) error
+	RemovePortPermanent(zone string, port Port) error
+	AddRichRuleRuntime(zone, rule string) error
+	AddRichRuleRuntimeTimeout(zone, rule string, timeout time.Duration) error
+	AddRichRulePermanent(zone, rule string) error
+	RemoveRichRuleRuntime(zone, rule string) error
+	RemoveRichRulePermanent(zone, rule string) error
+	AddInterfaceRuntime(zone, iface string) error
+	AddInterfacePermanent(zone, iface string) error
+	RemoveInterfaceRuntime(zone, iface string) error
+	RemoveInterfacePermanent(zone, iface string) error
+	AddSourceRuntime(zone, source string) error
+	AddSourcePermanent(zone, source string) error
+	RemoveSourceRuntime(zone, source string) error
+	RemoveSourcePermanent(zone, source string) error
+	EnableMasqueradeRuntime(zone string) error
+	EnableMasqueradePermanent(zone string) error
+	DisableMasqueradeRuntime(zone string) error
+	DisableMasqueradePermanent(zone string) error
+	AddForwardPortRuntime(zone string, fp ForwardPort) error
+	AddForwardPortPermanent(zone string, fp ForwardPort) error
+	RemoveForwardPortRuntime(zone string, fp ForwardPort) error
+	RemoveForwardPortPermanent(zone string, fp ForwardPort) error
+	SetTargetPermanent(zone, target string) error
+	AddIcmpBlockRuntime(zone, icmpType string) error
+	AddIcmpBlockPermanent(zone, icmpType string) error
+	RemoveIcmpBlockRuntime(zone, icmpType string) error
+	RemoveIcmpBlockPermanent(zone, icmpType string) error
+	EnableIcmpBlockInversionRuntime(zone string) error
+	EnableIcmpBlockInversionPermanent(zone string) error
+	DisableIcmpBlockInversionRuntime(zone string) error
+	DisableIcmpBlockInversionPermanent(zone string) error
+	ListIcmpTypes() ([]string, error)
+	GetIcmpType(name string) (*IcmpType, error)
+	CreateIcmpType(t *IcmpType) error
+	DeleteIcmpType(name string) error
+
+	// IPSets.
+	ListIPSets(permanent bool) ([]string, error)
+	GetIPSetEntries(name string, permanent bool) ([]string, error)
+	AddIPSetPermanent(name, ipsetType string) error
+	RemoveIPSetPermanent(name string) error
+	AddIPSetEntryRuntime(name, entry string) error
+	AddIPSetEntryPermanent(name, entry string) error
+	RemoveIPSetEntryRuntime(name, entry string) error
+	RemoveIPSetEntryPermanent(name, entry string) error
+
+	// Policies.
+	ListPolicies(permanent bool) ([]string, error)
+	GetPolicySettings(name string, permanent bool) (*Policy, error)
+	AddPolicyPermanent(p *Policy) error
+	UpdatePolicy(p *Policy, permanent bool) error
+	RemovePolicyPermanent(name string) error
+
+	// Services.
+	ListServiceNames() ([]string, error)
+	GetServiceDetails(name string) (*ServiceInfo, error)
+	CreateService(info *ServiceInfo) error
+	UpdateService(info *ServiceInfo) error
+	DeleteService(name string) error
+
+	// Helpers.
+	ListHelpers() ([]string, error)
+	GetHelper(name string) (*Helper, error)
+	CreateHelper(h *Helper) error
+	DeleteHelper(name string) error
+
+	// Direct chains, rules and passthroughs.
+	GetDirectSettings(permanent bool) (*DirectConfig, error)
+	AddDirectChainRuntime(ch DirectChain) error
+	AddDirectChainPermanent(ch DirectChain) error
+	RemoveDirectChainRuntime(ch DirectChain) error
+	RemoveDirectChainPermanent(ch DirectChain) error
+	AddDirectRuleRuntime(r DirectRule) error
+	AddDirectRulePermanent(r DirectRule) error
+	RemoveDirectRuleRuntime(r DirectRule) error
+	RemoveDirectRulePermanent(r DirectRule) error
+	AddDirectPassthroughRuntime(p DirectPassthrough) error
+	AddDirectPassthroughPermanent(p DirectPassthrough) error
+	RemoveDirectPassthroughRuntime(p DirectPassthrough) error
+	RemoveDirectPassthroughPermanent(p DirectPassthrough) error
+
+	// Runtime and permanent configuration.
+	RuntimeToPermanent() error
+	Reload() error
+
+	// Panic mode.
+	QueryPanicMode() (bool, error)
+	EnablePanicMode() error
+	DisablePanicMode() error
+
+	// Lockdown and its whitelist.
+	QueryLockdown() (bool, error)
+	EnableLockdown() error
+	DisableLockdown() error
+	GetLockdownWhitelist(permanent bool) (*LockdownWhitelist, error)
+	AddLockdownWhitelistRuntime(e LockdownEntry) error
+	AddLockdownWhitelistPermanent(e LockdownEntry) error
+	RemoveLockdownWhitelistRuntime(e LockdownEntry) error
+	RemoveLockdownWhitelistPermanent(e LockdownEntry) error
+
+	// Daemon settings from firewalld.conf.
+	GetLogDenied() (string, error)
+	SetLogDenied(value string) error
+	GetDaemonSettings() (map[string]string, error)
+	SetDaemonSetting(name, value string) error
+
+	// SubscribeSignals streams change notifications until the returned
+	// cancel function is called.
+	SubscribeSignals() (<-chan SignalEvent, func(), error)
+}
+
+var _ Backend = (*Client)(nil)
@@ -46,7 +46,12 @@ func NewClient() (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("connect system bus: %w", err)
 	}
+	return NewClientWithConn(conn)
+}
 
+// NewClientWithConn sets up a client on an already connected bus, such as a
+// private bus in tests. conn is closed if firewalld cannot be reached.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
 	obj := conn.Object(dbusInterface, dbusPath)
 	client := &Client{
 		conn:              conn,
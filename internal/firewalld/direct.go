@@ -0,0 +1,407 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// directTables are the tables firewalld accepts per direct IPV.
+var directTables = map[string][]string{
+	"ipv4": {"filter", "nat", "mangle", "raw", "security"},
+	"ipv6": {"filter", "nat", "mangle", "raw", "security"},
+	"eb":   {"filter", "nat", "broute"},
+}
+
+// DirectIPVs are the families the direct interface accepts.
+var DirectIPVs = []string{"ipv4", "ipv6", "eb"}
+
+type dbusDirectChain struct {
+	IPV   string
+	Table string
+	Chain string
+}
+
+type dbusDirectRule struct {
+	IPV      string
+	Table    string
+	Chain    string
+	Priority int32
+	Args     []string
+}
+
+type dbusDirectPassthrough struct {
+	IPV  string
+	Args []string
+}
+
+// dbusDirectSettings is the (a(sss)a(sssias)a(sas)) tuple of config.direct.
+type dbusDirectSettings struct {
+	Chains       []dbusDirectChain
+	Rules        []dbusDirectRule
+	Passthroughs []dbusDirectPassthrough
+}
+
+type directXML struct {
+	XMLName      xml.Name               `xml:"direct"`
+	Chains       []directChainXML       `xml:"chain"`
+	Rules        []directRuleXML        `xml:"rule"`
+	Passthroughs []directPassthroughXML `xml:"passthrough"`
+}
+
+type directChainXML struct {
+	IPV   string `xml:"ipv,attr"`
+	Table string `xml:"table,attr"`
+	Chain string `xml:"chain,attr"`
+}
+
+type directRuleXML struct {
+	IPV      string `xml:"ipv,attr"`
+	Table    string `xml:"table,attr"`
+	Chain    string `xml:"chain,attr"`
+	Priority int32  `xml:"priority,attr"`
+	Args     string `xml:",chardata"`
+}
+
+type directPassthroughXML struct {
+	IPV  string `xml:"ipv,attr"`
+	Args string `xml:",chardata"`
+}
+
+// GetDirectSettings returns the direct chains, rules and passthroughs.
+func (c *Client) GetDirectSettings(permanent bool) (*DirectConfig, error) {
+	if c.apiVersion != APIv2 {
+		return nil, ErrUnsupportedAPI
+	}
+
+	var settings dbusDirectSettings
+	if permanent {
+		slog.Debug("fetching direct settings (permanent)")
+		configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+		if err := c.callObject(configObj, dbusInterface+".config.direct.getSettings", &settings); err != nil {
+			return nil, mapDirectError(err)
+		}
+	} else {
+		slog.Debug("fetching direct settings (runtime)")
+		if err := c.call(dbusInterface+".direct.getAllChains", &settings.Chains); err != nil {
+			return nil, mapDirectError(err)
+		}
+		if err := c.call(dbusInterface+".direct.getAllRules", &settings.Rules); err != nil {
+			return nil, mapDirectError(err)
+		}
+		if err := c.call(dbusInterface+".direct.getAllPassthroughs", &settings.Passthroughs); err != nil {
+			return nil, mapDirectError(err)
+		}
+	}
+
+	cfg := &DirectConfig{}
+	for _, ch := range settings.Chains {
+		cfg.Chains = append(cfg.Chains, DirectChain(ch))
+	}
+	for _, r := range settings.Rules {
+		cfg.Rules = append(cfg.Rules, DirectRule(r))
+	}
+	for _, p := range settings.Passthroughs {
+		cfg.Passthroughs = append(cfg.Passthroughs, DirectPassthrough(p))
+	}
+	SortDirect(cfg)
+	return cfg, nil
+}
+
+func (c *Client) AddDirectChainRuntime(ch DirectChain) error {
+	return c.directChainCall("addChain", ch, false)
+}
+
+func (c *Client) AddDirectChainPermanent(ch DirectChain) error {
+	return c.directChainCall("addChain", ch, true)
+}
+
+func (c *Client) RemoveDirectChainRuntime(ch DirectChain) error {
+	return c.directChainCall("removeChain", ch, false)
+}
+
+func (c *Client) RemoveDirectChainPermanent(ch DirectChain) error {
+	return c.directChainCall("removeChain", ch, true)
+}
+
+func (c *Client) AddDirectRuleRuntime(r DirectRule) error {
+	return c.directRuleCall("addRule", r, false)
+}
+
+func (c *Client) AddDirectRulePermanent(r DirectRule) error {
+	return c.directRuleCall("addRule", r, true)
+}
+
+func (c *Client) RemoveDirectRuleRuntime(r DirectRule) error {
+	return c.directRuleCall("removeRule", r, false)
+}
+
+func (c *Client) RemoveDirectRulePermanent(r DirectRule) error {
+	return c.directRuleCall("removeRule", r, true)
+}
+
+func (c *Client) AddDirectPassthroughRuntime(p DirectPassthrough) error {
+	return c.directPassthroughCall("addPassthrough", p, false)
+}
+
+func (c *Client) AddDirectPassthroughPermanent(p DirectPassthrough) error {
+	return c.directPassthroughCall("addPassthrough", p, true)
+}
+
+func (c *Client) RemoveDirectPassthroughRuntime(p DirectPassthrough) error {
+	return c.directPassthroughCall("removePassthrough", p, false)
+}
+
+func (c *Client) RemoveDirectPassthroughPermanent(p DirectPassthrough) error {
+	return c.directPassthroughCall("removePassthrough", p, true)
+}
+
+func (c *Client) directChainCall(method string, ch DirectChain, permanent bool) error {
+	if err := CheckDirectChain(ch); err != nil {
+		return err
+	}
+	slog.Info("direct "+method+" ("+modeName(permanent)+")", "ipv", ch.IPV, "table", ch.Table, "chain", ch.Chain)
+	return c.directCall(method, permanent, ch.IPV, ch.Table, ch.Chain)
+}
+
+func (c *Client) directRuleCall(method string, r DirectRule, permanent bool) error {
+	if err := CheckDirectRule(r); err != nil {
+		return err
+	}
+	slog.Info("direct "+method+" ("+modeName(permanent)+")", "ipv", r.IPV, "table", r.Table, "chain", r.Chain, "priority", r.Priority, "args", r.Args)
+	return c.directCall(method, permanent, r.IPV, r.Table, r.Chain, r.Priority, r.Args)
+}
+
+func (c *Client) directPassthroughCall(method string, p DirectPassthrough, permanent bool) error {
+	if err := CheckDirectPassthrough(p); err != nil {
+		return err
+	}
+	slog.Info("direct "+method+" ("+modeName(permanent)+")", "ipv", p.IPV, "args", p.Args)
+	return c.directCall(method, permanent, p.IPV, p.Args)
+}
+
+// directCall runs method on the runtime direct interface or on config.direct.
+func (c *Client) directCall(method string, permanent bool, args ...any) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	var err error
+	if permanent {
+		configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+		err = c.callObject(configObj, dbusInterface+".config.direct."+method, nil, args...)
+	} else {
+		err = c.call(dbusInterface+".direct."+method, nil, args...)
+	}
+	if err != nil {
+		return mapDirectError(err)
+	}
+	return nil
+}
+
+func mapDirectError(err error) error {
+	if isPermissionDenied(err) {
+		return ErrPermissionDenied
+	}
+	return err
+}
+
+func modeName(permanent bool) string {
+	if permanent {
+		return "permanent"
+	}
+	return "runtime"
+}
+
+// CheckDirectChain validates the family, table and chain name.
+func CheckDirectChain(ch DirectChain) error {
+	tables, ok := directTables[ch.IPV]
+	if !ok {
+		return fmt.Errorf("invalid direct ipv %q (use %s)", ch.IPV, strings.Join(DirectIPVs, ", "))
+	}
+	if !slices.Contains(tables, ch.Table) {
+		return fmt.Errorf("invalid %s table %q (use %s)", ch.IPV, ch.Table, strings.Join(tables, ", "))
+	}
+	if ch.Chain == "" || strings.ContainsAny(ch.Chain, " \t") {
+		return fmt.Errorf("invalid chain name %q", ch.Chain)
+	}
+	return nil
+}
+
+// CheckDirectRule validates the chain part of r and that it has arguments.
+func CheckDirectRule(r DirectRule) error {
+	if err := CheckDirectChain(DirectChain{IPV: r.IPV, Table: r.Table, Chain: r.Chain}); err != nil {
+		return err
+	}
+	if len(r.Args) == 0 {
+		return fmt.Errorf("direct rule has no arguments")
+	}
+	return nil
+}
+
+// CheckDirectPassthrough validates the family and that p has arguments.
+func CheckDirectPassthrough(p DirectPassthrough) error {
+	if _, ok := directTables[p.IPV]; !ok {
+		return fmt.Errorf("invalid direct ipv %q (use %s)", p.IPV, strings.Join(DirectIPVs, ", "))
+	}
+	if len(p.Args) == 0 {
+		return fmt.Errorf("passthrough has no arguments")
+	}
+	return nil
+}
+
+// SortDirect orders cfg the way firewall-cmd lists it: chains and rules by
+// family, table and chain (rules then by priority), passthroughs by family.
+func SortDirect(cfg *DirectConfig) {
+	slices.SortStableFunc(cfg.Chains, func(a, b DirectChain) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	slices.SortStableFunc(cfg.Rules, func(a, b DirectRule) int {
+		if n := strings.Compare(a.IPV+" "+a.Table+" "+a.Chain, b.IPV+" "+b.Table+" "+b.Chain); n != 0 {
+			return n
+		}
+		return int(a.Priority) - int(b.Priority)
+	})
+	slices.SortStableFunc(cfg.Passthroughs, func(a, b DirectPassthrough) int {
+		return strings.Compare(a.IPV, b.IPV)
+	})
+}
+
+// String formats the chain like firewall-cmd --get-all-chains.
+func (ch DirectChain) String() string {
+	return ch.IPV + " " + ch.Table + " " + ch.Chain
+}
+
+// String formats the rule like firewall-cmd --get-all-rules.
+func (r DirectRule) String() string {
+	return fmt.Sprintf("%s %s %s %d %s", r.IPV, r.Table, r.Chain, r.Priority, JoinDirectArgs(r.Args))
+}
+
+// String formats the passthrough like firewall-cmd --get-all-passthroughs.
+func (p DirectPassthrough) String() string {
+	return p.IPV + " " + JoinDirectArgs(p.Args)
+}
+
+// Equal reports whether r and o are the same rule.
+func (r DirectRule) Equal(o DirectRule) bool {
+	return r.IPV == o.IPV && r.Table == o.Table && r.Chain == o.Chain && r.Priority == o.Priority && slices.Equal(r.Args, o.Args)
+}
+
+// Equal reports whether p and o are the same passthrough.
+func (p DirectPassthrough) Equal(o DirectPassthrough) bool {
+	return p.IPV == o.IPV && slices.Equal(p.Args, o.Args)
+}
+
+// SplitDirectArgs splits s into arguments the way a shell would for plain
+// words, single and double quotes, and backslash escapes.
+func SplitDirectArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		cur     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", s)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// JoinDirectArgs is the inverse of SplitDirectArgs; arguments with spaces,
+// quotes or backslashes are single-quoted.
+func JoinDirectArgs(args []string) string {
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\") {
+			out = append(out, arg)
+			continue
+		}
+		out = append(out, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(out, " ")
+}
+
+// ParseDirectXML decodes a firewalld direct.xml file.
+func ParseDirectXML(data []byte) (*DirectConfig, error) {
+	var raw directXML
+	if err := xml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse direct.xml: %w", err)
+	}
+	cfg := &DirectConfig{}
+	for _, ch := range raw.Chains {
+		cfg.Chains = append(cfg.Chains, DirectChain(ch))
+	}
+	for _, r := range raw.Rules {
+		args, err := SplitDirectArgs(r.Args)
+		if err != nil {
+			return nil, fmt.Errorf("parse direct.xml rule: %w", err)
+		}
+		cfg.Rules = append(cfg.Rules, DirectRule{IPV: r.IPV, Table: r.Table, Chain: r.Chain, Priority: r.Priority, Args: args})
+	}
+	for _, p := range raw.Passthroughs {
+		args, err := SplitDirectArgs(p.Args)
+		if err != nil {
+			return nil, fmt.Errorf("parse direct.xml passthrough: %w", err)
+		}
+		cfg.Passthroughs = append(cfg.Passthroughs, DirectPassthrough{IPV: p.IPV, Args: args})
+	}
+	return cfg, nil
+}
+
+// MarshalDirectXML encodes cfg as a firewalld direct.xml file.
+func MarshalDirectXML(cfg *DirectConfig) ([]byte, error) {
+	var raw directXML
+	for _, ch := range cfg.Chains {
+		raw.Chains = append(raw.Chains, directChainXML(ch))
+	}
+	for _, r := range cfg.Rules {
+		raw.Rules = append(raw.Rules, directRuleXML{IPV: r.IPV, Table: r.Table, Chain: r.Chain, Priority: r.Priority, Args: JoinDirectArgs(r.Args)})
+	}
+	for _, p := range cfg.Passthroughs {
+		raw.Passthroughs = append(raw.Passthroughs, directPassthroughXML{IPV: p.IPV, Args: JoinDirectArgs(p.Args)})
+	}
+	data, err := xml.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode direct.xml: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
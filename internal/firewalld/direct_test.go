@@ -0,0 +1,97 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitDirectArgs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: "-p tcp --dport 22 -j ACCEPT", want: []string{"-p", "tcp", "--dport", "22", "-j", "ACCEPT"}},
+		{in: `-m comment --comment "allow ssh"`, want: []string{"-m", "comment", "--comment", "allow ssh"}},
+		{in: `--comment 'it\'s'`, wantErr: true},
+		{in: `--comment it\'s`, want: []string{"--comment", "it's"}},
+		{in: `--comment ""`, want: []string{"--comment", ""}},
+		{in: "  ", want: nil},
+		{in: `"open`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := SplitDirectArgs(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("SplitDirectArgs(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("SplitDirectArgs(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if err == nil {
+			back, err := SplitDirectArgs(JoinDirectArgs(got))
+			if err != nil || !reflect.DeepEqual(back, tt.want) {
+				t.Fatalf("JoinDirectArgs(%q) does not split back: %q, %v", got, back, err)
+			}
+		}
+	}
+}
+
+func TestDirectXMLRoundTrip(t *testing.T) {
+	cfg := &DirectConfig{
+		Chains: []DirectChain{{IPV: "ipv4", Table: "filter", Chain: "blacklist"}},
+		Rules: []DirectRule{
+			{IPV: "ipv4", Table: "filter", Chain: "INPUT", Priority: -1, Args: []string{"-j", "blacklist"}},
+			{IPV: "ipv6", Table: "filter", Chain: "INPUT", Priority: 0, Args: []string{"-m", "comment", "--comment", "allow ssh", "-j", "ACCEPT"}},
+		},
+		Passthroughs: []DirectPassthrough{{IPV: "eb", Args: []string{"-t", "nat", "-L"}}},
+	}
+	data, err := MarshalDirectXML(cfg)
+	if err != nil {
+		t.Fatalf("MarshalDirectXML() error = %v", err)
+	}
+	got, err := ParseDirectXML(data)
+	if err != nil {
+		t.Fatalf("ParseDirectXML() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Fatalf("round trip = %+v, want %+v", got, cfg)
+	}
+}
+
+func TestCheckDirect(t *testing.T) {
+	if err := CheckDirectChain(DirectChain{IPV: "eb", Table: "broute", Chain: "BROUTING"}); err != nil {
+		t.Fatalf("CheckDirectChain(eb broute) error = %v", err)
+	}
+	bad := []DirectChain{
+		{IPV: "inet", Table: "filter", Chain: "x"},
+		{IPV: "ipv4", Table: "broute", Chain: "x"},
+		{IPV: "ipv4", Table: "filter", Chain: ""},
+		{IPV: "ipv4", Table: "filter", Chain: "two words"},
+	}
+	for _, ch := range bad {
+		if err := CheckDirectChain(ch); err == nil {
+			t.Fatalf("CheckDirectChain(%+v) should fail", ch)
+		}
+	}
+	if err := CheckDirectRule(DirectRule{IPV: "ipv4", Table: "filter", Chain: "INPUT"}); err == nil {
+		t.Fatalf("CheckDirectRule() should reject a rule without arguments")
+	}
+	if err := CheckDirectPassthrough(DirectPassthrough{IPV: "ipv4"}); err == nil {
+		t.Fatalf("CheckDirectPassthrough() should reject a passthrough without arguments")
+	}
+}
+
+func TestDirectMethodsRequireAPIv2(t *testing.T) {
+	c := &Client{}
+	if _, err := c.GetDirectSettings(false); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("GetDirectSettings() error = %v, want ErrUnsupportedAPI", err)
+	}
+	rule := DirectRule{IPV: "ipv4", Table: "filter", Chain: "INPUT", Args: []string{"-j", "ACCEPT"}}
+	if err := c.AddDirectRulePermanent(rule); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("AddDirectRulePermanent() error = %v, want ErrUnsupportedAPI", err)
+	}
+}
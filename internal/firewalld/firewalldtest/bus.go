@@ -0,0 +1,108 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ErrNoDaemon is returned by StartBus when dbus-daemon is not installed.
+var ErrNoDaemon = errors.New("dbus-daemon not found in PATH")
+
+const busStartTimeout = 10 * time.Second
+
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:path=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// Bus is a private dbus-daemon listening on a socket in a temporary
+// directory. It does not touch the system or session bus.
+type Bus struct {
+	Address string
+
+	cmd *exec.Cmd
+	dir string
+}
+
+// StartBus launches a private dbus-daemon and waits until it accepts
+// connections.
+func StartBus() (*Bus, error) {
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		return nil, ErrNoDaemon
+	}
+	dir, err := os.MkdirTemp("", "lazyfirewall-dbus-")
+	if err != nil {
+		return nil, fmt.Errorf("create bus dir: %w", err)
+	}
+	config := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(config, []byte(fmt.Sprintf(busConfig, filepath.Join(dir, "bus"))), 0o600); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("write bus config: %w", err)
+	}
+
+	cmd := exec.Command(daemon, "--config-file="+config, "--nofork", "--nopidfile", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("start dbus-daemon: %w", err)
+	}
+	b := &Bus{cmd: cmd, dir: dir}
+
+	addr := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		addr <- strings.TrimSpace(line)
+	}()
+	select {
+	case b.Address = <-addr:
+	case <-time.After(busStartTimeout):
+	}
+	if b.Address == "" {
+		b.Close()
+		return nil, fmt.Errorf("dbus-daemon did not report an address")
+	}
+	return b, nil
+}
+
+// Connect opens a new authenticated connection to the bus.
+func (b *Bus) Connect() (*dbus.Conn, error) {
+	return dbus.Connect(b.Address)
+}
+
+// Close stops the daemon and removes its socket directory.
+func (b *Bus) Close() error {
+	if b == nil || b.cmd == nil {
+		return nil
+	}
+	if b.cmd.Process != nil {
+		_ = b.cmd.Process.Kill()
+		_ = b.cmd.Wait()
+	}
+	b.cmd = nil
+	return os.RemoveAll(b.dir)
+}
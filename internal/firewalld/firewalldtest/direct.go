@@ -0,0 +1,185 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type DirectChain struct {
+	IPV   string
+	Table string
+	Chain string
+}
+
+type DirectRule struct {
+	IPV      string
+	Table    string
+	Chain    string
+	Priority int32
+	Args     []string
+}
+
+type DirectPassthrough struct {
+	IPV  string
+	Args []string
+}
+
+// Direct is the direct configuration of one mode, in the layout of the
+// config.direct settings tuple.
+type Direct struct {
+	Chains       []DirectChain
+	Rules        []DirectRule
+	Passthroughs []DirectPassthrough
+}
+
+func (d Direct) clone() Direct {
+	out := Direct{
+		Chains:       append([]DirectChain{}, d.Chains...),
+		Rules:        make([]DirectRule, 0, len(d.Rules)),
+		Passthroughs: make([]DirectPassthrough, 0, len(d.Passthroughs)),
+	}
+	for _, r := range d.Rules {
+		r.Args = append([]string(nil), r.Args...)
+		out.Rules = append(out.Rules, r)
+	}
+	for _, p := range d.Passthroughs {
+		p.Args = append([]string(nil), p.Args...)
+		out.Passthroughs = append(out.Passthroughs, p)
+	}
+	return out
+}
+
+// Direct returns a copy of the runtime or permanent direct configuration.
+func (s *Server) Direct(permanent bool) Direct {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.directFor(permanent).clone()
+}
+
+// SetDirect replaces the direct configuration of one mode.
+func (s *Server) SetDirect(d Direct, permanent bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.directFor(permanent) = d.clone()
+}
+
+func (s *Server) directFor(permanent bool) *Direct {
+	if permanent {
+		return &s.directPerm
+	}
+	return &s.directRun
+}
+
+// directMethods implements the add/remove calls shared by the runtime
+// direct interface and config.direct.
+func (s *Server) directMethods(permanent bool) map[string]interface{} {
+	mutate := func(fn func(d *Direct) *dbus.Error) *dbus.Error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if err := s.checkWritable(); err != nil {
+			return err
+		}
+		return fn(s.directFor(permanent))
+	}
+	return map[string]interface{}{
+		"getAllChains": func() ([]DirectChain, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.directFor(permanent).clone().Chains, nil
+		},
+		"getAllRules": func() ([]DirectRule, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.directFor(permanent).clone().Rules, nil
+		},
+		"getAllPassthroughs": func() ([]DirectPassthrough, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.directFor(permanent).clone().Passthroughs, nil
+		},
+		"addChain": func(ipv, table, chain string) *dbus.Error {
+			return mutate(func(d *Direct) *dbus.Error {
+				ch := DirectChain{ipv, table, chain}
+				if slices.Contains(d.Chains, ch) {
+					return fwError("ALREADY_ENABLED", chain)
+				}
+				d.Chains = append(d.Chains, ch)
+				return nil
+			})
+		},
+		"removeChain": func(ipv, table, chain string) *dbus.Error {
+			return mutate(func(d *Direct) *dbus.Error {
+				idx := slices.Index(d.Chains, DirectChain{ipv, table, chain})
+				if idx < 0 {
+					return fwError("NOT_ENABLED", chain)
+				}
+				d.Chains = slices.Delete(d.Chains, idx, idx+1)
+				return nil
+			})
+		},
+		"addRule": func(ipv, table, chain string, priority int32, args []string) *dbus.Error {
+			return mutate(func(d *Direct) *dbus.Error {
+				r := DirectRule{ipv, table, chain, priority, args}
+				if slices.ContainsFunc(d.Rules, r.equal) {
+					return fwError("ALREADY_ENABLED", strings.Join(args, " "))
+				}
+				d.Rules = append(d.Rules, r)
+				return nil
+			})
+		},
+		"removeRule": func(ipv, table, chain string, priority int32, args []string) *dbus.Error {
+			return mutate(func(d *Direct) *dbus.Error {
+				idx := slices.IndexFunc(d.Rules, DirectRule{ipv, table, chain, priority, args}.equal)
+				if idx < 0 {
+					return fwError("NOT_ENABLED", strings.Join(args, " "))
+				}
+				d.Rules = slices.Delete(d.Rules, idx, idx+1)
+				return nil
+			})
+		},
+		"addPassthrough": func(ipv string, args []string) *dbus.Error {
+			return mutate(func(d *Direct) *dbus.Error {
+				p := DirectPassthrough{ipv, args}
+				if slices.ContainsFunc(d.Passthroughs, p.equal) {
+					return fwError("ALREADY_ENABLED", strings.Join(args, " "))
+				}
+				d.Passthroughs = append(d.Passthroughs, p)
+				return nil
+			})
+		},
+		"removePassthrough": func(ipv string, args []string) *dbus.Error {
+			return mutate(func(d *Direct) *dbus.Error {
+				idx := slices.IndexFunc(d.Passthroughs, DirectPassthrough{ipv, args}.equal)
+				if idx < 0 {
+					return fwError("NOT_ENABLED", strings.Join(args, " "))
+				}
+				d.Passthroughs = slices.Delete(d.Passthroughs, idx, idx+1)
+				return nil
+			})
+		},
+	}
+}
+
+// configDirectMethods implements org.fedoraproject.FirewallD1.config.direct.
+func (s *Server) configDirectMethods() map[string]interface{} {
+	methods := s.directMethods(true)
+	methods["getSettings"] = func() (Direct, *dbus.Error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.directPerm.clone(), nil
+	}
+	return methods
+}
+
+func (r DirectRule) equal(o DirectRule) bool {
+	return r.IPV == o.IPV && r.Table == o.Table && r.Chain == o.Chain && r.Priority == o.Priority && slices.Equal(r.Args, o.Args)
+}
+
+func (p DirectPassthrough) equal(o DirectPassthrough) bool {
+	return p.IPV == o.IPV && slices.Equal(p.Args, o.Args)
+}
@@ -0,0 +1,7 @@
+//go:build linux
+// +build linux
+
+// Package firewalldtest runs an in-memory firewalld on a private D-Bus bus so
+// the firewalld client can be tested end-to-end without root or a real
+// firewalld. It needs dbus-daemon in PATH; Start skips the test otherwise.
+package firewalldtest
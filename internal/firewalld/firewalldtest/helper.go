@@ -0,0 +1,128 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Helper is the (sssssa(ss)) settings tuple of a helper.
+type Helper struct {
+	Version     string
+	Short       string
+	Description string
+	Family      string
+	Module      string
+	Ports       []Port
+}
+
+// BuiltinHelpers are the shipped helpers a new Server knows; they cannot be
+// removed.
+func BuiltinHelpers() map[string]Helper {
+	return map[string]Helper{
+		"ftp":  {Short: "FTP", Module: "nf_conntrack_ftp", Ports: []Port{{Port: "21", Protocol: "tcp"}}},
+		"sip":  {Short: "SIP", Module: "nf_conntrack_sip", Ports: []Port{{Port: "5060", Protocol: "udp"}}},
+		"tftp": {Short: "TFTP", Module: "nf_conntrack_tftp", Ports: []Port{{Port: "69", Protocol: "udp"}}},
+	}
+}
+
+// Helper returns the settings of helper name.
+func (s *Server) Helper(name string) (Helper, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, ok := s.helpers[name]
+	return h, ok
+}
+
+func (s *Server) configHelperMethods() map[string]interface{} {
+	return map[string]interface{}{
+		"getHelperNames": func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return sortedNames(s.helpers), nil
+		},
+		"getHelperByName": func(name string) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if _, ok := s.helpers[name]; !ok {
+				return "", fwError("INVALID_HELPER", name)
+			}
+			return s.helperPaths[name], nil
+		},
+		"addHelper": func(name string, settings Helper) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return "", err
+			}
+			if _, ok := s.helpers[name]; ok {
+				return "", fwError("NAME_CONFLICT", name)
+			}
+			s.helpers[name] = settings
+			s.exportHelper(name)
+			s.emit(dbusConfigPath, dbusInterface+".config.HelperAdded", name)
+			return s.helperPaths[name], nil
+		},
+	}
+}
+
+// configHelperObjectMethods implements org.fedoraproject.FirewallD1.config.helper
+// for helper name.
+func (s *Server) configHelperObjectMethods(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"getSettings": func() (Helper, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			h, ok := s.helpers[name]
+			if !ok {
+				return Helper{}, fwError("INVALID_HELPER", name)
+			}
+			if h.Ports == nil {
+				h.Ports = []Port{}
+			}
+			return h, nil
+		},
+		"remove": func() *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			if _, ok := s.helpers[name]; !ok {
+				return fwError("INVALID_HELPER", name)
+			}
+			if s.builtinHlp[name] {
+				return fwError("BUILTIN_HELPER", name)
+			}
+			path := s.helperPaths[name]
+			delete(s.helpers, name)
+			delete(s.helperPaths, name)
+			_ = s.conn.ExportMethodTable(nil, path, dbusInterface+".config.helper")
+			_ = s.conn.ExportMethodTable(nil, path, propsInterface)
+			s.emit(path, dbusInterface+".config.helper.Removed", name)
+			return nil
+		},
+	}
+}
+
+// exportHelper publishes the config object of a helper with its builtin
+// property. Callers hold s.mu or have not shared s yet.
+func (s *Server) exportHelper(name string) {
+	path := dbus.ObjectPath(fmt.Sprintf("%s/helper/%d", dbusConfigPath, s.helperSeq))
+	s.helperSeq++
+	s.helperPaths[name] = path
+	_ = s.conn.ExportMethodTable(s.configHelperObjectMethods(name), path, dbusInterface+".config.helper")
+	_ = s.conn.ExportMethodTable(map[string]interface{}{
+		"Get": func(iface, prop string) (dbus.Variant, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if iface != dbusInterface+".config.helper" || prop != "builtin" {
+				return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{"unknown property " + prop})
+			}
+			return dbus.MakeVariant(s.builtinHlp[name]), nil
+		},
+	}, path, propsInterface)
+}
@@ -0,0 +1,124 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// IcmpType is a custom ICMP type added through config.addIcmpType. The
+// server does not keep runtime and permanent ICMP types apart: a new type
+// can be used in zones at once.
+type IcmpType struct {
+	Version      string
+	Short        string
+	Description  string
+	Destinations []string
+}
+
+// CustomIcmpType returns a custom ICMP type added by a client.
+func (s *Server) CustomIcmpType(name string) (IcmpType, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.icmpCustom[name]
+	return t, ok
+}
+
+func (s *Server) configIcmpTypeMethods() map[string]interface{} {
+	return map[string]interface{}{
+		"getIcmpTypeNames": func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return append([]string(nil), s.icmpTypes...), nil
+		},
+		"getIcmpTypeByName": func(name string) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			path, ok := s.icmpPaths[name]
+			if !ok {
+				return "", fwError("INVALID_ICMPTYPE", name)
+			}
+			return path, nil
+		},
+		"addIcmpType": func(name string, settings IcmpType) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return "", err
+			}
+			if slices.Contains(s.icmpTypes, name) {
+				return "", fwError("NAME_CONFLICT", name)
+			}
+			s.icmpCustom[name] = settings
+			s.icmpTypes = append(s.icmpTypes, name)
+			sort.Strings(s.icmpTypes)
+			s.exportIcmpType(name)
+			s.emit(dbusConfigPath, dbusInterface+".config.IcmpTypeAdded", name)
+			return s.icmpPaths[name], nil
+		},
+	}
+}
+
+// configIcmpTypeObjectMethods implements
+// org.fedoraproject.FirewallD1.config.icmptype for ICMP type name.
+func (s *Server) configIcmpTypeObjectMethods(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"getSettings": func() (IcmpType, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if t, ok := s.icmpCustom[name]; ok {
+				return t, nil
+			}
+			if !slices.Contains(s.icmpTypes, name) {
+				return IcmpType{}, fwError("INVALID_ICMPTYPE", name)
+			}
+			return IcmpType{Short: name, Destinations: []string{}}, nil
+		},
+		"remove": func() *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			if !slices.Contains(s.icmpTypes, name) {
+				return fwError("INVALID_ICMPTYPE", name)
+			}
+			if _, ok := s.icmpCustom[name]; !ok {
+				return fwError("BUILTIN_ICMPTYPE", name)
+			}
+			path := s.icmpPaths[name]
+			delete(s.icmpCustom, name)
+			delete(s.icmpPaths, name)
+			s.icmpTypes = slices.DeleteFunc(s.icmpTypes, func(t string) bool { return t == name })
+			_ = s.conn.ExportMethodTable(nil, path, dbusInterface+".config.icmptype")
+			_ = s.conn.ExportMethodTable(nil, path, propsInterface)
+			s.emit(path, dbusInterface+".config.icmptype.Removed", name)
+			return nil
+		},
+	}
+}
+
+// exportIcmpType publishes the config object of an ICMP type with its
+// builtin property. Callers hold s.mu or have not shared s yet.
+func (s *Server) exportIcmpType(name string) {
+	path := dbus.ObjectPath(fmt.Sprintf("%s/icmptype/%d", dbusConfigPath, s.icmpSeq))
+	s.icmpSeq++
+	s.icmpPaths[name] = path
+	_ = s.conn.ExportMethodTable(s.configIcmpTypeObjectMethods(name), path, dbusInterface+".config.icmptype")
+	_ = s.conn.ExportMethodTable(map[string]interface{}{
+		"Get": func(iface, prop string) (dbus.Variant, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if iface != dbusInterface+".config.icmptype" || prop != "builtin" {
+				return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{"unknown property " + prop})
+			}
+			_, custom := s.icmpCustom[name]
+			return dbus.MakeVariant(!custom), nil
+		},
+	}, path, propsInterface)
+}
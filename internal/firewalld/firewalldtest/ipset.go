@@ -0,0 +1,157 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type IPSet struct {
+	Type    string
+	Entries []string
+}
+
+// ipsetSettings is the (ssssa{ss}as) tuple addIPSet takes.
+type ipsetSettings struct {
+	Version     string
+	Short       string
+	Description string
+	Type        string
+	Options     map[string]string
+	Entries     []string
+}
+
+// SetIPSet replaces ipset name in both runtime and permanent configuration.
+func (s *Server) SetIPSet(name string, set IPSet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runtimeSets[name] = &IPSet{Type: set.Type, Entries: slices.Clone(set.Entries)}
+	s.permSets[name] = &IPSet{Type: set.Type, Entries: slices.Clone(set.Entries)}
+	s.exportIPSet(name)
+}
+
+// IPSetEntries returns the runtime or permanent entries of ipset name.
+func (s *Server) IPSetEntries(name string, permanent bool) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sets := s.runtimeSets
+	if permanent {
+		sets = s.permSets
+	}
+	set, ok := sets[name]
+	if !ok {
+		return nil, false
+	}
+	return slices.Clone(set.Entries), true
+}
+
+func (s *Server) runtimeIPSetMethods() map[string]interface{} {
+	return map[string]interface{}{
+		"getIPSets": func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return sortedNames(s.runtimeSets), nil
+		},
+		"getEntries": func(name string) ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			set, err := lookupIPSet(s.runtimeSets, name)
+			if err != nil {
+				return nil, err
+			}
+			return nonNil(slices.Clone(set.Entries)), nil
+		},
+		"addEntry": func(name, entry string) *dbus.Error {
+			return s.changeIPSet(s.runtimeSets, name, func(set *IPSet) *dbus.Error {
+				return addItem(&set.Entries, entry, "entry")
+			})
+		},
+		"removeEntry": func(name, entry string) *dbus.Error {
+			return s.changeIPSet(s.runtimeSets, name, func(set *IPSet) *dbus.Error {
+				return removeItem(&set.Entries, entry, "entry")
+			})
+		},
+	}
+}
+
+// configIPSetMethods implements org.fedoraproject.FirewallD1.config.ipset
+// for the permanent ipset name.
+func (s *Server) configIPSetMethods(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"getEntries": func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			set, err := lookupIPSet(s.permSets, name)
+			if err != nil {
+				return nil, err
+			}
+			return nonNil(slices.Clone(set.Entries)), nil
+		},
+		"addEntry": func(entry string) *dbus.Error {
+			return s.changeIPSet(s.permSets, name, func(set *IPSet) *dbus.Error {
+				return addItem(&set.Entries, entry, "entry")
+			})
+		},
+		"removeEntry": func(entry string) *dbus.Error {
+			return s.changeIPSet(s.permSets, name, func(set *IPSet) *dbus.Error {
+				return removeItem(&set.Entries, entry, "entry")
+			})
+		},
+		"remove": func() *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			if _, err := lookupIPSet(s.permSets, name); err != nil {
+				return err
+			}
+			delete(s.permSets, name)
+			_ = s.conn.ExportMethodTable(nil, ipsetPath(name), dbusInterface+".config.ipset")
+			s.emit(ipsetPath(name), dbusInterface+".config.ipset.Removed", name)
+			return nil
+		},
+	}
+}
+
+func (s *Server) changeIPSet(sets map[string]*IPSet, name string, fn func(*IPSet) *dbus.Error) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	set, err := lookupIPSet(sets, name)
+	if err != nil {
+		return err
+	}
+	return fn(set)
+}
+
+// exportIPSet publishes the config object of a permanent ipset at the path
+// derived from its name. Callers hold s.mu.
+func (s *Server) exportIPSet(name string) {
+	_ = s.conn.ExportMethodTable(s.configIPSetMethods(name), ipsetPath(name), dbusInterface+".config.ipset")
+}
+
+func ipsetPath(name string) dbus.ObjectPath {
+	return dbus.ObjectPath(dbusConfigPath + "/ipset/" + name)
+}
+
+func lookupIPSet(sets map[string]*IPSet, name string) (*IPSet, *dbus.Error) {
+	set, ok := sets[name]
+	if !ok {
+		return nil, fwError("INVALID_IPSET", name)
+	}
+	return set, nil
+}
+
+func cloneIPSets(sets map[string]*IPSet) map[string]*IPSet {
+	out := make(map[string]*IPSet, len(sets))
+	for name, set := range sets {
+		out[name] = &IPSet{Type: set.Type, Entries: slices.Clone(set.Entries)}
+	}
+	return out
+}
@@ -0,0 +1,182 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// LockdownWhitelist is the whitelist of one mode, in the layout of the
+// config.policies getLockdownWhitelist tuple.
+type LockdownWhitelist struct {
+	Commands []string
+	Contexts []string
+	Users    []string
+	Uids     []int32
+}
+
+func (w LockdownWhitelist) clone() LockdownWhitelist {
+	return LockdownWhitelist{
+		Commands: append([]string{}, w.Commands...),
+		Contexts: append([]string{}, w.Contexts...),
+		Users:    append([]string{}, w.Users...),
+		Uids:     append([]int32{}, w.Uids...),
+	}
+}
+
+// Lockdown reports whether lockdown is enabled.
+func (s *Server) Lockdown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lockdown
+}
+
+// LockdownWhitelist returns a copy of the runtime or permanent whitelist.
+func (s *Server) LockdownWhitelist(permanent bool) LockdownWhitelist {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.whitelistFor(permanent).clone()
+}
+
+// SetLockdownWhitelist replaces the whitelist of one mode.
+func (s *Server) SetLockdownWhitelist(w LockdownWhitelist, permanent bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.whitelistFor(permanent) = w.clone()
+}
+
+func (s *Server) whitelistFor(permanent bool) *LockdownWhitelist {
+	if permanent {
+		return &s.allowPerm
+	}
+	return &s.allowRun
+}
+
+func (s *Server) setLockdown(enabled bool) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if s.lockdown == enabled {
+		if enabled {
+			return fwError("ALREADY_ENABLED", "lockdown")
+		}
+		return fwError("NOT_ENABLED", "lockdown")
+	}
+	s.lockdown = enabled
+	if enabled {
+		s.emit(dbusPath, dbusInterface+".policies.LockdownEnabled")
+	} else {
+		s.emit(dbusPath, dbusInterface+".policies.LockdownDisabled")
+	}
+	return nil
+}
+
+// whitelistMethods implements the add/remove/query/get calls shared by the
+// runtime policies interface and config.policies.
+func (s *Server) whitelistMethods(permanent bool) map[string]interface{} {
+	methods := map[string]interface{}{}
+	addList := func(suffix string, list func(w *LockdownWhitelist) *[]string) {
+		methods["addLockdownWhitelist"+suffix] = func(value string) *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			items := list(s.whitelistFor(permanent))
+			if slices.Contains(*items, value) {
+				return fwError("ALREADY_ENABLED", value)
+			}
+			*items = append(*items, value)
+			return nil
+		}
+		methods["removeLockdownWhitelist"+suffix] = func(value string) *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			items := list(s.whitelistFor(permanent))
+			idx := slices.Index(*items, value)
+			if idx < 0 {
+				return fwError("NOT_ENABLED", value)
+			}
+			*items = slices.Delete(*items, idx, idx+1)
+			return nil
+		}
+		methods["getLockdownWhitelist"+suffix+"s"] = func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return append([]string{}, *list(s.whitelistFor(permanent))...), nil
+		}
+	}
+	addList("Command", func(w *LockdownWhitelist) *[]string { return &w.Commands })
+	addList("Context", func(w *LockdownWhitelist) *[]string { return &w.Contexts })
+	addList("User", func(w *LockdownWhitelist) *[]string { return &w.Users })
+
+	methods["addLockdownWhitelistUid"] = func(uid int32) *dbus.Error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if err := s.checkWritable(); err != nil {
+			return err
+		}
+		w := s.whitelistFor(permanent)
+		if slices.Contains(w.Uids, uid) {
+			return fwError("ALREADY_ENABLED", "uid")
+		}
+		w.Uids = append(w.Uids, uid)
+		return nil
+	}
+	methods["removeLockdownWhitelistUid"] = func(uid int32) *dbus.Error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if err := s.checkWritable(); err != nil {
+			return err
+		}
+		w := s.whitelistFor(permanent)
+		idx := slices.Index(w.Uids, uid)
+		if idx < 0 {
+			return fwError("NOT_ENABLED", "uid")
+		}
+		w.Uids = slices.Delete(w.Uids, idx, idx+1)
+		return nil
+	}
+	methods["getLockdownWhitelistUids"] = func() ([]int32, *dbus.Error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return append([]int32{}, s.whitelistFor(permanent).Uids...), nil
+	}
+	return methods
+}
+
+// policiesMethods implements org.fedoraproject.FirewallD1.policies.
+func (s *Server) policiesMethods() map[string]interface{} {
+	methods := s.whitelistMethods(false)
+	methods["queryLockdown"] = func() (bool, *dbus.Error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.lockdown, nil
+	}
+	methods["enableLockdown"] = func() *dbus.Error {
+		return s.setLockdown(true)
+	}
+	methods["disableLockdown"] = func() *dbus.Error {
+		return s.setLockdown(false)
+	}
+	return methods
+}
+
+// configPoliciesMethods implements org.fedoraproject.FirewallD1.config.policies.
+func (s *Server) configPoliciesMethods() map[string]interface{} {
+	methods := s.whitelistMethods(true)
+	methods["getLockdownWhitelist"] = func() (LockdownWhitelist, *dbus.Error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.allowPerm.clone(), nil
+	}
+	return methods
+}
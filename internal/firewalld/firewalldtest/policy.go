@@ -0,0 +1,144 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func (s *Server) runtimePolicyMethods() map[string]interface{} {
+	return map[string]interface{}{
+		"getPolicies": func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return sortedNames(s.runtimePol), nil
+		},
+		"getPolicySettings": func(name string) (map[string]dbus.Variant, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			settings, ok := s.runtimePol[name]
+			if !ok {
+				return nil, fwError("INVALID_POLICY", name)
+			}
+			return settings, nil
+		},
+		"setPolicySettings": func(name string, settings map[string]dbus.Variant) *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			if _, ok := s.runtimePol[name]; !ok {
+				return fwError("INVALID_POLICY", name)
+			}
+			s.runtimePol[name] = normalizePolicy(settings)
+			return nil
+		},
+	}
+}
+
+// configPolicyMethods implements org.fedoraproject.FirewallD1.config.policy
+// for the permanent policy name.
+func (s *Server) configPolicyMethods(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"getSettings": func() (map[string]dbus.Variant, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			settings, ok := s.permPol[name]
+			if !ok {
+				return nil, fwError("INVALID_POLICY", name)
+			}
+			return settings, nil
+		},
+		"update": func(settings map[string]dbus.Variant) *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			if _, ok := s.permPol[name]; !ok {
+				return fwError("INVALID_POLICY", name)
+			}
+			s.permPol[name] = normalizePolicy(settings)
+			s.emit(s.policyPaths[name], dbusInterface+".config.policy.Updated", name)
+			return nil
+		},
+		"remove": func() *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			path, ok := s.policyPaths[name]
+			if _, exists := s.permPol[name]; !exists || !ok {
+				return fwError("INVALID_POLICY", name)
+			}
+			delete(s.permPol, name)
+			delete(s.policyPaths, name)
+			_ = s.conn.ExportMethodTable(nil, path, dbusInterface+".config.policy")
+			s.emit(path, dbusInterface+".config.policy.Removed", name)
+			return nil
+		},
+	}
+}
+
+// exportPolicy publishes the config object of a permanent policy. Callers
+// hold s.mu.
+func (s *Server) exportPolicy(name string) {
+	path, ok := s.policyPaths[name]
+	if !ok {
+		path = dbus.ObjectPath(fmt.Sprintf("%s/policy/%d", dbusConfigPath, s.policySeq))
+		s.policySeq++
+		s.policyPaths[name] = path
+	}
+	_ = s.conn.ExportMethodTable(s.configPolicyMethods(name), path, dbusInterface+".config.policy")
+}
+
+// normalizePolicy turns decoded a(ss) and a(ssss) values back into typed
+// tuples so the settings can be sent out again with the same signature.
+func normalizePolicy(settings map[string]dbus.Variant) map[string]dbus.Variant {
+	out := make(map[string]dbus.Variant, len(settings))
+	for key, v := range settings {
+		tuples, ok := v.Value().([][]interface{})
+		if !ok {
+			out[key] = v
+			continue
+		}
+		switch key {
+		case "ports", "source_ports":
+			list := make([]Port, 0, len(tuples))
+			for _, t := range tuples {
+				if len(t) == 2 {
+					list = append(list, Port{fmt.Sprint(t[0]), fmt.Sprint(t[1])})
+				}
+			}
+			out[key] = dbus.MakeVariant(list)
+		case "forward_ports":
+			list := make([]ForwardPort, 0, len(tuples))
+			for _, t := range tuples {
+				if len(t) == 4 {
+					list = append(list, ForwardPort{fmt.Sprint(t[0]), fmt.Sprint(t[1]), fmt.Sprint(t[2]), fmt.Sprint(t[3])})
+				}
+			}
+			out[key] = dbus.MakeVariant(list)
+		default:
+			out[key] = v
+		}
+	}
+	return out
+}
+
+func clonePolicies(policies map[string]map[string]dbus.Variant) map[string]map[string]dbus.Variant {
+	out := make(map[string]map[string]dbus.Variant, len(policies))
+	for name, settings := range policies {
+		copied := make(map[string]dbus.Variant, len(settings))
+		for key, v := range settings {
+			copied[key] = v
+		}
+		out[name] = copied
+	}
+	return out
+}
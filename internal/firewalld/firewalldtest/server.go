@@ -0,0 +1,557 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	dbusInterface  = "org.fedoraproject.FirewallD1"
+	dbusPath       = "/org/fedoraproject/FirewallD1"
+	dbusConfigPath = "/org/fedoraproject/FirewallD1/config"
+	propsInterface = "org.freedesktop.DBus.Properties"
+)
+
+// IcmpTypes are the ICMP types the server knows by default.
+var IcmpTypes = []string{
+	"destination-unreachable",
+	"echo-reply",
+	"echo-request",
+	"neighbour-advertisement",
+	"neighbour-solicitation",
+	"parameter-problem",
+	"redirect",
+	"router-advertisement",
+	"router-solicitation",
+	"time-exceeded",
+	"timestamp-reply",
+	"timestamp-request",
+}
+
+// Server is an in-memory firewalld owning org.fedoraproject.FirewallD1 on a
+// private bus. Runtime and permanent state are kept apart: reload copies
+// permanent to runtime and runtimeToPermanent copies it back.
+type Server struct {
+	bus  *Bus
+	conn *dbus.Conn
+
+	mu          sync.Mutex
+	closed      bool
+	version     string
+	readOnly    bool
+	panic       bool
+	lockdown    bool
+	defaultZone string
+	icmpTypes   []string
+	runtime     map[string]*Zone
+	permanent   map[string]*Zone
+	zonePaths   map[string]dbus.ObjectPath
+	zoneSeq     int
+	runtimeSets map[string]*IPSet
+	permSets    map[string]*IPSet
+	runtimePol  map[string]map[string]dbus.Variant
+	permPol     map[string]map[string]dbus.Variant
+	policyPaths map[string]dbus.ObjectPath
+	policySeq   int
+	services    map[string]map[string]dbus.Variant
+	builtinSvc  map[string]bool
+	servicePath map[string]dbus.ObjectPath
+	serviceSeq  int
+	icmpCustom  map[string]IcmpType
+	icmpPaths   map[string]dbus.ObjectPath
+	icmpSeq     int
+	helpers     map[string]Helper
+	builtinHlp  map[string]bool
+	helperPaths map[string]dbus.ObjectPath
+	helperSeq   int
+	directRun   Direct
+	directPerm  Direct
+	allowRun    LockdownWhitelist
+	allowPerm   LockdownWhitelist
+	settings    map[string]string
+}
+
+// DefaultZones is the state a new Server starts with; public is the default
+// zone and is bound to eth0.
+func DefaultZones() map[string]Zone {
+	return map[string]Zone{
+		"block":   {Target: "%%REJECT%%"},
+		"drop":    {Target: "DROP"},
+		"public":  {Target: "default", Short: "Public", Services: []string{"ssh", "dhcpv6-client"}, Interfaces: []string{"eth0"}},
+		"trusted": {Target: "ACCEPT"},
+	}
+}
+
+// Start runs a private bus with a Server on it for the duration of tb,
+// skipping the test when dbus-daemon is not installed.
+func Start(tb testing.TB) *Server {
+	tb.Helper()
+	bus, err := StartBus()
+	if errors.Is(err, ErrNoDaemon) {
+		tb.Skip("dbus-daemon not installed")
+	}
+	if err != nil {
+		tb.Fatalf("start bus: %v", err)
+	}
+	s, err := NewServer(bus)
+	if err != nil {
+		bus.Close()
+		tb.Fatalf("start firewalld fake: %v", err)
+	}
+	tb.Cleanup(func() {
+		s.Close()
+		bus.Close()
+	})
+	return s
+}
+
+// NewServer claims the firewalld name on bus and exports the fake objects.
+func NewServer(bus *Bus) (*Server, error) {
+	conn, err := bus.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	s := &Server{
+		bus:         bus,
+		conn:        conn,
+		version:     "2.1.0",
+		defaultZone: "public",
+		icmpTypes:   append([]string(nil), IcmpTypes...),
+		runtime:     make(map[string]*Zone),
+		permanent:   make(map[string]*Zone),
+		zonePaths:   make(map[string]dbus.ObjectPath),
+		runtimeSets: make(map[string]*IPSet),
+		permSets:    make(map[string]*IPSet),
+		runtimePol:  make(map[string]map[string]dbus.Variant),
+		permPol:     make(map[string]map[string]dbus.Variant),
+		policyPaths: make(map[string]dbus.ObjectPath),
+		services:    make(map[string]map[string]dbus.Variant),
+		builtinSvc:  make(map[string]bool),
+		servicePath: make(map[string]dbus.ObjectPath),
+		icmpCustom:  make(map[string]IcmpType),
+		icmpPaths:   make(map[string]dbus.ObjectPath),
+		helpers:     BuiltinHelpers(),
+		builtinHlp:  make(map[string]bool),
+		helperPaths: make(map[string]dbus.ObjectPath),
+		settings:    DefaultSettings(),
+	}
+	for _, name := range s.icmpTypes {
+		s.exportIcmpType(name)
+	}
+	for name := range s.helpers {
+		s.builtinHlp[name] = true
+		s.exportHelper(name)
+	}
+	for _, name := range BuiltinServices {
+		s.services[name] = map[string]dbus.Variant{"short": dbus.MakeVariant(name)}
+		s.builtinSvc[name] = true
+		s.exportService(name)
+	}
+	for name, z := range DefaultZones() {
+		s.SetZone(name, z)
+	}
+
+	exports := []struct {
+		methods map[string]interface{}
+		path    dbus.ObjectPath
+		iface   string
+	}{
+		{s.mainMethods(), dbusPath, dbusInterface},
+		{s.propertyMethods(), dbusPath, propsInterface},
+		{s.runtimeZoneMethods(), dbusPath, dbusInterface + ".zone"},
+		{s.runtimeIPSetMethods(), dbusPath, dbusInterface + ".ipset"},
+		{s.runtimePolicyMethods(), dbusPath, dbusInterface + ".policy"},
+		{s.directMethods(false), dbusPath, dbusInterface + ".direct"},
+		{s.configMethods(), dbusConfigPath, dbusInterface + ".config"},
+		{s.configPropertyMethods(), dbusConfigPath, propsInterface},
+		{s.configDirectMethods(), dbusConfigPath, dbusInterface + ".config.direct"},
+		{s.policiesMethods(), dbusPath, dbusInterface + ".policies"},
+		{s.configPoliciesMethods(), dbusConfigPath, dbusInterface + ".config.policies"},
+	}
+	for _, e := range exports {
+		if err := conn.ExportMethodTable(e.methods, e.path, e.iface); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("export %s: %w", e.iface, err)
+		}
+	}
+
+	reply, err := conn.RequestName(dbusInterface, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("request name: %w", err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, fmt.Errorf("request name: %s already owned", dbusInterface)
+	}
+	return s, nil
+}
+
+// Connect opens a client connection to the server's bus.
+func (s *Server) Connect() (*dbus.Conn, error) {
+	return s.bus.Connect()
+}
+
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	return s.conn.Close()
+}
+
+// SetVersion changes the version property reported to clients.
+func (s *Server) SetVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = version
+}
+
+// SetReadOnly makes authorizeAll and every mutation fail with AccessDenied.
+func (s *Server) SetReadOnly(readOnly bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readOnly = readOnly
+}
+
+// SetZone replaces zone name in both runtime and permanent configuration.
+func (s *Server) SetZone(name string, z Zone) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runtime[name] = z.clone()
+	s.permanent[name] = z.clone()
+	s.exportZone(name)
+}
+
+// Zone returns a copy of the runtime or permanent zone.
+func (s *Server) Zone(name string, permanent bool) (Zone, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zones := s.runtime
+	if permanent {
+		zones = s.permanent
+	}
+	z, ok := zones[name]
+	if !ok {
+		return Zone{}, false
+	}
+	return *z.clone(), true
+}
+
+func (s *Server) DefaultZone() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.defaultZone
+}
+
+func (s *Server) PanicMode() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.panic
+}
+
+func (s *Server) mainMethods() map[string]interface{} {
+	return map[string]interface{}{
+		"authorizeAll": func() *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.checkWritable()
+		},
+		"getDefaultZone": func() (string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.defaultZone, nil
+		},
+		"setDefaultZone": func(zone string) *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			if _, err := lookupZone(s.runtime, zone); err != nil {
+				return err
+			}
+			if zone == s.defaultZone {
+				return fwError("ZONE_ALREADY_SET", zone)
+			}
+			s.defaultZone = zone
+			s.emit(dbusPath, dbusInterface+".DefaultZoneChanged", zone)
+			return nil
+		},
+		"reload": func() *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			s.runtime = cloneZones(s.permanent)
+			s.runtimeSets = cloneIPSets(s.permSets)
+			s.runtimePol = clonePolicies(s.permPol)
+			s.directRun = s.directPerm.clone()
+			s.allowRun = s.allowPerm.clone()
+			s.emit(dbusPath, dbusInterface+".Reloaded")
+			return nil
+		},
+		"runtimeToPermanent": func() *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			s.permanent = cloneZones(s.runtime)
+			for name := range s.permanent {
+				s.exportZone(name)
+			}
+			s.permSets = cloneIPSets(s.runtimeSets)
+			for name := range s.permSets {
+				s.exportIPSet(name)
+			}
+			s.permPol = clonePolicies(s.runtimePol)
+			for name := range s.permPol {
+				s.exportPolicy(name)
+			}
+			s.directPerm = s.directRun.clone()
+			s.allowPerm = s.allowRun.clone()
+			return nil
+		},
+		"queryPanicMode": func() (bool, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.panic, nil
+		},
+		"enablePanicMode": func() *dbus.Error {
+			return s.setPanic(true)
+		},
+		"disablePanicMode": func() *dbus.Error {
+			return s.setPanic(false)
+		},
+		"listIcmpTypes": func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return append([]string(nil), s.icmpTypes...), nil
+		},
+		"getLogDenied": func() (string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return s.settings["LogDenied"], nil
+		},
+		"setLogDenied": s.setLogDenied,
+	}
+}
+
+func (s *Server) setPanic(enabled bool) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if s.panic == enabled {
+		if enabled {
+			return fwError("ALREADY_ENABLED", "panic mode")
+		}
+		return fwError("NOT_ENABLED", "panic mode")
+	}
+	s.panic = enabled
+	if enabled {
+		s.emit(dbusPath, dbusInterface+".PanicModeEnabled")
+	} else {
+		s.emit(dbusPath, dbusInterface+".PanicModeDisabled")
+	}
+	return nil
+}
+
+func (s *Server) propertyMethods() map[string]interface{} {
+	return map[string]interface{}{
+		"Get": func(iface, name string) (dbus.Variant, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if iface != dbusInterface {
+				return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.UnknownInterface", []interface{}{iface})
+			}
+			switch name {
+			case "version":
+				return dbus.MakeVariant(s.version), nil
+			case "state":
+				return dbus.MakeVariant("RUNNING"), nil
+			case "interface_version":
+				return dbus.MakeVariant("1.0"), nil
+			}
+			return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{"unknown property " + name})
+		},
+	}
+}
+
+func (s *Server) configMethods() map[string]interface{} {
+	methods := map[string]interface{}{
+		"getZoneNames": func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return sortedNames(s.permanent), nil
+		},
+		"getZoneByName": func(name string) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if _, err := lookupZone(s.permanent, name); err != nil {
+				return "", err
+			}
+			return s.zonePaths[name], nil
+		},
+		"addZone2": func(name string, settings map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return "", err
+			}
+			if _, ok := s.permanent[name]; ok {
+				return "", fwError("NAME_CONFLICT", name)
+			}
+			s.permanent[name] = zoneFromSettings(settings)
+			s.exportZone(name)
+			s.emit(dbusConfigPath, dbusInterface+".config.ZoneAdded", name)
+			return s.zonePaths[name], nil
+		},
+		"listIPSets": func() ([]dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			paths := make([]dbus.ObjectPath, 0, len(s.permSets))
+			for _, name := range sortedNames(s.permSets) {
+				paths = append(paths, ipsetPath(name))
+			}
+			return paths, nil
+		},
+		"addIPSet": func(name string, settings ipsetSettings) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return "", err
+			}
+			if _, ok := s.permSets[name]; ok {
+				return "", fwError("NAME_CONFLICT", name)
+			}
+			s.permSets[name] = &IPSet{Type: settings.Type, Entries: append([]string(nil), settings.Entries...)}
+			s.exportIPSet(name)
+			s.emit(dbusConfigPath, dbusInterface+".config.IPSetAdded", name)
+			return ipsetPath(name), nil
+		},
+		"getPolicyNames": func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return sortedNames(s.permPol), nil
+		},
+		"getPolicyByName": func(name string) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if _, ok := s.permPol[name]; !ok {
+				return "", fwError("INVALID_POLICY", name)
+			}
+			return s.policyPaths[name], nil
+		},
+		"addPolicy": func(name string, settings map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return "", err
+			}
+			if _, ok := s.permPol[name]; ok {
+				return "", fwError("NAME_CONFLICT", name)
+			}
+			s.permPol[name] = normalizePolicy(settings)
+			s.exportPolicy(name)
+			s.emit(dbusConfigPath, dbusInterface+".config.PolicyAdded", name)
+			return s.policyPaths[name], nil
+		},
+	}
+	for name, fn := range s.configServiceMethods() {
+		methods[name] = fn
+	}
+	for name, fn := range s.configIcmpTypeMethods() {
+		methods[name] = fn
+	}
+	for name, fn := range s.configHelperMethods() {
+		methods[name] = fn
+	}
+	return methods
+}
+
+// exportZone publishes the config object of a permanent zone. Callers hold
+// s.mu.
+func (s *Server) exportZone(name string) {
+	path, ok := s.zonePaths[name]
+	if !ok {
+		path = dbus.ObjectPath(fmt.Sprintf("%s/zone/%d", dbusConfigPath, s.zoneSeq))
+		s.zoneSeq++
+		s.zonePaths[name] = path
+	}
+	_ = s.conn.ExportMethodTable(s.configZoneMethods(name), path, dbusInterface+".config.zone")
+}
+
+func (s *Server) removePermanentZone(name string) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if _, err := lookupZone(s.permanent, name); err != nil {
+		return err
+	}
+	path := s.zonePaths[name]
+	delete(s.permanent, name)
+	delete(s.zonePaths, name)
+	_ = s.conn.ExportMethodTable(nil, path, dbusInterface+".config.zone")
+	s.emit(path, dbusInterface+".config.zone.Removed", name)
+	return nil
+}
+
+func (s *Server) checkWritable() *dbus.Error {
+	if s.readOnly {
+		return dbus.NewError("org.freedesktop.DBus.Error.AccessDenied", []interface{}{"not authorized"})
+	}
+	return nil
+}
+
+// emitZone sends a runtime zone signal such as ServiceAdded(zone, service,
+// timeout); removal signals carry no timeout.
+func (s *Server) emitZone(signal, zone string, args []interface{}, timeout int32) {
+	body := append([]interface{}{zone}, args...)
+	if strings.HasSuffix(signal, "Added") {
+		body = append(body, timeout)
+	}
+	s.emit(dbusPath, dbusInterface+".zone."+signal, body...)
+}
+
+func (s *Server) emit(path dbus.ObjectPath, name string, body ...interface{}) {
+	if s.closed {
+		return
+	}
+	_ = s.conn.Emit(path, name, body...)
+}
+
+// fwError builds the exception firewalld raises, e.g. "INVALID_ZONE: dmz".
+func fwError(code, msg string) *dbus.Error {
+	return dbus.NewError(dbusInterface+".Exception", []interface{}{code + ": " + msg})
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func cloneZones(zones map[string]*Zone) map[string]*Zone {
+	out := make(map[string]*Zone, len(zones))
+	for name, z := range zones {
+		out[name] = z.clone()
+	}
+	return out
+}
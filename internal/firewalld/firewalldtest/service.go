@@ -0,0 +1,120 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// BuiltinServices are the shipped services a new Server knows; they can be
+// updated but not removed.
+var BuiltinServices = []string{"dhcpv6-client", "http", "https", "ssh"}
+
+// Service returns a copy of the permanent settings of service name.
+func (s *Server) Service(name string) (map[string]dbus.Variant, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	settings, ok := s.services[name]
+	if !ok {
+		return nil, false
+	}
+	copied := make(map[string]dbus.Variant, len(settings))
+	for key, v := range settings {
+		copied[key] = v
+	}
+	return copied, true
+}
+
+func (s *Server) configServiceMethods() map[string]interface{} {
+	return map[string]interface{}{
+		"getServiceNames": func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return sortedNames(s.services), nil
+		},
+		"getServiceByName": func(name string) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if _, ok := s.services[name]; !ok {
+				return "", fwError("INVALID_SERVICE", name)
+			}
+			return s.servicePath[name], nil
+		},
+		"addService2": func(name string, settings map[string]dbus.Variant) (dbus.ObjectPath, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return "", err
+			}
+			if _, ok := s.services[name]; ok {
+				return "", fwError("NAME_CONFLICT", name)
+			}
+			s.services[name] = normalizePolicy(settings)
+			s.exportService(name)
+			s.emit(dbusConfigPath, dbusInterface+".config.ServiceAdded", name)
+			return s.servicePath[name], nil
+		},
+	}
+}
+
+// configServiceObjectMethods implements org.fedoraproject.FirewallD1.config.service
+// for service name.
+func (s *Server) configServiceObjectMethods(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"getSettings2": func() (map[string]dbus.Variant, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			settings, ok := s.services[name]
+			if !ok {
+				return nil, fwError("INVALID_SERVICE", name)
+			}
+			return settings, nil
+		},
+		"update2": func(settings map[string]dbus.Variant) *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			if _, ok := s.services[name]; !ok {
+				return fwError("INVALID_SERVICE", name)
+			}
+			s.services[name] = normalizePolicy(settings)
+			s.emit(s.servicePath[name], dbusInterface+".config.service.Updated", name)
+			return nil
+		},
+		"remove": func() *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			if _, ok := s.services[name]; !ok {
+				return fwError("INVALID_SERVICE", name)
+			}
+			if s.builtinSvc[name] {
+				return fwError("BUILTIN_SERVICE", name)
+			}
+			path := s.servicePath[name]
+			delete(s.services, name)
+			delete(s.servicePath, name)
+			_ = s.conn.ExportMethodTable(nil, path, dbusInterface+".config.service")
+			s.emit(path, dbusInterface+".config.service.Removed", name)
+			return nil
+		},
+	}
+}
+
+// exportService publishes the config object of a service. Callers hold s.mu.
+func (s *Server) exportService(name string) {
+	path, ok := s.servicePath[name]
+	if !ok {
+		path = dbus.ObjectPath(fmt.Sprintf("%s/service/%d", dbusConfigPath, s.serviceSeq))
+		s.serviceSeq++
+		s.servicePath[name] = path
+	}
+	_ = s.conn.ExportMethodTable(s.configServiceObjectMethods(name), path, dbusInterface+".config.service")
+}
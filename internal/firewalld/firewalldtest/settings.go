@@ -0,0 +1,117 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+)
+
+var logDeniedValues = []string{"all", "unicast", "broadcast", "multicast", "off"}
+
+// DefaultSettings are the firewalld.conf values a new Server reports as
+// config interface properties.
+func DefaultSettings() map[string]string {
+	return map[string]string{
+		"LogDenied":         "off",
+		"FirewallBackend":   "nftables",
+		"IPv6_rpfilter":     "yes",
+		"AllowZoneDrifting": "no",
+		"AutomaticHelpers":  "no",
+		"CleanupOnExit":     "yes",
+		"IndividualCalls":   "no",
+		"FlushAllOnReload":  "yes",
+		"RFC3964_IPv4":      "yes",
+	}
+}
+
+// Setting returns a firewalld.conf value.
+func (s *Server) Setting(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings[name]
+}
+
+// SetSetting changes a firewalld.conf value; an empty value removes the
+// property, as on firewalld versions without it.
+func (s *Server) SetSetting(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value == "" {
+		delete(s.settings, name)
+		return
+	}
+	s.settings[name] = value
+}
+
+func (s *Server) setLogDenied(value string) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	if !slices.Contains(logDeniedValues, value) {
+		return fwError("INVALID_VALUE", "'"+value+"', choose from "+"'all','unicast','broadcast','multicast','off'")
+	}
+	if s.settings["LogDenied"] == value {
+		return fwError("ALREADY_SET", value)
+	}
+	s.settings["LogDenied"] = value
+	s.emit(dbusPath, dbusInterface+".LogDeniedChanged", value)
+	return nil
+}
+
+// configPropertyMethods serves the firewalld.conf properties of the config
+// object. Set accepts any string; firewalld validates per property.
+func (s *Server) configPropertyMethods() map[string]interface{} {
+	unknownIface := func(iface string) *dbus.Error {
+		return dbus.NewError("org.freedesktop.DBus.Error.UnknownInterface", []interface{}{iface})
+	}
+	return map[string]interface{}{
+		"Get": func(iface, name string) (dbus.Variant, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if iface != dbusInterface+".config" {
+				return dbus.Variant{}, unknownIface(iface)
+			}
+			value, ok := s.settings[name]
+			if !ok {
+				return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{"unknown property " + name})
+			}
+			return dbus.MakeVariant(value), nil
+		},
+		"GetAll": func(iface string) (map[string]dbus.Variant, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if iface != dbusInterface+".config" {
+				return nil, unknownIface(iface)
+			}
+			props := make(map[string]dbus.Variant, len(s.settings))
+			for name, value := range s.settings {
+				props[name] = dbus.MakeVariant(value)
+			}
+			return props, nil
+		},
+		"Set": func(iface, name string, value dbus.Variant) *dbus.Error {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err := s.checkWritable(); err != nil {
+				return err
+			}
+			if iface != dbusInterface+".config" {
+				return unknownIface(iface)
+			}
+			if _, ok := s.settings[name]; !ok {
+				return dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{"unknown property " + name})
+			}
+			str, ok := value.Value().(string)
+			if !ok {
+				return fwError("INVALID_VALUE", name)
+			}
+			s.settings[name] = str
+			return nil
+		},
+	}
+}
@@ -0,0 +1,478 @@
+//go:build linux
+// +build linux
+
+package firewalldtest
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type Port struct {
+	Port     string
+	Protocol string
+}
+
+type ForwardPort struct {
+	Port     string
+	Protocol string
+	ToPort   string
+	ToAddr   string
+}
+
+// Zone is the in-memory state of one zone, runtime or permanent.
+type Zone struct {
+	Target             string
+	Short              string
+	Description        string
+	Services           []string
+	Ports              []Port
+	Protocols          []string
+	SourcePorts        []Port
+	ForwardPorts       []ForwardPort
+	RichRules          []string
+	Interfaces         []string
+	Sources            []string
+	IcmpBlocks         []string
+	IcmpBlockInversion bool
+	Masquerade         bool
+	Forward            bool
+}
+
+func (z *Zone) clone() *Zone {
+	c := *z
+	c.Services = slices.Clone(z.Services)
+	c.Ports = slices.Clone(z.Ports)
+	c.Protocols = slices.Clone(z.Protocols)
+	c.SourcePorts = slices.Clone(z.SourcePorts)
+	c.ForwardPorts = slices.Clone(z.ForwardPorts)
+	c.RichRules = slices.Clone(z.RichRules)
+	c.Interfaces = slices.Clone(z.Interfaces)
+	c.Sources = slices.Clone(z.Sources)
+	c.IcmpBlocks = slices.Clone(z.IcmpBlocks)
+	return &c
+}
+
+// settings encodes z the way getZoneSettings2 does: ports as a(ss), forward
+// ports as a(ssss) and rich rules under rules_str.
+func (z *Zone) settings() map[string]dbus.Variant {
+	target := z.Target
+	if target == "" {
+		target = "default"
+	}
+	return map[string]dbus.Variant{
+		"target":               dbus.MakeVariant(target),
+		"short":                dbus.MakeVariant(z.Short),
+		"description":          dbus.MakeVariant(z.Description),
+		"services":             dbus.MakeVariant(nonNil(z.Services)),
+		"ports":                dbus.MakeVariant(nonNil(z.Ports)),
+		"protocols":            dbus.MakeVariant(nonNil(z.Protocols)),
+		"source_ports":         dbus.MakeVariant(nonNil(z.SourcePorts)),
+		"forward_ports":        dbus.MakeVariant(nonNil(z.ForwardPorts)),
+		"rules_str":            dbus.MakeVariant(nonNil(z.RichRules)),
+		"interfaces":           dbus.MakeVariant(nonNil(z.Interfaces)),
+		"sources":              dbus.MakeVariant(nonNil(z.Sources)),
+		"icmp_blocks":          dbus.MakeVariant(nonNil(z.IcmpBlocks)),
+		"icmp_block_inversion": dbus.MakeVariant(z.IcmpBlockInversion),
+		"masquerade":           dbus.MakeVariant(z.Masquerade),
+		"forward":              dbus.MakeVariant(z.Forward),
+	}
+}
+
+// zoneFromSettings reads the keys addZone2 callers commonly send.
+func zoneFromSettings(settings map[string]dbus.Variant) *Zone {
+	z := &Zone{Target: "default"}
+	str := func(key string, dst *string) {
+		if v, ok := settings[key].Value().(string); ok {
+			*dst = v
+		}
+	}
+	list := func(key string, dst *[]string) {
+		if v, ok := settings[key].Value().([]string); ok {
+			*dst = slices.Clone(v)
+		}
+	}
+	flag := func(key string, dst *bool) {
+		if v, ok := settings[key].Value().(bool); ok {
+			*dst = v
+		}
+	}
+	str("target", &z.Target)
+	str("short", &z.Short)
+	str("description", &z.Description)
+	list("services", &z.Services)
+	list("protocols", &z.Protocols)
+	list("rules_str", &z.RichRules)
+	list("interfaces", &z.Interfaces)
+	list("sources", &z.Sources)
+	list("icmp_blocks", &z.IcmpBlocks)
+	flag("icmp_block_inversion", &z.IcmpBlockInversion)
+	flag("masquerade", &z.Masquerade)
+	flag("forward", &z.Forward)
+	return z
+}
+
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+func addItem[T comparable](list *[]T, item T, what string) *dbus.Error {
+	if slices.Contains(*list, item) {
+		return fwError("ALREADY_ENABLED", fmt.Sprintf("%s '%v' already enabled", what, item))
+	}
+	*list = append(*list, item)
+	return nil
+}
+
+func removeItem[T comparable](list *[]T, item T, what string) *dbus.Error {
+	idx := slices.Index(*list, item)
+	if idx < 0 {
+		return fwError("NOT_ENABLED", fmt.Sprintf("%s '%v' not enabled", what, item))
+	}
+	*list = slices.Delete(*list, idx, idx+1)
+	return nil
+}
+
+func setFlag(flag *bool, value bool, what string) *dbus.Error {
+	if *flag == value {
+		if value {
+			return fwError("ALREADY_ENABLED", what)
+		}
+		return fwError("NOT_ENABLED", what)
+	}
+	*flag = value
+	return nil
+}
+
+// change describes one reversible edit of a zone and the signals announcing
+// it, e.g. ServiceAdded / ServiceRemoved.
+type change struct {
+	apply   func(*Zone) *dbus.Error
+	revert  func(*Zone) *dbus.Error
+	added   string
+	removed string
+	args    []interface{}
+}
+
+func listChange[T comparable](field func(*Zone) *[]T, item T, what, signal string, args ...interface{}) change {
+	return change{
+		apply:   func(z *Zone) *dbus.Error { return addItem(field(z), item, what) },
+		revert:  func(z *Zone) *dbus.Error { return removeItem(field(z), item, what) },
+		added:   signal + "Added",
+		removed: signal + "Removed",
+		args:    args,
+	}
+}
+
+func flagChange(field func(*Zone) *bool, what, signal string) change {
+	return change{
+		apply:   func(z *Zone) *dbus.Error { return setFlag(field(z), true, what) },
+		revert:  func(z *Zone) *dbus.Error { return setFlag(field(z), false, what) },
+		added:   signal + "Added",
+		removed: signal + "Removed",
+	}
+}
+
+func (c change) inverse() change {
+	return change{apply: c.revert, revert: c.apply, added: c.removed, removed: c.added, args: c.args}
+}
+
+func services(z *Zone) *[]string          { return &z.Services }
+func ports(z *Zone) *[]Port               { return &z.Ports }
+func richRules(z *Zone) *[]string         { return &z.RichRules }
+func interfaces(z *Zone) *[]string        { return &z.Interfaces }
+func sources(z *Zone) *[]string           { return &z.Sources }
+func forwardPorts(z *Zone) *[]ForwardPort { return &z.ForwardPorts }
+func icmpBlocks(z *Zone) *[]string        { return &z.IcmpBlocks }
+func masquerade(z *Zone) *bool            { return &z.Masquerade }
+func icmpBlockInversion(z *Zone) *bool    { return &z.IcmpBlockInversion }
+
+func serviceChange(service string) change {
+	return listChange(services, service, "service", "Service", service)
+}
+
+func portChange(port, protocol string) change {
+	return listChange(ports, Port{port, protocol}, "port", "Port", port, protocol)
+}
+
+func richRuleChange(rule string) change {
+	return listChange(richRules, rule, "rich rule", "RichRule", rule)
+}
+
+func interfaceChange(iface string) change {
+	return listChange(interfaces, iface, "interface", "Interface", iface)
+}
+
+func sourceChange(source string) change {
+	return listChange(sources, source, "source", "Source", source)
+}
+
+func forwardPortChange(port, protocol, toPort, toAddr string) change {
+	return listChange(forwardPorts, ForwardPort{port, protocol, toPort, toAddr}, "forward port", "ForwardPort", port, protocol, toPort, toAddr)
+}
+
+func icmpBlockChange(icmpType string) change {
+	return listChange(icmpBlocks, icmpType, "icmp block", "IcmpBlock", icmpType)
+}
+
+func masqueradeChange() change {
+	return flagChange(masquerade, "masquerade", "Masquerade")
+}
+
+func icmpInversionChange() change {
+	return flagChange(icmpBlockInversion, "icmp block inversion", "IcmpBlockInversion")
+}
+
+// runtimeZoneMethods implements org.fedoraproject.FirewallD1.zone. Argument
+// lists match what lazyfirewall's Client sends.
+func (s *Server) runtimeZoneMethods() map[string]interface{} {
+	add := func(zone string, timeout int32, c change) (string, *dbus.Error) {
+		return zone, s.changeRuntime(zone, timeout, c)
+	}
+	remove := func(zone string, c change) (string, *dbus.Error) {
+		return zone, s.changeRuntime(zone, 0, c.inverse())
+	}
+	return map[string]interface{}{
+		"getZones": func() ([]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return sortedNames(s.runtime), nil
+		},
+		"getActiveZones": func() (map[string]map[string][]string, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			out := make(map[string]map[string][]string)
+			for name, z := range s.runtime {
+				if len(z.Interfaces) == 0 && len(z.Sources) == 0 {
+					continue
+				}
+				out[name] = map[string][]string{
+					"interfaces": nonNil(z.Interfaces),
+					"sources":    nonNil(z.Sources),
+				}
+			}
+			return out, nil
+		},
+		"getZoneSettings2": func(zone string) (map[string]dbus.Variant, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			z, err := lookupZone(s.runtime, zone)
+			if err != nil {
+				return nil, err
+			}
+			return z.settings(), nil
+		},
+		"addService": func(zone, service string, timeout int32) (string, *dbus.Error) {
+			return add(zone, timeout, serviceChange(service))
+		},
+		"removeService": func(zone, service string) (string, *dbus.Error) {
+			return remove(zone, serviceChange(service))
+		},
+		"addPort": func(zone, port, protocol string, timeout int32) (string, *dbus.Error) {
+			return add(zone, timeout, portChange(port, protocol))
+		},
+		"removePort": func(zone, port, protocol string) (string, *dbus.Error) {
+			return remove(zone, portChange(port, protocol))
+		},
+		"addRichRule": func(zone, rule string, timeout int32) (string, *dbus.Error) {
+			return add(zone, timeout, richRuleChange(rule))
+		},
+		"removeRichRule": func(zone, rule string) (string, *dbus.Error) {
+			return remove(zone, richRuleChange(rule))
+		},
+		"addInterface": func(zone, iface string, timeout int32) (string, *dbus.Error) {
+			if err := s.checkBinding(zone, iface); err != nil {
+				return "", err
+			}
+			return add(zone, timeout, interfaceChange(iface))
+		},
+		"removeInterface": func(zone, iface string) (string, *dbus.Error) {
+			return remove(zone, interfaceChange(iface))
+		},
+		"addSource": func(zone, source string, timeout int32) (string, *dbus.Error) {
+			if err := s.checkBinding(zone, source); err != nil {
+				return "", err
+			}
+			return add(zone, timeout, sourceChange(source))
+		},
+		"removeSource": func(zone, source string) (string, *dbus.Error) {
+			return remove(zone, sourceChange(source))
+		},
+		"addMasquerade": func(zone string, timeout int32) (string, *dbus.Error) {
+			return add(zone, timeout, masqueradeChange())
+		},
+		"removeMasquerade": func(zone string) (string, *dbus.Error) {
+			return remove(zone, masqueradeChange())
+		},
+		"addForwardPort": func(zone, port, protocol, toPort, toAddr string, timeout int32) (string, *dbus.Error) {
+			return add(zone, timeout, forwardPortChange(port, protocol, toPort, toAddr))
+		},
+		"removeForwardPort": func(zone, port, protocol, toPort, toAddr string) (string, *dbus.Error) {
+			return remove(zone, forwardPortChange(port, protocol, toPort, toAddr))
+		},
+		"addIcmpBlock": func(zone, icmpType string, timeout int32) (string, *dbus.Error) {
+			if err := s.checkIcmpType(icmpType); err != nil {
+				return "", err
+			}
+			return add(zone, timeout, icmpBlockChange(icmpType))
+		},
+		"removeIcmpBlock": func(zone, icmpType string) (string, *dbus.Error) {
+			return remove(zone, icmpBlockChange(icmpType))
+		},
+		"addIcmpBlockInversion": func(zone string) (string, *dbus.Error) {
+			return add(zone, 0, icmpInversionChange())
+		},
+		"removeIcmpBlockInversion": func(zone string) (string, *dbus.Error) {
+			return remove(zone, icmpInversionChange())
+		},
+	}
+}
+
+// changeRuntime applies c to the runtime zone and announces it. A non-zero
+// timeout reverts the change later, as firewalld does for timed rules.
+func (s *Server) changeRuntime(zone string, timeout int32, c change) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	z, err := lookupZone(s.runtime, zone)
+	if err != nil {
+		return err
+	}
+	if err := c.apply(z); err != nil {
+		return err
+	}
+	s.emitZone(c.added, zone, c.args, timeout)
+	if timeout > 0 {
+		time.AfterFunc(time.Duration(timeout)*time.Second, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.closed {
+				return
+			}
+			if z, ok := s.runtime[zone]; ok && c.revert(z) == nil {
+				s.emitZone(c.removed, zone, c.args, 0)
+			}
+		})
+	}
+	return nil
+}
+
+// checkBinding rejects binding an interface or source that another runtime
+// zone already owns.
+func (s *Server) checkBinding(zone, value string) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, z := range s.runtime {
+		if name != zone && (slices.Contains(z.Interfaces, value) || slices.Contains(z.Sources, value)) {
+			return fwError("ZONE_CONFLICT", fmt.Sprintf("'%s' already bound to zone '%s'", value, name))
+		}
+	}
+	return nil
+}
+
+func (s *Server) checkIcmpType(icmpType string) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !slices.Contains(s.icmpTypes, icmpType) {
+		return fwError("INVALID_ICMPTYPE", icmpType)
+	}
+	return nil
+}
+
+// configZoneMethods implements org.fedoraproject.FirewallD1.config.zone for
+// the permanent zone name.
+func (s *Server) configZoneMethods(name string) map[string]interface{} {
+	update := func(c change) *dbus.Error {
+		return s.changePermanent(name, c.apply)
+	}
+	undo := func(c change) *dbus.Error {
+		return s.changePermanent(name, c.revert)
+	}
+	return map[string]interface{}{
+		"getSettings2": func() (map[string]dbus.Variant, *dbus.Error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			z, err := lookupZone(s.permanent, name)
+			if err != nil {
+				return nil, err
+			}
+			return z.settings(), nil
+		},
+		"remove": func() *dbus.Error {
+			return s.removePermanentZone(name)
+		},
+		"setTarget": func(target string) *dbus.Error {
+			switch target {
+			case "default", "ACCEPT", "DROP", "%%REJECT%%":
+			default:
+				return fwError("INVALID_TARGET", target)
+			}
+			return s.changePermanent(name, func(z *Zone) *dbus.Error {
+				z.Target = target
+				return nil
+			})
+		},
+		"addService":      func(service string) *dbus.Error { return update(serviceChange(service)) },
+		"removeService":   func(service string) *dbus.Error { return undo(serviceChange(service)) },
+		"addPort":         func(port, protocol string) *dbus.Error { return update(portChange(port, protocol)) },
+		"removePort":      func(port, protocol string) *dbus.Error { return undo(portChange(port, protocol)) },
+		"addRichRule":     func(rule string) *dbus.Error { return update(richRuleChange(rule)) },
+		"removeRichRule":  func(rule string) *dbus.Error { return undo(richRuleChange(rule)) },
+		"addInterface":    func(iface string) *dbus.Error { return update(interfaceChange(iface)) },
+		"removeInterface": func(iface string) *dbus.Error { return undo(interfaceChange(iface)) },
+		"addSource":       func(source string) *dbus.Error { return update(sourceChange(source)) },
+		"removeSource":    func(source string) *dbus.Error { return undo(sourceChange(source)) },
+		"addMasquerade":   func() *dbus.Error { return update(masqueradeChange()) },
+		"removeMasquerade": func() *dbus.Error {
+			return undo(masqueradeChange())
+		},
+		"addForwardPort": func(port, protocol, toPort, toAddr string) *dbus.Error {
+			return update(forwardPortChange(port, protocol, toPort, toAddr))
+		},
+		"removeForwardPort": func(port, protocol, toPort, toAddr string) *dbus.Error {
+			return undo(forwardPortChange(port, protocol, toPort, toAddr))
+		},
+		"addIcmpBlock": func(icmpType string) *dbus.Error {
+			if err := s.checkIcmpType(icmpType); err != nil {
+				return err
+			}
+			return update(icmpBlockChange(icmpType))
+		},
+		"removeIcmpBlock":          func(icmpType string) *dbus.Error { return undo(icmpBlockChange(icmpType)) },
+		"addIcmpBlockInversion":    func() *dbus.Error { return update(icmpInversionChange()) },
+		"removeIcmpBlockInversion": func() *dbus.Error { return undo(icmpInversionChange()) },
+	}
+}
+
+func (s *Server) changePermanent(name string, fn func(*Zone) *dbus.Error) *dbus.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	z, err := lookupZone(s.permanent, name)
+	if err != nil {
+		return err
+	}
+	if err := fn(z); err != nil {
+		return err
+	}
+	s.emit(s.zonePaths[name], dbusInterface+".config.zone.Updated", name)
+	return nil
+}
+
+func lookupZone(zones map[string]*Zone, name string) (*Zone, *dbus.Error) {
+	z, ok := zones[name]
+	if !ok {
+		return nil, fwError("INVALID_ZONE", name)
+	}
+	return z, nil
+}
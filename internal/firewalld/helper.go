@@ -0,0 +1,264 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+var helperDirs = []string{
+	"/etc/firewalld/helpers",
+	"/usr/lib/firewalld/helpers",
+}
+
+// helperModulePrefix is required by firewalld for helper kernel modules.
+const helperModulePrefix = "nf_conntrack_"
+
+// dbusHelperSettings is the (sssssa(ss)) tuple of config.helper: version,
+// short, description, family, module and ports.
+type dbusHelperSettings struct {
+	Version     string
+	Short       string
+	Description string
+	Family      string
+	Module      string
+	Ports       []dbusPort
+}
+
+type helperXML struct {
+	XMLName     xml.Name      `xml:"helper"`
+	Module      string        `xml:"module,attr"`
+	Family      string        `xml:"family,attr,omitempty"`
+	Short       string        `xml:"short,omitempty"`
+	Description string        `xml:"description,omitempty"`
+	Ports       []servicePort `xml:"port"`
+}
+
+// ListHelpers returns the helpers of the permanent configuration.
+func (c *Client) ListHelpers() ([]string, error) {
+	if c.apiVersion != APIv2 {
+		return nil, ErrUnsupportedAPI
+	}
+
+	var helpers []string
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	if err := c.callObject(configObj, dbusInterface+".config.getHelperNames", &helpers); err != nil {
+		if isPermissionDenied(err) {
+			return nil, ErrPermissionDenied
+		}
+		return nil, err
+	}
+	sort.Strings(helpers)
+	slog.Debug("helpers listed", "count", len(helpers))
+	return helpers, nil
+}
+
+// GetHelper returns the permanent definition of a helper.
+func (c *Client) GetHelper(name string) (*Helper, error) {
+	if c.apiVersion != APIv2 {
+		return nil, ErrUnsupportedAPI
+	}
+
+	obj, err := c.getConfigHelperObject(name)
+	if err != nil {
+		return nil, err
+	}
+	var settings dbusHelperSettings
+	if err := c.callObject(obj, dbusInterface+".config.helper.getSettings", &settings); err != nil {
+		return nil, mapHelperError(err)
+	}
+	h := &Helper{
+		Name:        name,
+		Short:       settings.Short,
+		Description: settings.Description,
+		Family:      settings.Family,
+		Module:      settings.Module,
+	}
+	for _, p := range settings.Ports {
+		h.Ports = append(h.Ports, Port{Port: p.Port, Protocol: p.Protocol})
+	}
+	var builtin dbus.Variant
+	if err := c.callObject(obj, "org.freedesktop.DBus.Properties.Get", &builtin, dbusInterface+".config.helper", "builtin"); err != nil {
+		slog.Debug("helper builtin property unavailable", "helper", name, "error", err)
+	} else if b, ok := builtin.Value().(bool); ok {
+		h.Builtin = b
+	}
+	return h, nil
+}
+
+// CreateHelper adds a custom helper to the permanent configuration.
+func (c *Client) CreateHelper(h *Helper) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if err := CheckHelper(h); err != nil {
+		return err
+	}
+
+	slog.Info("adding helper (permanent)", "helper", h.Name, "module", h.Module)
+	settings := dbusHelperSettings{
+		Short:       h.Short,
+		Description: h.Description,
+		Family:      h.Family,
+		Module:      h.Module,
+		Ports:       make([]dbusPort, 0, len(h.Ports)),
+	}
+	for _, p := range h.Ports {
+		settings.Ports = append(settings.Ports, dbusPort{Port: p.Port, Protocol: p.Protocol})
+	}
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	var path dbus.ObjectPath
+	if err := c.callObject(configObj, dbusInterface+".config.addHelper", &path, h.Name, settings); err != nil {
+		if isPermissionDenied(err) {
+			return ErrPermissionDenied
+		}
+		return fmt.Errorf("add helper %s: %w", h.Name, err)
+	}
+	return nil
+}
+
+// DeleteHelper removes a custom helper. Shipped helpers are refused with
+// ErrBuiltinHelper.
+func (c *Client) DeleteHelper(name string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if h, err := ReadHelper(helperDirs, name); err == nil && h.Builtin {
+		return ErrBuiltinHelper
+	}
+
+	slog.Info("removing helper (permanent)", "helper", name)
+	obj, err := c.getConfigHelperObject(name)
+	if err != nil {
+		return err
+	}
+	if err := c.callObject(obj, dbusInterface+".config.helper.remove", nil); err != nil {
+		return mapHelperError(err)
+	}
+	return nil
+}
+
+func (c *Client) getConfigHelperObject(name string) (dbus.BusObject, error) {
+	var path dbus.ObjectPath
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	if err := c.callObject(configObj, dbusInterface+".config.getHelperByName", &path, name); err != nil {
+		return nil, mapHelperError(err)
+	}
+	return c.conn.Object(dbusInterface, path), nil
+}
+
+func mapHelperError(err error) error {
+	if isPermissionDenied(err) {
+		return ErrPermissionDenied
+	}
+	msg := strings.ToLower(err.Error())
+	var dbusErr *dbus.Error
+	if errors.As(err, &dbusErr) {
+		msg += " " + strings.ToLower(dbusErr.Name)
+	}
+	switch {
+	case strings.Contains(msg, "invalid_helper"):
+		return ErrInvalidHelper
+	case strings.Contains(msg, "builtin_helper"):
+		return ErrBuiltinHelper
+	}
+	return err
+}
+
+// CheckHelper validates what firewalld requires of a helper definition: a
+// name, an nf_conntrack_ module and a known family.
+func CheckHelper(h *Helper) error {
+	if h == nil || h.Name == "" {
+		return fmt.Errorf("helper name is empty")
+	}
+	if !strings.HasPrefix(h.Module, helperModulePrefix) || h.Module == helperModulePrefix {
+		return fmt.Errorf("invalid helper module %q (must start with %s)", h.Module, helperModulePrefix)
+	}
+	if h.Family != "" && h.Family != "ipv4" && h.Family != "ipv6" {
+		return fmt.Errorf("invalid helper family %q (use ipv4 or ipv6)", h.Family)
+	}
+	return nil
+}
+
+// UsesHelper reports whether service info loads helper h, either by
+// naming it or through the helper's kernel module.
+func (info *ServiceInfo) UsesHelper(h *Helper) bool {
+	for _, name := range info.Helpers {
+		if name == h.Name {
+			return true
+		}
+	}
+	for _, module := range info.Modules {
+		if module == h.Module || helperModulePrefix+module == h.Module {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadHelper loads helper name from the first of dirs holding its XML
+// file, so user definitions in /etc shadow the shipped ones.
+func ReadHelper(dirs []string, name string) (*Helper, error) {
+	if name == "" {
+		return nil, fmt.Errorf("helper name is empty")
+	}
+	data, builtin, err := readDefinition(dirs, name)
+	if err != nil {
+		return nil, fmt.Errorf("read helper %s: %w", name, err)
+	}
+	h, err := ParseHelperXML(name, data)
+	if err != nil {
+		return nil, err
+	}
+	h.Builtin = builtin
+	return h, nil
+}
+
+// ParseHelperXML decodes a firewalld helper definition.
+func ParseHelperXML(name string, data []byte) (*Helper, error) {
+	var raw helperXML
+	if err := xml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse helper %s: %w", name, err)
+	}
+	h := &Helper{
+		Name:        name,
+		Short:       raw.Short,
+		Description: raw.Description,
+		Family:      raw.Family,
+		Module:      raw.Module,
+	}
+	for _, p := range raw.Ports {
+		h.Ports = append(h.Ports, Port{Port: p.Port, Protocol: p.Protocol})
+	}
+	return h, nil
+}
+
+// MarshalHelperXML encodes h as a firewalld helper definition file.
+func MarshalHelperXML(h *Helper) ([]byte, error) {
+	if err := CheckHelper(h); err != nil {
+		return nil, err
+	}
+	raw := helperXML{Module: h.Module, Family: h.Family, Short: h.Short, Description: h.Description}
+	for _, p := range h.Ports {
+		raw.Ports = append(raw.Ports, servicePort{Port: p.Port, Protocol: p.Protocol})
+	}
+	data, err := xml.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode helper %s: %w", h.Name, err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
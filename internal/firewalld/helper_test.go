@@ -0,0 +1,115 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHelperXMLRoundTrip(t *testing.T) {
+	h := &Helper{
+		Name:        "myftp",
+		Short:       "My FTP",
+		Description: "FTP on a second port",
+		Family:      "ipv4",
+		Module:      "nf_conntrack_ftp",
+		Ports:       []Port{{Port: "21", Protocol: "tcp"}, {Port: "2121", Protocol: "tcp"}},
+	}
+	data, err := MarshalHelperXML(h)
+	if err != nil {
+		t.Fatalf("MarshalHelperXML() error = %v", err)
+	}
+	got, err := ParseHelperXML("myftp", data)
+	if err != nil {
+		t.Fatalf("ParseHelperXML() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, h) {
+		t.Fatalf("round trip = %+v, want %+v", got, h)
+	}
+}
+
+func TestCheckHelper(t *testing.T) {
+	tests := []struct {
+		helper  *Helper
+		wantErr bool
+	}{
+		{helper: &Helper{Name: "ftp", Module: "nf_conntrack_ftp"}},
+		{helper: &Helper{Name: "sip", Module: "nf_conntrack_sip", Family: "ipv6"}},
+		{helper: nil, wantErr: true},
+		{helper: &Helper{Module: "nf_conntrack_ftp"}, wantErr: true},
+		{helper: &Helper{Name: "ftp", Module: "ftp"}, wantErr: true},
+		{helper: &Helper{Name: "ftp", Module: "nf_conntrack_"}, wantErr: true},
+		{helper: &Helper{Name: "ftp", Module: "nf_conntrack_ftp", Family: "inet"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := CheckHelper(tt.helper); (err != nil) != tt.wantErr {
+			t.Fatalf("CheckHelper(%+v) error = %v, wantErr %v", tt.helper, err, tt.wantErr)
+		}
+	}
+}
+
+func TestServiceUsesHelper(t *testing.T) {
+	ftp := &Helper{Name: "ftp", Module: "nf_conntrack_ftp"}
+	tests := []struct {
+		info *ServiceInfo
+		want bool
+	}{
+		{info: &ServiceInfo{Helpers: []string{"ftp"}}, want: true},
+		{info: &ServiceInfo{Modules: []string{"nf_conntrack_ftp"}}, want: true},
+		{info: &ServiceInfo{Modules: []string{"ftp"}}, want: true},
+		{info: &ServiceInfo{Modules: []string{"nf_conntrack_tftp"}, Helpers: []string{"tftp"}}, want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.info.UsesHelper(ftp); got != tt.want {
+			t.Fatalf("UsesHelper(%+v) = %v, want %v", tt.info, got, tt.want)
+		}
+	}
+}
+
+func TestReadHelperBuiltin(t *testing.T) {
+	etc, usr := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(usr, "ftp.xml"):  `<helper module="nf_conntrack_ftp"><port port="21" protocol="tcp"/></helper>`,
+		filepath.Join(etc, "ftp.xml"):  `<helper module="nf_conntrack_ftp"><port port="2121" protocol="tcp"/></helper>`,
+		filepath.Join(etc, "mine.xml"): `<helper module="nf_conntrack_sip" family="ipv6"/>`,
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dirs := []string{etc, usr}
+
+	ftp, err := ReadHelper(dirs, "ftp")
+	if err != nil || !ftp.Builtin || ftp.Ports[0].Port != "2121" {
+		t.Fatalf("ReadHelper(ftp) = %+v, %v; want the builtin override", ftp, err)
+	}
+	mine, err := ReadHelper(dirs, "mine")
+	if err != nil || mine.Builtin || mine.Family != "ipv6" {
+		t.Fatalf("ReadHelper(mine) = %+v, %v", mine, err)
+	}
+	if _, err := ReadHelper(dirs, "missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("ReadHelper(missing) error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestHelperMethodsRequireAPIv2(t *testing.T) {
+	c := &Client{}
+	if _, err := c.ListHelpers(); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("ListHelpers() error = %v, want ErrUnsupportedAPI", err)
+	}
+	if _, err := c.GetHelper("ftp"); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("GetHelper() error = %v, want ErrUnsupportedAPI", err)
+	}
+	if err := c.CreateHelper(&Helper{Name: "x", Module: "nf_conntrack_x"}); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("CreateHelper() error = %v, want ErrUnsupportedAPI", err)
+	}
+	if err := c.DeleteHelper("x"); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("DeleteHelper() error = %v, want ErrUnsupportedAPI", err)
+	}
+}
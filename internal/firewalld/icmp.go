@@ -0,0 +1,262 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+var icmpTypeDirs = []string{
+	"/etc/firewalld/icmptypes",
+	"/usr/lib/firewalld/icmptypes",
+}
+
+// IcmpFamilies are the destinations an ICMP type can be limited to.
+var IcmpFamilies = []string{"ipv4", "ipv6"}
+
+// dbusIcmpTypeSettings is the (sssas) tuple of config.icmptype: version,
+// short, description and destinations.
+type dbusIcmpTypeSettings struct {
+	Version      string
+	Short        string
+	Description  string
+	Destinations []string
+}
+
+type icmpTypeXML struct {
+	XMLName     xml.Name                `xml:"icmptype"`
+	Short       string                  `xml:"short,omitempty"`
+	Description string                  `xml:"description,omitempty"`
+	Destination *icmpTypeDestinationXML `xml:"destination"`
+}
+
+type icmpTypeDestinationXML struct {
+	IPv4 string `xml:"ipv4,attr,omitempty"`
+	IPv6 string `xml:"ipv6,attr,omitempty"`
+}
+
+// ZoneTargets are the targets firewalld accepts for a zone.
+var ZoneTargets = []string{"default", "ACCEPT", "DROP", "%%REJECT%%"}
+
+// NormalizeTarget maps user input such as "reject" or "accept" to the
+// spelling firewalld expects.
+func NormalizeTarget(target string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(target)) {
+	case "DEFAULT":
+		return "default", nil
+	case "ACCEPT":
+		return "ACCEPT", nil
+	case "DROP":
+		return "DROP", nil
+	case "REJECT", "%%REJECT%%":
+		return "%%REJECT%%", nil
+	}
+	return "", fmt.Errorf("invalid zone target %q (use default, ACCEPT, DROP or REJECT)", target)
+}
+
+// ListIcmpTypes returns the ICMP types known to firewalld.
+func (c *Client) ListIcmpTypes() ([]string, error) {
+	if c.apiVersion != APIv2 {
+		return nil, ErrUnsupportedAPI
+	}
+
+	var types []string
+	method := dbusInterface + ".listIcmpTypes"
+	if err := c.call(method, &types); err != nil {
+		if isPermissionDenied(err) {
+			return nil, ErrPermissionDenied
+		}
+		return nil, err
+	}
+	sort.Strings(types)
+	slog.Debug("icmp types listed", "count", len(types))
+	return types, nil
+}
+
+// GetIcmpType returns the permanent definition of an ICMP type.
+func (c *Client) GetIcmpType(name string) (*IcmpType, error) {
+	if c.apiVersion != APIv2 {
+		return nil, ErrUnsupportedAPI
+	}
+
+	obj, err := c.getConfigIcmpTypeObject(name)
+	if err != nil {
+		return nil, err
+	}
+	var settings dbusIcmpTypeSettings
+	if err := c.callObject(obj, dbusInterface+".config.icmptype.getSettings", &settings); err != nil {
+		return nil, mapIcmpTypeError(err)
+	}
+	t := &IcmpType{
+		Name:         name,
+		Short:        settings.Short,
+		Description:  settings.Description,
+		Destinations: settings.Destinations,
+	}
+	var builtin dbus.Variant
+	if err := c.callObject(obj, "org.freedesktop.DBus.Properties.Get", &builtin, dbusInterface+".config.icmptype", "builtin"); err != nil {
+		slog.Debug("icmp type builtin property unavailable", "icmptype", name, "error", err)
+	} else if b, ok := builtin.Value().(bool); ok {
+		t.Builtin = b
+	}
+	return t, nil
+}
+
+// CreateIcmpType adds a custom ICMP type to the permanent configuration.
+func (c *Client) CreateIcmpType(t *IcmpType) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if t == nil || t.Name == "" {
+		return fmt.Errorf("icmp type name is empty")
+	}
+	if err := checkIcmpFamilies(t.Destinations); err != nil {
+		return err
+	}
+
+	slog.Info("adding icmp type (permanent)", "icmptype", t.Name)
+	settings := dbusIcmpTypeSettings{
+		Short:        t.Short,
+		Description:  t.Description,
+		Destinations: nonNilStrings(t.Destinations),
+	}
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	var path dbus.ObjectPath
+	if err := c.callObject(configObj, dbusInterface+".config.addIcmpType", &path, t.Name, settings); err != nil {
+		if isPermissionDenied(err) {
+			return ErrPermissionDenied
+		}
+		return fmt.Errorf("add icmp type %s: %w", t.Name, err)
+	}
+	return nil
+}
+
+// DeleteIcmpType removes a custom ICMP type. Shipped types are refused
+// with ErrBuiltinIcmpType.
+func (c *Client) DeleteIcmpType(name string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if t, err := ReadIcmpType(icmpTypeDirs, name); err == nil && t.Builtin {
+		return ErrBuiltinIcmpType
+	}
+
+	slog.Info("removing icmp type (permanent)", "icmptype", name)
+	obj, err := c.getConfigIcmpTypeObject(name)
+	if err != nil {
+		return err
+	}
+	if err := c.callObject(obj, dbusInterface+".config.icmptype.remove", nil); err != nil {
+		return mapIcmpTypeError(err)
+	}
+	return nil
+}
+
+func (c *Client) getConfigIcmpTypeObject(name string) (dbus.BusObject, error) {
+	var path dbus.ObjectPath
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	if err := c.callObject(configObj, dbusInterface+".config.getIcmpTypeByName", &path, name); err != nil {
+		return nil, mapIcmpTypeError(err)
+	}
+	return c.conn.Object(dbusInterface, path), nil
+}
+
+func mapIcmpTypeError(err error) error {
+	if isPermissionDenied(err) {
+		return ErrPermissionDenied
+	}
+	msg := strings.ToLower(err.Error())
+	var dbusErr *dbus.Error
+	if errors.As(err, &dbusErr) {
+		msg += " " + strings.ToLower(dbusErr.Name)
+	}
+	switch {
+	case strings.Contains(msg, "invalid_icmptype"):
+		return ErrInvalidIcmpType
+	case strings.Contains(msg, "builtin_icmptype"):
+		return ErrBuiltinIcmpType
+	}
+	return err
+}
+
+func checkIcmpFamilies(families []string) error {
+	for _, family := range families {
+		if family != "ipv4" && family != "ipv6" {
+			return fmt.Errorf("invalid icmp type destination %q (use ipv4 or ipv6)", family)
+		}
+	}
+	return nil
+}
+
+// ReadIcmpType loads ICMP type name from the first of dirs holding its XML
+// file, so user definitions in /etc shadow the shipped ones.
+func ReadIcmpType(dirs []string, name string) (*IcmpType, error) {
+	if name == "" {
+		return nil, fmt.Errorf("icmp type name is empty")
+	}
+	data, builtin, err := readDefinition(dirs, name)
+	if err != nil {
+		return nil, fmt.Errorf("read icmp type %s: %w", name, err)
+	}
+	t, err := ParseIcmpTypeXML(name, data)
+	if err != nil {
+		return nil, err
+	}
+	t.Builtin = builtin
+	return t, nil
+}
+
+// ParseIcmpTypeXML decodes a firewalld ICMP type definition.
+func ParseIcmpTypeXML(name string, data []byte) (*IcmpType, error) {
+	var raw icmpTypeXML
+	if err := xml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse icmp type %s: %w", name, err)
+	}
+	t := &IcmpType{Name: name, Short: raw.Short, Description: raw.Description}
+	if d := raw.Destination; d != nil {
+		if d.IPv4 == "yes" {
+			t.Destinations = append(t.Destinations, "ipv4")
+		}
+		if d.IPv6 == "yes" {
+			t.Destinations = append(t.Destinations, "ipv6")
+		}
+	}
+	return t, nil
+}
+
+// MarshalIcmpTypeXML encodes t as a firewalld ICMP type definition file.
+func MarshalIcmpTypeXML(t *IcmpType) ([]byte, error) {
+	if err := checkIcmpFamilies(t.Destinations); err != nil {
+		return nil, err
+	}
+	raw := icmpTypeXML{Short: t.Short, Description: t.Description}
+	if len(t.Destinations) > 0 {
+		raw.Destination = &icmpTypeDestinationXML{}
+		for _, family := range t.Destinations {
+			if family == "ipv4" {
+				raw.Destination.IPv4 = "yes"
+			} else {
+				raw.Destination.IPv6 = "yes"
+			}
+		}
+	}
+	data, err := xml.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode icmp type %s: %w", t.Name, err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
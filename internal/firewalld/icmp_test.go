@@ -0,0 +1,82 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTarget(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "default", want: "default"},
+		{in: "accept", want: "ACCEPT"},
+		{in: " DROP ", want: "DROP"},
+		{in: "reject", want: "%%REJECT%%"},
+		{in: "%%REJECT%%", want: "%%REJECT%%"},
+		{in: "allow", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeTarget(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("NormalizeTarget(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Fatalf("NormalizeTarget(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIcmpMethodsRequireAPIv2(t *testing.T) {
+	c := &Client{}
+	if _, err := c.ListIcmpTypes(); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("ListIcmpTypes() error = %v, want ErrUnsupportedAPI", err)
+	}
+	if err := c.SetTargetPermanent("public", "DROP"); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("SetTargetPermanent() error = %v, want ErrUnsupportedAPI", err)
+	}
+	if err := c.AddIcmpBlockRuntime("public", "echo-request"); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("AddIcmpBlockRuntime() error = %v, want ErrUnsupportedAPI", err)
+	}
+	if err := c.EnableIcmpBlockInversionPermanent("public"); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("EnableIcmpBlockInversionPermanent() error = %v, want ErrUnsupportedAPI", err)
+	}
+	if _, err := c.GetIcmpType("echo-request"); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("GetIcmpType() error = %v, want ErrUnsupportedAPI", err)
+	}
+	if err := c.CreateIcmpType(&IcmpType{Name: "x"}); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("CreateIcmpType() error = %v, want ErrUnsupportedAPI", err)
+	}
+}
+
+func TestIcmpTypeXMLRoundTrip(t *testing.T) {
+	tests := []*IcmpType{
+		{Name: "mld-query", Short: "MLD", Description: "Multicast listener query", Destinations: []string{"ipv6"}},
+		{Name: "both", Short: "Both"},
+		{Name: "explicit", Destinations: []string{"ipv4", "ipv6"}},
+	}
+	for _, want := range tests {
+		data, err := MarshalIcmpTypeXML(want)
+		if err != nil {
+			t.Fatalf("MarshalIcmpTypeXML(%s) error = %v", want.Name, err)
+		}
+		got, err := ParseIcmpTypeXML(want.Name, data)
+		if err != nil {
+			t.Fatalf("ParseIcmpTypeXML(%s) error = %v", want.Name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("round trip = %+v, want %+v", got, want)
+		}
+	}
+	if _, err := MarshalIcmpTypeXML(&IcmpType{Name: "x", Destinations: []string{"ipx"}}); err == nil {
+		t.Fatalf("MarshalIcmpTypeXML() should reject unknown families")
+	}
+}
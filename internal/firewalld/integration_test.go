@@ -0,0 +1,711 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"lazyfirewall/internal/firewalld/firewalldtest"
+)
+
+func newTestClient(t *testing.T, srv *firewalldtest.Server) *Client {
+	t.Helper()
+	conn, err := srv.Connect()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	c, err := NewClientWithConn(conn)
+	if err != nil {
+		t.Fatalf("NewClientWithConn() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestIntegrationNewClient(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+	if c.Version() != "2.1.0" || c.APIVersion() != APIv2 || c.ReadOnly() {
+		t.Fatalf("client = version %q api %v readOnly %v", c.Version(), c.APIVersion(), c.ReadOnly())
+	}
+
+	srv.SetReadOnly(true)
+	ro := newTestClient(t, srv)
+	if !ro.ReadOnly() {
+		t.Fatalf("client should be read-only when authorizeAll is denied")
+	}
+	if err := ro.AddServiceRuntime("public", "http"); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("AddServiceRuntime() read-only error = %v", err)
+	}
+	zones, err := ro.ListZones()
+	if err != nil || len(zones) == 0 {
+		t.Fatalf("read-only ListZones() = %v, %v", zones, err)
+	}
+
+	srv.SetReadOnly(false)
+	srv.SetVersion("0.9.11")
+	old := newTestClient(t, srv)
+	if old.APIVersion() != APIv1 {
+		t.Fatalf("APIVersion() = %v, want v1 for firewalld 0.x", old.APIVersion())
+	}
+	if _, err := old.GetDefaultZone(); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("GetDefaultZone() on v1 error = %v", err)
+	}
+}
+
+func TestIntegrationNewClientNotRunning(t *testing.T) {
+	bus, err := firewalldtest.StartBus()
+	if errors.Is(err, firewalldtest.ErrNoDaemon) {
+		t.Skip("dbus-daemon not installed")
+	}
+	if err != nil {
+		t.Fatalf("StartBus() error = %v", err)
+	}
+	defer bus.Close()
+
+	conn, err := bus.Connect()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if _, err := NewClientWithConn(conn); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("NewClientWithConn() error = %v, want ErrNotRunning", err)
+	}
+	if conn.Connected() {
+		t.Fatalf("connection should be closed on failure")
+	}
+}
+
+func TestIntegrationReadZones(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	srv.SetZone("dmz", firewalldtest.Zone{
+		Target:       "DROP",
+		Services:     []string{"ssh"},
+		Ports:        []firewalldtest.Port{{Port: "8080", Protocol: "tcp"}},
+		ForwardPorts: []firewalldtest.ForwardPort{{Port: "80", Protocol: "tcp", ToPort: "8080", ToAddr: "10.0.0.5"}},
+		RichRules:    []string{`rule family="ipv4" source address="10.0.0.0/8" accept`},
+		Sources:      []string{"192.0.2.0/24"},
+		IcmpBlocks:   []string{"echo-request"},
+		Masquerade:   true,
+	})
+	c := newTestClient(t, srv)
+
+	zones, err := c.ListZones()
+	if err != nil || !slices.Equal(zones, []string{"block", "dmz", "drop", "public", "trusted"}) {
+		t.Fatalf("ListZones() = %v, %v", zones, err)
+	}
+	if def, err := c.GetDefaultZone(); err != nil || def != "public" {
+		t.Fatalf("GetDefaultZone() = %q, %v", def, err)
+	}
+	active, err := c.GetActiveZones()
+	if err != nil || !slices.Equal(active["public"], []string{"eth0"}) || !slices.Equal(active["dmz"], []string{"192.0.2.0/24"}) {
+		t.Fatalf("GetActiveZones() = %v, %v", active, err)
+	}
+
+	for _, permanent := range []bool{false, true} {
+		z, err := c.GetZoneSettings("dmz", permanent)
+		if err != nil {
+			t.Fatalf("GetZoneSettings(permanent=%v) error = %v", permanent, err)
+		}
+		if z.Target != "DROP" || !z.Masquerade || len(z.Ports) != 1 || z.Ports[0].Port != "8080" ||
+			len(z.ForwardPorts) != 1 || z.ForwardPorts[0].ToAddr != "10.0.0.5" || len(z.RichRules) != 1 ||
+			!slices.Equal(z.IcmpBlocks, []string{"echo-request"}) {
+			t.Fatalf("GetZoneSettings(permanent=%v) = %+v", permanent, z)
+		}
+	}
+	if _, err := c.GetZoneSettings("nope", false); !errors.Is(err, ErrInvalidZone) {
+		t.Fatalf("GetZoneSettings(nope) error = %v, want ErrInvalidZone", err)
+	}
+	if _, err := c.GetZoneSettings("nope", true); !errors.Is(err, ErrInvalidZone) {
+		t.Fatalf("GetZoneSettings(nope, permanent) error = %v, want ErrInvalidZone", err)
+	}
+	types, err := c.ListIcmpTypes()
+	if err != nil || !slices.Contains(types, "echo-request") {
+		t.Fatalf("ListIcmpTypes() = %v, %v", types, err)
+	}
+}
+
+func TestIntegrationRuntimeMutations(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+	port := Port{Port: "8443", Protocol: "tcp"}
+	fp := ForwardPort{Port: "80", Protocol: "tcp", ToPort: "8080"}
+	rule := `rule family="ipv4" source address="198.51.100.0/24" drop`
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"add service", func() error { return c.AddServiceRuntime("public", "http") }},
+		{"add port", func() error { return c.AddPortRuntime("public", port) }},
+		{"add rich rule", func() error { return c.AddRichRuleRuntime("public", rule) }},
+		{"add source", func() error { return c.AddSourceRuntime("public", "198.51.100.7") }},
+		{"add forward port", func() error { return c.AddForwardPortRuntime("public", fp) }},
+		{"masquerade", func() error { return c.EnableMasqueradeRuntime("public") }},
+		{"icmp block", func() error { return c.AddIcmpBlockRuntime("public", "echo-request") }},
+		{"icmp inversion", func() error { return c.EnableIcmpBlockInversionRuntime("public") }},
+	}
+	for _, step := range steps {
+		if err := step.fn(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+	}
+
+	z, err := c.GetZoneSettings("public", false)
+	if err != nil {
+		t.Fatalf("GetZoneSettings() error = %v", err)
+	}
+	if !slices.Contains(z.Services, "http") || !slices.Contains(z.Ports, port) || !slices.Contains(z.RichRules, rule) ||
+		!slices.Contains(z.Sources, "198.51.100.7") || !slices.Contains(z.ForwardPorts, fp) || !z.Masquerade ||
+		!slices.Contains(z.IcmpBlocks, "echo-request") || !z.IcmpInvert {
+		t.Fatalf("runtime zone after mutations = %+v", z)
+	}
+	if perm, _ := srv.Zone("public", true); slices.Contains(perm.Services, "http") {
+		t.Fatalf("runtime change leaked into permanent config")
+	}
+
+	if err := c.AddServiceRuntime("public", "http"); err == nil || !strings.Contains(err.Error(), "ALREADY_ENABLED") {
+		t.Fatalf("duplicate AddServiceRuntime() error = %v", err)
+	}
+	if err := c.RemoveServiceRuntime("public", "http"); err != nil {
+		t.Fatalf("RemoveServiceRuntime() error = %v", err)
+	}
+	if err := c.RemovePortRuntime("public", port); err != nil {
+		t.Fatalf("RemovePortRuntime() error = %v", err)
+	}
+
+	if err := c.RuntimeToPermanent(); err != nil {
+		t.Fatalf("RuntimeToPermanent() error = %v", err)
+	}
+	perm, err := c.GetZoneSettings("public", true)
+	if err != nil || !slices.Contains(perm.RichRules, rule) || slices.Contains(perm.Services, "http") {
+		t.Fatalf("permanent after RuntimeToPermanent() = %+v, %v", perm, err)
+	}
+
+	if err := c.AddServiceRuntime("public", "https"); err != nil {
+		t.Fatalf("AddServiceRuntime() error = %v", err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if z, _ := srv.Zone("public", false); slices.Contains(z.Services, "https") {
+		t.Fatalf("Reload() should drop runtime-only changes")
+	}
+}
+
+func TestIntegrationTimedRuntimeRule(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+	if err := c.AddServiceRuntimeTimeout("public", "http", time.Second); err != nil {
+		t.Fatalf("AddServiceRuntimeTimeout() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if z, _ := srv.Zone("public", false); !slices.Contains(z.Services, "http") {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("timed service was not removed after its timeout")
+}
+
+func TestIntegrationPermanentMutations(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	if err := c.AddZonePermanent("lab"); err != nil {
+		t.Fatalf("AddZonePermanent() error = %v", err)
+	}
+	if err := c.AddServicePermanent("lab", "ssh"); err != nil {
+		t.Fatalf("AddServicePermanent() error = %v", err)
+	}
+	if err := c.AddPortPermanent("lab", Port{Port: "9000-9010", Protocol: "udp"}); err != nil {
+		t.Fatalf("AddPortPermanent() error = %v", err)
+	}
+	if err := c.AddInterfacePermanent("lab", "eth1"); err != nil {
+		t.Fatalf("AddInterfacePermanent() error = %v", err)
+	}
+	if err := c.SetTargetPermanent("lab", "reject"); err != nil {
+		t.Fatalf("SetTargetPermanent() error = %v", err)
+	}
+	if err := c.AddIcmpBlockPermanent("lab", "timestamp-request"); err != nil {
+		t.Fatalf("AddIcmpBlockPermanent() error = %v", err)
+	}
+	if err := c.AddIcmpBlockPermanent("lab", "bogus"); err == nil {
+		t.Fatalf("AddIcmpBlockPermanent() with unknown type should fail")
+	}
+
+	if _, err := c.GetZoneSettings("lab", false); !errors.Is(err, ErrInvalidZone) {
+		t.Fatalf("new permanent zone should not be in runtime before reload, err = %v", err)
+	}
+	z, err := c.GetZoneSettings("lab", true)
+	if err != nil || z.Target != "%%REJECT%%" || !slices.Equal(z.Services, []string{"ssh"}) ||
+		!slices.Equal(z.Interfaces, []string{"eth1"}) || !slices.Equal(z.IcmpBlocks, []string{"timestamp-request"}) {
+		t.Fatalf("GetZoneSettings(lab, permanent) = %+v, %v", z, err)
+	}
+
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if _, err := c.GetZoneSettings("lab", false); err != nil {
+		t.Fatalf("zone should be in runtime after reload, err = %v", err)
+	}
+
+	if err := c.RemoveServicePermanent("lab", "ssh"); err != nil {
+		t.Fatalf("RemoveServicePermanent() error = %v", err)
+	}
+	if err := c.RemoveZonePermanent("lab"); err != nil {
+		t.Fatalf("RemoveZonePermanent() error = %v", err)
+	}
+	if err := c.AddServicePermanent("lab", "ssh"); !errors.Is(err, ErrInvalidZone) {
+		t.Fatalf("AddServicePermanent() on removed zone error = %v", err)
+	}
+
+	if err := c.SetDefaultZone("trusted"); err != nil || srv.DefaultZone() != "trusted" {
+		t.Fatalf("SetDefaultZone() error = %v, default = %q", err, srv.DefaultZone())
+	}
+}
+
+func TestIntegrationIPSets(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	if err := c.AddIPSetPermanent("blocklist", "hash:ip"); err != nil {
+		t.Fatalf("AddIPSetPermanent() error = %v", err)
+	}
+	if err := c.AddIPSetEntryPermanent("blocklist", "192.0.2.1"); err != nil {
+		t.Fatalf("AddIPSetEntryPermanent() error = %v", err)
+	}
+	sets, err := c.ListIPSets(true)
+	if err != nil || !slices.Equal(sets, []string{"blocklist"}) {
+		t.Fatalf("ListIPSets(permanent) = %v, %v", sets, err)
+	}
+	if entries, err := c.GetIPSetEntries("blocklist", true); err != nil || !slices.Equal(entries, []string{"192.0.2.1"}) {
+		t.Fatalf("GetIPSetEntries(permanent) = %v, %v", entries, err)
+	}
+	if _, err := c.GetIPSetEntries("blocklist", false); !errors.Is(err, ErrInvalidIPSet) {
+		t.Fatalf("runtime ipset before reload error = %v, want ErrInvalidIPSet", err)
+	}
+
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if err := c.AddIPSetEntryRuntime("blocklist", "192.0.2.2"); err != nil {
+		t.Fatalf("AddIPSetEntryRuntime() error = %v", err)
+	}
+	if entries, _ := srv.IPSetEntries("blocklist", false); !slices.Equal(entries, []string{"192.0.2.1", "192.0.2.2"}) {
+		t.Fatalf("runtime entries = %v", entries)
+	}
+	if err := c.RemoveIPSetPermanent("blocklist"); err != nil {
+		t.Fatalf("RemoveIPSetPermanent() error = %v", err)
+	}
+	if sets, _ := c.ListIPSets(true); len(sets) != 0 {
+		t.Fatalf("ListIPSets(permanent) after remove = %v", sets)
+	}
+}
+
+func TestIntegrationPolicies(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	p := &Policy{
+		Name:         "int-to-ext",
+		Target:       "ACCEPT",
+		Priority:     -10,
+		IngressZones: []string{"trusted"},
+		EgressZones:  []string{"public"},
+		Ports:        []Port{{Port: "443", Protocol: "tcp"}},
+	}
+	if err := c.AddPolicyPermanent(p); err != nil {
+		t.Fatalf("AddPolicyPermanent() error = %v", err)
+	}
+	got, err := c.GetPolicySettings("int-to-ext", true)
+	if err != nil || got.Priority != -10 || got.Target != "ACCEPT" || len(got.Ports) != 1 || got.Ports[0].Port != "443" {
+		t.Fatalf("GetPolicySettings() = %+v, %v", got, err)
+	}
+
+	got.Services = []string{"http"}
+	if err := c.UpdatePolicy(got, true); err != nil {
+		t.Fatalf("UpdatePolicy() error = %v", err)
+	}
+	got, err = c.GetPolicySettings("int-to-ext", true)
+	if err != nil || !slices.Equal(got.Services, []string{"http"}) || len(got.Ports) != 1 {
+		t.Fatalf("GetPolicySettings() after update = %+v, %v", got, err)
+	}
+	if err := c.RemovePolicyPermanent("int-to-ext"); err != nil {
+		t.Fatalf("RemovePolicyPermanent() error = %v", err)
+	}
+	if _, err := c.GetPolicySettings("int-to-ext", true); !errors.Is(err, ErrInvalidPolicy) {
+		t.Fatalf("GetPolicySettings() after remove error = %v", err)
+	}
+}
+
+func TestIntegrationServices(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	svc := &ServiceInfo{
+		Name:         "lazyfirewall-test",
+		Short:        "Test",
+		Ports:        []Port{{Port: "8080", Protocol: "tcp"}},
+		Destinations: map[string]string{"ipv4": "192.0.2.1"},
+	}
+	if err := c.CreateService(svc); err != nil {
+		t.Fatalf("CreateService() error = %v", err)
+	}
+	if err := c.CreateService(svc); err == nil {
+		t.Fatalf("CreateService() twice should fail")
+	}
+	settings, ok := srv.Service("lazyfirewall-test")
+	if !ok || settings["short"].Value() != "Test" {
+		t.Fatalf("service after create = %v, %v", settings, ok)
+	}
+	if ports, _ := settings["ports"].Value().([]firewalldtest.Port); len(ports) != 1 || ports[0].Port != "8080" {
+		t.Fatalf("service ports = %v", settings["ports"])
+	}
+
+	svc.Includes = []string{"http"}
+	if err := c.UpdateService(svc); err != nil {
+		t.Fatalf("UpdateService() error = %v", err)
+	}
+	settings, _ = srv.Service("lazyfirewall-test")
+	if got := variantToStringSlice(settings["includes"]); !slices.Equal(got, []string{"http"}) {
+		t.Fatalf("includes after update = %v", got)
+	}
+	if err := c.UpdateService(&ServiceInfo{Name: "gopher"}); !errors.Is(err, ErrInvalidService) {
+		t.Fatalf("UpdateService(gopher) error = %v, want ErrInvalidService", err)
+	}
+
+	if err := c.DeleteService("ssh"); !errors.Is(err, ErrBuiltinService) {
+		t.Fatalf("DeleteService(ssh) error = %v, want ErrBuiltinService", err)
+	}
+	if err := c.DeleteService("lazyfirewall-test"); err != nil {
+		t.Fatalf("DeleteService() error = %v", err)
+	}
+	if _, ok := srv.Service("lazyfirewall-test"); ok {
+		t.Fatalf("service still present after delete")
+	}
+}
+
+func TestIntegrationIcmpTypes(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	if err := c.CreateIcmpType(&IcmpType{Name: "mld-query", Short: "MLD", Destinations: []string{"ipv6"}}); err != nil {
+		t.Fatalf("CreateIcmpType() error = %v", err)
+	}
+	if custom, ok := srv.CustomIcmpType("mld-query"); !ok || custom.Short != "MLD" {
+		t.Fatalf("server icmp type = %+v, %v", custom, ok)
+	}
+	types, err := c.ListIcmpTypes()
+	if err != nil || !slices.Contains(types, "mld-query") {
+		t.Fatalf("ListIcmpTypes() = %v, %v", types, err)
+	}
+	got, err := c.GetIcmpType("mld-query")
+	if err != nil || got.Builtin || !slices.Equal(got.Destinations, []string{"ipv6"}) {
+		t.Fatalf("GetIcmpType(mld-query) = %+v, %v", got, err)
+	}
+	if shipped, err := c.GetIcmpType("echo-request"); err != nil || !shipped.Builtin {
+		t.Fatalf("GetIcmpType(echo-request) = %+v, %v", shipped, err)
+	}
+	if _, err := c.GetIcmpType("bogus"); !errors.Is(err, ErrInvalidIcmpType) {
+		t.Fatalf("GetIcmpType(bogus) error = %v, want ErrInvalidIcmpType", err)
+	}
+	if err := c.DeleteIcmpType("echo-request"); !errors.Is(err, ErrBuiltinIcmpType) {
+		t.Fatalf("DeleteIcmpType(echo-request) error = %v, want ErrBuiltinIcmpType", err)
+	}
+	if err := c.DeleteIcmpType("mld-query"); err != nil {
+		t.Fatalf("DeleteIcmpType() error = %v", err)
+	}
+	if _, ok := srv.CustomIcmpType("mld-query"); ok {
+		t.Fatalf("icmp type still present after delete")
+	}
+}
+
+func TestIntegrationHelpers(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	helpers, err := c.ListHelpers()
+	if err != nil || !slices.Equal(helpers, []string{"ftp", "sip", "tftp"}) {
+		t.Fatalf("ListHelpers() = %v, %v", helpers, err)
+	}
+	ftp, err := c.GetHelper("ftp")
+	if err != nil || !ftp.Builtin || ftp.Module != "nf_conntrack_ftp" || len(ftp.Ports) != 1 {
+		t.Fatalf("GetHelper(ftp) = %+v, %v", ftp, err)
+	}
+
+	h := &Helper{Name: "ftp-alt", Short: "FTP alt", Family: "ipv4", Module: "nf_conntrack_ftp", Ports: []Port{{Port: "2121", Protocol: "tcp"}}}
+	if err := c.CreateHelper(h); err != nil {
+		t.Fatalf("CreateHelper() error = %v", err)
+	}
+	if custom, ok := srv.Helper("ftp-alt"); !ok || custom.Family != "ipv4" || len(custom.Ports) != 1 {
+		t.Fatalf("server helper = %+v, %v", custom, ok)
+	}
+	got, err := c.GetHelper("ftp-alt")
+	if err != nil || got.Builtin || !slices.Equal(got.Ports, h.Ports) {
+		t.Fatalf("GetHelper(ftp-alt) = %+v, %v", got, err)
+	}
+	if err := c.CreateHelper(&Helper{Name: "bad", Module: "ftp"}); err == nil {
+		t.Fatalf("CreateHelper() should reject modules without nf_conntrack_")
+	}
+	if _, err := c.GetHelper("bogus"); !errors.Is(err, ErrInvalidHelper) {
+		t.Fatalf("GetHelper(bogus) error = %v, want ErrInvalidHelper", err)
+	}
+	if err := c.DeleteHelper("ftp"); !errors.Is(err, ErrBuiltinHelper) {
+		t.Fatalf("DeleteHelper(ftp) error = %v, want ErrBuiltinHelper", err)
+	}
+	if err := c.DeleteHelper("ftp-alt"); err != nil {
+		t.Fatalf("DeleteHelper() error = %v", err)
+	}
+	if _, ok := srv.Helper("ftp-alt"); ok {
+		t.Fatalf("helper still present after delete")
+	}
+}
+
+func TestIntegrationDirect(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	chain := DirectChain{IPV: "ipv4", Table: "filter", Chain: "blacklist"}
+	rule := DirectRule{IPV: "ipv4", Table: "filter", Chain: "INPUT", Priority: 0, Args: []string{"-s", "192.0.2.0/24", "-j", "blacklist"}}
+	pass := DirectPassthrough{IPV: "ipv6", Args: []string{"-t", "raw", "-L"}}
+	if err := c.AddDirectChainRuntime(chain); err != nil {
+		t.Fatalf("AddDirectChainRuntime() error = %v", err)
+	}
+	if err := c.AddDirectRuleRuntime(rule); err != nil {
+		t.Fatalf("AddDirectRuleRuntime() error = %v", err)
+	}
+	if err := c.AddDirectPassthroughPermanent(pass); err != nil {
+		t.Fatalf("AddDirectPassthroughPermanent() error = %v", err)
+	}
+	if err := c.AddDirectRuleRuntime(rule); err == nil {
+		t.Fatalf("adding a rule twice should fail")
+	}
+
+	runtime, err := c.GetDirectSettings(false)
+	if err != nil || len(runtime.Chains) != 1 || len(runtime.Rules) != 1 || !runtime.Rules[0].Equal(rule) || len(runtime.Passthroughs) != 0 {
+		t.Fatalf("GetDirectSettings(runtime) = %+v, %v", runtime, err)
+	}
+	permanent, err := c.GetDirectSettings(true)
+	if err != nil || len(permanent.Rules) != 0 || len(permanent.Passthroughs) != 1 || !permanent.Passthroughs[0].Equal(pass) {
+		t.Fatalf("GetDirectSettings(permanent) = %+v, %v", permanent, err)
+	}
+
+	if err := c.RemoveDirectRuleRuntime(rule); err != nil {
+		t.Fatalf("RemoveDirectRuleRuntime() error = %v", err)
+	}
+	if err := c.RemoveDirectChainRuntime(chain); err != nil {
+		t.Fatalf("RemoveDirectChainRuntime() error = %v", err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if d := srv.Direct(false); len(d.Chains) != 0 || len(d.Passthroughs) != 1 {
+		t.Fatalf("runtime direct after reload = %+v", d)
+	}
+	if err := c.RemoveDirectPassthroughPermanent(pass); err != nil {
+		t.Fatalf("RemoveDirectPassthroughPermanent() error = %v", err)
+	}
+	if d := srv.Direct(true); len(d.Passthroughs) != 0 {
+		t.Fatalf("permanent passthroughs after remove = %+v", d.Passthroughs)
+	}
+}
+
+func TestIntegrationLockdown(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	events, cancel, err := c.SubscribeSignals()
+	if err != nil {
+		t.Fatalf("SubscribeSignals() error = %v", err)
+	}
+	defer cancel()
+
+	if on, err := c.QueryLockdown(); err != nil || on {
+		t.Fatalf("QueryLockdown() = %v, %v", on, err)
+	}
+	if err := c.EnableLockdown(); err != nil {
+		t.Fatalf("EnableLockdown() error = %v", err)
+	}
+	if ev := waitSignal(t, events); ev.Name != dbusInterface+".policies.LockdownEnabled" {
+		t.Fatalf("signal = %+v, want LockdownEnabled", ev)
+	}
+	if on, err := c.QueryLockdown(); err != nil || !on || !srv.Lockdown() {
+		t.Fatalf("QueryLockdown() after enable = %v, %v", on, err)
+	}
+	if err := c.DisableLockdown(); err != nil {
+		t.Fatalf("DisableLockdown() error = %v", err)
+	}
+
+	uid := LockdownEntry{Kind: "uid", Value: "1000"}
+	cmd := LockdownEntry{Kind: "command", Value: "/usr/bin/lazyfirewall*"}
+	if err := c.AddLockdownWhitelistRuntime(uid); err != nil {
+		t.Fatalf("AddLockdownWhitelistRuntime() error = %v", err)
+	}
+	if err := c.AddLockdownWhitelistRuntime(uid); err == nil {
+		t.Fatalf("adding a uid twice should fail")
+	}
+	if err := c.AddLockdownWhitelistPermanent(cmd); err != nil {
+		t.Fatalf("AddLockdownWhitelistPermanent() error = %v", err)
+	}
+	runtime, err := c.GetLockdownWhitelist(false)
+	if err != nil || !slices.Equal(runtime.UIDs, []int32{1000}) || len(runtime.Commands) != 0 {
+		t.Fatalf("GetLockdownWhitelist(runtime) = %+v, %v", runtime, err)
+	}
+	permanent, err := c.GetLockdownWhitelist(true)
+	if err != nil || !slices.Equal(permanent.Commands, []string{cmd.Value}) || len(permanent.UIDs) != 0 {
+		t.Fatalf("GetLockdownWhitelist(permanent) = %+v, %v", permanent, err)
+	}
+
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if wl := srv.LockdownWhitelist(false); len(wl.Uids) != 0 || len(wl.Commands) != 1 {
+		t.Fatalf("runtime whitelist after reload = %+v", wl)
+	}
+	if err := c.RemoveLockdownWhitelistPermanent(cmd); err != nil {
+		t.Fatalf("RemoveLockdownWhitelistPermanent() error = %v", err)
+	}
+	if err := c.RemoveLockdownWhitelistRuntime(uid); err == nil {
+		t.Fatalf("removing a missing uid should fail")
+	}
+}
+
+func TestIntegrationSettings(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	events, cancel, err := c.SubscribeSignals()
+	if err != nil {
+		t.Fatalf("SubscribeSignals() error = %v", err)
+	}
+	defer cancel()
+
+	if v, err := c.GetLogDenied(); err != nil || v != "off" {
+		t.Fatalf("GetLogDenied() = %q, %v", v, err)
+	}
+	if err := c.SetLogDenied("unicast"); err != nil {
+		t.Fatalf("SetLogDenied() error = %v", err)
+	}
+	if ev := waitSignal(t, events); ev.Name != dbusInterface+".LogDeniedChanged" {
+		t.Fatalf("signal = %+v, want LogDeniedChanged", ev)
+	}
+	if err := c.SetLogDenied("unicast"); err == nil {
+		t.Fatalf("setting the same LogDenied twice should fail")
+	}
+	if err := c.SetLogDenied("some"); err == nil || srv.Setting("LogDenied") != "unicast" {
+		t.Fatalf("SetLogDenied(some) error = %v", err)
+	}
+
+	srv.SetSetting("AllowZoneDrifting", "")
+	settings, err := c.GetDaemonSettings()
+	if err != nil || settings["LogDenied"] != "unicast" || settings["FirewallBackend"] != "nftables" {
+		t.Fatalf("GetDaemonSettings() = %v, %v", settings, err)
+	}
+	if _, ok := settings["AllowZoneDrifting"]; ok {
+		t.Fatalf("GetDaemonSettings() should skip properties firewalld lacks: %v", settings)
+	}
+	if err := c.SetDaemonSetting("CleanupOnExit", "no"); err != nil || srv.Setting("CleanupOnExit") != "no" {
+		t.Fatalf("SetDaemonSetting() error = %v", err)
+	}
+	if err := c.SetDaemonSetting("AllowZoneDrifting", "yes"); err == nil {
+		t.Fatalf("setting a missing property should fail")
+	}
+
+	srv.SetReadOnly(true)
+	if err := c.SetDaemonSetting("CleanupOnExit", "yes"); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("SetDaemonSetting() read-only error = %v, want ErrPermissionDenied", err)
+	}
+}
+
+func TestIntegrationSignals(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	events, cancel, err := c.SubscribeSignals()
+	if err != nil {
+		t.Fatalf("SubscribeSignals() error = %v", err)
+	}
+	defer cancel()
+
+	if err := c.AddServiceRuntime("public", "http"); err != nil {
+		t.Fatalf("AddServiceRuntime() error = %v", err)
+	}
+	ev := waitSignal(t, events)
+	if ev.Name != dbusInterface+".zone.ServiceAdded" || ev.Zone != "public" {
+		t.Fatalf("signal = %+v, want ServiceAdded for public", ev)
+	}
+
+	if err := c.AddServicePermanent("public", "https"); err != nil {
+		t.Fatalf("AddServicePermanent() error = %v", err)
+	}
+	ev = waitSignal(t, events)
+	if ev.Name != dbusInterface+".config.zone.Updated" || ev.Zone != "public" {
+		t.Fatalf("signal = %+v, want config.zone.Updated for public", ev)
+	}
+
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if ev := waitSignal(t, events); ev.Name != dbusInterface+".Reloaded" {
+		t.Fatalf("signal = %+v, want Reloaded", ev)
+	}
+
+	cancel()
+	cancel()
+}
+
+func TestIntegrationPanicMode(t *testing.T) {
+	srv := firewalldtest.Start(t)
+	c := newTestClient(t, srv)
+
+	events, cancel, err := c.SubscribeSignals()
+	if err != nil {
+		t.Fatalf("SubscribeSignals() error = %v", err)
+	}
+	defer cancel()
+
+	if on, err := c.QueryPanicMode(); err != nil || on {
+		t.Fatalf("QueryPanicMode() = %v, %v", on, err)
+	}
+	if err := c.EnablePanicMode(); err != nil {
+		t.Fatalf("EnablePanicMode() error = %v", err)
+	}
+	if ev := waitSignal(t, events); ev.Name != dbusInterface+".PanicModeEnabled" {
+		t.Fatalf("signal = %+v, want PanicModeEnabled", ev)
+	}
+	if on, err := c.QueryPanicMode(); err != nil || !on || !srv.PanicMode() {
+		t.Fatalf("QueryPanicMode() after enable = %v, %v", on, err)
+	}
+	if err := c.EnablePanicMode(); err == nil {
+		t.Fatalf("EnablePanicMode() twice should fail")
+	}
+	if err := c.DisablePanicMode(); err != nil {
+		t.Fatalf("DisablePanicMode() error = %v", err)
+	}
+	if ev := waitSignal(t, events); ev.Name != dbusInterface+".PanicModeDisabled" {
+		t.Fatalf("signal = %+v, want PanicModeDisabled", ev)
+	}
+	if srv.PanicMode() {
+		t.Fatalf("panic mode should be off")
+	}
+}
+
+func waitSignal(t *testing.T, events <-chan SignalEvent) SignalEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatalf("signal channel closed")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for signal")
+	}
+	return SignalEvent{}
+}
@@ -0,0 +1,90 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckLockdownEntry(t *testing.T) {
+	valid := []LockdownEntry{
+		{Kind: "command", Value: "/usr/bin/python3 -s /usr/bin/firewall-cmd*"},
+		{Kind: "context", Value: "system_u:system_r:NetworkManager_t:s0"},
+		{Kind: "user", Value: "root"},
+		{Kind: "uid", Value: "0"},
+	}
+	for _, e := range valid {
+		if err := CheckLockdownEntry(e); err != nil {
+			t.Fatalf("CheckLockdownEntry(%v) error = %v", e, err)
+		}
+	}
+	invalid := []LockdownEntry{
+		{Kind: "group", Value: "wheel"},
+		{Kind: "command", Value: " "},
+		{Kind: "uid", Value: "-1"},
+		{Kind: "uid", Value: "root"},
+		{Kind: "user", Value: "two words"},
+	}
+	for _, e := range invalid {
+		if err := CheckLockdownEntry(e); err == nil {
+			t.Fatalf("CheckLockdownEntry(%v) should fail", e)
+		}
+	}
+}
+
+func TestLockdownWhitelistAllows(t *testing.T) {
+	wl := &LockdownWhitelist{
+		Commands: []string{"/usr/bin/python3 -s /usr/bin/firewall-cmd*", "/usr/bin/lazyfirewall"},
+		Users:    []string{"admin"},
+		UIDs:     []int32{0},
+	}
+	tests := []struct {
+		uid     int
+		user    string
+		command string
+		want    bool
+	}{
+		{uid: 0, want: true},
+		{uid: 1000, user: "admin", want: true},
+		{uid: 1000, user: "bob", command: "/usr/bin/python3 -s /usr/bin/firewall-cmd --reload", want: true},
+		{uid: 1000, user: "bob", command: "/usr/bin/lazyfirewall", want: true},
+		{uid: 1000, user: "bob", command: "/usr/bin/lazyfirewall --dry-run", want: false},
+	}
+	for _, tt := range tests {
+		if got := wl.Allows(tt.uid, tt.user, tt.command); got != tt.want {
+			t.Fatalf("Allows(%d, %q, %q) = %v, want %v", tt.uid, tt.user, tt.command, got, tt.want)
+		}
+	}
+	if (*LockdownWhitelist)(nil).Allows(0, "root", "") {
+		t.Fatalf("nil whitelist should allow nobody")
+	}
+}
+
+func TestLockdownWhitelistXMLRoundTrip(t *testing.T) {
+	wl := &LockdownWhitelist{
+		Commands: []string{"/usr/bin/python3 -s /usr/bin/firewall-config"},
+		Contexts: []string{"system_u:system_r:NetworkManager_t:s0"},
+		Users:    []string{"root"},
+		UIDs:     []int32{0, 1000},
+	}
+	data, err := MarshalLockdownWhitelistXML(wl)
+	if err != nil {
+		t.Fatalf("MarshalLockdownWhitelistXML() error = %v", err)
+	}
+	if !strings.Contains(string(data), `<selinux context="system_u:system_r:NetworkManager_t:s0"></selinux>`) || !strings.Contains(string(data), `<user id="1000"></user>`) {
+		t.Fatalf("unexpected XML:\n%s", data)
+	}
+	got, err := ParseLockdownWhitelistXML(data)
+	if err != nil {
+		t.Fatalf("ParseLockdownWhitelistXML() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, wl) {
+		t.Fatalf("round trip = %+v, want %+v", got, wl)
+	}
+	if entries := got.Entries(); len(entries) != 5 || entries[0].Kind != "command" || entries[4].String() != "uid 1000" {
+		t.Fatalf("Entries() = %v", entries)
+	}
+}
@@ -3,7 +3,15 @@
 
 package firewalld
 
-import "log/slog"
+import (
+	"log/slog"
+	"math"
+	"time"
+)
+
+// MaxRuntimeTimeout is the longest lifetime firewalld accepts for a runtime
+// entry; its timeout argument is an int32 number of seconds.
+const MaxRuntimeTimeout = time.Duration(math.MaxInt32) * time.Second
 
 func (c *Client) AddServicePermanent(zone, service string) error {
 	if c.apiVersion != APIv2 {
@@ -24,16 +32,24 @@ func (c *Client) AddServicePermanent(zone, service string) error {
 }
 
 func (c *Client) AddServiceRuntime(zone, service string) error {
+	return c.AddServiceRuntimeTimeout(zone, service, 0)
+}
+
+func (c *Client) AddServiceRuntimeTimeout(zone, service string, timeout time.Duration) error {
 	if c.apiVersion != APIv2 {
 		return ErrUnsupportedAPI
 	}
 	if c.readOnly {
 		return ErrPermissionDenied
 	}
+	seconds, err := timeoutSeconds(timeout)
+	if err != nil {
+		return err
+	}
 
-	slog.Info("adding service (runtime)", "zone", zone, "service", service)
+	slog.Info("adding service (runtime)", "zone", zone, "service", service, "timeout", timeout)
 	method := dbusInterface + ".zone.addService"
-	return c.call(method, nil, zone, service, uint32(0))
+	return c.call(method, nil, zone, service, seconds)
 }
 
 func (c *Client) RemoveServicePermanent(zone, service string) error {
@@ -86,16 +102,24 @@ func (c *Client) AddPortPermanent(zone string, port Port) error {
 }
 
 func (c *Client) AddPortRuntime(zone string, port Port) error {
+	return c.AddPortRuntimeTimeout(zone, port, 0)
+}
+
+func (c *Client) AddPortRuntimeTimeout(zone string, port Port, timeout time.Duration) error {
 	if c.apiVersion != APIv2 {
 		return ErrUnsupportedAPI
 	}
 	if c.readOnly {
 		return ErrPermissionDenied
 	}
+	seconds, err := timeoutSeconds(timeout)
+	if err != nil {
+		return err
+	}
 
-	slog.Info("adding port (runtime)", "zone", zone, "port", port.Port, "protocol", port.Protocol)
+	slog.Info("adding port (runtime)", "zone", zone, "port", port.Port, "protocol", port.Protocol, "timeout", timeout)
 	method := dbusInterface + ".zone.addPort"
-	return c.call(method, nil, zone, port.Port, port.Protocol, uint32(0))
+	return c.call(method, nil, zone, port.Port, port.Protocol, seconds)
 }
 
 func (c *Client) RemovePortPermanent(zone string, port Port) error {
@@ -148,16 +172,24 @@ func (c *Client) AddRichRulePermanent(zone, rule string) error {
 }
 
 func (c *Client) AddRichRuleRuntime(zone, rule string) error {
+	return c.AddRichRuleRuntimeTimeout(zone, rule, 0)
+}
+
+func (c *Client) AddRichRuleRuntimeTimeout(zone, rule string, timeout time.Duration) error {
 	if c.apiVersion != APIv2 {
 		return ErrUnsupportedAPI
 	}
 	if c.readOnly {
 		return ErrPermissionDenied
 	}
+	seconds, err := timeoutSeconds(timeout)
+	if err != nil {
+		return err
+	}
 
-	slog.Info("adding rich rule (runtime)", "zone", zone, "rule", rule)
+	slog.Info("adding rich rule (runtime)", "zone", zone, "rule", rule, "timeout", timeout)
 	method := dbusInterface + ".zone.addRichRule"
-	return c.call(method, nil, zone, rule, uint32(0))
+	return c.call(method, nil, zone, rule, seconds)
 }
 
 func (c *Client) RemoveRichRulePermanent(zone, rule string) error {
@@ -219,7 +251,7 @@ func (c *Client) AddInterfaceRuntime(zone, iface string) error {
 
 	slog.Info("adding interface (runtime)", "zone", zone, "interface", iface)
 	method := dbusInterface + ".zone.addInterface"
-	return c.call(method, nil, zone, iface, uint32(0))
+	return c.call(method, nil, zone, iface, int32(0))
 }
 
 func (c *Client) RemoveInterfacePermanent(zone, iface string) error {
@@ -281,7 +313,7 @@ func (c *Client) AddSourceRuntime(zone, source string) error {
 
 	slog.Info("adding source (runtime)", "zone", zone, "source", source)
 	method := dbusInterface + ".zone.addSource"
-	return c.call(method, nil, zone, source, uint32(0))
+	return c.call(method, nil, zone, source, int32(0))
 }
 
 func (c *Client) RemoveSourcePermanent(zone, source string) error {
@@ -361,7 +393,7 @@ func (c *Client) EnableMasqueradeRuntime(zone string) error {
 
 	slog.Info("enable masquerade (runtime)", "zone", zone)
 	method := dbusInterface + ".zone.addMasquerade"
-	return c.call(method, nil, zone, uint32(0))
+	return c.call(method, nil, zone, int32(0))
 }
 
 func (c *Client) DisableMasqueradeRuntime(zone string) error {
@@ -377,6 +409,214 @@ func (c *Client) DisableMasqueradeRuntime(zone string) error {
 	return c.call(method, nil, zone)
 }
 
+func (c *Client) AddForwardPortPermanent(zone string, fp ForwardPort) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("adding forward port (permanent)", "zone", zone, "port", fp.Port, "protocol", fp.Protocol, "to_port", fp.ToPort, "to_addr", fp.ToAddr)
+	obj, err := c.getConfigZoneObject(zone)
+	if err != nil {
+		return err
+	}
+
+	method := dbusInterface + ".config.zone.addForwardPort"
+	return c.callObject(obj, method, nil, fp.Port, fp.Protocol, fp.ToPort, fp.ToAddr)
+}
+
+func (c *Client) AddForwardPortRuntime(zone string, fp ForwardPort) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("adding forward port (runtime)", "zone", zone, "port", fp.Port, "protocol", fp.Protocol, "to_port", fp.ToPort, "to_addr", fp.ToAddr)
+	method := dbusInterface + ".zone.addForwardPort"
+	return c.call(method, nil, zone, fp.Port, fp.Protocol, fp.ToPort, fp.ToAddr, int32(0))
+}
+
+func (c *Client) RemoveForwardPortPermanent(zone string, fp ForwardPort) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("removing forward port (permanent)", "zone", zone, "port", fp.Port, "protocol", fp.Protocol, "to_port", fp.ToPort, "to_addr", fp.ToAddr)
+	obj, err := c.getConfigZoneObject(zone)
+	if err != nil {
+		return err
+	}
+
+	method := dbusInterface + ".config.zone.removeForwardPort"
+	return c.callObject(obj, method, nil, fp.Port, fp.Protocol, fp.ToPort, fp.ToAddr)
+}
+
+func (c *Client) RemoveForwardPortRuntime(zone string, fp ForwardPort) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("removing forward port (runtime)", "zone", zone, "port", fp.Port, "protocol", fp.Protocol, "to_port", fp.ToPort, "to_addr", fp.ToAddr)
+	method := dbusInterface + ".zone.removeForwardPort"
+	return c.call(method, nil, zone, fp.Port, fp.Protocol, fp.ToPort, fp.ToAddr)
+}
+
+func (c *Client) SetTargetPermanent(zone, target string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	target, err := NormalizeTarget(target)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("set target (permanent)", "zone", zone, "target", target)
+	obj, err := c.getConfigZoneObject(zone)
+	if err != nil {
+		return err
+	}
+
+	method := dbusInterface + ".config.zone.setTarget"
+	return c.callObject(obj, method, nil, target)
+}
+
+func (c *Client) AddIcmpBlockPermanent(zone, icmpType string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("adding icmp block (permanent)", "zone", zone, "icmptype", icmpType)
+	obj, err := c.getConfigZoneObject(zone)
+	if err != nil {
+		return err
+	}
+
+	method := dbusInterface + ".config.zone.addIcmpBlock"
+	return c.callObject(obj, method, nil, icmpType)
+}
+
+func (c *Client) AddIcmpBlockRuntime(zone, icmpType string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("adding icmp block (runtime)", "zone", zone, "icmptype", icmpType)
+	method := dbusInterface + ".zone.addIcmpBlock"
+	return c.call(method, nil, zone, icmpType, int32(0))
+}
+
+func (c *Client) RemoveIcmpBlockPermanent(zone, icmpType string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("removing icmp block (permanent)", "zone", zone, "icmptype", icmpType)
+	obj, err := c.getConfigZoneObject(zone)
+	if err != nil {
+		return err
+	}
+
+	method := dbusInterface + ".config.zone.removeIcmpBlock"
+	return c.callObject(obj, method, nil, icmpType)
+}
+
+func (c *Client) RemoveIcmpBlockRuntime(zone, icmpType string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("removing icmp block (runtime)", "zone", zone, "icmptype", icmpType)
+	method := dbusInterface + ".zone.removeIcmpBlock"
+	return c.call(method, nil, zone, icmpType)
+}
+
+func (c *Client) EnableIcmpBlockInversionPermanent(zone string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("enable icmp block inversion (permanent)", "zone", zone)
+	obj, err := c.getConfigZoneObject(zone)
+	if err != nil {
+		return err
+	}
+
+	method := dbusInterface + ".config.zone.addIcmpBlockInversion"
+	return c.callObject(obj, method, nil)
+}
+
+func (c *Client) DisableIcmpBlockInversionPermanent(zone string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("disable icmp block inversion (permanent)", "zone", zone)
+	obj, err := c.getConfigZoneObject(zone)
+	if err != nil {
+		return err
+	}
+
+	method := dbusInterface + ".config.zone.removeIcmpBlockInversion"
+	return c.callObject(obj, method, nil)
+}
+
+func (c *Client) EnableIcmpBlockInversionRuntime(zone string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("enable icmp block inversion (runtime)", "zone", zone)
+	method := dbusInterface + ".zone.addIcmpBlockInversion"
+	return c.call(method, nil, zone)
+}
+
+func (c *Client) DisableIcmpBlockInversionRuntime(zone string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("disable icmp block inversion (runtime)", "zone", zone)
+	method := dbusInterface + ".zone.removeIcmpBlockInversion"
+	return c.call(method, nil, zone)
+}
+
 func (c *Client) RuntimeToPermanent() error {
 	if c.apiVersion != APIv2 {
 		return ErrUnsupportedAPI
@@ -410,3 +650,16 @@ func (c *Client) Reload() error {
 	c.invalidateAllIPSetEntriesCache()
 	return nil
 }
+
+// timeoutSeconds converts a runtime lifetime into firewalld's timeout
+// argument, where 0 means the entry never expires.
+func timeoutSeconds(timeout time.Duration) (int32, error) {
+	if timeout < 0 {
+		return 0, ErrInvalidTimeout
+	}
+	seconds := (timeout + time.Second - 1) / time.Second
+	if seconds > MaxRuntimeTimeout/time.Second {
+		return 0, ErrInvalidTimeout
+	}
+	return int32(seconds), nil
+}
@@ -0,0 +1,44 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeoutSeconds(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    int32
+		wantErr bool
+	}{
+		{name: "permanent", timeout: 0, want: 0},
+		{name: "minutes", timeout: 30 * time.Minute, want: 1800},
+		{name: "rounds up", timeout: 1500 * time.Millisecond, want: 2},
+		{name: "max", timeout: MaxRuntimeTimeout, want: 1<<31 - 1},
+		{name: "negative", timeout: -time.Second, wantErr: true},
+		{name: "too long", timeout: MaxRuntimeTimeout + time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := timeoutSeconds(tt.timeout)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTimeout) {
+					t.Fatalf("timeoutSeconds() error = %v, want ErrInvalidTimeout", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("timeoutSeconds() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("timeoutSeconds() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
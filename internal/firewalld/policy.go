@@ -0,0 +1,307 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+type dbusPort struct {
+	Port     string
+	Protocol string
+}
+
+type dbusForwardPort struct {
+	Port     string
+	Protocol string
+	ToPort   string
+	ToAddr   string
+}
+
+func (c *Client) ListPolicies(permanent bool) ([]string, error) {
+	if c.apiVersion != APIv2 {
+		return nil, ErrUnsupportedAPI
+	}
+
+	var (
+		names []string
+		err   error
+	)
+	if permanent {
+		slog.Debug("listing policies (permanent)")
+		configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+		err = c.callObject(configObj, dbusInterface+".config.getPolicyNames", &names)
+	} else {
+		slog.Debug("listing policies (runtime)")
+		err = c.call(dbusInterface+".policy.getPolicies", &names)
+	}
+	if err != nil {
+		if isPermissionDenied(err) {
+			return nil, ErrPermissionDenied
+		}
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *Client) GetPolicySettings(name string, permanent bool) (*Policy, error) {
+	if c.apiVersion != APIv2 {
+		return nil, ErrUnsupportedAPI
+	}
+
+	var settings map[string]dbus.Variant
+	if permanent {
+		slog.Debug("fetching permanent policy settings", "policy", name)
+		obj, err := c.getConfigPolicyObject(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.callObject(obj, dbusInterface+".config.policy.getSettings", &settings); err != nil {
+			return nil, mapPolicyError(err)
+		}
+	} else {
+		slog.Debug("fetching runtime policy settings", "policy", name)
+		if err := c.call(dbusInterface+".policy.getPolicySettings", &settings, name); err != nil {
+			return nil, mapPolicyError(err)
+		}
+	}
+	return parsePolicySettings(name, settings)
+}
+
+func (c *Client) AddPolicyPermanent(p *Policy) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if p == nil || p.Name == "" {
+		return fmt.Errorf("policy name is empty")
+	}
+
+	slog.Info("adding policy (permanent)", "policy", p.Name)
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	var path dbus.ObjectPath
+	if err := c.callObject(configObj, dbusInterface+".config.addPolicy", &path, p.Name, policySettings(p)); err != nil {
+		if isPermissionDenied(err) {
+			return ErrPermissionDenied
+		}
+		return fmt.Errorf("add policy %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// UpdatePolicy replaces the settings of an existing policy with p.
+func (c *Client) UpdatePolicy(p *Policy, permanent bool) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if p == nil || p.Name == "" {
+		return fmt.Errorf("policy name is empty")
+	}
+
+	settings := policySettings(p)
+	if permanent {
+		slog.Info("updating policy (permanent)", "policy", p.Name)
+		obj, err := c.getConfigPolicyObject(p.Name)
+		if err != nil {
+			return err
+		}
+		if err := c.callObject(obj, dbusInterface+".config.policy.update", nil, settings); err != nil {
+			return mapPolicyError(err)
+		}
+		return nil
+	}
+
+	slog.Info("updating policy (runtime)", "policy", p.Name)
+	if err := c.call(dbusInterface+".policy.setPolicySettings", nil, p.Name, settings); err != nil {
+		return mapPolicyError(err)
+	}
+	return nil
+}
+
+func (c *Client) RemovePolicyPermanent(name string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+
+	slog.Info("removing policy (permanent)", "policy", name)
+	obj, err := c.getConfigPolicyObject(name)
+	if err != nil {
+		return err
+	}
+	if err := c.callObject(obj, dbusInterface+".config.policy.remove", nil); err != nil {
+		return mapPolicyError(err)
+	}
+	return nil
+}
+
+func (c *Client) getConfigPolicyObject(name string) (dbus.BusObject, error) {
+	var path dbus.ObjectPath
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	if err := c.callObject(configObj, dbusInterface+".config.getPolicyByName", &path, name); err != nil {
+		return nil, mapPolicyError(err)
+	}
+	return c.conn.Object(dbusInterface, path), nil
+}
+
+func mapPolicyError(err error) error {
+	if isPermissionDenied(err) {
+		return ErrPermissionDenied
+	}
+	if isInvalidPolicy(err) {
+		return ErrInvalidPolicy
+	}
+	return err
+}
+
+func isInvalidPolicy(err error) bool {
+	var dbusErr *dbus.Error
+	if errors.As(err, &dbusErr) {
+		name := strings.ToLower(dbusErr.Name)
+		if strings.Contains(name, "invalid_policy") || strings.Contains(name, "invalidpolicy") {
+			return true
+		}
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "invalid_policy") || strings.Contains(msg, "invalid policy")
+}
+
+func policySettings(p *Policy) map[string]dbus.Variant {
+	ports := make([]dbusPort, 0, len(p.Ports))
+	for _, port := range p.Ports {
+		ports = append(ports, dbusPort{Port: port.Port, Protocol: port.Protocol})
+	}
+	sourcePorts := make([]dbusPort, 0, len(p.SourcePorts))
+	for _, port := range p.SourcePorts {
+		sourcePorts = append(sourcePorts, dbusPort{Port: port.Port, Protocol: port.Protocol})
+	}
+	forwardPorts := make([]dbusForwardPort, 0, len(p.ForwardPorts))
+	for _, fp := range p.ForwardPorts {
+		forwardPorts = append(forwardPorts, dbusForwardPort{Port: fp.Port, Protocol: fp.Protocol, ToPort: fp.ToPort, ToAddr: fp.ToAddr})
+	}
+
+	settings := map[string]dbus.Variant{
+		"short":         dbus.MakeVariant(p.Short),
+		"description":   dbus.MakeVariant(p.Description),
+		"priority":      dbus.MakeVariant(int32(p.Priority)),
+		"ingress_zones": dbus.MakeVariant(nonNilStrings(p.IngressZones)),
+		"egress_zones":  dbus.MakeVariant(nonNilStrings(p.EgressZones)),
+		"services":      dbus.MakeVariant(nonNilStrings(p.Services)),
+		"ports":         dbus.MakeVariant(ports),
+		"protocols":     dbus.MakeVariant(nonNilStrings(p.Protocols)),
+		"source_ports":  dbus.MakeVariant(sourcePorts),
+		"forward_ports": dbus.MakeVariant(forwardPorts),
+		"icmp_blocks":   dbus.MakeVariant(nonNilStrings(p.IcmpBlocks)),
+		"masquerade":    dbus.MakeVariant(p.Masquerade),
+		"rich_rules":    dbus.MakeVariant(nonNilStrings(p.RichRules)),
+	}
+	if p.Target != "" {
+		settings["target"] = dbus.MakeVariant(p.Target)
+	}
+	return settings
+}
+
+func nonNilStrings(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
+
+func parsePolicySettings(name string, settings map[string]dbus.Variant) (*Policy, error) {
+	p := &Policy{Name: name}
+
+	stringField := func(key string, dst *string) {
+		v, ok := settings[key]
+		if !ok {
+			return
+		}
+		if val, ok := v.Value().(string); ok {
+			*dst = val
+		} else {
+			slog.Warn("unexpected policy field type", "key", key, "type", fmt.Sprintf("%T", v.Value()))
+		}
+	}
+	stringField("short", &p.Short)
+	stringField("description", &p.Description)
+	stringField("target", &p.Target)
+
+	if v, ok := settings["priority"]; ok {
+		switch val := v.Value().(type) {
+		case int32:
+			p.Priority = int(val)
+		case int64:
+			p.Priority = int(val)
+		case int:
+			p.Priority = val
+		default:
+			slog.Warn("unexpected policy field type", "key", "priority", "type", fmt.Sprintf("%T", v.Value()))
+		}
+	}
+	if v, ok := settings["ingress_zones"]; ok {
+		p.IngressZones = variantToStringSlice(v)
+	}
+	if v, ok := settings["egress_zones"]; ok {
+		p.EgressZones = variantToStringSlice(v)
+	}
+	if v, ok := settings["services"]; ok {
+		p.Services = variantToStringSlice(v)
+	}
+	if v, ok := settings["ports"]; ok {
+		ports, err := variantToPorts(v)
+		if err != nil {
+			slog.Warn("failed to parse policy ports", "policy", name, "error", err)
+		} else {
+			p.Ports = ports
+		}
+	}
+	if v, ok := settings["protocols"]; ok {
+		p.Protocols = variantToStringSlice(v)
+	}
+	if v, ok := settings["source_ports"]; ok {
+		ports, err := variantToPorts(v)
+		if err != nil {
+			slog.Warn("failed to parse policy source ports", "policy", name, "error", err)
+		} else {
+			p.SourcePorts = ports
+		}
+	}
+	if v, ok := settings["forward_ports"]; ok {
+		forwards, err := variantToForwardPorts(v)
+		if err != nil {
+			slog.Warn("failed to parse policy forward ports", "policy", name, "error", err)
+		} else {
+			p.ForwardPorts = forwards
+		}
+	}
+	if v, ok := settings["icmp_blocks"]; ok {
+		p.IcmpBlocks = variantToStringSlice(v)
+	}
+	if v, ok := settings["masquerade"]; ok {
+		if val, ok := v.Value().(bool); ok {
+			p.Masquerade = val
+		} else {
+			slog.Warn("unexpected policy field type", "key", "masquerade", "type", fmt.Sprintf("%T", v.Value()))
+		}
+	}
+	if v, ok := settings["rich_rules"]; ok {
+		p.RichRules = variantToStringSlice(v)
+	}
+
+	return p, nil
+}
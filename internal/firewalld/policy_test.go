@@ -0,0 +1,92 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestParsePolicySettings(t *testing.T) {
+	settings := map[string]dbus.Variant{
+		"target":        dbus.MakeVariant("ACCEPT"),
+		"priority":      dbus.MakeVariant(int32(-10)),
+		"ingress_zones": dbus.MakeVariant([]string{"internal"}),
+		"egress_zones":  dbus.MakeVariant([]string{"dmz"}),
+		"services":      dbus.MakeVariant([]string{"http"}),
+		"ports":         dbus.MakeVariant([][]string{{"8080", "tcp"}}),
+		"masquerade":    dbus.MakeVariant(true),
+		"rich_rules":    dbus.MakeVariant([]string{`rule service name="ssh" accept`}),
+		"short":         dbus.MakeVariant("Internal to DMZ"),
+	}
+
+	p, err := parsePolicySettings("int-dmz", settings)
+	if err != nil {
+		t.Fatalf("parsePolicySettings() error = %v, want nil", err)
+	}
+	if p.Name != "int-dmz" || p.Target != "ACCEPT" || p.Priority != -10 {
+		t.Fatalf("policy = %+v, unexpected basic fields", p)
+	}
+	if len(p.IngressZones) != 1 || p.IngressZones[0] != "internal" {
+		t.Fatalf("ingress zones = %#v, want [internal]", p.IngressZones)
+	}
+	if len(p.EgressZones) != 1 || p.EgressZones[0] != "dmz" {
+		t.Fatalf("egress zones = %#v, want [dmz]", p.EgressZones)
+	}
+	if len(p.Ports) != 1 || p.Ports[0] != (Port{Port: "8080", Protocol: "tcp"}) {
+		t.Fatalf("ports = %#v, unexpected", p.Ports)
+	}
+	if !p.Masquerade || len(p.RichRules) != 1 || p.Short != "Internal to DMZ" {
+		t.Fatalf("policy = %+v, unexpected", p)
+	}
+}
+
+func TestPolicySettingsSignatures(t *testing.T) {
+	p := &Policy{
+		Name:         "int-dmz",
+		Target:       "REJECT",
+		Priority:     5,
+		IngressZones: []string{"internal"},
+		Ports:        []Port{{Port: "80", Protocol: "tcp"}},
+		ForwardPorts: []ForwardPort{{Port: "22", Protocol: "tcp", ToPort: "2222"}},
+	}
+	settings := policySettings(p)
+
+	want := map[string]string{
+		"priority":      "i",
+		"ingress_zones": "as",
+		"egress_zones":  "as",
+		"ports":         "a(ss)",
+		"source_ports":  "a(ss)",
+		"forward_ports": "a(ssss)",
+		"masquerade":    "b",
+		"target":        "s",
+	}
+	for key, sig := range want {
+		v, ok := settings[key]
+		if !ok {
+			t.Fatalf("settings missing %q", key)
+		}
+		if got := v.Signature().String(); got != sig {
+			t.Fatalf("settings[%q] signature = %s, want %s", key, got, sig)
+		}
+	}
+
+	if _, ok := policySettings(&Policy{Name: "x"})["target"]; ok {
+		t.Fatalf("empty target should be omitted")
+	}
+}
+
+func TestMapPolicyError(t *testing.T) {
+	err := mapPolicyError(&dbus.Error{Name: "org.fedoraproject.FirewallD1.Exception", Body: []interface{}{"INVALID_POLICY: nope"}})
+	if !errors.Is(err, ErrInvalidPolicy) {
+		t.Fatalf("mapPolicyError() = %v, want ErrInvalidPolicy", err)
+	}
+	err = mapPolicyError(&dbus.Error{Name: "org.freedesktop.DBus.Error.AccessDenied"})
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("mapPolicyError() = %v, want ErrPermissionDenied", err)
+	}
+}
@@ -0,0 +1,66 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParsePort parses "80/tcp" or a range such as "6000-6010/udp". The
+// protocol is lowercased.
+func ParsePort(value string) (Port, error) {
+	portStr, proto, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return Port{}, fmt.Errorf("use format port/proto (e.g. 80/tcp)")
+	}
+	portStr = strings.TrimSpace(portStr)
+	if !ValidPortRange(portStr) {
+		return Port{}, fmt.Errorf("invalid port: %s", portStr)
+	}
+	proto = strings.ToLower(strings.TrimSpace(proto))
+	if !ValidPortProtocol(proto) {
+		return Port{}, fmt.Errorf("invalid protocol: %s", proto)
+	}
+	return Port{Port: portStr, Protocol: proto}, nil
+}
+
+// ValidPortRange reports whether value is a port or a lo-hi range within
+// 1-65535.
+func ValidPortRange(value string) bool {
+	lo, hi, isRange := strings.Cut(value, "-")
+	loNum, err := strconv.Atoi(lo)
+	if err != nil || loNum < 1 || loNum > 65535 {
+		return false
+	}
+	if !isRange {
+		return true
+	}
+	hiNum, err := strconv.Atoi(hi)
+	return err == nil && hiNum >= loNum && hiNum <= 65535
+}
+
+// ValidPortProtocol reports whether proto can carry a port.
+func ValidPortProtocol(proto string) bool {
+	switch proto {
+	case "tcp", "udp", "sctp", "dccp":
+		return true
+	default:
+		return false
+	}
+}
+
+// FormatForwardPort renders fp in firewall-cmd syntax,
+// "port=80:proto=tcp:toport=8080:toaddr=10.0.0.5".
+func FormatForwardPort(fp ForwardPort) string {
+	label := "port=" + fp.Port + ":proto=" + fp.Protocol
+	if fp.ToPort != "" {
+		label += ":toport=" + fp.ToPort
+	}
+	if fp.ToAddr != "" {
+		label += ":toaddr=" + fp.ToAddr
+	}
+	return label
+}
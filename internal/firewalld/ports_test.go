@@ -0,0 +1,48 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import "testing"
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Port
+		wantErr bool
+	}{
+		{input: "80/tcp", want: Port{Port: "80", Protocol: "tcp"}},
+		{input: " 6000-6010/UDP ", want: Port{Port: "6000-6010", Protocol: "udp"}},
+		{input: "80", wantErr: true},
+		{input: "0/tcp", wantErr: true},
+		{input: "65536/tcp", wantErr: true},
+		{input: "10-5/tcp", wantErr: true},
+		{input: "80/icmp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePort(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ParsePort(%q) error = %v, wantErr = %v", tt.input, err, tt.wantErr)
+		}
+		if err == nil && got != tt.want {
+			t.Fatalf("ParsePort(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFormatForwardPort(t *testing.T) {
+	tests := []struct {
+		fp   ForwardPort
+		want string
+	}{
+		{fp: ForwardPort{Port: "80", Protocol: "tcp", ToPort: "8080"}, want: "port=80:proto=tcp:toport=8080"},
+		{fp: ForwardPort{Port: "80", Protocol: "tcp", ToAddr: "10.0.0.5"}, want: "port=80:proto=tcp:toaddr=10.0.0.5"},
+		{fp: ForwardPort{Port: "22", Protocol: "tcp", ToPort: "2222", ToAddr: "fd00::1"}, want: "port=22:proto=tcp:toport=2222:toaddr=fd00::1"},
+	}
+	for _, tt := range tests {
+		if got := FormatForwardPort(tt.fp); got != tt.want {
+			t.Fatalf("FormatForwardPort(%+v) = %q, want %q", tt.fp, got, tt.want)
+		}
+	}
+}
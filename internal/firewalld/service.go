@@ -5,12 +5,15 @@ package firewalld
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/godbus/dbus/v5"
 )
 
 var serviceDirs = []string{
@@ -19,11 +22,16 @@ var serviceDirs = []string{
 }
 
 type serviceXML struct {
-	XMLName     xml.Name        `xml:"service"`
-	Short       string          `xml:"short"`
-	Description string          `xml:"description"`
-	Ports       []servicePort   `xml:"port"`
-	Modules     []serviceModule `xml:"module"`
+	XMLName     xml.Name            `xml:"service"`
+	Short       string              `xml:"short,omitempty"`
+	Description string              `xml:"description,omitempty"`
+	Ports       []servicePort       `xml:"port"`
+	Protocols   []serviceProtocol   `xml:"protocol"`
+	SourcePorts []servicePort       `xml:"source-port"`
+	Modules     []serviceModule     `xml:"module"`
+	Destination *serviceDestination `xml:"destination"`
+	Includes    []serviceInclude    `xml:"include"`
+	Helpers     []serviceModule     `xml:"helper"`
 }
 
 type servicePort struct {
@@ -31,31 +39,201 @@ type servicePort struct {
 	Protocol string `xml:"protocol,attr"`
 }
 
+type serviceProtocol struct {
+	Value string `xml:"value,attr"`
+}
+
 type serviceModule struct {
 	Name string `xml:"name,attr"`
 }
 
+type serviceDestination struct {
+	IPv4 string `xml:"ipv4,attr,omitempty"`
+	IPv6 string `xml:"ipv6,attr,omitempty"`
+}
+
+type serviceInclude struct {
+	Service string `xml:"service,attr"`
+}
+
 func (c *Client) GetServiceDetails(name string) (*ServiceInfo, error) {
+	return ReadService(serviceDirs, name)
+}
+
+func (c *Client) ListServiceNames() ([]string, error) {
+	return ListServices(serviceDirs)
+}
+
+// CreateService adds a custom service definition to the permanent
+// configuration.
+func (c *Client) CreateService(info *ServiceInfo) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if info == nil || info.Name == "" {
+		return fmt.Errorf("service name is empty")
+	}
+
+	slog.Info("adding service (permanent)", "service", info.Name)
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	var path dbus.ObjectPath
+	if err := c.callObject(configObj, dbusInterface+".config.addService2", &path, info.Name, serviceSettings(info)); err != nil {
+		if isPermissionDenied(err) {
+			return ErrPermissionDenied
+		}
+		return fmt.Errorf("add service %s: %w", info.Name, err)
+	}
+	return nil
+}
+
+// UpdateService replaces the permanent definition of an existing service
+// with info. Updating a shipped service stores an override in /etc.
+func (c *Client) UpdateService(info *ServiceInfo) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if info == nil || info.Name == "" {
+		return fmt.Errorf("service name is empty")
+	}
+
+	slog.Info("updating service (permanent)", "service", info.Name)
+	obj, err := c.getConfigServiceObject(info.Name)
+	if err != nil {
+		return err
+	}
+	if err := c.callObject(obj, dbusInterface+".config.service.update2", nil, serviceSettings(info)); err != nil {
+		return mapServiceError(err)
+	}
+	return nil
+}
+
+// DeleteService removes a custom service. Shipped services are refused
+// with ErrBuiltinService.
+func (c *Client) DeleteService(name string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if info, err := ReadService(serviceDirs, name); err == nil && info.Builtin {
+		return ErrBuiltinService
+	}
+
+	slog.Info("removing service (permanent)", "service", name)
+	obj, err := c.getConfigServiceObject(name)
+	if err != nil {
+		return err
+	}
+	if err := c.callObject(obj, dbusInterface+".config.service.remove", nil); err != nil {
+		return mapServiceError(err)
+	}
+	return nil
+}
+
+func (c *Client) getConfigServiceObject(name string) (dbus.BusObject, error) {
+	var path dbus.ObjectPath
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	if err := c.callObject(configObj, dbusInterface+".config.getServiceByName", &path, name); err != nil {
+		return nil, mapServiceError(err)
+	}
+	return c.conn.Object(dbusInterface, path), nil
+}
+
+func mapServiceError(err error) error {
+	if isPermissionDenied(err) {
+		return ErrPermissionDenied
+	}
+	msg := strings.ToLower(err.Error())
+	var dbusErr *dbus.Error
+	if errors.As(err, &dbusErr) {
+		msg += " " + strings.ToLower(dbusErr.Name)
+	}
+	switch {
+	case strings.Contains(msg, "invalid_service"):
+		return ErrInvalidService
+	case strings.Contains(msg, "builtin_service"):
+		return ErrBuiltinService
+	}
+	return err
+}
+
+func serviceSettings(info *ServiceInfo) map[string]dbus.Variant {
+	ports := make([]dbusPort, 0, len(info.Ports))
+	for _, port := range info.Ports {
+		ports = append(ports, dbusPort{Port: port.Port, Protocol: port.Protocol})
+	}
+	sourcePorts := make([]dbusPort, 0, len(info.SourcePorts))
+	for _, port := range info.SourcePorts {
+		sourcePorts = append(sourcePorts, dbusPort{Port: port.Port, Protocol: port.Protocol})
+	}
+	destinations := make(map[string]string, len(info.Destinations))
+	for family, addr := range info.Destinations {
+		if addr != "" {
+			destinations[family] = addr
+		}
+	}
+	return map[string]dbus.Variant{
+		"short":        dbus.MakeVariant(info.Short),
+		"description":  dbus.MakeVariant(info.Description),
+		"ports":        dbus.MakeVariant(ports),
+		"protocols":    dbus.MakeVariant(nonNilStrings(info.Protocols)),
+		"source_ports": dbus.MakeVariant(sourcePorts),
+		"module_names": dbus.MakeVariant(nonNilStrings(info.Modules)),
+		"destination":  dbus.MakeVariant(destinations),
+		"includes":     dbus.MakeVariant(nonNilStrings(info.Includes)),
+		"helpers":      dbus.MakeVariant(nonNilStrings(info.Helpers)),
+	}
+}
+
+// ReadService loads service name from the first of dirs holding its XML
+// file, so user overrides in /etc shadow the shipped definitions.
+func ReadService(dirs []string, name string) (*ServiceInfo, error) {
 	if name == "" {
 		return nil, fmt.Errorf("service name is empty")
 	}
-	var data []byte
-	var lastErr error
-	for _, dir := range serviceDirs {
-		path := filepath.Join(dir, name+".xml")
-		b, err := os.ReadFile(path)
+	data, builtin, err := readDefinition(dirs, name)
+	if err != nil {
+		return nil, fmt.Errorf("read service %s: %w", name, err)
+	}
+	info, err := ParseServiceXML(name, data)
+	if err != nil {
+		return nil, err
+	}
+	info.Builtin = builtin
+	return info, nil
+}
+
+// readDefinition reads name.xml from the first of dirs holding it. The
+// definition is builtin when any directory after the first has a copy,
+// i.e. it is shipped and possibly overridden.
+func readDefinition(dirs []string, name string) ([]byte, bool, error) {
+	lastErr := os.ErrNotExist
+	for i, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, name+".xml"))
 		if err != nil {
 			lastErr = err
 			continue
 		}
-		data = b
-		lastErr = nil
-		break
-	}
-	if lastErr != nil {
-		return nil, fmt.Errorf("read service %s: %w", name, lastErr)
+		builtin := i > 0
+		for _, later := range dirs[i+1:] {
+			if _, err := os.Stat(filepath.Join(later, name+".xml")); err == nil {
+				builtin = true
+			}
+		}
+		return data, builtin, nil
 	}
+	return nil, false, lastErr
+}
 
+// ParseServiceXML decodes a firewalld service definition.
+func ParseServiceXML(name string, data []byte) (*ServiceInfo, error) {
 	var svc serviceXML
 	if err := xml.Unmarshal(data, &svc); err != nil {
 		return nil, fmt.Errorf("parse service %s: %w", name, err)
@@ -69,19 +247,78 @@ func (c *Client) GetServiceDetails(name string) (*ServiceInfo, error) {
 	for _, p := range svc.Ports {
 		info.Ports = append(info.Ports, Port{Port: p.Port, Protocol: p.Protocol})
 	}
+	for _, p := range svc.Protocols {
+		if p.Value != "" {
+			info.Protocols = append(info.Protocols, p.Value)
+		}
+	}
+	for _, p := range svc.SourcePorts {
+		info.SourcePorts = append(info.SourcePorts, Port{Port: p.Port, Protocol: p.Protocol})
+	}
 	for _, m := range svc.Modules {
 		if m.Name != "" {
 			info.Modules = append(info.Modules, m.Name)
 		}
 	}
+	if d := svc.Destination; d != nil {
+		info.Destinations = make(map[string]string)
+		if d.IPv4 != "" {
+			info.Destinations["ipv4"] = d.IPv4
+		}
+		if d.IPv6 != "" {
+			info.Destinations["ipv6"] = d.IPv6
+		}
+	}
+	for _, inc := range svc.Includes {
+		if inc.Service != "" {
+			info.Includes = append(info.Includes, inc.Service)
+		}
+	}
+	for _, h := range svc.Helpers {
+		if h.Name != "" {
+			info.Helpers = append(info.Helpers, h.Name)
+		}
+	}
 
 	slog.Debug("service details loaded", "service", name, "ports", len(info.Ports))
 	return info, nil
 }
 
-func (c *Client) ListServiceNames() ([]string, error) {
+// MarshalServiceXML encodes info as a firewalld service definition file.
+func MarshalServiceXML(info *ServiceInfo) ([]byte, error) {
+	svc := serviceXML{Short: info.Short, Description: info.Description}
+	for _, p := range info.Ports {
+		svc.Ports = append(svc.Ports, servicePort{Port: p.Port, Protocol: p.Protocol})
+	}
+	for _, p := range info.Protocols {
+		svc.Protocols = append(svc.Protocols, serviceProtocol{Value: p})
+	}
+	for _, p := range info.SourcePorts {
+		svc.SourcePorts = append(svc.SourcePorts, servicePort{Port: p.Port, Protocol: p.Protocol})
+	}
+	for _, m := range info.Modules {
+		svc.Modules = append(svc.Modules, serviceModule{Name: m})
+	}
+	if info.Destinations["ipv4"] != "" || info.Destinations["ipv6"] != "" {
+		svc.Destination = &serviceDestination{IPv4: info.Destinations["ipv4"], IPv6: info.Destinations["ipv6"]}
+	}
+	for _, inc := range info.Includes {
+		svc.Includes = append(svc.Includes, serviceInclude{Service: inc})
+	}
+	for _, h := range info.Helpers {
+		svc.Helpers = append(svc.Helpers, serviceModule{Name: h})
+	}
+	data, err := xml.MarshalIndent(svc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode service %s: %w", info.Name, err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// ListServices returns the sorted names of the service XML files in dirs.
+func ListServices(dirs []string) ([]string, error) {
 	seen := make(map[string]struct{})
-	for _, dir := range serviceDirs {
+	for _, dir := range dirs {
 		entries, err := os.ReadDir(dir)
 		if err != nil {
 			if os.IsNotExist(err) {
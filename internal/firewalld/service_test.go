@@ -0,0 +1,108 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func TestServiceXMLRoundTrip(t *testing.T) {
+	info := &ServiceInfo{
+		Name:         "myapp",
+		Short:        "My App",
+		Description:  "Internal API",
+		Ports:        []Port{{Port: "8080", Protocol: "tcp"}, {Port: "9000-9010", Protocol: "udp"}},
+		Protocols:    []string{"gre"},
+		SourcePorts:  []Port{{Port: "53", Protocol: "udp"}},
+		Modules:      []string{"nf_conntrack_ftp"},
+		Destinations: map[string]string{"ipv4": "224.0.0.251"},
+		Includes:     []string{"ssh"},
+		Helpers:      []string{"ftp"},
+	}
+	data, err := MarshalServiceXML(info)
+	if err != nil {
+		t.Fatalf("MarshalServiceXML() error = %v", err)
+	}
+	got, err := ParseServiceXML("myapp", data)
+	if err != nil {
+		t.Fatalf("ParseServiceXML() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, info) {
+		t.Fatalf("round trip = %+v, want %+v", got, info)
+	}
+}
+
+func TestReadServiceBuiltin(t *testing.T) {
+	etc, lib := t.TempDir(), t.TempDir()
+	write := func(dir, name string) {
+		if err := os.WriteFile(filepath.Join(dir, name+".xml"), []byte(`<service><short>`+name+`</short></service>`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(etc, "custom")
+	write(etc, "ssh")
+	write(lib, "ssh")
+	write(lib, "http")
+
+	tests := []struct {
+		name    string
+		builtin bool
+	}{
+		{"custom", false},
+		{"ssh", true},
+		{"http", true},
+	}
+	for _, tt := range tests {
+		info, err := ReadService([]string{etc, lib}, tt.name)
+		if err != nil {
+			t.Fatalf("ReadService(%s) error = %v", tt.name, err)
+		}
+		if info.Builtin != tt.builtin {
+			t.Fatalf("ReadService(%s).Builtin = %v, want %v", tt.name, info.Builtin, tt.builtin)
+		}
+	}
+}
+
+func TestServiceSettingsSignatures(t *testing.T) {
+	settings := serviceSettings(&ServiceInfo{Name: "x", Destinations: map[string]string{"ipv6": ""}})
+	want := map[string]string{
+		"short":        "s",
+		"ports":        "a(ss)",
+		"protocols":    "as",
+		"source_ports": "a(ss)",
+		"module_names": "as",
+		"destination":  "a{ss}",
+		"includes":     "as",
+		"helpers":      "as",
+	}
+	for key, sig := range want {
+		v, ok := settings[key]
+		if !ok {
+			t.Fatalf("settings missing %q", key)
+		}
+		if got := v.Signature().String(); got != sig {
+			t.Fatalf("settings[%q] signature = %s, want %s", key, got, sig)
+		}
+	}
+	if dest := settings["destination"].Value().(map[string]string); len(dest) != 0 {
+		t.Fatalf("empty destinations should be dropped, got %v", dest)
+	}
+}
+
+func TestMapServiceError(t *testing.T) {
+	err := mapServiceError(&dbus.Error{Name: "org.fedoraproject.FirewallD1.Exception", Body: []interface{}{"INVALID_SERVICE: nope"}})
+	if !errors.Is(err, ErrInvalidService) {
+		t.Fatalf("mapServiceError() = %v, want ErrInvalidService", err)
+	}
+	err = mapServiceError(&dbus.Error{Name: "org.fedoraproject.FirewallD1.Exception", Body: []interface{}{"BUILTIN_SERVICE: ssh"}})
+	if !errors.Is(err, ErrBuiltinService) {
+		t.Fatalf("mapServiceError() = %v, want ErrBuiltinService", err)
+	}
+}
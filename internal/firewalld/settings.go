@@ -0,0 +1,145 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// LogDeniedValues are the values setLogDenied accepts.
+var LogDeniedValues = []string{"all", "unicast", "broadcast", "multicast", "off"}
+
+// DaemonSetting is a firewalld.conf option that the config interface exposes
+// as a property. Values lists what firewalld accepts for it.
+type DaemonSetting struct {
+	Name   string
+	Values []string
+}
+
+var yesNo = []string{"yes", "no"}
+
+// DaemonSettings are the options the settings editor offers, in display
+// order. Older firewalld versions lack some of them.
+var DaemonSettings = []DaemonSetting{
+	{Name: "LogDenied", Values: LogDeniedValues},
+	{Name: "FirewallBackend", Values: []string{"nftables", "iptables"}},
+	{Name: "IPv6_rpfilter", Values: []string{"yes", "no", "strict", "loose", "strict-forward", "loose-forward"}},
+	{Name: "AllowZoneDrifting", Values: yesNo},
+	{Name: "AutomaticHelpers", Values: []string{"yes", "no", "system"}},
+	{Name: "CleanupOnExit", Values: yesNo},
+	{Name: "CleanupModulesOnExit", Values: yesNo},
+	{Name: "FlushAllOnReload", Values: yesNo},
+	{Name: "IndividualCalls", Values: yesNo},
+	{Name: "RFC3964_IPv4", Values: yesNo},
+}
+
+// LookupDaemonSetting returns the DaemonSettings entry called name.
+func LookupDaemonSetting(name string) (DaemonSetting, bool) {
+	idx := slices.IndexFunc(DaemonSettings, func(s DaemonSetting) bool { return s.Name == name })
+	if idx < 0 {
+		return DaemonSetting{}, false
+	}
+	return DaemonSettings[idx], true
+}
+
+// CheckDaemonSetting validates that name is a known setting and value one of
+// its accepted values.
+func CheckDaemonSetting(name, value string) error {
+	setting, ok := LookupDaemonSetting(name)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", name)
+	}
+	if !slices.Contains(setting.Values, value) {
+		return fmt.Errorf("invalid %s %q (use %s)", name, value, strings.Join(setting.Values, ", "))
+	}
+	return nil
+}
+
+// GetLogDenied returns the current LogDenied value.
+func (c *Client) GetLogDenied() (string, error) {
+	if c.apiVersion != APIv2 {
+		return "", ErrUnsupportedAPI
+	}
+
+	var value string
+	if err := c.call(dbusInterface+".getLogDenied", &value); err != nil {
+		return "", mapSettingsError(err)
+	}
+	return value, nil
+}
+
+// SetLogDenied changes LogDenied at runtime and in firewalld.conf;
+// firewalld reloads to apply it.
+func (c *Client) SetLogDenied(value string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if err := CheckDaemonSetting("LogDenied", value); err != nil {
+		return err
+	}
+
+	slog.Info("setting log denied", "value", value)
+	if err := c.call(dbusInterface+".setLogDenied", nil, value); err != nil {
+		return mapSettingsError(err)
+	}
+	return nil
+}
+
+// GetDaemonSettings returns the config interface properties named in
+// DaemonSettings that this firewalld has.
+func (c *Client) GetDaemonSettings() (map[string]string, error) {
+	if c.apiVersion != APIv2 {
+		return nil, ErrUnsupportedAPI
+	}
+
+	var props map[string]dbus.Variant
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	if err := c.callObject(configObj, "org.freedesktop.DBus.Properties.GetAll", &props, dbusInterface+".config"); err != nil {
+		return nil, mapSettingsError(err)
+	}
+	settings := make(map[string]string, len(DaemonSettings))
+	for _, s := range DaemonSettings {
+		if v, ok := props[s.Name]; ok {
+			settings[s.Name] = fmt.Sprint(v.Value())
+		}
+	}
+	return settings, nil
+}
+
+// SetDaemonSetting writes name to firewalld.conf through the config
+// interface. Apart from LogDenied (see SetLogDenied), changes apply on the
+// next reload.
+func (c *Client) SetDaemonSetting(name, value string) error {
+	if c.apiVersion != APIv2 {
+		return ErrUnsupportedAPI
+	}
+	if c.readOnly {
+		return ErrPermissionDenied
+	}
+	if err := CheckDaemonSetting(name, value); err != nil {
+		return err
+	}
+
+	slog.Info("setting daemon option (permanent)", "name", name, "value", value)
+	configObj := c.conn.Object(dbusInterface, dbusConfigPath)
+	if err := c.callObject(configObj, "org.freedesktop.DBus.Properties.Set", nil, dbusInterface+".config", name, dbus.MakeVariant(value)); err != nil {
+		return mapSettingsError(err)
+	}
+	return nil
+}
+
+func mapSettingsError(err error) error {
+	if isPermissionDenied(err) {
+		return ErrPermissionDenied
+	}
+	return err
+}
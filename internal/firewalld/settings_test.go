@@ -0,0 +1,35 @@
+//go:build linux
+// +build linux
+
+package firewalld
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckDaemonSetting(t *testing.T) {
+	for _, tt := range [][2]string{{"LogDenied", "unicast"}, {"FirewallBackend", "iptables"}, {"IPv6_rpfilter", "loose-forward"}, {"AutomaticHelpers", "system"}} {
+		if err := CheckDaemonSetting(tt[0], tt[1]); err != nil {
+			t.Fatalf("CheckDaemonSetting(%q, %q) error = %v", tt[0], tt[1], err)
+		}
+	}
+	for _, tt := range [][2]string{{"LogDenied", "some"}, {"CleanupOnExit", "true"}, {"MinimalMark", "100"}} {
+		if err := CheckDaemonSetting(tt[0], tt[1]); err == nil {
+			t.Fatalf("CheckDaemonSetting(%q, %q) should fail", tt[0], tt[1])
+		}
+	}
+	if s, ok := LookupDaemonSetting("LogDenied"); !ok || len(s.Values) != len(LogDeniedValues) {
+		t.Fatalf("LookupDaemonSetting(LogDenied) = %+v, %v", s, ok)
+	}
+}
+
+func TestSettingsMethodsRequireAPIv2(t *testing.T) {
+	c := &Client{}
+	if _, err := c.GetLogDenied(); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("GetLogDenied() error = %v, want ErrUnsupportedAPI", err)
+	}
+	if err := c.SetDaemonSetting("CleanupOnExit", "no"); !errors.Is(err, ErrUnsupportedAPI) {
+		t.Fatalf("SetDaemonSetting() error = %v, want ErrUnsupportedAPI", err)
+	}
+}
@@ -10,27 +10,137 @@ type Port struct {
 	Protocol string
 }
 
+type ForwardPort struct {
+	Port     string
+	Protocol string
+	ToPort   string
+	ToAddr   string
+}
+
 type ServiceInfo struct {
+	Name         string
+	Short        string
+	Description  string
+	Ports        []Port
+	Protocols    []string
+	SourcePorts  []Port
+	Modules      []string
+	Destinations map[string]string // "ipv4"/"ipv6" -> address
+	Includes     []string
+	Helpers      []string
+	// Builtin is set when firewalld ships a definition of the service; a
+	// custom copy in /etc only overrides it.
+	Builtin bool
+}
+
+// IcmpType is an ICMP type definition. An empty Destinations list means
+// the type applies to both ipv4 and ipv6.
+type IcmpType struct {
+	Name         string
+	Short        string
+	Description  string
+	Destinations []string
+	Builtin      bool
+}
+
+// Helper is a conntrack helper definition. An empty Family means the
+// helper is loaded for both ipv4 and ipv6.
+type Helper struct {
 	Name        string
 	Short       string
 	Description string
+	Family      string
+	Module      string
 	Ports       []Port
-	Modules     []string
+	Builtin     bool
+}
+
+// DirectChain is a chain created through the direct interface. IPV is
+// ipv4, ipv6 or eb.
+type DirectChain struct {
+	IPV   string
+	Table string
+	Chain string
+}
+
+// DirectRule is a rule added through the direct interface; Args are the
+// iptables arguments after the chain.
+type DirectRule struct {
+	IPV      string
+	Table    string
+	Chain    string
+	Priority int32
+	Args     []string
+}
+
+// DirectPassthrough is a raw iptables, ip6tables or ebtables call.
+type DirectPassthrough struct {
+	IPV  string
+	Args []string
+}
+
+// DirectConfig is the direct configuration of one mode.
+type DirectConfig struct {
+	Chains       []DirectChain
+	Rules        []DirectRule
+	Passthroughs []DirectPassthrough
+}
+
+// LockdownWhitelist lists the callers that may still change firewalld while
+// lockdown is enabled.
+type LockdownWhitelist struct {
+	Commands []string
+	Contexts []string
+	Users    []string
+	UIDs     []int32
+}
+
+// LockdownEntry is one whitelist entry; Kind is one of LockdownKinds.
+type LockdownEntry struct {
+	Kind  string
+	Value string
 }
 
 type Zone struct {
-	Name        string
-	Services    []string
-	Ports       []Port
-	RichRules   []string
-	Masquerade  bool
-	Interfaces  []string
-	Sources     []string
-	Target      string
-	IcmpBlocks  []string
-	IcmpInvert  bool
-	Short       string
-	Description string
+	Name         string
+	Services     []string
+	Ports        []Port
+	Protocols    []string
+	SourcePorts  []Port
+	ForwardPorts []ForwardPort
+	RichRules    []string
+	Masquerade   bool
+	Forward      bool
+	Interfaces   []string
+	Sources      []string
+	Target       string
+	IcmpBlocks   []string
+	IcmpInvert   bool
+	Short        string
+	Description  string
+	// Version and the ingress/egress priorities are zone attributes;
+	// the priorities need firewalld 1.0 or newer.
+	Version         string
+	IngressPriority int
+	EgressPriority  int
+}
+
+type Policy struct {
+	Name         string
+	Short        string
+	Description  string
+	Target       string
+	Priority     int
+	IngressZones []string
+	EgressZones  []string
+	Services     []string
+	Ports        []Port
+	Protocols    []string
+	SourcePorts  []Port
+	ForwardPorts []ForwardPort
+	IcmpBlocks   []string
+	Masquerade   bool
+	RichRules    []string
 }
 
 type IPSet struct {
@@ -48,4 +158,12 @@ var (
 	ErrUnsupportedAPI   = errors.New("firewalld version not supported")
 	ErrInvalidZone      = errors.New("zone does not exist")
 	ErrInvalidIPSet     = errors.New("ipset does not exist")
+	ErrInvalidTimeout   = errors.New("invalid runtime timeout")
+	ErrInvalidPolicy    = errors.New("policy does not exist")
+	ErrInvalidService   = errors.New("service does not exist")
+	ErrBuiltinService   = errors.New("service is shipped with firewalld and cannot be deleted")
+	ErrInvalidIcmpType  = errors.New("icmp type does not exist")
+	ErrBuiltinIcmpType  = errors.New("icmp type is shipped with firewalld and cannot be deleted")
+	ErrInvalidHelper    = errors.New("helper does not exist")
+	ErrBuiltinHelper    = errors.New("helper is shipped with firewalld and cannot be deleted")
 )
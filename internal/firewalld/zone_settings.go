@@ -98,6 +98,28 @@ func parseZoneSettings(zone string, settings map[string]dbus.Variant) (*Zone, er
 		}
 	}
 
+	if v, ok := settings["protocols"]; ok {
+		z.Protocols = variantToStringSlice(v)
+	}
+
+	if v, ok := settings["source_ports"]; ok {
+		ports, err := variantToPorts(v)
+		if err != nil {
+			slog.Warn("failed to parse source ports", "zone", zone, "error", err)
+		} else {
+			z.SourcePorts = ports
+		}
+	}
+
+	if v, ok := settings["forward_ports"]; ok {
+		forwards, err := variantToForwardPorts(v)
+		if err != nil {
+			slog.Warn("failed to parse forward ports", "zone", zone, "error", err)
+		} else {
+			z.ForwardPorts = forwards
+		}
+	}
+
 	if v, ok := settings["masquerade"]; ok {
 		if val, ok := v.Value().(bool); ok {
 			z.Masquerade = val
@@ -106,6 +128,14 @@ func parseZoneSettings(zone string, settings map[string]dbus.Variant) (*Zone, er
 		}
 	}
 
+	if v, ok := settings["forward"]; ok {
+		if val, ok := v.Value().(bool); ok {
+			z.Forward = val
+		} else {
+			slog.Warn("unexpected forward type", "type", fmt.Sprintf("%T", v.Value()))
+		}
+	}
+
 	if v, ok := settings["rules_str"]; ok {
 		z.RichRules = variantToStringSlice(v)
 	}
@@ -154,6 +184,24 @@ func parseZoneSettings(zone string, settings map[string]dbus.Variant) (*Zone, er
 		}
 	}
 
+	if v, ok := settings["version"]; ok {
+		if val, ok := v.Value().(string); ok {
+			z.Version = val
+		} else {
+			slog.Warn("unexpected version type", "type", fmt.Sprintf("%T", v.Value()))
+		}
+	}
+
+	for key, dst := range map[string]*int{"ingress_priority": &z.IngressPriority, "egress_priority": &z.EgressPriority} {
+		if v, ok := settings[key]; ok {
+			if val, ok := v.Value().(int32); ok {
+				*dst = int(val)
+			} else {
+				slog.Warn("unexpected "+key+" type", "type", fmt.Sprintf("%T", v.Value()))
+			}
+		}
+	}
+
 	slog.Debug("zone parsed", "zone", zone, "services", len(z.Services), "ports", len(z.Ports))
 	return z, nil
 }
@@ -193,6 +241,69 @@ func variantToPorts(v dbus.Variant) ([]Port, error) {
 	}
 }
 
+func variantToForwardPorts(v dbus.Variant) ([]ForwardPort, error) {
+	switch val := v.Value().(type) {
+	case [][]string:
+		out := make([]ForwardPort, 0, len(val))
+		for _, item := range val {
+			fp, err := forwardPortFromStrings(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, fp)
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]ForwardPort, 0, len(val))
+		for _, item := range val {
+			fp, err := forwardPortFromInterface(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, fp)
+		}
+		return out, nil
+	case [][]interface{}:
+		out := make([]ForwardPort, 0, len(val))
+		for _, item := range val {
+			fp, err := forwardPortFromInterface(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, fp)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unexpected forward port format: %T", val)
+	}
+}
+
+func forwardPortFromInterface(item interface{}) (ForwardPort, error) {
+	switch tuple := item.(type) {
+	case []string:
+		return forwardPortFromStrings(tuple)
+	case []interface{}:
+		fields := make([]string, 0, len(tuple))
+		for _, f := range tuple {
+			s, ok := f.(string)
+			if !ok {
+				return ForwardPort{}, fmt.Errorf("invalid forward port field type: %T", f)
+			}
+			fields = append(fields, s)
+		}
+		return forwardPortFromStrings(fields)
+	default:
+		return ForwardPort{}, fmt.Errorf("unexpected forward port tuple type: %T", item)
+	}
+}
+
+func forwardPortFromStrings(fields []string) (ForwardPort, error) {
+	if len(fields) != 4 {
+		return ForwardPort{}, fmt.Errorf("invalid forward port tuple: %v", fields)
+	}
+	return ForwardPort{Port: fields[0], Protocol: fields[1], ToPort: fields[2], ToAddr: fields[3]}, nil
+}
+
 func isInvalidZone(err error) bool {
 	var dbusErr *dbus.Error
 	if errors.As(err, &dbusErr) {
@@ -117,6 +117,83 @@ func TestVariantToPorts(t *testing.T) {
 	}
 }
 
+func TestParseZoneSettingsForwardingKeys(t *testing.T) {
+	settings := map[string]dbus.Variant{
+		"protocols":     dbus.MakeVariant([]string{"gre"}),
+		"source_ports":  dbus.MakeVariant([][]string{{"1024-2048", "udp"}}),
+		"forward_ports": dbus.MakeVariant([][]string{{"80", "tcp", "8080", "10.0.0.5"}}),
+		"forward":       dbus.MakeVariant(true),
+	}
+
+	z, err := parseZoneSettings("dmz", settings)
+	if err != nil {
+		t.Fatalf("parseZoneSettings() error = %v, want nil", err)
+	}
+	if len(z.Protocols) != 1 || z.Protocols[0] != "gre" {
+		t.Fatalf("protocols = %#v, want [gre]", z.Protocols)
+	}
+	if len(z.SourcePorts) != 1 || z.SourcePorts[0] != (Port{Port: "1024-2048", Protocol: "udp"}) {
+		t.Fatalf("source ports = %#v, unexpected", z.SourcePorts)
+	}
+	want := ForwardPort{Port: "80", Protocol: "tcp", ToPort: "8080", ToAddr: "10.0.0.5"}
+	if len(z.ForwardPorts) != 1 || z.ForwardPorts[0] != want {
+		t.Fatalf("forward ports = %#v, want [%#v]", z.ForwardPorts, want)
+	}
+	if !z.Forward {
+		t.Fatalf("forward = false, want true")
+	}
+}
+
+func TestVariantToForwardPorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   dbus.Variant
+		want    []ForwardPort
+		wantErr bool
+	}{
+		{
+			name:  "tuple list",
+			input: dbus.MakeVariant([][]string{{"22", "tcp", "2222", ""}}),
+			want:  []ForwardPort{{Port: "22", Protocol: "tcp", ToPort: "2222"}},
+		},
+		{
+			name:  "interface tuples",
+			input: dbus.MakeVariant([]interface{}{[]interface{}{"53", "udp", "", "192.0.2.1"}}),
+			want:  []ForwardPort{{Port: "53", Protocol: "udp", ToAddr: "192.0.2.1"}},
+		},
+		{
+			name:    "short tuple",
+			input:   dbus.MakeVariant([][]string{{"80", "tcp"}}),
+			wantErr: true,
+		},
+		{
+			name:    "invalid input",
+			input:   dbus.MakeVariant("80:tcp"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := variantToForwardPorts(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("variantToForwardPorts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("len(forward ports) = %d, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("forward port[%d] = %#v, want %#v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
 func TestVariantToStringSlice(t *testing.T) {
 	got := variantToStringSlice(dbus.MakeVariant([]interface{}{"a", 1, "b"}))
 	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
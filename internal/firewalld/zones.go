@@ -6,6 +6,7 @@ package firewalld
 import (
 	"fmt"
 	"log/slog"
+	"sort"
 
 	"github.com/godbus/dbus/v5"
 )
@@ -210,14 +211,14 @@ func toStringSlice(value interface{}) []string {
 		return variantToStringSlice(val)
 	case map[string][]string:
 		list := make([]string, 0)
-		for _, items := range val {
-			list = append(list, items...)
+		for _, key := range sortedKeys(val) {
+			list = append(list, val[key]...)
 		}
 		return list
 	case map[string]interface{}:
 		list := make([]string, 0)
-		for _, item := range val {
-			list = append(list, toStringSlice(item)...)
+		for _, key := range sortedKeys(val) {
+			list = append(list, toStringSlice(val[key])...)
 		}
 		return list
 	default:
@@ -225,6 +226,17 @@ func toStringSlice(value interface{}) []string {
 	}
 }
 
+// sortedKeys orders map keys so flattened zone references come out the
+// same on every call.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func dedupeStrings(items []string) []string {
 	if len(items) == 0 {
 		return items
@@ -94,6 +94,14 @@ func TestToStringSlice(t *testing.T) {
 			},
 			want: []string{"eth0", "10.0.0.0/24"},
 		},
+		{
+			name: "map string slice in key order",
+			input: map[string][]string{
+				"sources":    {"10.0.0.0/24"},
+				"interfaces": {"eth0", "eth1"},
+			},
+			want: []string{"eth0", "eth1", "10.0.0.0/24"},
+		},
 		{
 			name:  "variant",
 			input: dbus.MakeVariant([]string{"x"}),
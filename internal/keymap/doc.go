@@ -0,0 +1,3 @@
+// Package keymap maps key presses to the actions of the terminal interface
+// and lets the [keys] section of the config file rebind them.
+package keymap
@@ -0,0 +1,295 @@
+package keymap
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Actions of the main screen. The names are the keys of the [keys]
+// section in the config file.
+const (
+	Help            = "help"
+	Quit            = "quit"
+	Focus           = "focus"
+	Up              = "up"
+	Down            = "down"
+	PrevTab         = "prev_tab"
+	NextTab         = "next_tab"
+	TabServices     = "tab_services"
+	TabPorts        = "tab_ports"
+	TabRich         = "tab_rich"
+	TabNetwork      = "tab_network"
+	TabIPSets       = "tab_ipsets"
+	TabInfo         = "tab_info"
+	TabPolicies     = "tab_policies"
+	TabDirect       = "tab_direct"
+	TogglePermanent = "toggle_permanent"
+	SplitView       = "split_view"
+	Logs            = "logs"
+	Refresh         = "refresh"
+	Search          = "search"
+	NewOrNext       = "new"
+	PrevMatch       = "prev_match"
+	Add             = "add"
+	Remove          = "remove"
+	DefaultOrDelete = "default_or_delete"
+	Edit            = "edit"
+	RuleBuilder     = "rule_builder"
+	RuleBuilderEdit = "rule_builder_edit"
+	RuleUp          = "rule_up"
+	RuleDown        = "rule_down"
+	AddInterface    = "add_interface"
+	AddSource       = "add_source"
+	AddForwardPort  = "add_forward_port"
+	Masquerade      = "masquerade"
+	IcmpInversion   = "icmp_inversion"
+	IcmpTypes       = "icmp_types"
+	Commit          = "commit"
+	Reload          = "reload"
+	Templates       = "templates"
+	Panic           = "panic"
+	Lockdown        = "lockdown"
+	Settings        = "settings"
+	Restore         = "restore"
+	Backup          = "backup"
+	Export          = "export"
+	Import          = "import"
+	Undo            = "undo"
+	Redo            = "redo"
+)
+
+// Binding is an action with its keys and help text. Section groups the
+// help screen.
+type Binding struct {
+	Action  string
+	Section string
+	Keys    []string
+	Help    string
+}
+
+// defaults lists every action in help screen order.
+var defaults = []Binding{
+	{Help, "Global", []string{"?"}, "Toggle help"},
+	{Quit, "Global", []string{"q"}, "Quit (Ctrl+C always quits)"},
+
+	{Focus, "Navigation", []string{"tab"}, "Switch focus"},
+	{Down, "Navigation", []string{"j", "down"}, "Move selection down (also in lists and screens)"},
+	{Up, "Navigation", []string{"k", "up"}, "Move selection up (also in lists and screens)"},
+	{PrevTab, "Navigation", []string{"h", "left"}, "Previous tab"},
+	{NextTab, "Navigation", []string{"l", "right"}, "Next tab"},
+	{TabServices, "Navigation", []string{"1"}, "Services tab"},
+	{TabPorts, "Navigation", []string{"2"}, "Ports tab"},
+	{TabRich, "Navigation", []string{"3"}, "Rich Rules tab"},
+	{TabNetwork, "Navigation", []string{"4"}, "Network tab"},
+	{TabIPSets, "Navigation", []string{"5"}, "IPSets tab"},
+	{TabInfo, "Navigation", []string{"6"}, "Info tab"},
+	{TabPolicies, "Navigation", []string{"7"}, "Policies tab"},
+	{TabDirect, "Navigation", []string{"8"}, "Direct tab"},
+
+	{TogglePermanent, "View", []string{"P"}, "Toggle runtime/permanent"},
+	{SplitView, "View", []string{"S"}, "Split diff view"},
+	{Logs, "View", []string{"L"}, "Toggle logs"},
+	{Refresh, "View", []string{"r"}, "Refresh data"},
+
+	{Search, "Search", []string{"/"}, "Search current tab"},
+	{NewOrNext, "Search", []string{"n"}, "Next match; without a search: new zone (zones), IPSet or policy"},
+	{PrevMatch, "Search", []string{"N"}, "Previous match"},
+
+	{Add, "Actions", []string{"a"}, "Add service/port/rule/entry (append e.g. 30m for a runtime timeout); Info: block ICMP type"},
+	{Remove, "Actions", []string{"d"}, "Remove selected item; zones: delete zone; Info: unblock ICMP type"},
+	{DefaultOrDelete, "Actions", []string{"D"}, "Zones: set default zone; IPSets/Policies: delete IPSet/policy"},
+	{Edit, "Actions", []string{"e"}, "Edit rich rule; Info: zone target; Policies: edit policy"},
+	{RuleBuilder, "Actions", []string{"b"}, "Rich rule builder: new rule"},
+	{RuleBuilderEdit, "Actions", []string{"B"}, "Rich rule builder: edit selected rule"},
+	{RuleUp, "Actions", []string{"K"}, "Move rich rule up in evaluation order"},
+	{RuleDown, "Actions", []string{"J"}, "Move rich rule down in evaluation order"},
+	{AddInterface, "Actions", []string{"i"}, "Add interface (Network)"},
+	{AddSource, "Actions", []string{"s"}, "Add source (Network)"},
+	{AddForwardPort, "Actions", []string{"f"}, "Add forward port (Network)"},
+	{Masquerade, "Actions", []string{"m"}, "Toggle masquerade (Network)"},
+	{IcmpInversion, "Actions", []string{"v"}, "Toggle ICMP block inversion (Info)"},
+	{IcmpTypes, "Actions", []string{"I"}, "ICMP type browser (Info)"},
+	{Commit, "Actions", []string{"c"}, "Commit runtime -> permanent"},
+	{Reload, "Actions", []string{"u"}, "Reload (revert runtime)"},
+	{Templates, "Actions", []string{"t"}, "Apply template"},
+	{Panic, "Actions", []string{"alt+p", "alt+P"}, "Panic mode (type YES)"},
+	{Lockdown, "Actions", []string{"alt+l", "alt+L"}, "Lockdown: L toggle (type YES), a/d whitelist entry"},
+	{Settings, "Actions", []string{"alt+s", "alt+S"}, "Daemon settings (LogDenied, FirewallBackend, ...)"},
+	{Restore, "Actions", []string{"ctrl+r"}, "Backup restore menu"},
+	{Backup, "Actions", []string{"ctrl+b"}, "Create backup"},
+	{Export, "Actions", []string{"ctrl+e"}, "Export zone (JSON/XML)"},
+	{Import, "Actions", []string{"alt+i", "alt+I"}, "Import zone (JSON/XML)"},
+	{Undo, "Actions", []string{"ctrl+z"}, "Undo"},
+	{Redo, "Actions", []string{"ctrl+y"}, "Redo"},
+}
+
+// reserved keys keep their meaning everywhere: they confirm, cancel and
+// quit in every screen and prompt.
+var reserved = []string{"ctrl+c", "esc", "enter"}
+
+// Keymap resolves key presses to actions. A nil *Keymap is the default
+// keymap.
+type Keymap struct {
+	bindings []Binding
+	actions  map[string]string
+}
+
+var builtin = func() *Keymap {
+	k, err := New(nil)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}()
+
+// Default returns the built-in keymap.
+func Default() *Keymap {
+	return builtin
+}
+
+// New returns the default keymap with the keys of the actions in overrides
+// replaced; an empty list unbinds an action. Unknown actions, invalid or
+// reserved keys and keys bound to two actions are all reported.
+func New(overrides map[string][]string) (*Keymap, error) {
+	var errs []error
+	keys := make(map[string][]string, len(overrides))
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if !slices.ContainsFunc(defaults, func(b Binding) bool { return b.Action == name }) {
+			errs = append(errs, fmt.Errorf("unknown action %q", name))
+			continue
+		}
+		keys[name] = []string{}
+		for _, key := range overrides[name] {
+			if key == "space" {
+				key = " "
+			}
+			switch {
+			case slices.Contains(reserved, key):
+				errs = append(errs, fmt.Errorf("%s: key %q is reserved", name, key))
+			case !ValidKey(key):
+				errs = append(errs, fmt.Errorf("%s: invalid key %q", name, key))
+			default:
+				keys[name] = append(keys[name], key)
+			}
+		}
+	}
+
+	k := &Keymap{actions: make(map[string]string)}
+	for _, b := range defaults {
+		if override, ok := keys[b.Action]; ok {
+			b.Keys = override
+		}
+		for _, key := range b.Keys {
+			if other, ok := k.actions[key]; ok && other != b.Action {
+				errs = append(errs, fmt.Errorf("key %q is bound to both %s and %s", key, other, b.Action))
+				continue
+			}
+			k.actions[key] = b.Action
+		}
+		k.bindings = append(k.bindings, b)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return k, nil
+}
+
+// Action returns the action bound to key, or "" when there is none.
+func (k *Keymap) Action(key string) string {
+	if k == nil {
+		k = builtin
+	}
+	return k.actions[key]
+}
+
+// Keys returns the keys bound to action.
+func (k *Keymap) Keys(action string) []string {
+	if k == nil {
+		k = builtin
+	}
+	for _, b := range k.bindings {
+		if b.Action == action {
+			return b.Keys
+		}
+	}
+	return nil
+}
+
+// Label renders the keys of action for display, e.g. "j/Down" or "Alt+I".
+// Keys differing only in case after a modifier are shown once.
+func (k *Keymap) Label(action string) string {
+	var labels []string
+	for _, key := range k.Keys(action) {
+		label := KeyLabel(key)
+		if !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	if len(labels) == 0 {
+		return "(unbound)"
+	}
+	return strings.Join(labels, "/")
+}
+
+// Bindings returns the active bindings in help screen order.
+func (k *Keymap) Bindings() []Binding {
+	if k == nil {
+		k = builtin
+	}
+	return k.bindings
+}
+
+// KeyLabel renders a key name for display: modifiers and named keys are
+// capitalized ("alt+i" is "Alt+I"), single characters are kept.
+func KeyLabel(key string) string {
+	if key == " " {
+		return "Space"
+	}
+	parts := strings.Split(key, "+")
+	if len(parts) > 1 && parts[len(parts)-1] == "" {
+		// "ctrl++" and similar: the last key is '+'.
+		parts = append(parts[:len(parts)-2], "+")
+	}
+	for i, p := range parts {
+		if len([]rune(p)) == 1 {
+			if i > 0 {
+				parts[i] = strings.ToUpper(p)
+			}
+			continue
+		}
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "+")
+}
+
+var (
+	namedKeys = []string{"up", "down", "left", "right", "tab", "shift+tab", "backspace", "delete", "insert", "home", "end", "pgup", "pgdown"}
+	fnKeyRe   = regexp.MustCompile(`^f([1-9]|1[0-9]|20)$`)
+)
+
+// ValidKey reports whether key is a key name as the terminal library
+// reports it: a single character (" " is space), a named key (up, tab,
+// pgdown, f1, ...)
+// or one of those with ctrl+, alt+ or shift+ in front.
+func ValidKey(key string) bool {
+	if len([]rune(key)) == 1 {
+		return true
+	}
+	if slices.Contains(namedKeys, key) || fnKeyRe.MatchString(key) {
+		return true
+	}
+	for _, mod := range []string{"ctrl+", "alt+", "shift+"} {
+		if rest, ok := strings.CutPrefix(key, mod); ok && rest != "" {
+			return ValidKey(rest) || slices.Contains(reserved, rest)
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package keymap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultKeymap(t *testing.T) {
+	k := Default()
+	for key, want := range map[string]string{"q": Quit, "j": Down, "down": Down, "alt+I": Import, "ctrl+r": Restore, "x": ""} {
+		if got := k.Action(key); got != want {
+			t.Fatalf("Action(%q) = %q, want %q", key, got, want)
+		}
+	}
+	if got := k.Label(Import); got != "Alt+I" {
+		t.Fatalf("Label(import) = %q, want Alt+I", got)
+	}
+	if got := k.Label(Down); got != "j/Down" {
+		t.Fatalf("Label(down) = %q, want j/Down", got)
+	}
+	for _, b := range k.Bindings() {
+		if b.Section == "" || b.Help == "" || len(b.Keys) == 0 {
+			t.Fatalf("binding %+v is incomplete", b)
+		}
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	k, err := New(map[string][]string{
+		Import: {"ctrl+o"},
+		Down:   {"n", "down"},
+		Up:     {"e", "up"},
+		// n and e are taken by the defaults of these two.
+		NewOrNext: {"+"},
+		Edit:      {"space"},
+		Logs:      {},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for key, want := range map[string]string{"ctrl+o": Import, "alt+i": "", "n": Down, "e": Up, "j": "", "+": NewOrNext, " ": Edit, "L": ""} {
+		if got := k.Action(key); got != want {
+			t.Fatalf("Action(%q) = %q, want %q", key, got, want)
+		}
+	}
+	if k.Label(Logs) != "(unbound)" || k.Label(Edit) != "Space" {
+		t.Fatalf("labels = %q, %q", k.Label(Logs), k.Label(Edit))
+	}
+}
+
+func TestOverrideErrors(t *testing.T) {
+	_, err := New(map[string][]string{
+		"jump":  {"x"},
+		Import:  {"ctrl+"},
+		Export:  {"esc"},
+		Refresh: {"q"},
+	})
+	if err == nil {
+		t.Fatalf("New() should fail")
+	}
+	for _, want := range []string{`unknown action "jump"`, `import: invalid key "ctrl+"`, `export: key "esc" is reserved`, `key "q" is bound to both quit and refresh`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	for _, key := range []string{"a", "?", " ", "up", "pgdown", "f12", "ctrl+a", "alt+I", "alt+enter", "shift+tab", "ctrl+shift+up"} {
+		if !ValidKey(key) {
+			t.Fatalf("ValidKey(%q) = false", key)
+		}
+	}
+	for _, key := range []string{"", "ab", "ctrl+", "hyper+a", "f25", "Up"} {
+		if ValidKey(key) {
+			t.Fatalf("ValidKey(%q) = true", key)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+//go:build linux
+// +build linux
+
+package lockout
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/richrule"
+)
+
+type Kind int
+
+const (
+	RemoveService Kind = iota
+	RemovePort
+	RemoveRichRule
+	RemoveSource
+	RemoveInterface
+	SetTarget
+	RemoveZone
+	SetDefaultZone
+)
+
+// Change is a pending mutation of Zone. Value holds the service, rich rule,
+// source, interface, target or new default zone; Port is used by RemovePort.
+// Replace is the rule that takes the removed rich rule's place when a rule is
+// edited rather than deleted.
+type Change struct {
+	Kind    Kind
+	Zone    string
+	Value   string
+	Port    firewalld.Port
+	Replace string
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case RemoveService:
+		return "removing service " + c.Value
+	case RemovePort:
+		return "removing port " + c.Port.Port + "/" + c.Port.Protocol
+	case RemoveRichRule:
+		if c.Replace != "" {
+			return "editing the rich rule"
+		}
+		return "removing the rich rule"
+	case RemoveSource:
+		return "removing source " + c.Value
+	case RemoveInterface:
+		return "removing interface " + c.Value
+	case SetTarget:
+		return "setting target " + c.Value
+	case RemoveZone:
+		return "deleting zone " + c.Zone
+	case SetDefaultZone:
+		return "setting default zone " + c.Value
+	}
+	return "the change"
+}
+
+// Snapshot is the firewall state an analysis runs against. Services maps
+// service names to their ports; well-known services missing from it fall
+// back to their standard ports.
+type Snapshot struct {
+	Active   map[string][]string
+	Default  string
+	Zones    map[string]*firewalld.Zone
+	Services map[string][]firewalld.Port
+}
+
+type Result struct {
+	// Zone handles the session now; After handles it once the change is made.
+	Zone    string
+	After   string
+	Blocked bool
+	Reason  string
+}
+
+var knownServices = map[string][]firewalld.Port{
+	"ssh": {{Port: "22", Protocol: "tcp"}},
+}
+
+// Analyze reports whether c would stop the session from being accepted. A
+// session the snapshot does not appear to accept (for example one allowed by
+// a rule lazyfirewall cannot model) is never reported as blocked.
+func Analyze(s *Session, snap Snapshot, c Change) Result {
+	res := Result{Zone: s.ZoneFor(snap.Active, snap.Default)}
+	before := snap.Zones[res.Zone]
+	if before == nil {
+		return res
+	}
+	ok, by := allows(s, before, snap.Services)
+	if !ok {
+		return res
+	}
+
+	active := applyBindingChange(snap.Active, c)
+	res.After = s.ZoneFor(active, defaultAfter(snap.Default, c))
+	if c.Kind == RemoveZone && res.After == c.Zone {
+		res.Blocked = true
+		res.Reason = fmt.Sprintf("SSH (%s) is handled by zone %s, which %s removes", s, res.After, c)
+		return res
+	}
+	after := snap.Zones[res.After]
+	if res.After == c.Zone && after != nil {
+		after = applyZoneChange(after, c)
+	}
+	if after == nil {
+		res.Blocked = true
+		res.Reason = fmt.Sprintf("%s moves SSH (%s) to zone %s, whose settings are unknown", c, s, res.After)
+		return res
+	}
+	if ok, _ := allows(s, after, snap.Services); ok {
+		return res
+	}
+	res.Blocked = true
+	if res.After != res.Zone {
+		res.Reason = fmt.Sprintf("%s moves SSH (%s) from zone %s to zone %s, which does not allow it", c, s, res.Zone, res.After)
+	} else {
+		res.Reason = fmt.Sprintf("SSH (%s) is allowed by %s in zone %s; after %s nothing allows it", s, by, res.Zone, c)
+	}
+	return res
+}
+
+func defaultAfter(defaultZone string, c Change) string {
+	if c.Kind == SetDefaultZone {
+		return c.Value
+	}
+	return defaultZone
+}
+
+func applyBindingChange(active map[string][]string, c Change) map[string][]string {
+	if c.Kind != RemoveSource && c.Kind != RemoveInterface && c.Kind != RemoveZone {
+		return active
+	}
+	out := make(map[string][]string, len(active))
+	for zone, bindings := range active {
+		if zone != c.Zone {
+			out[zone] = bindings
+			continue
+		}
+		if c.Kind == RemoveZone {
+			continue
+		}
+		kept := make([]string, 0, len(bindings))
+		for _, b := range bindings {
+			if b != c.Value {
+				kept = append(kept, b)
+			}
+		}
+		if len(kept) > 0 {
+			out[zone] = kept
+		}
+	}
+	return out
+}
+
+func applyZoneChange(z *firewalld.Zone, c Change) *firewalld.Zone {
+	next := *z
+	switch c.Kind {
+	case RemoveService:
+		next.Services = without(z.Services, c.Value)
+	case RemovePort:
+		next.Ports = slices.DeleteFunc(slices.Clone(z.Ports), func(p firewalld.Port) bool {
+			return p.Port == c.Port.Port && strings.EqualFold(p.Protocol, c.Port.Protocol)
+		})
+	case RemoveRichRule:
+		next.RichRules = without(z.RichRules, c.Value)
+		if c.Replace != "" {
+			next.RichRules = append(next.RichRules, c.Replace)
+		}
+	case SetTarget:
+		next.Target = c.Value
+	}
+	return &next
+}
+
+func without(list []string, value string) []string {
+	return slices.DeleteFunc(slices.Clone(list), func(v string) bool { return v == value })
+}
+
+// allows reports whether zone z accepts the session and names what accepts it.
+func allows(s *Session, z *firewalld.Zone, services map[string][]firewalld.Port) (bool, string) {
+	if strings.EqualFold(z.Target, "ACCEPT") {
+		return true, "target ACCEPT"
+	}
+	for _, svc := range z.Services {
+		if coversPort(servicePorts(svc, services), s.Port) {
+			return true, "service " + svc
+		}
+	}
+	if coversPort(z.Ports, s.Port) {
+		return true, "port"
+	}
+	for _, rule := range z.RichRules {
+		if richRuleAccepts(rule, s, services) {
+			return true, "rich rule"
+		}
+	}
+	return false, ""
+}
+
+func servicePorts(name string, services map[string][]firewalld.Port) []firewalld.Port {
+	if ports, ok := services[name]; ok {
+		return ports
+	}
+	return knownServices[name]
+}
+
+func coversPort(ports []firewalld.Port, port int) bool {
+	for _, p := range ports {
+		if !strings.EqualFold(p.Protocol, "tcp") {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(p.Port, "-")
+		loNum, err := strconv.Atoi(lo)
+		if err != nil {
+			continue
+		}
+		hiNum := loNum
+		if isRange {
+			if hiNum, err = strconv.Atoi(hi); err != nil {
+				continue
+			}
+		}
+		if port >= loNum && port <= hiNum {
+			return true
+		}
+	}
+	return false
+}
+
+// richRuleAccepts reports whether rule accepts the session: an accept rule
+// for its address family whose source matches the client, whose destination
+// matches the server address, and which either names no element or a service
+// or port covering the session's port. Sources and destinations given as an
+// ipset or MAC cannot be matched against the session and never count, nor
+// does a destination when the server address is unknown.
+func richRuleAccepts(rule string, s *Session, services map[string][]firewalld.Port) bool {
+	r, err := richrule.Parse(rule)
+	if err != nil || r.Action == nil || r.Action.Kind != "accept" {
+		return false
+	}
+	if r.Family != "" && (r.Family == "ipv4") != (s.Client.To4() != nil) {
+		return false
+	}
+	if r.Source != nil && !addressMatches(r.Source, s.Client) {
+		return false
+	}
+	if r.Destination != nil && !addressMatches(r.Destination, s.Server) {
+		return false
+	}
+	if r.Element == nil {
+		return true
+	}
+	switch r.Element.Kind {
+	case "service":
+		return coversPort(servicePorts(r.Element.Name, services), s.Port)
+	case "port":
+		return coversPort([]firewalld.Port{{Port: r.Element.Port, Protocol: r.Element.Protocol}}, s.Port)
+	}
+	return false
+}
+
+func addressMatches(a *richrule.Address, ip net.IP) bool {
+	if a.Address == "" || ip == nil {
+		return false
+	}
+	return sourceMatches(a.Address, ip) != a.Invert
+}
+
+// Source is the subset of the firewalld client Check needs.
+type Source interface {
+	GetActiveZones() (map[string][]string, error)
+	GetDefaultZone() (string, error)
+	GetZoneSettings(zone string, permanent bool) (*firewalld.Zone, error)
+	GetServiceDetails(name string) (*firewalld.ServiceInfo, error)
+}
+
+// Check fetches the state relevant to c from src and analyses it.
+func Check(src Source, s *Session, c Change, permanent bool) (Result, error) {
+	if s == nil {
+		return Result{}, nil
+	}
+	active, err := src.GetActiveZones()
+	if err != nil {
+		return Result{}, fmt.Errorf("active zones: %w", err)
+	}
+	defaultZone, err := src.GetDefaultZone()
+	if err != nil {
+		return Result{}, fmt.Errorf("default zone: %w", err)
+	}
+	snap := Snapshot{
+		Active:   active,
+		Default:  defaultZone,
+		Zones:    make(map[string]*firewalld.Zone),
+		Services: make(map[string][]firewalld.Port),
+	}
+	names := []string{s.ZoneFor(active, defaultZone), c.Zone, s.ZoneFor(applyBindingChange(active, c), defaultAfter(defaultZone, c))}
+	for _, name := range names {
+		if name == "" || snap.Zones[name] != nil {
+			continue
+		}
+		z, err := src.GetZoneSettings(name, permanent)
+		if err != nil {
+			return Result{}, fmt.Errorf("zone %s: %w", name, err)
+		}
+		snap.Zones[name] = z
+		for _, svc := range referencedServices(z) {
+			if _, ok := snap.Services[svc]; ok {
+				continue
+			}
+			info, err := src.GetServiceDetails(svc)
+			if err != nil {
+				continue
+			}
+			snap.Services[svc] = info.Ports
+		}
+	}
+	return Analyze(s, snap, c), nil
+}
+
+func referencedServices(z *firewalld.Zone) []string {
+	names := slices.Clone(z.Services)
+	for _, rule := range z.RichRules {
+		r, err := richrule.Parse(rule)
+		if err != nil || r.Element == nil || r.Element.Kind != "service" {
+			continue
+		}
+		if !slices.Contains(names, r.Element.Name) {
+			names = append(names, r.Element.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,6 @@
+//go:build linux
+// +build linux
+
+// Package lockout predicts whether a firewall change would cut off the SSH
+// session lazyfirewall is running in.
+package lockout
@@ -0,0 +1,442 @@
+//go:build linux
+// +build linux
+
+package lockout
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+func TestParseSSHConnection(t *testing.T) {
+	tests := []struct {
+		value  string
+		client string
+		port   int
+		ok     bool
+	}{
+		{value: "192.0.2.10 51234 192.0.2.1 22", client: "192.0.2.10", port: 22, ok: true},
+		{value: "2001:db8::10 51234 2001:db8::1 2222", client: "2001:db8::10", port: 2222, ok: true},
+		{value: "", ok: false},
+		{value: "192.0.2.10 51234 192.0.2.1", ok: false},
+		{value: "nope 1 192.0.2.1 22", ok: false},
+		{value: "192.0.2.10 51234 192.0.2.1 0", ok: false},
+	}
+
+	for _, tt := range tests {
+		s, err := ParseSSHConnection(tt.value)
+		if (err == nil) != tt.ok {
+			t.Fatalf("ParseSSHConnection(%q) error = %v, want ok=%v", tt.value, err, tt.ok)
+		}
+		if !tt.ok {
+			continue
+		}
+		if s.Client.String() != tt.client || s.Port != tt.port {
+			t.Fatalf("ParseSSHConnection(%q) = %s:%d, want %s:%d", tt.value, s.Client, s.Port, tt.client, tt.port)
+		}
+	}
+
+	s, err := ParseSSHClient("192.0.2.10 51234 22")
+	if err != nil || s.Port != 22 || s.Server != nil {
+		t.Fatalf("ParseSSHClient() = %+v, %v", s, err)
+	}
+}
+
+func TestParseProcNetTCP(t *testing.T) {
+	data := []byte(`  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1000 1 0000000000000000 100 0 0 10 0
+   1: 0100000A:0016 0A0200C0:D431 01 00000000:00000000 02:000A7B2F 00000000     0        0 2000 4 0000000000000000 20 4 1 10 -1
+`)
+	conns := parseProcNetTCP(data)
+	if len(conns) != 1 {
+		t.Fatalf("parseProcNetTCP() = %v, want only the established connection", conns)
+	}
+	s := conns["2000"]
+	if s == nil || s.Client.String() != "192.0.2.10" || s.Server.String() != "10.0.0.1" || s.Port != 22 {
+		t.Fatalf("parseProcNetTCP()[2000] = %+v", s)
+	}
+
+	ip, port, err := parseProcAddr("B80D0120000000000000000001000000:08AE")
+	if err != nil || ip.String() != "2001:db8::1" || port != 2222 {
+		t.Fatalf("parseProcAddr(ipv6) = %v, %d, %v", ip, port, err)
+	}
+	ip, _, err = parseProcAddr("0000000000000000FFFF00000A02000A:0016")
+	if err != nil || ip.To4() == nil || ip.String() != "10.0.2.10" {
+		t.Fatalf("parseProcAddr(v4-mapped) = %v, %v", ip, err)
+	}
+}
+
+func TestProcSession(t *testing.T) {
+	proc := t.TempDir()
+	writeProc := func(name, data string) {
+		t.Helper()
+		path := filepath.Join(proc, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeProc("300/comm", "sudo\n")
+	writeProc("300/stat", "300 (sudo) S 200 300 200 0 -1")
+	writeProc("200/comm", "sshd-session\n")
+	writeProc("200/stat", "200 (sshd-session) S 1 200 200 0 -1")
+	writeProc("net/tcp", "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"+
+		"   1: 0100000A:0016 0A0200C0:D431 01 00000000:00000000 02:000A7B2F 00000000     0        0 2000 4 0000000000000000 20 4 1 10 -1\n")
+	if err := os.MkdirAll(filepath.Join(proc, "200/fd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for fd, target := range map[string]string{"0": "/dev/null", "3": "socket:[1999]", "4": "socket:[2000]"} {
+		if err := os.Symlink(target, filepath.Join(proc, "200/fd", fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := procSession(proc, 300)
+	if err != nil || s == nil || s.Client.String() != "192.0.2.10" || s.Server.String() != "10.0.0.1" || s.Port != 22 {
+		t.Fatalf("procSession() = %+v, %v", s, err)
+	}
+
+	writeProc("400/comm", "bash\n")
+	writeProc("400/stat", "400 (ba) sh) S 1 400 400 0 -1")
+	if s, err := procSession(proc, 400); s != nil || err != nil {
+		t.Fatalf("procSession() without sshd ancestor = %+v, %v", s, err)
+	}
+
+	if err := os.Remove(filepath.Join(proc, "200/fd/4")); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := procSession(proc, 300); s != nil || err == nil {
+		t.Fatalf("procSession() without sshd connection = %+v, %v, want an error", s, err)
+	}
+}
+
+func TestZoneFor(t *testing.T) {
+	s := &Session{Client: net.ParseIP("192.0.2.10"), Port: 22, Interface: "eth0"}
+	tests := []struct {
+		name   string
+		active map[string][]string
+		want   string
+	}{
+		{name: "source wins", active: map[string][]string{"public": {"eth0"}, "trusted": {"192.0.2.0/24"}}, want: "trusted"},
+		{name: "exact source", active: map[string][]string{"work": {"192.0.2.10"}}, want: "work"},
+		{name: "interface", active: map[string][]string{"dmz": {"eth1"}, "public": {"eth0"}}, want: "public"},
+		{name: "default", active: map[string][]string{"dmz": {"eth1", "198.51.100.0/24"}}, want: "home"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.ZoneFor(tt.active, "home"); got != tt.want {
+				t.Fatalf("ZoneFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var none *Session
+	if got := none.ZoneFor(map[string][]string{"public": {"eth0"}}, "public"); got != "" {
+		t.Fatalf("ZoneFor() without session = %q, want empty", got)
+	}
+	if !s.Binds("192.0.2.0/24") || !s.Binds("eth0") || s.Binds("eth1") {
+		t.Fatalf("Binds() mismatch")
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	session := &Session{Client: net.ParseIP("192.0.2.10"), Port: 22, Interface: "eth0"}
+	snapshot := func() Snapshot {
+		return Snapshot{
+			Active:  map[string][]string{"public": {"eth0"}, "trusted": {"198.51.100.0/24"}},
+			Default: "public",
+			Zones: map[string]*firewalld.Zone{
+				"public": {
+					Name:      "public",
+					Target:    "default",
+					Services:  []string{"ssh", "http"},
+					Ports:     []firewalld.Port{{Port: "8080", Protocol: "tcp"}},
+					RichRules: []string{`rule family="ipv4" source address="10.0.0.0/8" service name="ssh" accept`},
+				},
+				"trusted": {Name: "trusted", Target: "ACCEPT"},
+				"block":   {Name: "block", Target: "%%REJECT%%"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*Snapshot)
+		change  Change
+		blocked bool
+		reason  string
+	}{
+		{
+			name:    "remove ssh service",
+			change:  Change{Kind: RemoveService, Zone: "public", Value: "ssh"},
+			blocked: true,
+			reason:  "allowed by service ssh in zone public",
+		},
+		{
+			name:   "remove unrelated service",
+			change: Change{Kind: RemoveService, Zone: "public", Value: "http"},
+		},
+		{
+			name:   "remove ssh from another zone",
+			change: Change{Kind: RemoveService, Zone: "dmz", Value: "ssh"},
+		},
+		{
+			name: "port still open",
+			mutate: func(s *Snapshot) {
+				s.Zones["public"].Ports = append(s.Zones["public"].Ports, firewalld.Port{Port: "20-30", Protocol: "tcp"})
+			},
+			change: Change{Kind: RemoveService, Zone: "public", Value: "ssh"},
+		},
+		{
+			name: "remove covering port",
+			mutate: func(s *Snapshot) {
+				s.Zones["public"].Services = nil
+				s.Zones["public"].Ports = []firewalld.Port{{Port: "22", Protocol: "tcp"}}
+			},
+			change:  Change{Kind: RemovePort, Zone: "public", Port: firewalld.Port{Port: "22", Protocol: "tcp"}},
+			blocked: true,
+		},
+		{
+			name: "rich rule for client",
+			mutate: func(s *Snapshot) {
+				s.Zones["public"].RichRules = []string{`rule family="ipv4" source address="192.0.2.0/24" accept`}
+			},
+			change: Change{Kind: RemoveService, Zone: "public", Value: "ssh"},
+		},
+		{
+			name: "remove accepting rich rule",
+			mutate: func(s *Snapshot) {
+				s.Zones["public"].Services = nil
+				s.Zones["public"].RichRules = []string{`rule family="ipv4" source address="192.0.2.0/24" service name="ssh" accept`}
+			},
+			change:  Change{Kind: RemoveRichRule, Zone: "public", Value: `rule family="ipv4" source address="192.0.2.0/24" service name="ssh" accept`},
+			blocked: true,
+		},
+		{
+			name:    "target drop",
+			change:  Change{Kind: SetTarget, Zone: "public", Value: "DROP"},
+			blocked: false,
+		},
+		{
+			name: "target drop without services",
+			mutate: func(s *Snapshot) {
+				s.Zones["public"].Target = "ACCEPT"
+				s.Zones["public"].Services = nil
+			},
+			change:  Change{Kind: SetTarget, Zone: "public", Value: "DROP"},
+			blocked: true,
+		},
+		{
+			name: "source removal moves session",
+			mutate: func(s *Snapshot) {
+				s.Active["trusted"] = []string{"192.0.2.0/24"}
+				s.Default = "block"
+				s.Active["public"] = nil
+			},
+			change:  Change{Kind: RemoveSource, Zone: "trusted", Value: "192.0.2.0/24"},
+			blocked: true,
+			reason:  "from zone trusted to zone block",
+		},
+		{
+			name: "edit keeps rich rule accepting",
+			mutate: func(s *Snapshot) {
+				s.Zones["public"].Services = nil
+				s.Zones["public"].RichRules = []string{`rule family="ipv4" source address="192.0.2.0/24" service name="ssh" accept`}
+			},
+			change: Change{
+				Kind:    RemoveRichRule,
+				Zone:    "public",
+				Value:   `rule family="ipv4" source address="192.0.2.0/24" service name="ssh" accept`,
+				Replace: `rule priority="-5" family="ipv4" source address="192.0.2.0/24" service name="ssh" accept`,
+			},
+		},
+		{
+			name: "edit stops rich rule accepting",
+			mutate: func(s *Snapshot) {
+				s.Zones["public"].Services = nil
+				s.Zones["public"].RichRules = []string{`rule family="ipv4" source address="192.0.2.0/24" service name="ssh" accept`}
+			},
+			change: Change{
+				Kind:    RemoveRichRule,
+				Zone:    "public",
+				Value:   `rule family="ipv4" source address="192.0.2.0/24" service name="ssh" accept`,
+				Replace: `rule family="ipv4" source address="192.0.2.0/24" service name="ssh" drop`,
+			},
+			blocked: true,
+		},
+		{
+			name: "delete session zone",
+			mutate: func(s *Snapshot) {
+				s.Active["trusted"] = []string{"192.0.2.0/24"}
+				s.Active["public"] = nil
+				s.Default = "block"
+			},
+			change:  Change{Kind: RemoveZone, Zone: "trusted"},
+			blocked: true,
+			reason:  "from zone trusted to zone block",
+		},
+		{
+			name:   "delete unrelated zone",
+			change: Change{Kind: RemoveZone, Zone: "trusted"},
+		},
+		{
+			name: "delete default zone",
+			mutate: func(s *Snapshot) {
+				s.Active["public"] = nil
+			},
+			change:  Change{Kind: RemoveZone, Zone: "public"},
+			blocked: true,
+			reason:  "which deleting zone public removes",
+		},
+		{
+			name: "default zone change",
+			mutate: func(s *Snapshot) {
+				s.Active["public"] = nil
+			},
+			change:  Change{Kind: SetDefaultZone, Value: "block"},
+			blocked: true,
+			reason:  "from zone public to zone block",
+		},
+		{
+			name:   "default zone change behind interface",
+			change: Change{Kind: SetDefaultZone, Value: "block"},
+		},
+		{
+			name:   "interface removal falls back to allowing default",
+			change: Change{Kind: RemoveInterface, Zone: "public", Value: "eth0"},
+		},
+		{
+			name: "session not modelled",
+			mutate: func(s *Snapshot) {
+				s.Zones["public"].Services = []string{"http"}
+				s.Zones["public"].Ports = nil
+				s.Zones["public"].RichRules = nil
+			},
+			change: Change{Kind: RemoveService, Zone: "public", Value: "http"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snap := snapshot()
+			if tt.mutate != nil {
+				tt.mutate(&snap)
+			}
+			res := Analyze(session, snap, tt.change)
+			if res.Blocked != tt.blocked {
+				t.Fatalf("Analyze() blocked = %v, want %v (%+v)", res.Blocked, tt.blocked, res)
+			}
+			if tt.reason != "" && !strings.Contains(res.Reason, tt.reason) {
+				t.Fatalf("Analyze() reason = %q, want it to contain %q", res.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRichRuleAccepts(t *testing.T) {
+	s := &Session{Client: net.ParseIP("192.0.2.10"), Server: net.ParseIP("192.0.2.1"), Port: 22}
+	tests := []struct {
+		rule string
+		want bool
+	}{
+		{rule: `rule service name="ssh" accept`, want: true},
+		{rule: `rule family="ipv6" service name="ssh" accept`, want: false},
+		{rule: `rule family="ipv4" source address="192.0.2.0/24" port port="22" protocol="tcp" accept`, want: true},
+		{rule: `rule family="ipv4" source not address="192.0.2.0/24" accept`, want: false},
+		{rule: `rule family="ipv4" source NOT address="192.0.2.0/24" accept`, want: false},
+		{rule: `rule family="ipv4" source NOT address="198.51.100.0/24" service name="ssh" accept`, want: true},
+		{rule: `rule source ipset="admins" service name="ssh" accept`, want: false},
+		{rule: `rule source mac="00:11:22:33:44:55" service name="ssh" accept`, want: false},
+		{rule: `rule source NOT ipset="blocked" service name="ssh" accept`, want: false},
+		{rule: `rule family="ipv4" source address="192.0.2.0/24" service name="ssh" drop`, want: false},
+		{rule: `rule port port="2222" protocol="tcp" accept`, want: false},
+		{rule: `rule source-port port="22" protocol="tcp" accept`, want: false},
+		{rule: `rule family="ipv4" source address="192.0.2.10" accept limit value="1/m"`, want: true},
+		{rule: `rule family="ipv4" destination address="192.0.2.1" service name="ssh" accept`, want: true},
+		{rule: `rule family="ipv4" destination address="10.9.9.9" accept`, want: false},
+		{rule: `rule family="ipv4" destination not address="10.9.9.9" accept`, want: true},
+		{rule: `rule family="ipv4" log prefix="x accept y" drop`, want: false},
+		{rule: `rule service name="ssh" log prefix="drop" accept`, want: true},
+		{rule: `rule service name="ssh" acept`, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := richRuleAccepts(tt.rule, s, nil); got != tt.want {
+			t.Fatalf("richRuleAccepts(%q) = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+
+	noServer := &Session{Client: s.Client, Port: s.Port}
+	if richRuleAccepts(`rule family="ipv4" destination address="192.0.2.1" accept`, noServer, nil) {
+		t.Fatalf("richRuleAccepts() counted a destination rule without a known server address")
+	}
+}
+
+type fakeSource struct {
+	zones    map[string]*firewalld.Zone
+	services map[string][]firewalld.Port
+	fetched  []string
+}
+
+func (f *fakeSource) GetActiveZones() (map[string][]string, error) {
+	return map[string][]string{"public": {"eth0"}}, nil
+}
+
+func (f *fakeSource) GetDefaultZone() (string, error) {
+	return "public", nil
+}
+
+func (f *fakeSource) GetZoneSettings(zone string, permanent bool) (*firewalld.Zone, error) {
+	f.fetched = append(f.fetched, zone)
+	z, ok := f.zones[zone]
+	if !ok {
+		return nil, firewalld.ErrInvalidZone
+	}
+	return z, nil
+}
+
+func (f *fakeSource) GetServiceDetails(name string) (*firewalld.ServiceInfo, error) {
+	ports, ok := f.services[name]
+	if !ok {
+		return nil, errors.New("unknown service")
+	}
+	return &firewalld.ServiceInfo{Name: name, Ports: ports}, nil
+}
+
+func TestCheck(t *testing.T) {
+	src := &fakeSource{
+		zones: map[string]*firewalld.Zone{
+			"public": {Name: "public", Services: []string{"custom-ssh"}},
+		},
+		services: map[string][]firewalld.Port{"custom-ssh": {{Port: "2222", Protocol: "tcp"}}},
+	}
+	s := &Session{Client: net.ParseIP("192.0.2.10"), Port: 2222, Interface: "eth0"}
+
+	res, err := Check(src, s, Change{Kind: RemoveService, Zone: "public", Value: "custom-ssh"}, true)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !res.Blocked || res.Zone != "public" {
+		t.Fatalf("Check() = %+v, want blocked in public", res)
+	}
+	if len(src.fetched) != 1 {
+		t.Fatalf("zones fetched = %v, want public once", src.fetched)
+	}
+
+	if _, err := Check(src, s, Change{Kind: RemoveSource, Zone: "dmz", Value: "192.0.2.0/24"}, true); err == nil {
+		t.Fatalf("Check() expected error for unknown zone")
+	}
+
+	if res, err := Check(src, nil, Change{Kind: RemoveService, Zone: "public", Value: "custom-ssh"}, true); err != nil || res.Blocked {
+		t.Fatalf("Check() without session = %+v, %v", res, err)
+	}
+}
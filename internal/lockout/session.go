@@ -0,0 +1,271 @@
+//go:build linux
+// +build linux
+
+package lockout
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Session is the SSH connection lazyfirewall is running under.
+type Session struct {
+	Client net.IP
+	Server net.IP
+	// Port is the server-side port the connection arrived on.
+	Port int
+	// Interface is the local interface owning Server, if it could be found.
+	Interface string
+}
+
+func (s *Session) String() string {
+	return fmt.Sprintf("%s -> port %d/tcp", s.Client, s.Port)
+}
+
+// DetectSession reads SSH_CONNECTION (or the older SSH_CLIENT) as set by
+// sshd. When neither is set, as after sudo resets the environment, it looks
+// for an sshd process among the ancestors and reads its connection from
+// /proc. It returns nil and no error when not running over SSH, and an error
+// when an sshd ancestor was found but its connection could not be read.
+func DetectSession() (*Session, error) {
+	s, err := ParseSSHConnection(os.Getenv("SSH_CONNECTION"))
+	if err != nil {
+		s, err = ParseSSHClient(os.Getenv("SSH_CLIENT"))
+	}
+	if err != nil {
+		if s, err = procSession("/proc", os.Getppid()); s == nil {
+			return nil, err
+		}
+	}
+	if s.Server != nil {
+		s.Interface = interfaceForIP(s.Server)
+	}
+	return s, nil
+}
+
+// ParseSSHConnection parses "client_ip client_port server_ip server_port".
+func ParseSSHConnection(value string) (*Session, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("invalid SSH_CONNECTION %q", value)
+	}
+	client := net.ParseIP(fields[0])
+	server := net.ParseIP(fields[2])
+	port, err := strconv.Atoi(fields[3])
+	if client == nil || server == nil || err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid SSH_CONNECTION %q", value)
+	}
+	return &Session{Client: client, Server: server, Port: port}, nil
+}
+
+// ParseSSHClient parses "client_ip client_port server_port"; the server
+// address is unknown.
+func ParseSSHClient(value string) (*Session, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("invalid SSH_CLIENT %q", value)
+	}
+	client := net.ParseIP(fields[0])
+	port, err := strconv.Atoi(fields[2])
+	if client == nil || err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid SSH_CLIENT %q", value)
+	}
+	return &Session{Client: client, Port: port}, nil
+}
+
+// procSession walks up from pid to the nearest sshd process (sshd-session
+// since OpenSSH 9.8) and returns the connection of its first established TCP
+// socket. It returns nil and no error when no ancestor is sshd.
+func procSession(proc string, pid int) (*Session, error) {
+	for pid > 1 {
+		comm, err := os.ReadFile(filepath.Join(proc, strconv.Itoa(pid), "comm"))
+		if err != nil {
+			return nil, nil
+		}
+		switch strings.TrimSpace(string(comm)) {
+		case "sshd", "sshd-session":
+			return sshdSession(proc, pid)
+		}
+		if pid, err = parentPID(proc, pid); err != nil {
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+// parentPID reads the ppid field of /proc/PID/stat, which follows the
+// parenthesised command name.
+func parentPID(proc string, pid int) (int, error) {
+	data, err := os.ReadFile(filepath.Join(proc, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, err
+	}
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("invalid stat for pid %d", pid)
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("invalid stat for pid %d", pid)
+	}
+	return strconv.Atoi(fields[1])
+}
+
+func sshdSession(proc string, pid int) (*Session, error) {
+	fdDir := filepath.Join(proc, strconv.Itoa(pid), "fd")
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return nil, fmt.Errorf("read sockets of sshd (pid %d): %w", pid, err)
+	}
+	fds := make([]int, 0, len(entries))
+	for _, e := range entries {
+		if fd, err := strconv.Atoi(e.Name()); err == nil {
+			fds = append(fds, fd)
+		}
+	}
+	sort.Ints(fds)
+
+	conns := make(map[string]*Session)
+	for _, name := range []string{"tcp", "tcp6"} {
+		data, err := os.ReadFile(filepath.Join(proc, "net", name))
+		if err != nil {
+			continue
+		}
+		for inode, s := range parseProcNetTCP(data) {
+			conns[inode] = s
+		}
+	}
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(fdDir, strconv.Itoa(fd)))
+		if err != nil {
+			continue
+		}
+		inode, ok := strings.CutPrefix(link, "socket:[")
+		if !ok {
+			continue
+		}
+		if s, ok := conns[strings.TrimSuffix(inode, "]")]; ok {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no TCP connection found for sshd (pid %d)", pid)
+}
+
+// parseProcNetTCP maps socket inodes to the established connections listed
+// in /proc/net/tcp or /proc/net/tcp6, seen from the server side.
+func parseProcNetTCP(data []byte) map[string]*Session {
+	conns := make(map[string]*Session)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		if len(fields) < 10 || fields[3] != "01" {
+			continue
+		}
+		server, port, err := parseProcAddr(fields[1])
+		if err != nil {
+			continue
+		}
+		client, _, err := parseProcAddr(fields[2])
+		if err != nil {
+			continue
+		}
+		conns[fields[9]] = &Session{Client: client, Server: server, Port: port}
+	}
+	return conns
+}
+
+// parseProcAddr decodes "0100007F:0016": the address is hex in host (little
+// endian) byte order per 32-bit word, the port hex in network order.
+func parseProcAddr(value string) (net.IP, int, error) {
+	addrHex, portHex, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid address %q", value)
+	}
+	raw, err := hex.DecodeString(addrHex)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("invalid address %q", value)
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid port in %q", value)
+	}
+	return net.IP(raw), int(port), nil
+}
+
+func interfaceForIP(ip net.IP) string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return iface.Name
+			}
+		}
+	}
+	return ""
+}
+
+// ZoneFor returns the zone firewalld uses for the session given the active
+// zone bindings: a zone whose source matches the client wins over one bound
+// to the interface, and the default zone catches everything else.
+func (s *Session) ZoneFor(active map[string][]string, defaultZone string) string {
+	if s == nil {
+		return ""
+	}
+	names := make([]string, 0, len(active))
+	for name := range active {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, binding := range active[name] {
+			if sourceMatches(binding, s.Client) {
+				return name
+			}
+		}
+	}
+	if s.Interface != "" {
+		for _, name := range names {
+			for _, binding := range active[name] {
+				if binding == s.Interface {
+					return name
+				}
+			}
+		}
+	}
+	return defaultZone
+}
+
+// Binds reports whether adding value as a source or interface would claim
+// the session for that zone.
+func (s *Session) Binds(value string) bool {
+	if s == nil {
+		return false
+	}
+	return sourceMatches(value, s.Client) || (s.Interface != "" && value == s.Interface)
+}
+
+func sourceMatches(source string, ip net.IP) bool {
+	if strings.Contains(source, "/") {
+		_, ipNet, err := net.ParseCIDR(source)
+		return err == nil && ipNet.Contains(ip)
+	}
+	addr := net.ParseIP(source)
+	return addr != nil && addr.Equal(ip)
+}
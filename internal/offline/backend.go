@@ -0,0 +1,269 @@
+//go:build linux
+// +build linux
+
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+var (
+	ErrRuntime     = errors.New("runtime configuration is not available offline")
+	ErrUnsupported = errors.New("not supported in offline mode")
+)
+
+const (
+	confFile            = "firewalld.conf"
+	fallbackDefaultZone = "public"
+	accessWrite         = 0x2 // W_OK for access(2)
+)
+
+// Backend reads and writes the XML configuration under a root such as
+// /mnt/image/etc/firewalld. Definitions shipped with firewalld are read from
+// the matching usr/lib/firewalld directory; edits always land in root.
+type Backend struct {
+	root     string
+	system   string
+	readOnly bool
+
+	mu sync.Mutex
+}
+
+var _ firewalld.Backend = (*Backend)(nil)
+
+// Open prepares a backend on root, which must be an existing directory.
+func Open(root string) (*Backend, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("offline root %s: %w", root, err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("offline root: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("offline root %s is not a directory", abs)
+	}
+	b := &Backend{
+		root:     abs,
+		system:   SystemDir(abs),
+		readOnly: syscall.Access(abs, accessWrite) != nil,
+	}
+	slog.Info("offline backend opened", "root", b.root, "system", b.system, "read_only", b.readOnly)
+	return b, nil
+}
+
+// SystemDir returns the usr/lib/firewalld directory next to an
+// etc/firewalld root, or "" when root has another layout or it is missing.
+func SystemDir(root string) string {
+	root = filepath.Clean(root)
+	etc := filepath.Dir(root)
+	if filepath.Base(root) != "firewalld" || filepath.Base(etc) != "etc" {
+		return ""
+	}
+	dir := filepath.Join(filepath.Dir(etc), "usr", "lib", "firewalld")
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// Root is the configuration directory edits are written to.
+func (b *Backend) Root() string {
+	return b.root
+}
+
+func (b *Backend) ReadOnly() bool {
+	return b.readOnly
+}
+
+func (b *Backend) Close() error {
+	return nil
+}
+
+// dirs returns the directories holding sub, user configuration first.
+func (b *Backend) dirs(sub string) []string {
+	dirs := []string{filepath.Join(b.root, sub)}
+	if b.system != "" {
+		dirs = append(dirs, filepath.Join(b.system, sub))
+	}
+	return dirs
+}
+
+// lookup returns the first path of sub/name.xml that exists.
+func (b *Backend) lookup(sub, name string) (string, bool) {
+	for _, dir := range b.dirs(sub) {
+		path := filepath.Join(dir, name+".xml")
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func (b *Backend) checkWritable() error {
+	if b.readOnly {
+		return firewalld.ErrPermissionDenied
+	}
+	return nil
+}
+
+func (b *Backend) GetDefaultZone() (string, error) {
+	conf, err := b.readConf()
+	if err != nil {
+		return "", err
+	}
+	if zone := conf["DefaultZone"]; zone != "" {
+		return zone, nil
+	}
+	return fallbackDefaultZone, nil
+}
+
+// SetDefaultZone rewrites the DefaultZone line of firewalld.conf, keeping
+// the rest of the file as it is.
+func (b *Backend) SetDefaultZone(zone string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if _, err := b.zonePath(zone); err != nil {
+		return err
+	}
+
+	slog.Info("setting default zone (offline)", "zone", zone)
+	return b.setConfValue("DefaultZone", zone)
+}
+
+// GetActiveZones reports the zones with interface or source bindings.
+func (b *Backend) GetActiveZones() (map[string][]string, error) {
+	names, err := b.ListZones()
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[string][]string)
+	for _, name := range names {
+		z, err := b.GetZoneSettings(name, true)
+		if err != nil {
+			return nil, err
+		}
+		refs := append(append([]string(nil), z.Interfaces...), z.Sources...)
+		if len(refs) > 0 {
+			active[name] = refs
+		}
+	}
+	return active, nil
+}
+
+// Reload and RuntimeToPermanent succeed without doing anything: offline
+// there is only the permanent configuration.
+func (b *Backend) Reload() error {
+	return nil
+}
+
+func (b *Backend) RuntimeToPermanent() error {
+	return nil
+}
+
+func (b *Backend) QueryPanicMode() (bool, error) {
+	return false, nil
+}
+
+func (b *Backend) EnablePanicMode() error {
+	return ErrRuntime
+}
+
+func (b *Backend) DisablePanicMode() error {
+	return ErrRuntime
+}
+
+func (b *Backend) SubscribeSignals() (<-chan firewalld.SignalEvent, func(), error) {
+	return nil, nil, ErrUnsupported
+}
+
+func (b *Backend) ListPolicies(permanent bool) ([]string, error) {
+	return nil, ErrUnsupported
+}
+
+func (b *Backend) GetPolicySettings(name string, permanent bool) (*firewalld.Policy, error) {
+	return nil, ErrUnsupported
+}
+
+func (b *Backend) AddPolicyPermanent(p *firewalld.Policy) error {
+	return ErrUnsupported
+}
+
+func (b *Backend) UpdatePolicy(p *firewalld.Policy, permanent bool) error {
+	return ErrUnsupported
+}
+
+func (b *Backend) RemovePolicyPermanent(name string) error {
+	return ErrUnsupported
+}
+
+// listXMLNames returns the sorted base names of the XML files in dirs.
+func listXMLNames(dirs []string) ([]string, error) {
+	seen := make(map[string]struct{})
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("read %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".xml") {
+				continue
+			}
+			if base := strings.TrimSuffix(name, ".xml"); base != "" {
+				seen[base] = struct{}{}
+			}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// writeFile replaces path atomically so a failed write never leaves a
+// truncated file behind.
+func writeFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
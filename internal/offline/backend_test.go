@@ -0,0 +1,454 @@
+//go:build linux
+// +build linux
+
+package offline
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+// newTestRoot lays out an image with shipped definitions in usr/lib and an
+// empty etc/firewalld, and opens it.
+func newTestRoot(t *testing.T) *Backend {
+	t.Helper()
+	image := t.TempDir()
+	files := map[string]string{
+		"usr/lib/firewalld/zones/public.xml": `<?xml version="1.0" encoding="utf-8"?>
+<zone><short>Public</short><service name="ssh"/><interface name="eth0"/></zone>`,
+		"usr/lib/firewalld/zones/drop.xml":             `<zone target="DROP"/>`,
+		"usr/lib/firewalld/services/ssh.xml":           `<service><short>SSH</short><port protocol="tcp" port="22"/></service>`,
+		"usr/lib/firewalld/services/http.xml":          `<service><short>HTTP</short><port protocol="tcp" port="80"/></service>`,
+		"usr/lib/firewalld/icmptypes/echo-request.xml": `<icmptype/>`,
+		"usr/lib/firewalld/ipsets/shipped.xml":         `<ipset type="hash:ip"><entry>192.0.2.1</entry></ipset>`,
+		"usr/lib/firewalld/helpers/ftp.xml":            `<helper module="nf_conntrack_ftp"><port port="21" protocol="tcp"/></helper>`,
+		"etc/firewalld/firewalld.conf":                 "# settings\nDefaultZone=drop\nLogDenied=off\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(image, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := Open(filepath.Join(image, "etc", "firewalld"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return b
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatalf("Open() on a missing directory should fail")
+	}
+	b := newTestRoot(t)
+	if b.system == "" || !strings.HasSuffix(b.system, filepath.Join("usr", "lib", "firewalld")) {
+		t.Fatalf("system dir = %q, want the image's usr/lib/firewalld", b.system)
+	}
+	if SystemDir(t.TempDir()) != "" {
+		t.Fatalf("SystemDir() should be empty for a root outside etc/firewalld")
+	}
+}
+
+func TestReadShippedConfig(t *testing.T) {
+	b := newTestRoot(t)
+
+	zones, err := b.ListZones()
+	if err != nil || !slices.Equal(zones, []string{"drop", "public"}) {
+		t.Fatalf("ListZones() = %v, %v", zones, err)
+	}
+	if def, err := b.GetDefaultZone(); err != nil || def != "drop" {
+		t.Fatalf("GetDefaultZone() = %q, %v", def, err)
+	}
+	z, err := b.GetZoneSettings("public", false)
+	if err != nil || z.Name != "public" || z.Target != "default" || !slices.Equal(z.Services, []string{"ssh"}) {
+		t.Fatalf("GetZoneSettings(public) = %+v, %v", z, err)
+	}
+	if _, err := b.GetZoneSettings("nope", true); !errors.Is(err, firewalld.ErrInvalidZone) {
+		t.Fatalf("GetZoneSettings(nope) error = %v, want ErrInvalidZone", err)
+	}
+	active, err := b.GetActiveZones()
+	if err != nil || len(active) != 1 || !slices.Equal(active["public"], []string{"eth0"}) {
+		t.Fatalf("GetActiveZones() = %v, %v", active, err)
+	}
+	services, err := b.ListServiceNames()
+	if err != nil || !slices.Equal(services, []string{"http", "ssh"}) {
+		t.Fatalf("ListServiceNames() = %v, %v", services, err)
+	}
+	if info, err := b.GetServiceDetails("http"); err != nil || len(info.Ports) != 1 || info.Ports[0].Port != "80" {
+		t.Fatalf("GetServiceDetails(http) = %+v, %v", info, err)
+	}
+}
+
+func TestZoneEditsWriteToRoot(t *testing.T) {
+	b := newTestRoot(t)
+
+	if err := b.AddServicePermanent("public", "http"); err != nil {
+		t.Fatalf("AddServicePermanent() error = %v", err)
+	}
+	if err := b.AddServicePermanent("public", "http"); err == nil || !strings.Contains(err.Error(), "ALREADY_ENABLED") {
+		t.Fatalf("duplicate AddServicePermanent() error = %v", err)
+	}
+	if err := b.AddServicePermanent("public", "gopher"); err == nil || !strings.Contains(err.Error(), "INVALID_SERVICE") {
+		t.Fatalf("unknown service error = %v", err)
+	}
+	if err := b.AddPortPermanent("public", firewalld.Port{Port: "8080", Protocol: "tcp"}); err != nil {
+		t.Fatalf("AddPortPermanent() error = %v", err)
+	}
+	if err := b.AddRichRulePermanent("public", `rule family=ipv4 source address=10.0.0.0/8 accept`); err != nil {
+		t.Fatalf("AddRichRulePermanent() error = %v", err)
+	}
+	if err := b.EnableMasqueradePermanent("public"); err != nil {
+		t.Fatalf("EnableMasqueradePermanent() error = %v", err)
+	}
+	if err := b.SetTargetPermanent("public", "reject"); err != nil {
+		t.Fatalf("SetTargetPermanent() error = %v", err)
+	}
+	if err := b.AddIcmpBlockPermanent("public", "bogus"); err == nil {
+		t.Fatalf("AddIcmpBlockPermanent() with unknown type should fail")
+	}
+	if err := b.AddInterfacePermanent("drop", "eth0"); err == nil || !strings.Contains(err.Error(), "ZONE_CONFLICT") {
+		t.Fatalf("binding eth0 twice error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(b.root, "zones", "public.xml")); err != nil {
+		t.Fatalf("edited zone not written to root: %v", err)
+	}
+	shipped, err := os.ReadFile(filepath.Join(b.system, "zones", "public.xml"))
+	if err != nil || strings.Contains(string(shipped), "http") {
+		t.Fatalf("shipped zone must stay untouched")
+	}
+
+	z, err := b.GetZoneSettings("public", true)
+	if err != nil {
+		t.Fatalf("GetZoneSettings() error = %v", err)
+	}
+	if !slices.Equal(z.Services, []string{"ssh", "http"}) || len(z.Ports) != 1 || len(z.RichRules) != 1 ||
+		!z.Masquerade || z.Target != "%%REJECT%%" || z.Short != "Public" {
+		t.Fatalf("zone after edits = %+v", z)
+	}
+
+	if err := b.RemoveRichRulePermanent("public", `rule family="ipv4" source address="10.0.0.0/8" accept`); err != nil {
+		t.Fatalf("RemoveRichRulePermanent() with other quoting error = %v", err)
+	}
+	if err := b.RemoveServicePermanent("public", "ftp"); err == nil || !strings.Contains(err.Error(), "NOT_ENABLED") {
+		t.Fatalf("RemoveServicePermanent(ftp) error = %v", err)
+	}
+	if err := b.AddServiceRuntime("public", "http"); !errors.Is(err, ErrRuntime) {
+		t.Fatalf("AddServiceRuntime() error = %v, want ErrRuntime", err)
+	}
+}
+
+func TestZoneLifecycleAndDefault(t *testing.T) {
+	b := newTestRoot(t)
+
+	if err := b.AddZonePermanent("lab"); err != nil {
+		t.Fatalf("AddZonePermanent() error = %v", err)
+	}
+	if err := b.AddZonePermanent("public"); err == nil {
+		t.Fatalf("AddZonePermanent() over a shipped zone should fail")
+	}
+	if err := b.SetDefaultZone("lab"); err != nil {
+		t.Fatalf("SetDefaultZone() error = %v", err)
+	}
+	conf, _ := os.ReadFile(filepath.Join(b.root, confFile))
+	if string(conf) != "# settings\nDefaultZone=lab\nLogDenied=off\n" {
+		t.Fatalf("firewalld.conf = %q", conf)
+	}
+	if err := b.SetDefaultZone("nope"); !errors.Is(err, firewalld.ErrInvalidZone) {
+		t.Fatalf("SetDefaultZone(nope) error = %v", err)
+	}
+
+	if err := b.RemoveZonePermanent("drop"); err == nil || !strings.Contains(err.Error(), "BUILTIN_ZONE") {
+		t.Fatalf("RemoveZonePermanent(drop) error = %v", err)
+	}
+	if err := b.AddServicePermanent("drop", "ssh"); err != nil {
+		t.Fatalf("AddServicePermanent(drop) error = %v", err)
+	}
+	if err := b.RemoveZonePermanent("drop"); err != nil {
+		t.Fatalf("removing the override of drop error = %v", err)
+	}
+	if z, err := b.GetZoneSettings("drop", true); err != nil || len(z.Services) != 0 || z.Target != "DROP" {
+		t.Fatalf("drop after removing override = %+v, %v", z, err)
+	}
+	if err := b.RemoveZonePermanent("lab"); err != nil {
+		t.Fatalf("RemoveZonePermanent(lab) error = %v", err)
+	}
+	if err := b.AddZonePermanent("../evil"); err == nil {
+		t.Fatalf("AddZonePermanent() must reject path traversal")
+	}
+}
+
+func TestZoneEditRefusesUnmodelledContent(t *testing.T) {
+	b := newTestRoot(t)
+	path := filepath.Join(b.root, "zones", "lab.xml")
+	data := `<?xml version="1.0" encoding="utf-8"?>
+<zone><short>Lab</short><port port="80" protocol="tcp" comment="web"/></zone>`
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.AddServicePermanent("lab", "ssh"); err == nil || !strings.Contains(err.Error(), "refusing to rewrite zone lab") {
+		t.Fatalf("AddServicePermanent() error = %v, want refusal", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != data {
+		t.Fatalf("refused edit changed the zone file:\n%s", got)
+	}
+}
+
+func TestIPSets(t *testing.T) {
+	b := newTestRoot(t)
+
+	if err := b.AddIPSetPermanent("blocklist", "hash:net"); err != nil {
+		t.Fatalf("AddIPSetPermanent() error = %v", err)
+	}
+	if err := b.AddIPSetEntryPermanent("blocklist", "198.51.100.0/24"); err != nil {
+		t.Fatalf("AddIPSetEntryPermanent() error = %v", err)
+	}
+	if err := b.AddIPSetEntryPermanent("shipped", "192.0.2.2"); err != nil {
+		t.Fatalf("AddIPSetEntryPermanent(shipped) error = %v", err)
+	}
+	sets, err := b.ListIPSets(true)
+	if err != nil || !slices.Equal(sets, []string{"blocklist", "shipped"}) {
+		t.Fatalf("ListIPSets() = %v, %v", sets, err)
+	}
+	if entries, err := b.GetIPSetEntries("shipped", false); err != nil || !slices.Equal(entries, []string{"192.0.2.1", "192.0.2.2"}) {
+		t.Fatalf("GetIPSetEntries(shipped) = %v, %v", entries, err)
+	}
+	data, err := os.ReadFile(filepath.Join(b.root, "ipsets", "blocklist.xml"))
+	if err != nil || !strings.Contains(string(data), `<ipset type="hash:net">`) || !strings.Contains(string(data), "<entry>198.51.100.0/24</entry>") {
+		t.Fatalf("blocklist.xml = %s, %v", data, err)
+	}
+	if err := b.RemoveIPSetEntryPermanent("blocklist", "203.0.113.1"); err == nil {
+		t.Fatalf("removing a missing entry should fail")
+	}
+	if err := b.RemoveIPSetPermanent("blocklist"); err != nil {
+		t.Fatalf("RemoveIPSetPermanent() error = %v", err)
+	}
+	if _, err := b.GetIPSetEntries("blocklist", true); !errors.Is(err, firewalld.ErrInvalidIPSet) {
+		t.Fatalf("GetIPSetEntries() after remove error = %v", err)
+	}
+}
+
+func TestReadOnlyRoot(t *testing.T) {
+	b := newTestRoot(t)
+	b.readOnly = true
+	if err := b.AddServicePermanent("public", "http"); !errors.Is(err, firewalld.ErrPermissionDenied) {
+		t.Fatalf("AddServicePermanent() on read-only root error = %v", err)
+	}
+	if err := b.AddIPSetPermanent("x", "hash:ip"); !errors.Is(err, firewalld.ErrPermissionDenied) {
+		t.Fatalf("AddIPSetPermanent() on read-only root error = %v", err)
+	}
+}
+
+func TestCustomServices(t *testing.T) {
+	b := newTestRoot(t)
+
+	svc := &firewalld.ServiceInfo{Name: "myapp", Short: "My App", Ports: []firewalld.Port{{Port: "8080", Protocol: "tcp"}}}
+	if err := b.CreateService(svc); err != nil {
+		t.Fatalf("CreateService() error = %v", err)
+	}
+	if err := b.CreateService(&firewalld.ServiceInfo{Name: "ssh"}); err == nil || !strings.Contains(err.Error(), "NAME_CONFLICT") {
+		t.Fatalf("CreateService(ssh) error = %v", err)
+	}
+	if err := b.AddServicePermanent("public", "myapp"); err != nil {
+		t.Fatalf("custom service not usable in a zone: %v", err)
+	}
+	svc.Includes = []string{"http"}
+	if err := b.UpdateService(svc); err != nil {
+		t.Fatalf("UpdateService() error = %v", err)
+	}
+	if info, err := b.GetServiceDetails("myapp"); err != nil || info.Builtin || !slices.Equal(info.Includes, []string{"http"}) {
+		t.Fatalf("GetServiceDetails(myapp) = %+v, %v", info, err)
+	}
+
+	if err := b.UpdateService(&firewalld.ServiceInfo{Name: "ssh", Short: "SSH", Ports: []firewalld.Port{{Port: "2222", Protocol: "tcp"}}}); err != nil {
+		t.Fatalf("UpdateService(ssh) error = %v", err)
+	}
+	if info, err := b.GetServiceDetails("ssh"); err != nil || !info.Builtin || info.Ports[0].Port != "2222" {
+		t.Fatalf("ssh override = %+v, %v", info, err)
+	}
+	if err := b.DeleteService("ssh"); !errors.Is(err, firewalld.ErrBuiltinService) {
+		t.Fatalf("DeleteService(ssh) error = %v, want ErrBuiltinService", err)
+	}
+	if err := b.UpdateService(&firewalld.ServiceInfo{Name: "gopher"}); !errors.Is(err, firewalld.ErrInvalidService) {
+		t.Fatalf("UpdateService(gopher) error = %v, want ErrInvalidService", err)
+	}
+
+	if err := b.DeleteService("myapp"); err != nil {
+		t.Fatalf("DeleteService() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(b.root, "services", "myapp.xml")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("myapp.xml still present: %v", err)
+	}
+}
+
+func TestCustomIcmpTypes(t *testing.T) {
+	b := newTestRoot(t)
+
+	if err := b.CreateIcmpType(&firewalld.IcmpType{Name: "mld-query", Short: "MLD", Destinations: []string{"ipv6"}}); err != nil {
+		t.Fatalf("CreateIcmpType() error = %v", err)
+	}
+	if err := b.CreateIcmpType(&firewalld.IcmpType{Name: "echo-request"}); err == nil || !strings.Contains(err.Error(), "NAME_CONFLICT") {
+		t.Fatalf("CreateIcmpType(echo-request) error = %v", err)
+	}
+	if err := b.AddIcmpBlockPermanent("public", "mld-query"); err != nil {
+		t.Fatalf("custom icmp type not usable in a zone: %v", err)
+	}
+	got, err := b.GetIcmpType("mld-query")
+	if err != nil || got.Short != "MLD" || got.Builtin || !slices.Equal(got.Destinations, []string{"ipv6"}) {
+		t.Fatalf("GetIcmpType(mld-query) = %+v, %v", got, err)
+	}
+	if shipped, err := b.GetIcmpType("echo-request"); err != nil || !shipped.Builtin {
+		t.Fatalf("GetIcmpType(echo-request) = %+v, %v", shipped, err)
+	}
+	if err := b.DeleteIcmpType("echo-request"); !errors.Is(err, firewalld.ErrBuiltinIcmpType) {
+		t.Fatalf("DeleteIcmpType(echo-request) error = %v, want ErrBuiltinIcmpType", err)
+	}
+	if err := b.DeleteIcmpType("mld-query"); err != nil {
+		t.Fatalf("DeleteIcmpType() error = %v", err)
+	}
+	if _, err := b.GetIcmpType("mld-query"); !errors.Is(err, firewalld.ErrInvalidIcmpType) {
+		t.Fatalf("GetIcmpType() after delete error = %v", err)
+	}
+}
+
+func TestCustomHelpers(t *testing.T) {
+	b := newTestRoot(t)
+
+	h := &firewalld.Helper{Name: "ftp-alt", Family: "ipv4", Module: "nf_conntrack_ftp", Ports: []firewalld.Port{{Port: "2121", Protocol: "tcp"}}}
+	if err := b.CreateHelper(h); err != nil {
+		t.Fatalf("CreateHelper() error = %v", err)
+	}
+	if err := b.CreateHelper(&firewalld.Helper{Name: "ftp", Module: "nf_conntrack_ftp"}); err == nil || !strings.Contains(err.Error(), "NAME_CONFLICT") {
+		t.Fatalf("CreateHelper(ftp) error = %v", err)
+	}
+	if err := b.CreateHelper(&firewalld.Helper{Name: "bad", Module: "ftp"}); err == nil {
+		t.Fatalf("CreateHelper() should reject modules without nf_conntrack_")
+	}
+	names, err := b.ListHelpers()
+	if err != nil || !slices.Equal(names, []string{"ftp", "ftp-alt"}) {
+		t.Fatalf("ListHelpers() = %v, %v", names, err)
+	}
+	got, err := b.GetHelper("ftp-alt")
+	if err != nil || got.Builtin || got.Family != "ipv4" || !slices.Equal(got.Ports, h.Ports) {
+		t.Fatalf("GetHelper(ftp-alt) = %+v, %v", got, err)
+	}
+	if err := b.DeleteHelper("ftp"); !errors.Is(err, firewalld.ErrBuiltinHelper) {
+		t.Fatalf("DeleteHelper(ftp) error = %v, want ErrBuiltinHelper", err)
+	}
+	if err := b.DeleteHelper("ftp-alt"); err != nil {
+		t.Fatalf("DeleteHelper() error = %v", err)
+	}
+	if _, err := b.GetHelper("ftp-alt"); !errors.Is(err, firewalld.ErrInvalidHelper) {
+		t.Fatalf("GetHelper() after delete error = %v", err)
+	}
+}
+
+func TestDirectConfig(t *testing.T) {
+	b := newTestRoot(t)
+
+	cfg, err := b.GetDirectSettings(true)
+	if err != nil || len(cfg.Rules) != 0 {
+		t.Fatalf("GetDirectSettings() without direct.xml = %+v, %v", cfg, err)
+	}
+	rule := firewalld.DirectRule{IPV: "ipv4", Table: "filter", Chain: "INPUT", Args: []string{"-m", "comment", "--comment", "allow ssh", "-j", "ACCEPT"}}
+	if err := b.AddDirectRulePermanent(rule); err != nil {
+		t.Fatalf("AddDirectRulePermanent() error = %v", err)
+	}
+	if err := b.AddDirectRulePermanent(rule); err == nil {
+		t.Fatalf("adding a rule twice should fail")
+	}
+	if err := b.AddDirectChainPermanent(firewalld.DirectChain{IPV: "ipv4", Table: "broute", Chain: "x"}); err == nil {
+		t.Fatalf("AddDirectChainPermanent() should reject an invalid table")
+	}
+	if err := b.AddDirectRuleRuntime(rule); !errors.Is(err, ErrRuntime) {
+		t.Fatalf("AddDirectRuleRuntime() error = %v, want ErrRuntime", err)
+	}
+	data, err := os.ReadFile(filepath.Join(b.root, "direct.xml"))
+	if err != nil || !strings.Contains(string(data), "allow ssh") {
+		t.Fatalf("direct.xml = %s, %v", data, err)
+	}
+	cfg, err = b.GetDirectSettings(true)
+	if err != nil || len(cfg.Rules) != 1 || !cfg.Rules[0].Equal(rule) {
+		t.Fatalf("GetDirectSettings() = %+v, %v", cfg, err)
+	}
+	if err := b.RemoveDirectRulePermanent(rule); err != nil {
+		t.Fatalf("RemoveDirectRulePermanent() error = %v", err)
+	}
+	if cfg, _ := b.GetDirectSettings(true); len(cfg.Rules) != 0 {
+		t.Fatalf("rules after remove = %+v", cfg.Rules)
+	}
+}
+
+func TestLockdownWhitelist(t *testing.T) {
+	b := newTestRoot(t)
+
+	if err := b.EnableLockdown(); !errors.Is(err, ErrRuntime) {
+		t.Fatalf("EnableLockdown() error = %v, want ErrRuntime", err)
+	}
+	user := firewalld.LockdownEntry{Kind: "user", Value: "admin"}
+	uid := firewalld.LockdownEntry{Kind: "uid", Value: "0"}
+	for _, e := range []firewalld.LockdownEntry{user, uid} {
+		if err := b.AddLockdownWhitelistPermanent(e); err != nil {
+			t.Fatalf("AddLockdownWhitelistPermanent(%v) error = %v", e, err)
+		}
+	}
+	if err := b.AddLockdownWhitelistPermanent(uid); err == nil {
+		t.Fatalf("adding a uid twice should fail")
+	}
+	if err := b.AddLockdownWhitelistRuntime(user); !errors.Is(err, ErrRuntime) {
+		t.Fatalf("AddLockdownWhitelistRuntime() error = %v, want ErrRuntime", err)
+	}
+	data, err := os.ReadFile(filepath.Join(b.root, "lockdown-whitelist.xml"))
+	if err != nil || !strings.Contains(string(data), `<user id="0">`) {
+		t.Fatalf("lockdown-whitelist.xml = %s, %v", data, err)
+	}
+	if err := b.RemoveLockdownWhitelistPermanent(user); err != nil {
+		t.Fatalf("RemoveLockdownWhitelistPermanent() error = %v", err)
+	}
+	wl, err := b.GetLockdownWhitelist(true)
+	if err != nil || len(wl.Users) != 0 || len(wl.UIDs) != 1 {
+		t.Fatalf("GetLockdownWhitelist() = %+v, %v", wl, err)
+	}
+}
+
+func TestDaemonSettings(t *testing.T) {
+	b := newTestRoot(t)
+
+	if v, err := b.GetLogDenied(); err != nil || v != "off" {
+		t.Fatalf("GetLogDenied() = %q, %v", v, err)
+	}
+	if err := b.SetLogDenied("all"); err != nil {
+		t.Fatalf("SetLogDenied() error = %v", err)
+	}
+	if err := b.SetLogDenied("all"); err == nil || !strings.Contains(err.Error(), "ALREADY_SET") {
+		t.Fatalf("SetLogDenied() twice error = %v", err)
+	}
+	if err := b.SetDaemonSetting("CleanupOnExit", "maybe"); err == nil {
+		t.Fatalf("SetDaemonSetting() should validate the value")
+	}
+	if err := b.SetDaemonSetting("CleanupOnExit", "no"); err != nil {
+		t.Fatalf("SetDaemonSetting() error = %v", err)
+	}
+	conf, _ := os.ReadFile(filepath.Join(b.root, confFile))
+	if string(conf) != "# settings\nDefaultZone=drop\nLogDenied=all\nCleanupOnExit=no\n" {
+		t.Fatalf("firewalld.conf = %q", conf)
+	}
+	settings, err := b.GetDaemonSettings()
+	if err != nil || len(settings) != 2 || settings["LogDenied"] != "all" || settings["CleanupOnExit"] != "no" {
+		t.Fatalf("GetDaemonSettings() = %v, %v", settings, err)
+	}
+}
@@ -0,0 +1,154 @@
+//go:build linux
+// +build linux
+
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+const directFile = "direct.xml"
+
+// GetDirectSettings reads direct.xml; like the other reads it returns the
+// permanent configuration for both modes.
+func (b *Backend) GetDirectSettings(permanent bool) (*firewalld.DirectConfig, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.loadDirect()
+}
+
+func (b *Backend) loadDirect() (*firewalld.DirectConfig, error) {
+	data, err := os.ReadFile(filepath.Join(b.root, directFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &firewalld.DirectConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := firewalld.ParseDirectXML(data)
+	if err != nil {
+		return nil, err
+	}
+	firewalld.SortDirect(cfg)
+	return cfg, nil
+}
+
+// editDirect applies fn to direct.xml and writes it back.
+func (b *Backend) editDirect(fn func(cfg *firewalld.DirectConfig) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	cfg, err := b.loadDirect()
+	if err != nil {
+		return err
+	}
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	data, err := firewalld.MarshalDirectXML(cfg)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(b.root, directFile)
+	slog.Info("writing direct configuration (offline)", "file", path)
+	return writeFile(path, data)
+}
+
+func (b *Backend) AddDirectChainPermanent(ch firewalld.DirectChain) error {
+	if err := firewalld.CheckDirectChain(ch); err != nil {
+		return err
+	}
+	return b.editDirect(func(cfg *firewalld.DirectConfig) error {
+		if slices.Contains(cfg.Chains, ch) {
+			return fmt.Errorf("ALREADY_ENABLED: chain %s", ch)
+		}
+		cfg.Chains = append(cfg.Chains, ch)
+		return nil
+	})
+}
+
+func (b *Backend) RemoveDirectChainPermanent(ch firewalld.DirectChain) error {
+	return b.editDirect(func(cfg *firewalld.DirectConfig) error {
+		idx := slices.Index(cfg.Chains, ch)
+		if idx < 0 {
+			return fmt.Errorf("NOT_ENABLED: chain %s", ch)
+		}
+		cfg.Chains = slices.Delete(cfg.Chains, idx, idx+1)
+		return nil
+	})
+}
+
+func (b *Backend) AddDirectRulePermanent(r firewalld.DirectRule) error {
+	if err := firewalld.CheckDirectRule(r); err != nil {
+		return err
+	}
+	return b.editDirect(func(cfg *firewalld.DirectConfig) error {
+		if slices.ContainsFunc(cfg.Rules, r.Equal) {
+			return fmt.Errorf("ALREADY_ENABLED: rule %s", r)
+		}
+		cfg.Rules = append(cfg.Rules, r)
+		return nil
+	})
+}
+
+func (b *Backend) RemoveDirectRulePermanent(r firewalld.DirectRule) error {
+	return b.editDirect(func(cfg *firewalld.DirectConfig) error {
+		idx := slices.IndexFunc(cfg.Rules, r.Equal)
+		if idx < 0 {
+			return fmt.Errorf("NOT_ENABLED: rule %s", r)
+		}
+		cfg.Rules = slices.Delete(cfg.Rules, idx, idx+1)
+		return nil
+	})
+}
+
+func (b *Backend) AddDirectPassthroughPermanent(p firewalld.DirectPassthrough) error {
+	if err := firewalld.CheckDirectPassthrough(p); err != nil {
+		return err
+	}
+	return b.editDirect(func(cfg *firewalld.DirectConfig) error {
+		if slices.ContainsFunc(cfg.Passthroughs, p.Equal) {
+			return fmt.Errorf("ALREADY_ENABLED: passthrough %s", p)
+		}
+		cfg.Passthroughs = append(cfg.Passthroughs, p)
+		return nil
+	})
+}
+
+func (b *Backend) RemoveDirectPassthroughPermanent(p firewalld.DirectPassthrough) error {
+	return b.editDirect(func(cfg *firewalld.DirectConfig) error {
+		idx := slices.IndexFunc(cfg.Passthroughs, p.Equal)
+		if idx < 0 {
+			return fmt.Errorf("NOT_ENABLED: passthrough %s", p)
+		}
+		cfg.Passthroughs = slices.Delete(cfg.Passthroughs, idx, idx+1)
+		return nil
+	})
+}
+
+// Runtime variants fail: there is no running firewall to change.
+
+func (b *Backend) AddDirectChainRuntime(ch firewalld.DirectChain) error { return ErrRuntime }
+
+func (b *Backend) RemoveDirectChainRuntime(ch firewalld.DirectChain) error { return ErrRuntime }
+
+func (b *Backend) AddDirectRuleRuntime(r firewalld.DirectRule) error { return ErrRuntime }
+
+func (b *Backend) RemoveDirectRuleRuntime(r firewalld.DirectRule) error { return ErrRuntime }
+
+func (b *Backend) AddDirectPassthroughRuntime(p firewalld.DirectPassthrough) error {
+	return ErrRuntime
+}
+
+func (b *Backend) RemoveDirectPassthroughRuntime(p firewalld.DirectPassthrough) error {
+	return ErrRuntime
+}
@@ -0,0 +1,7 @@
+//go:build linux
+// +build linux
+
+// Package offline implements firewalld.Backend on a firewalld configuration
+// directory, for preparing images and containers where the daemon is not
+// running. Only permanent configuration exists offline.
+package offline
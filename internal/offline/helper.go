@@ -0,0 +1,71 @@
+//go:build linux
+// +build linux
+
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/validation"
+)
+
+func (b *Backend) ListHelpers() ([]string, error) {
+	return listXMLNames(b.dirs("helpers"))
+}
+
+func (b *Backend) GetHelper(name string) (*firewalld.Helper, error) {
+	if validation.IsValidZoneName(name) != nil {
+		return nil, fmt.Errorf("invalid helper name %q", name)
+	}
+	if _, ok := b.lookup("helpers", name); !ok {
+		return nil, fmt.Errorf("%w: %s", firewalld.ErrInvalidHelper, name)
+	}
+	return firewalld.ReadHelper(b.dirs("helpers"), name)
+}
+
+func (b *Backend) CreateHelper(h *firewalld.Helper) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if h == nil || validation.IsValidZoneName(h.Name) != nil {
+		return fmt.Errorf("invalid helper name")
+	}
+	if _, ok := b.lookup("helpers", h.Name); ok {
+		return fmt.Errorf("NAME_CONFLICT: helper %s already exists", h.Name)
+	}
+	data, err := firewalld.MarshalHelperXML(h)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(b.root, "helpers", h.Name+".xml")
+	slog.Info("writing helper (offline)", "helper", h.Name, "file", path)
+	return writeFile(path, data)
+}
+
+func (b *Backend) DeleteHelper(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	h, err := b.GetHelper(name)
+	if err != nil {
+		return err
+	}
+	if h.Builtin {
+		return firewalld.ErrBuiltinHelper
+	}
+	path := filepath.Join(b.root, "helpers", name+".xml")
+	slog.Info("removing helper (offline)", "helper", name, "file", path)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
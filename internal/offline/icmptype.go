@@ -0,0 +1,71 @@
+//go:build linux
+// +build linux
+
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/validation"
+)
+
+func (b *Backend) ListIcmpTypes() ([]string, error) {
+	return listXMLNames(b.dirs("icmptypes"))
+}
+
+func (b *Backend) GetIcmpType(name string) (*firewalld.IcmpType, error) {
+	if validation.IsValidZoneName(name) != nil {
+		return nil, fmt.Errorf("invalid icmp type name %q", name)
+	}
+	if _, ok := b.lookup("icmptypes", name); !ok {
+		return nil, fmt.Errorf("%w: %s", firewalld.ErrInvalidIcmpType, name)
+	}
+	return firewalld.ReadIcmpType(b.dirs("icmptypes"), name)
+}
+
+func (b *Backend) CreateIcmpType(t *firewalld.IcmpType) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if t == nil || validation.IsValidZoneName(t.Name) != nil {
+		return fmt.Errorf("invalid icmp type name")
+	}
+	if _, ok := b.lookup("icmptypes", t.Name); ok {
+		return fmt.Errorf("NAME_CONFLICT: icmp type %s already exists", t.Name)
+	}
+	data, err := firewalld.MarshalIcmpTypeXML(t)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(b.root, "icmptypes", t.Name+".xml")
+	slog.Info("writing icmp type (offline)", "icmptype", t.Name, "file", path)
+	return writeFile(path, data)
+}
+
+func (b *Backend) DeleteIcmpType(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	t, err := b.GetIcmpType(name)
+	if err != nil {
+		return err
+	}
+	if t.Builtin {
+		return firewalld.ErrBuiltinIcmpType
+	}
+	path := filepath.Join(b.root, "icmptypes", name+".xml")
+	slog.Info("removing icmp type (offline)", "icmptype", name, "file", path)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
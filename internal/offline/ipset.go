@@ -0,0 +1,137 @@
+//go:build linux
+// +build linux
+
+package offline
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/validation"
+)
+
+type ipsetXML struct {
+	XMLName     xml.Name         `xml:"ipset"`
+	Type        string           `xml:"type,attr"`
+	Short       string           `xml:"short,omitempty"`
+	Description string           `xml:"description,omitempty"`
+	Options     []ipsetOptionXML `xml:"option"`
+	Entries     []string         `xml:"entry"`
+}
+
+type ipsetOptionXML struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr,omitempty"`
+}
+
+func (b *Backend) ListIPSets(permanent bool) ([]string, error) {
+	return listXMLNames(b.dirs("ipsets"))
+}
+
+func (b *Backend) loadIPSet(name string) (*ipsetXML, error) {
+	if validation.IsValidZoneName(name) != nil {
+		return nil, fmt.Errorf("invalid ipset name %q", name)
+	}
+	path, ok := b.lookup("ipsets", name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", firewalld.ErrInvalidIPSet, name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set ipsetXML
+	if err := xml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse ipset %s: %w", name, err)
+	}
+	return &set, nil
+}
+
+func (b *Backend) writeIPSet(name string, set *ipsetXML) error {
+	data, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(b.root, "ipsets", name+".xml")
+	slog.Info("writing ipset (offline)", "ipset", name, "file", path)
+	return writeFile(path, append([]byte(xml.Header), append(data, '\n')...))
+}
+
+func (b *Backend) GetIPSetEntries(name string, permanent bool) ([]string, error) {
+	set, err := b.loadIPSet(name)
+	if err != nil {
+		return nil, err
+	}
+	return set.Entries, nil
+}
+
+func (b *Backend) AddIPSetPermanent(name, ipsetType string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if validation.IsValidZoneName(name) != nil {
+		return fmt.Errorf("invalid ipset name %q", name)
+	}
+	if ipsetType == "" {
+		return fmt.Errorf("ipset type is empty")
+	}
+	if _, ok := b.lookup("ipsets", name); ok {
+		return fmt.Errorf("NAME_CONFLICT: ipset %s already exists", name)
+	}
+	return b.writeIPSet(name, &ipsetXML{Type: ipsetType, Short: name})
+}
+
+func (b *Backend) RemoveIPSetPermanent(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if _, err := b.loadIPSet(name); err != nil {
+		return err
+	}
+	path := filepath.Join(b.root, "ipsets", name+".xml")
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("BUILTIN_IPSET: ipset %s is shipped with firewalld", name)
+	}
+	slog.Info("removing ipset (offline)", "ipset", name, "file", path)
+	return os.Remove(path)
+}
+
+func (b *Backend) updateIPSet(name string, fn func(set *ipsetXML) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	set, err := b.loadIPSet(name)
+	if err != nil {
+		return err
+	}
+	if err := fn(set); err != nil {
+		return err
+	}
+	return b.writeIPSet(name, set)
+}
+
+func (b *Backend) AddIPSetEntryPermanent(name, entry string) error {
+	return b.updateIPSet(name, func(set *ipsetXML) error {
+		return addItem(&set.Entries, entry, "entry "+entry)
+	})
+}
+
+func (b *Backend) RemoveIPSetEntryPermanent(name, entry string) error {
+	return b.updateIPSet(name, func(set *ipsetXML) error {
+		return removeItem(&set.Entries, entry, "entry "+entry)
+	})
+}
+
+func (b *Backend) AddIPSetEntryRuntime(name, entry string) error { return ErrRuntime }
+
+func (b *Backend) RemoveIPSetEntryRuntime(name, entry string) error { return ErrRuntime }
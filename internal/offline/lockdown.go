@@ -0,0 +1,120 @@
+//go:build linux
+// +build linux
+
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+const lockdownFile = "lockdown-whitelist.xml"
+
+// QueryLockdown reports lockdown as off; like panic mode it only exists in a
+// running firewalld.
+func (b *Backend) QueryLockdown() (bool, error) {
+	return false, nil
+}
+
+func (b *Backend) EnableLockdown() error {
+	return ErrRuntime
+}
+
+func (b *Backend) DisableLockdown() error {
+	return ErrRuntime
+}
+
+// GetLockdownWhitelist reads lockdown-whitelist.xml; like the other reads it
+// returns the permanent whitelist for both modes.
+func (b *Backend) GetLockdownWhitelist(permanent bool) (*firewalld.LockdownWhitelist, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.loadWhitelist()
+}
+
+func (b *Backend) loadWhitelist() (*firewalld.LockdownWhitelist, error) {
+	data, err := os.ReadFile(filepath.Join(b.root, lockdownFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &firewalld.LockdownWhitelist{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return firewalld.ParseLockdownWhitelistXML(data)
+}
+
+func (b *Backend) AddLockdownWhitelistPermanent(e firewalld.LockdownEntry) error {
+	return b.editWhitelist(e, false)
+}
+
+func (b *Backend) RemoveLockdownWhitelistPermanent(e firewalld.LockdownEntry) error {
+	return b.editWhitelist(e, true)
+}
+
+// Runtime variants fail: there is no runtime whitelist offline.
+
+func (b *Backend) AddLockdownWhitelistRuntime(e firewalld.LockdownEntry) error {
+	return ErrRuntime
+}
+
+func (b *Backend) RemoveLockdownWhitelistRuntime(e firewalld.LockdownEntry) error {
+	return ErrRuntime
+}
+
+// editWhitelist adds or removes e in lockdown-whitelist.xml.
+func (b *Backend) editWhitelist(e firewalld.LockdownEntry, remove bool) error {
+	if err := firewalld.CheckLockdownEntry(e); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	wl, err := b.loadWhitelist()
+	if err != nil {
+		return err
+	}
+	switch e.Kind {
+	case "command":
+		wl.Commands, err = editEntries(wl.Commands, e.Value, e, remove)
+	case "context":
+		wl.Contexts, err = editEntries(wl.Contexts, e.Value, e, remove)
+	case "user":
+		wl.Users, err = editEntries(wl.Users, e.Value, e, remove)
+	case "uid":
+		uid, _ := strconv.ParseInt(e.Value, 10, 32)
+		wl.UIDs, err = editEntries(wl.UIDs, int32(uid), e, remove)
+	}
+	if err != nil {
+		return err
+	}
+	data, err := firewalld.MarshalLockdownWhitelistXML(wl)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(b.root, lockdownFile)
+	slog.Info("writing lockdown whitelist (offline)", "file", path)
+	return writeFile(path, data)
+}
+
+// editEntries adds or removes v, the value of e, from items.
+func editEntries[T comparable](items []T, v T, e firewalld.LockdownEntry, remove bool) ([]T, error) {
+	idx := slices.Index(items, v)
+	switch {
+	case remove && idx < 0:
+		return nil, fmt.Errorf("NOT_ENABLED: whitelist %s", e)
+	case remove:
+		return slices.Delete(items, idx, idx+1), nil
+	case idx >= 0:
+		return nil, fmt.Errorf("ALREADY_ENABLED: whitelist %s", e)
+	}
+	return append(items, v), nil
+}
@@ -0,0 +1,89 @@
+//go:build linux
+// +build linux
+
+package offline
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/validation"
+)
+
+func (b *Backend) ListServiceNames() ([]string, error) {
+	return firewalld.ListServices(b.dirs("services"))
+}
+
+func (b *Backend) GetServiceDetails(name string) (*firewalld.ServiceInfo, error) {
+	return firewalld.ReadService(b.dirs("services"), name)
+}
+
+func (b *Backend) writeService(info *firewalld.ServiceInfo) error {
+	data, err := firewalld.MarshalServiceXML(info)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(b.root, "services", info.Name+".xml")
+	slog.Info("writing service (offline)", "service", info.Name, "file", path)
+	return writeFile(path, data)
+}
+
+func (b *Backend) CreateService(info *firewalld.ServiceInfo) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if info == nil || validation.IsValidZoneName(info.Name) != nil {
+		return fmt.Errorf("invalid service name")
+	}
+	if _, ok := b.lookup("services", info.Name); ok {
+		return fmt.Errorf("NAME_CONFLICT: service %s already exists", info.Name)
+	}
+	return b.writeService(info)
+}
+
+// UpdateService rewrites the service in root; for a shipped service this
+// creates the override, like firewalld does.
+func (b *Backend) UpdateService(info *firewalld.ServiceInfo) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if info == nil || validation.IsValidZoneName(info.Name) != nil {
+		return fmt.Errorf("invalid service name")
+	}
+	if _, ok := b.lookup("services", info.Name); !ok {
+		return fmt.Errorf("%w: %s", firewalld.ErrInvalidService, info.Name)
+	}
+	return b.writeService(info)
+}
+
+func (b *Backend) DeleteService(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if validation.IsValidZoneName(name) != nil {
+		return fmt.Errorf("invalid service name %q", name)
+	}
+	info, err := firewalld.ReadService(b.dirs("services"), name)
+	if err != nil {
+		return fmt.Errorf("%w: %s", firewalld.ErrInvalidService, name)
+	}
+	if info.Builtin {
+		return firewalld.ErrBuiltinService
+	}
+	path := filepath.Join(b.root, "services", name+".xml")
+	slog.Info("removing service (offline)", "service", name, "file", path)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+//go:build linux
+// +build linux
+
+package offline
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+// GetLogDenied reads LogDenied from firewalld.conf; firewalld treats a
+// missing line as off.
+func (b *Backend) GetLogDenied() (string, error) {
+	conf, err := b.readConf()
+	if err != nil {
+		return "", err
+	}
+	if v := conf["LogDenied"]; v != "" {
+		return v, nil
+	}
+	return "off", nil
+}
+
+func (b *Backend) SetLogDenied(value string) error {
+	return b.SetDaemonSetting("LogDenied", value)
+}
+
+// GetDaemonSettings returns the DaemonSettings that firewalld.conf sets.
+func (b *Backend) GetDaemonSettings() (map[string]string, error) {
+	conf, err := b.readConf()
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string]string, len(firewalld.DaemonSettings))
+	for _, s := range firewalld.DaemonSettings {
+		if v, ok := conf[s.Name]; ok {
+			settings[s.Name] = v
+		}
+	}
+	return settings, nil
+}
+
+// SetDaemonSetting rewrites or appends the name line of firewalld.conf.
+func (b *Backend) SetDaemonSetting(name, value string) error {
+	if err := firewalld.CheckDaemonSetting(name, value); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	conf, err := b.readConf()
+	if err != nil {
+		return err
+	}
+	if conf[name] == value {
+		return fmt.Errorf("ALREADY_SET: %s=%s", name, value)
+	}
+	slog.Info("setting daemon option (offline)", "name", name, "value", value)
+	return b.setConfValue(name, value)
+}
+
+// readConf parses the KEY=value lines of firewalld.conf. A missing file
+// reads as empty.
+func (b *Backend) readConf() (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(b.root, confFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	conf := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok {
+			conf[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return conf, scanner.Err()
+}
+
+// setConfValue rewrites the key line of firewalld.conf, or appends one,
+// keeping the rest of the file as it is.
+func (b *Backend) setConfValue(key, value string) error {
+	path := filepath.Join(b.root, confFile)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	replaced := false
+	for i, line := range lines {
+		k, _, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && strings.TrimSpace(k) == key {
+			lines[i] = key + "=" + value
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, key+"="+value)
+	}
+	return writeFile(path, []byte(strings.Join(lines, "\n")+"\n"))
+}
@@ -0,0 +1,395 @@
+//go:build linux
+// +build linux
+
+package offline
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"lazyfirewall/internal/backup"
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/richrule"
+	"lazyfirewall/internal/validation"
+)
+
+func (b *Backend) ListZones() ([]string, error) {
+	return listXMLNames(b.dirs("zones"))
+}
+
+// zonePath returns the file zone is read from: the user copy in root if
+// there is one, the shipped definition otherwise.
+func (b *Backend) zonePath(zone string) (string, error) {
+	if err := validation.IsValidZoneName(zone); err != nil {
+		return "", fmt.Errorf("invalid zone name: %w", err)
+	}
+	path, ok := b.lookup("zones", zone)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", firewalld.ErrInvalidZone, zone)
+	}
+	return path, nil
+}
+
+// GetZoneSettings returns the zone's configuration; runtime and permanent
+// are the same offline.
+func (b *Backend) GetZoneSettings(zone string, permanent bool) (*firewalld.Zone, error) {
+	path, err := b.zonePath(zone)
+	if err != nil {
+		return nil, err
+	}
+	z, err := backup.ParseZoneXMLFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("zone %s: %w", zone, err)
+	}
+	z.Name = zone
+	if z.Target == "" {
+		z.Target = "default"
+	}
+	return z, nil
+}
+
+func (b *Backend) AddZonePermanent(zone string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if err := validation.IsValidZoneName(zone); err != nil {
+		return fmt.Errorf("invalid zone name: %w", err)
+	}
+	if _, ok := b.lookup("zones", zone); ok {
+		return fmt.Errorf("NAME_CONFLICT: zone %s already exists", zone)
+	}
+	slog.Info("adding zone (offline)", "zone", zone)
+	return b.writeZone(zone, &firewalld.Zone{Target: "default"})
+}
+
+// RemoveZonePermanent deletes the user copy of zone. Shipped zones cannot be
+// removed, as with firewalld; removing an override restores the default.
+func (b *Backend) RemoveZonePermanent(zone string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	path, err := b.zonePath(zone)
+	if err != nil {
+		return err
+	}
+	if filepath.Dir(path) != filepath.Join(b.root, "zones") {
+		return fmt.Errorf("BUILTIN_ZONE: zone %s is shipped with firewalld", zone)
+	}
+	slog.Info("removing zone (offline)", "zone", zone, "file", path)
+	return os.Remove(path)
+}
+
+// updateZone loads zone, applies fn and writes the result to root. Zone
+// files that do not survive a parse and rewrite unchanged are refused, so an
+// edit never drops content the codec does not model.
+func (b *Backend) updateZone(zone string, fn func(z *firewalld.Zone) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	path, err := b.zonePath(zone)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := backup.CheckZoneXMLRoundTrip(data); err != nil {
+		return fmt.Errorf("refusing to rewrite zone %s (%s): %w", zone, path, err)
+	}
+	z, err := b.GetZoneSettings(zone, true)
+	if err != nil {
+		return err
+	}
+	if err := fn(z); err != nil {
+		return err
+	}
+	return b.writeZone(zone, z)
+}
+
+func (b *Backend) writeZone(zone string, z *firewalld.Zone) error {
+	data, err := backup.MarshalZoneXML(z)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(b.root, "zones", zone+".xml")
+	slog.Info("writing zone (offline)", "zone", zone, "file", path)
+	return writeFile(path, append(data, '\n'))
+}
+
+func addItem[T comparable](list *[]T, item T, label string) error {
+	if slices.Contains(*list, item) {
+		return fmt.Errorf("ALREADY_ENABLED: %s", label)
+	}
+	*list = append(*list, item)
+	return nil
+}
+
+func removeItem[T comparable](list *[]T, item T, label string) error {
+	i := slices.Index(*list, item)
+	if i < 0 {
+		return fmt.Errorf("NOT_ENABLED: %s", label)
+	}
+	*list = slices.Delete(*list, i, i+1)
+	return nil
+}
+
+func setFlag(flag *bool, enabled bool, label string) error {
+	if *flag == enabled {
+		if enabled {
+			return fmt.Errorf("ALREADY_ENABLED: %s", label)
+		}
+		return fmt.Errorf("NOT_ENABLED: %s", label)
+	}
+	*flag = enabled
+	return nil
+}
+
+// checkKnown rejects names missing from the catalog, like firewalld does
+// for services and ICMP types. An empty catalog checks nothing.
+func checkKnown(list func() ([]string, error), name, code string) error {
+	known, err := list()
+	if err != nil {
+		return err
+	}
+	if len(known) > 0 && !slices.Contains(known, name) {
+		return fmt.Errorf("%s: %s", code, name)
+	}
+	return nil
+}
+
+// normalizeRichRule rejects rules firewalld would not accept and rewrites the
+// rest the way they read back from zone XML, so rules added and removed in
+// any spelling compare equal.
+func normalizeRichRule(rule string) (string, error) {
+	if _, err := richrule.Parse(rule); err != nil {
+		return "", fmt.Errorf("INVALID_RULE: %w", err)
+	}
+	return richrule.Normalize(rule), nil
+}
+
+func portLabel(p firewalld.Port) string {
+	return "port " + p.Port + "/" + p.Protocol
+}
+
+func forwardPortLabel(fp firewalld.ForwardPort) string {
+	return "forward-port " + firewalld.FormatForwardPort(fp)
+}
+
+func (b *Backend) AddServicePermanent(zone, service string) error {
+	if err := checkKnown(b.ListServiceNames, service, "INVALID_SERVICE"); err != nil {
+		return err
+	}
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return addItem(&z.Services, service, "service "+service)
+	})
+}
+
+func (b *Backend) RemoveServicePermanent(zone, service string) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return removeItem(&z.Services, service, "service "+service)
+	})
+}
+
+func (b *Backend) AddPortPermanent(zone string, port firewalld.Port) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return addItem(&z.Ports, port, portLabel(port))
+	})
+}
+
+func (b *Backend) RemovePortPermanent(zone string, port firewalld.Port) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return removeItem(&z.Ports, port, portLabel(port))
+	})
+}
+
+func (b *Backend) AddRichRulePermanent(zone, rule string) error {
+	rule, err := normalizeRichRule(rule)
+	if err != nil {
+		return err
+	}
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return addItem(&z.RichRules, rule, "rule "+rule)
+	})
+}
+
+func (b *Backend) RemoveRichRulePermanent(zone, rule string) error {
+	rule, err := normalizeRichRule(rule)
+	if err != nil {
+		return err
+	}
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return removeItem(&z.RichRules, rule, "rule "+rule)
+	})
+}
+
+func (b *Backend) AddInterfacePermanent(zone, iface string) error {
+	if err := b.checkUnbound(zone, iface); err != nil {
+		return err
+	}
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return addItem(&z.Interfaces, iface, "interface "+iface)
+	})
+}
+
+func (b *Backend) RemoveInterfacePermanent(zone, iface string) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return removeItem(&z.Interfaces, iface, "interface "+iface)
+	})
+}
+
+func (b *Backend) AddSourcePermanent(zone, source string) error {
+	if err := b.checkUnbound(zone, source); err != nil {
+		return err
+	}
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return addItem(&z.Sources, source, "source "+source)
+	})
+}
+
+func (b *Backend) RemoveSourcePermanent(zone, source string) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return removeItem(&z.Sources, source, "source "+source)
+	})
+}
+
+// checkUnbound refuses to bind an interface or source already bound to
+// another zone.
+func (b *Backend) checkUnbound(zone, value string) error {
+	active, err := b.GetActiveZones()
+	if err != nil {
+		return err
+	}
+	for name, refs := range active {
+		if name != zone && slices.Contains(refs, value) {
+			return fmt.Errorf("ZONE_CONFLICT: %s is bound to zone %s", value, name)
+		}
+	}
+	return nil
+}
+
+func (b *Backend) EnableMasqueradePermanent(zone string) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return setFlag(&z.Masquerade, true, "masquerade")
+	})
+}
+
+func (b *Backend) DisableMasqueradePermanent(zone string) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return setFlag(&z.Masquerade, false, "masquerade")
+	})
+}
+
+func (b *Backend) AddForwardPortPermanent(zone string, fp firewalld.ForwardPort) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return addItem(&z.ForwardPorts, fp, forwardPortLabel(fp))
+	})
+}
+
+func (b *Backend) RemoveForwardPortPermanent(zone string, fp firewalld.ForwardPort) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return removeItem(&z.ForwardPorts, fp, forwardPortLabel(fp))
+	})
+}
+
+func (b *Backend) SetTargetPermanent(zone, target string) error {
+	target, err := firewalld.NormalizeTarget(target)
+	if err != nil {
+		return err
+	}
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		z.Target = target
+		return nil
+	})
+}
+
+func (b *Backend) AddIcmpBlockPermanent(zone, icmpType string) error {
+	if err := checkKnown(b.ListIcmpTypes, icmpType, "INVALID_ICMPTYPE"); err != nil {
+		return err
+	}
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return addItem(&z.IcmpBlocks, icmpType, "icmp-block "+icmpType)
+	})
+}
+
+func (b *Backend) RemoveIcmpBlockPermanent(zone, icmpType string) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return removeItem(&z.IcmpBlocks, icmpType, "icmp-block "+icmpType)
+	})
+}
+
+func (b *Backend) EnableIcmpBlockInversionPermanent(zone string) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return setFlag(&z.IcmpInvert, true, "icmp-block-inversion")
+	})
+}
+
+func (b *Backend) DisableIcmpBlockInversionPermanent(zone string) error {
+	return b.updateZone(zone, func(z *firewalld.Zone) error {
+		return setFlag(&z.IcmpInvert, false, "icmp-block-inversion")
+	})
+}
+
+// Runtime variants fail: there is no running firewall to change.
+
+func (b *Backend) AddServiceRuntime(zone, service string) error { return ErrRuntime }
+
+func (b *Backend) AddServiceRuntimeTimeout(zone, service string, timeout time.Duration) error {
+	return ErrRuntime
+}
+
+func (b *Backend) RemoveServiceRuntime(zone, service string) error { return ErrRuntime }
+
+func (b *Backend) AddPortRuntime(zone string, port firewalld.Port) error { return ErrRuntime }
+
+func (b *Backend) AddPortRuntimeTimeout(zone string, port firewalld.Port, timeout time.Duration) error {
+	return ErrRuntime
+}
+
+func (b *Backend) RemovePortRuntime(zone string, port firewalld.Port) error { return ErrRuntime }
+
+func (b *Backend) AddRichRuleRuntime(zone, rule string) error { return ErrRuntime }
+
+func (b *Backend) AddRichRuleRuntimeTimeout(zone, rule string, timeout time.Duration) error {
+	return ErrRuntime
+}
+
+func (b *Backend) RemoveRichRuleRuntime(zone, rule string) error { return ErrRuntime }
+
+func (b *Backend) AddInterfaceRuntime(zone, iface string) error { return ErrRuntime }
+
+func (b *Backend) RemoveInterfaceRuntime(zone, iface string) error { return ErrRuntime }
+
+func (b *Backend) AddSourceRuntime(zone, source string) error { return ErrRuntime }
+
+func (b *Backend) RemoveSourceRuntime(zone, source string) error { return ErrRuntime }
+
+func (b *Backend) EnableMasqueradeRuntime(zone string) error { return ErrRuntime }
+
+func (b *Backend) DisableMasqueradeRuntime(zone string) error { return ErrRuntime }
+
+func (b *Backend) AddForwardPortRuntime(zone string, fp firewalld.ForwardPort) error {
+	return ErrRuntime
+}
+
+func (b *Backend) RemoveForwardPortRuntime(zone string, fp firewalld.ForwardPort) error {
+	return ErrRuntime
+}
+
+func (b *Backend) AddIcmpBlockRuntime(zone, icmpType string) error { return ErrRuntime }
+
+func (b *Backend) RemoveIcmpBlockRuntime(zone, icmpType string) error { return ErrRuntime }
+
+func (b *Backend) EnableIcmpBlockInversionRuntime(zone string) error { return ErrRuntime }
+
+func (b *Backend) DisableIcmpBlockInversionRuntime(zone string) error { return ErrRuntime }
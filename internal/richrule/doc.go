@@ -0,0 +1,3 @@
+// Package richrule parses firewalld rich rules into a Rule, validates them
+// the way firewalld does, and renders them back in the rule language.
+package richrule
@@ -0,0 +1,65 @@
+package richrule
+
+import (
+	"cmp"
+	"strconv"
+)
+
+// Groups of priority 0 rules. firewalld evaluates them in this order after
+// all negative priorities and before all positive ones: log and audit
+// first, then deny, then allow. Other rules (mark, masquerade,
+// forward-port, tcp-mss-clamp) live in their own tables and are listed last.
+const (
+	GroupLog   = "log"
+	GroupDeny  = "deny"
+	GroupAllow = "allow"
+	GroupOther = "other"
+)
+
+var groupRank = map[string]int{GroupLog: 0, GroupDeny: 1, GroupAllow: 2, GroupOther: 3}
+
+// Group returns the group r is evaluated in at priority 0. The action
+// decides; rules without one are deny for icmp-block and log when they
+// only log or audit.
+func (r *Rule) Group() string {
+	if r.Action != nil {
+		switch r.Action.Kind {
+		case "accept":
+			return GroupAllow
+		case "reject", "drop":
+			return GroupDeny
+		}
+		return GroupOther
+	}
+	if r.Element != nil && r.Element.Kind == "icmp-block" {
+		return GroupDeny
+	}
+	if r.Element == nil || r.Element.Kind != "masquerade" && r.Element.Kind != "forward-port" && r.Element.Kind != "tcp-mss-clamp" {
+		if r.Log != nil || r.Audit != nil {
+			return GroupLog
+		}
+	}
+	return GroupOther
+}
+
+// Section names the block r is listed under in evaluation order: its
+// priority when non-zero, its group otherwise.
+func (r *Rule) Section() string {
+	if r.Priority != 0 {
+		return "priority " + strconv.Itoa(r.Priority)
+	}
+	return r.Group()
+}
+
+// Compare orders rules by when firewalld evaluates them: negative
+// priorities ascending, the priority 0 groups, then positive priorities
+// ascending. Rules that tie are evaluated in no defined order.
+func Compare(a, b *Rule) int {
+	if c := cmp.Compare(a.Priority, b.Priority); c != 0 {
+		return c
+	}
+	if a.Priority != 0 {
+		return 0
+	}
+	return cmp.Compare(groupRank[a.Group()], groupRank[b.Group()])
+}
@@ -0,0 +1,300 @@
+package richrule
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type token struct {
+	pos    int
+	key    string
+	value  string
+	hasVal bool
+}
+
+// tokenize splits text into keywords and key=value attributes. Values may
+// be quoted with " or '. Keywords and keys are lower-cased.
+func tokenize(text string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(text) {
+		if text[i] == ' ' || text[i] == '\t' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(text) && text[i] != ' ' && text[i] != '\t' && text[i] != '=' {
+			i++
+		}
+		word := strings.ToLower(text[start:i])
+		if i >= len(text) || text[i] != '=' {
+			tokens = append(tokens, token{pos: start, key: word})
+			continue
+		}
+		if word == "" {
+			return nil, &Error{Pos: start, Msg: "missing attribute name before '='"}
+		}
+		i++ // skip '='
+		var value string
+		if i < len(text) && (text[i] == '"' || text[i] == '\'') {
+			end := strings.IndexByte(text[i+1:], text[i])
+			if end < 0 {
+				return nil, &Error{Pos: i, Msg: "unterminated quote"}
+			}
+			value = text[i+1 : i+1+end]
+			i += end + 2
+		} else {
+			valStart := i
+			for i < len(text) && text[i] != ' ' && text[i] != '\t' {
+				i++
+			}
+			value = text[valStart:i]
+		}
+		tokens = append(tokens, token{pos: start, key: word, value: value, hasVal: true})
+	}
+	return tokens, nil
+}
+
+// Parse parses and validates a rich rule. Errors are *Error values pointing
+// at the offending part of text.
+func Parse(text string) (*Rule, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, &Error{Pos: 0, Msg: "rich rule is empty"}
+	}
+	if tokens[0].hasVal || tokens[0].key != "rule" {
+		return nil, &Error{Pos: tokens[0].pos, Msg: "rich rule must start with 'rule'"}
+	}
+	p := parser{rule: &Rule{pos: map[string]int{"rule": tokens[0].pos}}, part: "rule", name: "rule"}
+	for _, tok := range tokens[1:] {
+		if tok.hasVal {
+			err = p.attribute(tok)
+		} else {
+			err = p.keyword(tok)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := p.rule.Validate(); err != nil {
+		return nil, err
+	}
+	return p.rule, nil
+}
+
+// Normalize returns rule the way firewalld lists it, so that equivalent
+// spellings compare equal. A rule that does not parse is returned unchanged.
+func Normalize(rule string) string {
+	r, err := Parse(rule)
+	if err != nil {
+		return rule
+	}
+	return r.String()
+}
+
+// parser walks the tokens; part is the position key attributes are
+// recorded under and name the keyword shown in errors.
+type parser struct {
+	rule   *Rule
+	part   string
+	name   string
+	fresh  bool
+	limit  **Limit
+	cursor *Limit
+}
+
+func (p *parser) enter(tok token, part string) {
+	p.part = part
+	p.name = tok.key
+	p.fresh = true
+	p.limit = nil
+	p.cursor = nil
+	p.rule.pos[part] = tok.pos
+}
+
+func (p *parser) keyword(tok token) error {
+	r := p.rule
+	switch tok.key {
+	case "not":
+		if (p.part != "source" && p.part != "destination") || !p.fresh {
+			return &Error{Pos: tok.pos, Msg: "'not' must directly follow source or destination"}
+		}
+		addr := r.Source
+		if p.part == "destination" {
+			addr = r.Destination
+		}
+		if addr.Invert {
+			return &Error{Pos: tok.pos, Msg: "duplicate 'not'"}
+		}
+		addr.Invert = true
+		return nil
+	case "limit":
+		if p.limit == nil {
+			return &Error{Pos: tok.pos, Msg: "limit is not valid after " + p.name}
+		}
+		if *p.limit != nil {
+			return &Error{Pos: tok.pos, Msg: "duplicate limit for " + p.name}
+		}
+		limit := &Limit{}
+		*p.limit = limit
+		p.enter(tok, p.part+".limit")
+		p.cursor = limit
+		return nil
+	case "source", "destination":
+		if (tok.key == "source" && r.Source != nil) || (tok.key == "destination" && r.Destination != nil) {
+			return &Error{Pos: tok.pos, Msg: "duplicate " + tok.key}
+		}
+		if tok.key == "source" {
+			r.Source = &Address{}
+		} else {
+			r.Destination = &Address{}
+		}
+		p.enter(tok, tok.key)
+		return nil
+	case "log", "nflog":
+		if r.Log != nil {
+			return &Error{Pos: tok.pos, Msg: "duplicate log"}
+		}
+		r.Log = &Log{NFLog: tok.key == "nflog"}
+		p.enter(tok, "log")
+		p.limit = &r.Log.Limit
+		return nil
+	case "audit":
+		if r.Audit != nil {
+			return &Error{Pos: tok.pos, Msg: "duplicate audit"}
+		}
+		r.Audit = &Audit{}
+		p.enter(tok, "audit")
+		p.limit = &r.Audit.Limit
+		return nil
+	case "rule":
+		return &Error{Pos: tok.pos, Msg: "unexpected 'rule'"}
+	}
+	if slices.Contains(Actions, tok.key) {
+		if r.Action != nil {
+			return &Error{Pos: tok.pos, Msg: "more than one action (" + r.Action.Kind + " and " + tok.key + ")"}
+		}
+		r.Action = &Action{Kind: tok.key}
+		p.enter(tok, "action")
+		p.limit = &r.Action.Limit
+		return nil
+	}
+	if slices.Contains(Elements, tok.key) {
+		if r.Element != nil {
+			return &Error{Pos: tok.pos, Msg: "more than one element (" + r.Element.Kind + " and " + tok.key + ")"}
+		}
+		r.Element = &Element{Kind: tok.key}
+		p.enter(tok, "element")
+		return nil
+	}
+	return &Error{Pos: tok.pos, Msg: "unknown keyword '" + tok.key + "'"}
+}
+
+func (p *parser) attribute(tok token) error {
+	r := p.rule
+	key := p.part + "." + tok.key
+	if _, dup := r.pos[key]; dup {
+		return &Error{Pos: tok.pos, Msg: "duplicate " + tok.key + "= for " + p.name}
+	}
+	target, err := p.target(tok)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return &Error{Pos: tok.pos, Msg: p.name + " does not take " + tok.key + "="}
+	}
+	*target = tok.value
+	p.fresh = false
+	r.pos[key] = tok.pos
+	return nil
+}
+
+// target returns the field tok sets in the current part, or nil if the
+// part has no such attribute. Numeric attributes are stored directly.
+func (p *parser) target(tok token) (*string, error) {
+	r := p.rule
+	switch p.part {
+	case "rule":
+		switch tok.key {
+		case "family":
+			return &r.Family, nil
+		case "priority":
+			n, err := strconv.Atoi(tok.value)
+			if err != nil {
+				return nil, &Error{Pos: tok.pos, Msg: "priority must be a number, got '" + tok.value + "'"}
+			}
+			r.Priority = n
+			return new(string), nil
+		}
+	case "source", "destination":
+		addr := r.Source
+		if p.part == "destination" {
+			addr = r.Destination
+		}
+		switch tok.key {
+		case "address":
+			return &addr.Address, nil
+		case "mac":
+			if p.part == "source" {
+				return &addr.MAC, nil
+			}
+		case "ipset":
+			return &addr.IPSet, nil
+		}
+	case "element":
+		e := r.Element
+		switch {
+		case tok.key == "name" && (e.Kind == "service" || e.Kind == "icmp-block" || e.Kind == "icmp-type"):
+			return &e.Name, nil
+		case tok.key == "value" && e.Kind == "protocol":
+			return &e.Protocol, nil
+		case tok.key == "value" && e.Kind == "tcp-mss-clamp":
+			return &e.Value, nil
+		case tok.key == "port" && (e.Kind == "port" || e.Kind == "source-port" || e.Kind == "forward-port"):
+			return &e.Port, nil
+		case tok.key == "protocol" && (e.Kind == "port" || e.Kind == "source-port" || e.Kind == "forward-port"):
+			return &e.Protocol, nil
+		case tok.key == "to-port" && e.Kind == "forward-port":
+			return &e.ToPort, nil
+		case tok.key == "to-addr" && e.Kind == "forward-port":
+			return &e.ToAddr, nil
+		}
+	case "log":
+		l := r.Log
+		switch {
+		case tok.key == "prefix":
+			return &l.Prefix, nil
+		case tok.key == "level" && !l.NFLog:
+			return &l.Level, nil
+		case tok.key == "group" && l.NFLog:
+			return &l.Group, nil
+		case tok.key == "queue-size" && l.NFLog:
+			return &l.QueueSize, nil
+		}
+	case "action":
+		a := r.Action
+		switch {
+		case tok.key == "type" && a.Kind == "reject":
+			return &a.Type, nil
+		case tok.key == "set" && a.Kind == "mark":
+			return &a.Set, nil
+		}
+	case "log.limit", "audit.limit", "action.limit":
+		switch tok.key {
+		case "value":
+			return &p.cursor.Value, nil
+		case "burst":
+			n, err := strconv.Atoi(tok.value)
+			if err != nil {
+				return nil, &Error{Pos: tok.pos, Msg: "burst must be a number, got '" + tok.value + "'"}
+			}
+			p.cursor.Burst = n
+			return new(string), nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,219 @@
+package richrule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Priority bounds accepted by firewalld.
+const (
+	MinPriority = -32768
+	MaxPriority = 32767
+)
+
+// Elements are the element kinds, at most one of which a rule has.
+var Elements = []string{"service", "port", "protocol", "icmp-block", "icmp-type", "masquerade", "forward-port", "source-port", "tcp-mss-clamp"}
+
+// Actions are the terminal actions, at most one of which a rule has.
+var Actions = []string{"accept", "reject", "drop", "mark"}
+
+// LogLevels are the syslog levels log accepts.
+var LogLevels = []string{"emerg", "alert", "crit", "error", "warning", "notice", "info", "debug"}
+
+// RejectTypes are the reject types firewalld accepts per family.
+var RejectTypes = map[string][]string{
+	"ipv4": {"icmp-host-prohibited", "host-prohib", "icmp-net-unreachable", "net-unreach", "icmp-host-unreachable", "host-unreach", "icmp-port-unreachable", "port-unreach", "icmp-proto-unreachable", "proto-unreach", "icmp-net-prohibited", "net-prohib", "tcp-reset", "tcp-rst", "icmp-admin-prohibited", "admin-prohib"},
+	"ipv6": {"icmp6-adm-prohibited", "adm-prohibited", "icmp6-no-route", "no-route", "icmp6-addr-unreachable", "addr-unreach", "icmp6-port-unreachable", "port-unreach", "tcp-reset"},
+}
+
+// Rule is a parsed rich rule. Nil parts are absent from the rule.
+type Rule struct {
+	Family      string
+	Priority    int
+	Source      *Address
+	Destination *Address
+	Element     *Element
+	Log         *Log
+	Audit       *Audit
+	Action      *Action
+
+	// pos maps parts ("source", "source.address", ...) to their offset in
+	// the parsed text; it is nil for rules built in code.
+	pos map[string]int
+}
+
+// Address is a source or destination. Exactly one of Address, MAC and
+// IPSet is set; MAC is only valid for sources.
+type Address struct {
+	Invert  bool
+	Address string
+	MAC     string
+	IPSet   string
+}
+
+// Element is the thing a rule matches or enables. Name is used by service,
+// icmp-block and icmp-type, Port and Protocol by port, source-port and
+// forward-port, Protocol alone by protocol (its value attribute), Value by
+// tcp-mss-clamp ("pmtu" or a size; empty means pmtu).
+type Element struct {
+	Kind     string
+	Name     string
+	Port     string
+	Protocol string
+	ToPort   string
+	ToAddr   string
+	Value    string
+}
+
+// Limit is a rate such as 3/m, with an optional burst.
+type Limit struct {
+	Value string
+	Burst int
+}
+
+// Log is a log or, with NFLog set, an nflog part. Level applies to log,
+// Group and QueueSize to nflog.
+type Log struct {
+	NFLog     bool
+	Prefix    string
+	Level     string
+	Group     string
+	QueueSize string
+	Limit     *Limit
+}
+
+type Audit struct {
+	Limit *Limit
+}
+
+// Action is accept, reject (with an optional Type), drop or mark (with Set).
+type Action struct {
+	Kind  string
+	Type  string
+	Set   string
+	Limit *Limit
+}
+
+// String renders r in firewalld's rule language, in the order firewalld
+// lists rules.
+func (r *Rule) String() string {
+	var b strings.Builder
+	b.WriteString("rule")
+	writeAttr(&b, "family", r.Family)
+	if r.Priority != 0 {
+		writeAttr(&b, "priority", strconv.Itoa(r.Priority))
+	}
+	writeAddress(&b, "source", r.Source)
+	writeAddress(&b, "destination", r.Destination)
+	if e := r.Element; e != nil {
+		b.WriteString(" " + e.Kind)
+		switch e.Kind {
+		case "service", "icmp-block", "icmp-type":
+			writeAttr(&b, "name", e.Name)
+		case "port", "source-port":
+			writeAttr(&b, "port", e.Port)
+			writeAttr(&b, "protocol", e.Protocol)
+		case "protocol":
+			writeAttr(&b, "value", e.Protocol)
+		case "tcp-mss-clamp":
+			writeAttr(&b, "value", e.Value)
+		case "forward-port":
+			writeAttr(&b, "port", e.Port)
+			writeAttr(&b, "protocol", e.Protocol)
+			writeAttr(&b, "to-port", e.ToPort)
+			writeAttr(&b, "to-addr", e.ToAddr)
+		}
+	}
+	if l := r.Log; l != nil {
+		if l.NFLog {
+			b.WriteString(" nflog")
+			writeAttr(&b, "group", l.Group)
+			writeAttr(&b, "prefix", l.Prefix)
+			writeAttr(&b, "queue-size", l.QueueSize)
+		} else {
+			b.WriteString(" log")
+			writeAttr(&b, "prefix", l.Prefix)
+			writeAttr(&b, "level", l.Level)
+		}
+		writeLimit(&b, l.Limit)
+	}
+	if r.Audit != nil {
+		b.WriteString(" audit")
+		writeLimit(&b, r.Audit.Limit)
+	}
+	if a := r.Action; a != nil {
+		b.WriteString(" " + a.Kind)
+		writeAttr(&b, "type", a.Type)
+		writeAttr(&b, "set", a.Set)
+		writeLimit(&b, a.Limit)
+	}
+	return b.String()
+}
+
+func writeAttr(b *strings.Builder, key, value string) {
+	if value == "" {
+		return
+	}
+	quote := `"`
+	if strings.Contains(value, `"`) {
+		quote = "'"
+	}
+	b.WriteString(" " + key + "=" + quote + value + quote)
+}
+
+func writeAddress(b *strings.Builder, name string, a *Address) {
+	if a == nil {
+		return
+	}
+	b.WriteString(" " + name)
+	if a.Invert {
+		b.WriteString(" NOT")
+	}
+	writeAttr(b, "address", a.Address)
+	writeAttr(b, "mac", a.MAC)
+	writeAttr(b, "ipset", a.IPSet)
+}
+
+func writeLimit(b *strings.Builder, l *Limit) {
+	if l == nil {
+		return
+	}
+	b.WriteString(" limit")
+	writeAttr(b, "value", l.Value)
+	if l.Burst > 0 {
+		writeAttr(b, "burst", strconv.Itoa(l.Burst))
+	}
+}
+
+// Error is a problem with a rule. Pos is the byte offset of the offending
+// part in the parsed text, or -1 for rules built in code.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	if e.Pos < 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s (column %d)", e.Msg, e.Pos+1)
+}
+
+// errorf reports a problem with part, located at the most specific recorded
+// prefix of part ("source.address", then "source").
+func (r *Rule) errorf(part, format string, args ...any) error {
+	pos := -1
+	for key := part; r.pos != nil; {
+		if p, ok := r.pos[key]; ok {
+			pos = p
+			break
+		}
+		idx := strings.LastIndexByte(key, '.')
+		if idx < 0 {
+			break
+		}
+		key = key[:idx]
+	}
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
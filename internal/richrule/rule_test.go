@@ -0,0 +1,154 @@
+package richrule
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "rule service name=ssh accept", want: `rule service name="ssh" accept`},
+		{
+			in:   `rule family="ipv4" source NOT address="10.0.0.0/8" port port="8080-8090" protocol="tcp" log prefix="web " level="info" limit value="3/m" reject type="icmp-port-unreachable"`,
+			want: `rule family="ipv4" source NOT address="10.0.0.0/8" port port="8080-8090" protocol="tcp" log prefix="web " level="info" limit value="3/m" reject type="icmp-port-unreachable"`,
+		},
+		{in: "RULE priority=-5 family=ipv6 source ipset=blocked drop", want: `rule family="ipv6" priority="-5" source ipset="blocked" drop`},
+		{in: "rule family=ipv4 forward-port port=80 protocol=tcp to-port=8080 to-addr=192.0.2.10", want: `rule family="ipv4" forward-port port="80" protocol="tcp" to-port="8080" to-addr="192.0.2.10"`},
+		{in: "rule protocol value=gre audit limit value=1/h burst=5 accept", want: `rule protocol value="gre" audit limit value="1/h" burst="5" accept`},
+		{in: "rule source mac=00:11:22:33:44:55 icmp-block name=echo-request", want: `rule source mac="00:11:22:33:44:55" icmp-block name="echo-request"`},
+		{in: "rule service name=http nflog group=5 prefix='say \"hi\"' queue-size=10", want: `rule service name="http" nflog group="5" prefix='say "hi"' queue-size="10"`},
+		{in: "rule family=ipv4 source address=192.0.2.0/24 mark set=0x1/0xff", want: `rule family="ipv4" source address="192.0.2.0/24" mark set="0x1/0xff"`},
+		{in: "rule tcp-mss-clamp value=pmtu", want: `rule tcp-mss-clamp value="pmtu"`},
+		{in: "rule family=ipv4 source address=10.0.0.0/8 tcp-mss-clamp value=1400", want: `rule family="ipv4" source address="10.0.0.0/8" tcp-mss-clamp value="1400"`},
+		{in: "rule tcp-mss-clamp", want: `rule tcp-mss-clamp`},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.in)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.in, err)
+		}
+		if got := r.String(); got != tt.want {
+			t.Fatalf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+		again, err := Parse(r.String())
+		if err != nil || again.String() != tt.want {
+			t.Fatalf("reparsing %q = %v, %v", tt.want, again, err)
+		}
+	}
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	tests := []struct {
+		in  string
+		pos int
+	}{
+		{in: "service name=ssh accept", pos: 0},
+		{in: "rule service name=ssh bogus", pos: 22},
+		{in: `rule service name="ssh accept`, pos: 18},
+		{in: "rule service name=ssh port port=22 protocol=tcp accept", pos: 22},
+		{in: "rule source address=10.0.0.1 accept", pos: 12},
+		{in: "rule family=ipv6 source address=10.0.0.1 accept", pos: 24},
+		{in: "rule family=ipv4 port port=70000 protocol=tcp accept", pos: 22},
+		{in: "rule service name=ssh log level=loud accept", pos: 26},
+		{in: "rule service name=ssh accept limit value=fast", pos: 35},
+		{in: "rule service name=ssh accept drop", pos: 29},
+		{in: "rule service name=ssh", pos: 0},
+		{in: "rule family=ipv4 masquerade accept", pos: 28},
+		{in: "rule service name=ssh reject type=tcp-reset", pos: 29},
+		{in: "rule priority=high service name=ssh accept", pos: 5},
+		{in: "rule service not name=ssh accept", pos: 13},
+		{in: "rule service name=ssh name=http accept", pos: 22},
+		{in: "rule tcp-mss-clamp value=100", pos: 19},
+		{in: "rule tcp-mss-clamp value=auto", pos: 19},
+		{in: "rule tcp-mss-clamp value=pmtu accept", pos: 30},
+		{in: "rule service name=ssh value=pmtu accept", pos: 22},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.in)
+		var perr *Error
+		if !errors.As(err, &perr) {
+			t.Fatalf("Parse(%q) error = %v, want *Error", tt.in, err)
+		}
+		if perr.Pos != tt.pos {
+			t.Fatalf("Parse(%q) error %q at %d, want %d", tt.in, perr.Msg, perr.Pos, tt.pos)
+		}
+	}
+}
+
+func TestValidateBuiltRule(t *testing.T) {
+	r := &Rule{
+		Family:  "ipv4",
+		Source:  &Address{Address: "192.0.2.1"},
+		Element: &Element{Kind: "service", Name: "ssh"},
+		Action:  &Action{Kind: "accept", Limit: &Limit{Value: "10/m"}},
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if got := r.String(); got != `rule family="ipv4" source address="192.0.2.1" service name="ssh" accept limit value="10/m"` {
+		t.Fatalf("String() = %q", got)
+	}
+	r.Family = ""
+	err := r.Validate()
+	var perr *Error
+	if !errors.As(err, &perr) || perr.Pos != -1 || perr.Error() != "source address needs family=ipv4 or family=ipv6" {
+		t.Fatalf("Validate() without family error = %v", err)
+	}
+}
+
+func TestCompareEvaluationOrder(t *testing.T) {
+	rules := []string{
+		`rule priority="5" service name="http" accept`,
+		`rule service name="ssh" accept`,
+		`rule family="ipv4" source address="10.0.0.0/8" drop`,
+		`rule service name="ftp" log prefix="ftp"`,
+		`rule priority="-3" service name="dns" reject`,
+		`rule family="ipv4" forward-port port="80" protocol="tcp" to-port="8080"`,
+		`rule icmp-block name="echo-request"`,
+	}
+	parsed := make([]*Rule, 0, len(rules))
+	for _, text := range rules {
+		r, err := Parse(text)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", text, err)
+		}
+		parsed = append(parsed, r)
+	}
+	slices.SortStableFunc(parsed, Compare)
+	var got []string
+	for _, r := range parsed {
+		got = append(got, r.Section())
+	}
+	want := []string{"priority -3", GroupLog, GroupDeny, GroupDeny, GroupAllow, GroupOther, "priority 5"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("sections = %v, want %v", got, want)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{
+			rule: `rule  family=ipv4 service name='ssh'   accept`,
+			want: `rule family="ipv4" service name="ssh" accept`,
+		},
+		{
+			rule: `rule family="ipv4" source not address="10.0.0.0/8" accept limit value="1/m"`,
+			want: `rule family="ipv4" source NOT address="10.0.0.0/8" accept limit value="1/m"`,
+		},
+		{rule: `rule priority="0" service name="ssh" accept`, want: `rule service name="ssh" accept`},
+		{rule: `rule bogus accept`, want: `rule bogus accept`},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.rule); got != tt.want {
+			t.Fatalf("Normalize(%q) = %q, want %q", tt.rule, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package richrule
+
+import (
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	nameRe  = regexp.MustCompile(`^[A-Za-z0-9_.:+-]+$`)
+	limitRe = regexp.MustCompile(`^([0-9]+)/(s|m|h|d|second|minute|hour|day)$`)
+	markRe  = regexp.MustCompile(`^(0x[0-9a-fA-F]+|[0-9]+)(/(0x[0-9a-fA-F]+|[0-9]+))?$`)
+)
+
+// protocols are the port protocols firewalld accepts.
+var protocols = []string{"tcp", "udp", "sctp", "dccp"}
+
+// maxLogPrefix is the longest log prefix nftables accepts.
+const maxLogPrefix = 127
+
+// minMSS is the smallest tcp-mss-clamp size firewalld accepts.
+const minMSS = 536
+
+// Validate checks r with firewalld's rules: a family for addresses,
+// forward ports and reject types, well-formed values, and a sensible
+// combination of element, log, audit and action.
+func (r *Rule) Validate() error {
+	if r.Family != "" && r.Family != "ipv4" && r.Family != "ipv6" {
+		return r.errorf("rule.family", "invalid family '%s' (use ipv4 or ipv6)", r.Family)
+	}
+	if r.Priority < MinPriority || r.Priority > MaxPriority {
+		return r.errorf("rule.priority", "priority %d is out of range (%d to %d)", r.Priority, MinPriority, MaxPriority)
+	}
+	if err := r.checkAddress("source", r.Source); err != nil {
+		return err
+	}
+	if err := r.checkAddress("destination", r.Destination); err != nil {
+		return err
+	}
+	if err := r.checkElement(); err != nil {
+		return err
+	}
+	if err := r.checkLog(); err != nil {
+		return err
+	}
+	if r.Audit != nil {
+		if err := r.checkLimit("audit.limit", r.Audit.Limit); err != nil {
+			return err
+		}
+	}
+	if err := r.checkAction(); err != nil {
+		return err
+	}
+	return r.checkCombination()
+}
+
+func (r *Rule) checkAddress(part string, a *Address) error {
+	if a == nil {
+		return nil
+	}
+	set := 0
+	for _, v := range []string{a.Address, a.MAC, a.IPSet} {
+		if v != "" {
+			set++
+		}
+	}
+	switch {
+	case set == 0 && part == "source":
+		return r.errorf(part, "source needs address=, mac= or ipset=")
+	case set == 0:
+		return r.errorf(part, "destination needs address= or ipset=")
+	case set > 1:
+		return r.errorf(part, "%s takes only one of address=, mac= and ipset=", part)
+	}
+	switch {
+	case a.Address != "":
+		family, ok := addressFamily(a.Address)
+		if !ok {
+			return r.errorf(part+".address", "invalid address '%s'", a.Address)
+		}
+		if r.Family == "" {
+			return r.errorf(part+".address", "%s address needs family=ipv4 or family=ipv6", part)
+		}
+		if family != r.Family {
+			return r.errorf(part+".address", "%s is not an %s address", a.Address, r.Family)
+		}
+	case a.MAC != "":
+		if part != "source" {
+			return r.errorf(part+".mac", "only source takes mac=")
+		}
+		if hw, err := net.ParseMAC(a.MAC); err != nil || len(hw) != 6 {
+			return r.errorf(part+".mac", "invalid mac '%s'", a.MAC)
+		}
+	case a.IPSet != "":
+		if !nameRe.MatchString(a.IPSet) {
+			return r.errorf(part+".ipset", "invalid ipset name '%s'", a.IPSet)
+		}
+	}
+	return nil
+}
+
+// addressFamily reports the family of an address or CIDR network.
+func addressFamily(addr string) (string, bool) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		var err error
+		if ip, _, err = net.ParseCIDR(addr); err != nil {
+			return "", false
+		}
+	}
+	if ip.To4() != nil && !strings.Contains(addr, ":") {
+		return "ipv4", true
+	}
+	return "ipv6", true
+}
+
+func (r *Rule) checkElement() error {
+	e := r.Element
+	if e == nil {
+		return nil
+	}
+	switch e.Kind {
+	case "service", "icmp-block", "icmp-type":
+		if e.Name == "" {
+			return r.errorf("element", "%s needs name=", e.Kind)
+		}
+		if !nameRe.MatchString(e.Name) {
+			return r.errorf("element.name", "invalid %s name '%s'", e.Kind, e.Name)
+		}
+	case "port", "source-port", "forward-port":
+		if e.Port == "" || e.Protocol == "" {
+			return r.errorf("element", "%s needs port= and protocol=", e.Kind)
+		}
+		if !validPort(e.Port) {
+			return r.errorf("element.port", "invalid port '%s'", e.Port)
+		}
+		if !slices.Contains(protocols, e.Protocol) {
+			return r.errorf("element.protocol", "invalid protocol '%s' (use %s)", e.Protocol, strings.Join(protocols, ", "))
+		}
+		if e.Kind == "forward-port" {
+			return r.checkForwardPort(e)
+		}
+	case "protocol":
+		if e.Protocol == "" {
+			return r.errorf("element", "protocol needs value=")
+		}
+		if !nameRe.MatchString(e.Protocol) {
+			return r.errorf("element.value", "invalid protocol '%s'", e.Protocol)
+		}
+	case "tcp-mss-clamp":
+		if e.Value != "" && e.Value != "pmtu" {
+			if n, err := strconv.Atoi(e.Value); err != nil || n < minMSS || n > 65535 {
+				return r.errorf("element.value", "invalid tcp-mss-clamp value '%s' (use pmtu or %d to 65535)", e.Value, minMSS)
+			}
+		}
+	case "masquerade":
+	default:
+		return r.errorf("element", "unknown element '%s'", e.Kind)
+	}
+	return nil
+}
+
+func (r *Rule) checkForwardPort(e *Element) error {
+	if e.ToPort == "" && e.ToAddr == "" {
+		return r.errorf("element", "forward-port needs to-port= or to-addr=")
+	}
+	if e.ToPort != "" && !validPort(e.ToPort) {
+		return r.errorf("element.to-port", "invalid to-port '%s'", e.ToPort)
+	}
+	if r.Family == "" {
+		return r.errorf("element", "forward-port needs family=ipv4 or family=ipv6")
+	}
+	if e.ToAddr != "" {
+		if ip := net.ParseIP(e.ToAddr); ip == nil {
+			return r.errorf("element.to-addr", "invalid to-addr '%s'", e.ToAddr)
+		}
+		if family, _ := addressFamily(e.ToAddr); family != r.Family {
+			return r.errorf("element.to-addr", "%s is not an %s address", e.ToAddr, r.Family)
+		}
+	}
+	return nil
+}
+
+// validPort accepts a port or an ascending lo-hi range within 1-65535.
+func validPort(port string) bool {
+	lo, hi, isRange := strings.Cut(port, "-")
+	a, err := strconv.Atoi(lo)
+	if err != nil || a < 1 || a > 65535 {
+		return false
+	}
+	if !isRange {
+		return true
+	}
+	b, err := strconv.Atoi(hi)
+	return err == nil && b >= a && b <= 65535
+}
+
+func (r *Rule) checkLog() error {
+	l := r.Log
+	if l == nil {
+		return nil
+	}
+	if len(l.Prefix) > maxLogPrefix {
+		return r.errorf("log.prefix", "log prefix is longer than %d characters", maxLogPrefix)
+	}
+	if l.NFLog {
+		if l.Level != "" {
+			return r.errorf("log", "nflog does not take level=")
+		}
+		if l.Group != "" {
+			if n, err := strconv.Atoi(l.Group); err != nil || n < 0 || n > 65535 {
+				return r.errorf("log.group", "invalid nflog group '%s' (0 to 65535)", l.Group)
+			}
+		}
+		if l.QueueSize != "" {
+			if n, err := strconv.Atoi(l.QueueSize); err != nil || n < 0 {
+				return r.errorf("log.queue-size", "invalid nflog queue-size '%s'", l.QueueSize)
+			}
+		}
+	} else {
+		if l.Group != "" || l.QueueSize != "" {
+			return r.errorf("log", "only nflog takes group= and queue-size=")
+		}
+		if l.Level != "" && !slices.Contains(LogLevels, l.Level) {
+			return r.errorf("log.level", "invalid log level '%s' (use %s)", l.Level, strings.Join(LogLevels, ", "))
+		}
+	}
+	return r.checkLimit("log.limit", l.Limit)
+}
+
+func (r *Rule) checkAction() error {
+	a := r.Action
+	if a == nil {
+		return nil
+	}
+	if !slices.Contains(Actions, a.Kind) {
+		return r.errorf("action", "unknown action '%s'", a.Kind)
+	}
+	if a.Type != "" {
+		if a.Kind != "reject" {
+			return r.errorf("action", "only reject takes type=")
+		}
+		if r.Family == "" {
+			return r.errorf("action.type", "reject type needs family=ipv4 or family=ipv6")
+		}
+		if !slices.Contains(RejectTypes[r.Family], a.Type) {
+			return r.errorf("action.type", "invalid %s reject type '%s'", r.Family, a.Type)
+		}
+	}
+	if a.Kind == "mark" && a.Set == "" {
+		return r.errorf("action", "mark needs set=")
+	}
+	if a.Set != "" {
+		if a.Kind != "mark" {
+			return r.errorf("action", "only mark takes set=")
+		}
+		if !markRe.MatchString(a.Set) {
+			return r.errorf("action.set", "invalid mark '%s' (use mark or mark/mask)", a.Set)
+		}
+	}
+	return r.checkLimit("action.limit", a.Limit)
+}
+
+func (r *Rule) checkLimit(part string, l *Limit) error {
+	if l == nil {
+		return nil
+	}
+	if l.Value == "" {
+		return r.errorf(part, "limit needs value=, e.g. 3/m")
+	}
+	m := limitRe.FindStringSubmatch(l.Value)
+	if m == nil {
+		return r.errorf(part+".value", "invalid limit '%s' (use rate/s|m|h|d, e.g. 3/m)", l.Value)
+	}
+	if n, _ := strconv.Atoi(m[1]); n < 1 {
+		return r.errorf(part+".value", "limit rate must be at least 1")
+	}
+	if l.Burst < 0 || l.Burst > 65535 {
+		return r.errorf(part+".burst", "burst %d is out of range (1 to 65535)", l.Burst)
+	}
+	return nil
+}
+
+// checkCombination mirrors firewalld's rule-level checks: a rule needs an
+// element or a source/destination to act on, and something to do with it.
+func (r *Rule) checkCombination() error {
+	if r.Element == nil && (r.Log == nil || r.Priority == 0) {
+		if r.Action == nil {
+			return r.errorf("rule", "rule without an element needs an action")
+		}
+		if r.Source == nil && r.Destination == nil && r.Priority == 0 {
+			return r.errorf("rule", "rule without an element needs a source or destination")
+		}
+	}
+	if r.Element == nil {
+		return nil
+	}
+	switch r.Element.Kind {
+	case "icmp-block", "masquerade", "forward-port", "tcp-mss-clamp":
+		if r.Action != nil {
+			return r.errorf("action", "%s does not take an action", r.Element.Kind)
+		}
+	default:
+		if r.Log == nil && r.Audit == nil && r.Action == nil {
+			return r.errorf("rule", "rule needs an action, log or audit")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+//go:build linux
+// +build linux
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"lazyfirewall/internal/backup"
+	"lazyfirewall/internal/firewalld"
+)
+
+type step struct {
+	change Change
+	do     func() error
+	undo   func() error
+}
+
+// ApplyOptions adjusts Apply.
+type ApplyOptions struct {
+	// NoBackup skips the zone backups; a failed apply is then rolled back
+	// by reverting the applied steps alone.
+	NoBackup bool
+}
+
+// Apply writes the plan to the permanent configuration and reloads firewalld.
+// Affected zones are backed up first unless opts.NoBackup is set; if any step
+// or the reload fails, the applied steps are reverted and the zone backups
+// restored.
+func Apply(client firewalld.Backend, plan *Plan, out io.Writer, opts ApplyOptions) error {
+	if plan.Empty() {
+		return nil
+	}
+	if client.ReadOnly() {
+		return firewalld.ErrPermissionDenied
+	}
+
+	backups := make(map[string]backup.Backup)
+	zones := plan.zones()
+	if opts.NoBackup {
+		zones = nil
+	}
+	for _, zone := range zones {
+		b, err := backup.CreateZoneBackupWithDescription(zone, "pre-apply")
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("backup zone %s: %w", zone, err)
+		}
+		backups[zone] = b
+		fmt.Fprintf(out, "Backup: %s\n", b.Path)
+	}
+
+	steps, err := buildSteps(client, plan)
+	if err != nil {
+		return err
+	}
+	applied, err := runSteps(steps, out)
+	if err == nil {
+		if err = client.Reload(); err != nil {
+			err = fmt.Errorf("reload: %w", err)
+		}
+	}
+	if err == nil {
+		return nil
+	}
+
+	slog.Error("apply failed, rolling back", "error", err)
+	fmt.Fprintf(out, "Error: %v\nRolling back...\n", err)
+	rollbackErr := undoSteps(steps[:applied], out)
+	for _, zone := range sortedNames(backups) {
+		if restoreErr := backup.RestoreZoneBackupAndReload(zone, backups[zone], client.Reload); restoreErr != nil {
+			rollbackErr = errors.Join(rollbackErr, fmt.Errorf("restore zone %s: %w", zone, restoreErr))
+		}
+	}
+	if rollbackErr != nil {
+		return fmt.Errorf("apply failed: %w (rollback incomplete: %v)", err, rollbackErr)
+	}
+	return fmt.Errorf("apply failed, previous state restored: %w", err)
+}
+
+// runSteps executes steps in order and reports how many succeeded.
+func runSteps(steps []step, out io.Writer) (int, error) {
+	for i, s := range steps {
+		if err := s.do(); err != nil {
+			return i, fmt.Errorf("%s: %w", s.change, err)
+		}
+		fmt.Fprintf(out, "  %s %s\n", s.change.Action.symbol(), s.change)
+	}
+	return len(steps), nil
+}
+
+func undoSteps(steps []step, out io.Writer) error {
+	var errs error
+	for i := len(steps) - 1; i >= 0; i-- {
+		s := steps[i]
+		if err := s.undo(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("undo %s: %w", s.change, err))
+			continue
+		}
+		fmt.Fprintf(out, "  reverted: %s\n", s.change)
+	}
+	return errs
+}
+
+func buildSteps(client firewalld.Backend, plan *Plan) ([]step, error) {
+	steps := make([]step, 0, len(plan.Changes))
+	for _, c := range plan.Changes {
+		do, undo, err := stepFuncs(client, c)
+		if err != nil {
+			return nil, err
+		}
+		if c.Action == ActionRemove {
+			do, undo = undo, do
+		}
+		steps = append(steps, step{change: c, do: do, undo: undo})
+	}
+	return steps, nil
+}
+
+// stepFuncs returns the add and remove operations for c; callers swap them
+// for removals.
+func stepFuncs(client firewalld.Backend, c Change) (func() error, func() error, error) {
+	name, value := c.Name, c.Value
+	if c.Object == "ipset" {
+		switch c.Kind {
+		case "":
+			return func() error { return client.AddIPSetPermanent(name, value) },
+				func() error { return client.RemoveIPSetPermanent(name) }, nil
+		case "entry":
+			return func() error { return client.AddIPSetEntryPermanent(name, value) },
+				func() error { return client.RemoveIPSetEntryPermanent(name, value) }, nil
+		}
+		return nil, nil, fmt.Errorf("unsupported ipset change: %s", c.Kind)
+	}
+
+	switch c.Kind {
+	case "":
+		return func() error { return client.AddZonePermanent(name) },
+			func() error { return client.RemoveZonePermanent(name) }, nil
+	case "service":
+		return func() error { return client.AddServicePermanent(name, value) },
+			func() error { return client.RemoveServicePermanent(name, value) }, nil
+	case "port":
+		port, err := firewalld.ParsePort(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		return func() error { return client.AddPortPermanent(name, port) },
+			func() error { return client.RemovePortPermanent(name, port) }, nil
+	case "rich rule":
+		return func() error { return client.AddRichRulePermanent(name, value) },
+			func() error { return client.RemoveRichRulePermanent(name, value) }, nil
+	case "source":
+		return func() error { return client.AddSourcePermanent(name, value) },
+			func() error { return client.RemoveSourcePermanent(name, value) }, nil
+	case "interface":
+		return func() error { return client.AddInterfacePermanent(name, value) },
+			func() error { return client.RemoveInterfacePermanent(name, value) }, nil
+	case "masquerade":
+		enable := func() error { return client.EnableMasqueradePermanent(name) }
+		disable := func() error { return client.DisableMasqueradePermanent(name) }
+		if value == "true" {
+			return enable, disable, nil
+		}
+		return disable, enable, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported zone change: %s", c.Kind)
+}
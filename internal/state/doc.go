@@ -0,0 +1,6 @@
+//go:build linux
+// +build linux
+
+// Package state loads declarative firewall state files and plans and applies
+// them against firewalld.
+package state
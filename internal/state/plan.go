@@ -0,0 +1,326 @@
+//go:build linux
+// +build linux
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/lockout"
+	"lazyfirewall/internal/richrule"
+)
+
+type Action int
+
+const (
+	ActionCreate Action = iota
+	ActionAdd
+	ActionSet
+	ActionRemove
+)
+
+func (a Action) symbol() string {
+	switch a {
+	case ActionCreate, ActionAdd:
+		return "+"
+	case ActionRemove:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// Change is one step of a plan. Object is "zone" or "ipset"; Kind is empty
+// when the object itself is created.
+type Change struct {
+	Action Action
+	Object string
+	Name   string
+	Kind   string
+	Value  string
+	Old    string
+}
+
+func (c Change) String() string {
+	if c.Kind == "" {
+		s := fmt.Sprintf("create %s %s", c.Object, c.Name)
+		if c.Value != "" {
+			s += " (" + c.Value + ")"
+		}
+		return s
+	}
+	switch c.Action {
+	case ActionAdd:
+		return fmt.Sprintf("add %s %s to %s %s", c.Kind, c.Value, c.Object, c.Name)
+	case ActionRemove:
+		return fmt.Sprintf("remove %s %s from %s %s", c.Kind, c.Value, c.Object, c.Name)
+	default:
+		return fmt.Sprintf("set %s %s -> %s on %s %s", c.Kind, c.Old, c.Value, c.Object, c.Name)
+	}
+}
+
+// Lockout returns the lockout check for a change that removes something
+// from a zone; other changes cannot cut off an SSH session.
+func (c Change) Lockout() (lockout.Change, bool) {
+	if c.Object != "zone" || c.Action != ActionRemove {
+		return lockout.Change{}, false
+	}
+	change := lockout.Change{Zone: c.Name, Value: c.Value}
+	switch c.Kind {
+	case "service":
+		change.Kind = lockout.RemoveService
+	case "port":
+		port, err := firewalld.ParsePort(c.Value)
+		if err != nil {
+			return lockout.Change{}, false
+		}
+		change.Kind = lockout.RemovePort
+		change.Port = port
+	case "rich rule":
+		change.Kind = lockout.RemoveRichRule
+	case "source":
+		change.Kind = lockout.RemoveSource
+	case "interface":
+		change.Kind = lockout.RemoveInterface
+	default:
+		return lockout.Change{}, false
+	}
+	return change, true
+}
+
+type Plan struct {
+	Changes []Change
+}
+
+func (p *Plan) Empty() bool {
+	return p == nil || len(p.Changes) == 0
+}
+
+// Counts returns the number of additions, in-place changes and removals.
+func (p *Plan) Counts() (add, change, remove int) {
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate, ActionAdd:
+			add++
+		case ActionSet:
+			change++
+		case ActionRemove:
+			remove++
+		}
+	}
+	return add, change, remove
+}
+
+// Current is a snapshot of the permanent configuration for the objects a
+// State refers to. Missing map entries mean the object does not exist.
+type Current struct {
+	Zones  map[string]*firewalld.Zone
+	IPSets map[string][]string
+}
+
+// Fetch reads the permanent configuration of every zone and ipset in desired.
+func Fetch(client firewalld.Backend, desired *State) (*Current, error) {
+	cur := &Current{
+		Zones:  make(map[string]*firewalld.Zone),
+		IPSets: make(map[string][]string),
+	}
+	if len(desired.Zones) > 0 {
+		names, err := client.ListZones()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range sortedNames(desired.Zones) {
+			if !slices.Contains(names, name) {
+				continue
+			}
+			z, err := client.GetZoneSettings(name, true)
+			if err != nil {
+				if errors.Is(err, firewalld.ErrInvalidZone) {
+					continue
+				}
+				return nil, fmt.Errorf("zone %s: %w", name, err)
+			}
+			cur.Zones[name] = z
+		}
+	}
+	if len(desired.IPSets) > 0 {
+		names, err := client.ListIPSets(true)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range sortedNames(desired.IPSets) {
+			if !slices.Contains(names, name) {
+				continue
+			}
+			entries, err := client.GetIPSetEntries(name, true)
+			if err != nil {
+				return nil, fmt.Errorf("ipset %s: %w", name, err)
+			}
+			if entries == nil {
+				entries = []string{}
+			}
+			cur.IPSets[name] = entries
+		}
+	}
+	return cur, nil
+}
+
+// BuildPlan computes the changes needed to turn cur into desired. IPSets
+// come first so zone sources can reference them; within an object additions
+// precede removals so access is never narrower than in either end state.
+func BuildPlan(desired *State, cur *Current) (*Plan, error) {
+	plan := &Plan{}
+	for _, name := range sortedNames(desired.IPSets) {
+		want := desired.IPSets[name]
+		entries, exists := cur.IPSets[name]
+		if !exists {
+			if want.Type == "" {
+				return nil, fmt.Errorf("ipset %q does not exist and has no type", name)
+			}
+			plan.Changes = append(plan.Changes, Change{Action: ActionCreate, Object: "ipset", Name: name, Value: want.Type})
+		}
+		if want.Entries != nil {
+			plan.Changes = append(plan.Changes, diffList("ipset", name, "entry", entries, *want.Entries)...)
+		}
+	}
+
+	for _, name := range sortedNames(desired.Zones) {
+		want := desired.Zones[name]
+		z, exists := cur.Zones[name]
+		if !exists {
+			plan.Changes = append(plan.Changes, Change{Action: ActionCreate, Object: "zone", Name: name})
+			z = &firewalld.Zone{Name: name}
+		}
+
+		var changes []Change
+		if want.Services != nil {
+			changes = append(changes, diffList("zone", name, "service", z.Services, *want.Services)...)
+		}
+		if want.Ports != nil {
+			have := make([]string, 0, len(z.Ports))
+			for _, p := range z.Ports {
+				have = append(have, p.Port+"/"+p.Protocol)
+			}
+			wantPorts := make([]string, 0, len(*want.Ports))
+			for _, p := range *want.Ports {
+				port, err := firewalld.ParsePort(p)
+				if err != nil {
+					return nil, fmt.Errorf("zone %q: %w", name, err)
+				}
+				wantPorts = append(wantPorts, port.Port+"/"+port.Protocol)
+			}
+			changes = append(changes, diffList("zone", name, "port", have, wantPorts)...)
+		}
+		if want.RichRules != nil {
+			changes = append(changes, diffRichRules("zone", name, z.RichRules, *want.RichRules)...)
+		}
+		if want.Sources != nil {
+			changes = append(changes, diffList("zone", name, "source", z.Sources, *want.Sources)...)
+		}
+		if want.Interfaces != nil {
+			changes = append(changes, diffList("zone", name, "interface", z.Interfaces, *want.Interfaces)...)
+		}
+		if want.Masquerade != nil && *want.Masquerade != z.Masquerade {
+			changes = append(changes, Change{
+				Action: ActionSet, Object: "zone", Name: name, Kind: "masquerade",
+				Value: fmt.Sprint(*want.Masquerade), Old: fmt.Sprint(z.Masquerade),
+			})
+		}
+		slices.SortStableFunc(changes, func(a, b Change) int {
+			return int(a.Action) - int(b.Action)
+		})
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	return plan, nil
+}
+
+func diffList(object, name, kind string, have, want []string) []Change {
+	var changes []Change
+	for _, v := range want {
+		if !slices.Contains(have, v) {
+			changes = append(changes, Change{Action: ActionAdd, Object: object, Name: name, Kind: kind, Value: v})
+		}
+	}
+	for _, v := range have {
+		if !slices.Contains(want, v) {
+			changes = append(changes, Change{Action: ActionRemove, Object: object, Name: name, Kind: kind, Value: v})
+		}
+	}
+	return changes
+}
+
+// diffRichRules is diffList for rich rules. Rules are compared in their
+// canonical form, so the state file may quote or space them differently
+// from firewalld.
+func diffRichRules(object, name string, have, want []string) []Change {
+	haveNorm := normalRichRules(have)
+	wantNorm := normalRichRules(want)
+	var changes []Change
+	for i, v := range want {
+		if !slices.Contains(haveNorm, wantNorm[i]) {
+			changes = append(changes, Change{Action: ActionAdd, Object: object, Name: name, Kind: "rich rule", Value: v})
+		}
+	}
+	for i, v := range have {
+		if !slices.Contains(wantNorm, haveNorm[i]) {
+			changes = append(changes, Change{Action: ActionRemove, Object: object, Name: name, Kind: "rich rule", Value: v})
+		}
+	}
+	return changes
+}
+
+func normalRichRules(rules []string) []string {
+	out := make([]string, len(rules))
+	for i, rule := range rules {
+		out[i] = richrule.Normalize(rule)
+	}
+	return out
+}
+
+// Render writes a terraform-style diff of the plan.
+func (p *Plan) Render(w io.Writer) {
+	if p.Empty() {
+		fmt.Fprintln(w, "No changes. Firewall configuration matches the state file.")
+		return
+	}
+	header := ""
+	for _, c := range p.Changes {
+		key := c.Object + "\x00" + c.Name
+		if key != header {
+			if header != "" {
+				fmt.Fprintln(w)
+			}
+			header = key
+			if c.Kind == "" {
+				line := fmt.Sprintf("+ %s %q", c.Object, c.Name)
+				if c.Value != "" {
+					line += " (" + c.Value + ")"
+				}
+				fmt.Fprintln(w, line)
+				continue
+			}
+			fmt.Fprintf(w, "~ %s %q\n", c.Object, c.Name)
+		}
+		if c.Action == ActionSet {
+			fmt.Fprintf(w, "    ~ %s: %s -> %s\n", c.Kind, c.Old, c.Value)
+			continue
+		}
+		fmt.Fprintf(w, "    %s %s %s\n", c.Action.symbol(), c.Kind, c.Value)
+	}
+	add, change, remove := p.Counts()
+	fmt.Fprintf(w, "\nPlan: %d to add, %d to change, %d to remove.\n", add, change, remove)
+}
+
+func (p *Plan) zones() []string {
+	var zones []string
+	for _, c := range p.Changes {
+		if c.Object == "zone" && !slices.Contains(zones, c.Name) {
+			zones = append(zones, c.Name)
+		}
+	}
+	return zones
+}
@@ -0,0 +1,131 @@
+//go:build linux
+// +build linux
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/richrule"
+	"lazyfirewall/internal/validation"
+
+	"gopkg.in/yaml.v3"
+)
+
+const maxStateFileSize = 1 << 20
+
+// State is the desired configuration. Omitted fields are left unmanaged; a
+// present field (even an empty list) is enforced exactly.
+type State struct {
+	Zones  map[string]ZoneState  `yaml:"zones"`
+	IPSets map[string]IPSetState `yaml:"ipsets"`
+}
+
+type ZoneState struct {
+	Services   *[]string `yaml:"services"`
+	Ports      *[]string `yaml:"ports"`
+	RichRules  *[]string `yaml:"rich_rules"`
+	Sources    *[]string `yaml:"sources"`
+	Interfaces *[]string `yaml:"interfaces"`
+	Masquerade *bool     `yaml:"masquerade"`
+}
+
+type IPSetState struct {
+	Type    string    `yaml:"type"`
+	Entries *[]string `yaml:"entries"`
+}
+
+func LoadFile(path string) (*State, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxStateFileSize {
+		return nil, fmt.Errorf("file too large: %d bytes (max %d)", info.Size(), maxStateFileSize)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*State, error) {
+	var s State
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("state file is empty")
+		}
+		return nil, fmt.Errorf("invalid state file: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *State) Validate() error {
+	if len(s.Zones) == 0 && len(s.IPSets) == 0 {
+		return fmt.Errorf("state file defines no zones or ipsets")
+	}
+	for _, name := range sortedNames(s.Zones) {
+		if err := validation.IsValidZoneName(name); err != nil {
+			return fmt.Errorf("zone %q: %w", name, err)
+		}
+		z := s.Zones[name]
+		if z.Ports != nil {
+			for _, p := range *z.Ports {
+				if _, err := firewalld.ParsePort(p); err != nil {
+					return fmt.Errorf("zone %q: %w", name, err)
+				}
+			}
+		}
+		if z.RichRules != nil {
+			for _, r := range *z.RichRules {
+				if _, err := richrule.Parse(r); err != nil {
+					return fmt.Errorf("zone %q: rich rule %q: %w", name, r, err)
+				}
+			}
+		}
+		if z.Sources != nil {
+			for _, src := range *z.Sources {
+				if !isAddress(src) && !strings.HasPrefix(src, "ipset:") {
+					return fmt.Errorf("zone %q: invalid source: %s", name, src)
+				}
+			}
+		}
+	}
+	for _, name := range sortedNames(s.IPSets) {
+		if err := validation.IsValidZoneName(name); err != nil {
+			return fmt.Errorf("ipset %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func isAddress(value string) bool {
+	if net.ParseIP(value) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
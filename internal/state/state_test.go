@@ -0,0 +1,283 @@
+//go:build linux
+// +build linux
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/lockout"
+)
+
+const sampleState = `
+zones:
+  public:
+    services: [ssh, https]
+    ports: [443/TCP, 6000-6010/udp]
+    masquerade: true
+    sources: []
+  dmz:
+    interfaces: [eth1]
+ipsets:
+  blocklist:
+    type: hash:ip
+    entries: [192.0.2.1]
+`
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(sampleState))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	public := s.Zones["public"]
+	if public.Services == nil || len(*public.Services) != 2 {
+		t.Fatalf("public services = %v, want 2 entries", public.Services)
+	}
+	if public.Sources == nil || len(*public.Sources) != 0 {
+		t.Fatalf("public sources = %v, want managed empty list", public.Sources)
+	}
+	if public.RichRules != nil {
+		t.Fatalf("public rich rules = %v, want unmanaged (nil)", public.RichRules)
+	}
+	if public.Masquerade == nil || !*public.Masquerade {
+		t.Fatalf("public masquerade = %v, want true", public.Masquerade)
+	}
+	if s.IPSets["blocklist"].Type != "hash:ip" {
+		t.Fatalf("blocklist type = %q, want hash:ip", s.IPSets["blocklist"].Type)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "no objects", input: "zones: {}\n"},
+		{name: "unknown key", input: "zones:\n  public:\n    servces: [ssh]\n"},
+		{name: "bad zone name", input: "zones:\n  ../bad:\n    services: [ssh]\n"},
+		{name: "bad port", input: "zones:\n  public:\n    ports: [80]\n"},
+		{name: "bad source", input: "zones:\n  public:\n    sources: [nope]\n"},
+		{name: "bad rich rule", input: "zones:\n  public:\n    rich_rules: [accept]\n"},
+		{name: "malformed rich rule", input: "zones:\n  public:\n    rich_rules: ['rule service name=\"ssh\" acept']\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.input)); err == nil {
+				t.Fatalf("Parse(%q) expected error", tt.input)
+			}
+		})
+	}
+}
+
+func TestBuildPlan(t *testing.T) {
+	desired, err := Parse([]byte(sampleState))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	current := &Current{
+		Zones: map[string]*firewalld.Zone{
+			"public": {
+				Name:     "public",
+				Services: []string{"ssh", "dhcpv6-client"},
+				Ports:    []firewalld.Port{{Port: "443", Protocol: "tcp"}},
+				Sources:  []string{"10.0.0.0/8"},
+			},
+		},
+		IPSets: map[string][]string{},
+	}
+
+	plan, err := BuildPlan(desired, current)
+	if err != nil {
+		t.Fatalf("BuildPlan() error = %v", err)
+	}
+	var got []string
+	for _, c := range plan.Changes {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"create ipset blocklist (hash:ip)",
+		"add entry 192.0.2.1 to ipset blocklist",
+		"create zone dmz",
+		"add interface eth1 to zone dmz",
+		"add service https to zone public",
+		"add port 6000-6010/udp to zone public",
+		"set masquerade false -> true on zone public",
+		"remove service dhcpv6-client from zone public",
+		"remove source 10.0.0.0/8 from zone public",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("BuildPlan() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if add, change, remove := plan.Counts(); add != 6 || change != 1 || remove != 2 {
+		t.Fatalf("Counts() = %d, %d, %d, want 6, 1, 2", add, change, remove)
+	}
+	if zones := plan.zones(); strings.Join(zones, ",") != "dmz,public" {
+		t.Fatalf("zones() = %v, want [dmz public]", zones)
+	}
+}
+
+func TestBuildPlanNoChanges(t *testing.T) {
+	desired, err := Parse([]byte("zones:\n  public:\n    services: [ssh]\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	current := &Current{Zones: map[string]*firewalld.Zone{"public": {Name: "public", Services: []string{"ssh"}, Ports: []firewalld.Port{{Port: "22", Protocol: "tcp"}}}}}
+	plan, err := BuildPlan(desired, current)
+	if err != nil {
+		t.Fatalf("BuildPlan() error = %v", err)
+	}
+	if !plan.Empty() {
+		t.Fatalf("plan = %+v, want empty (unmanaged fields ignored)", plan.Changes)
+	}
+	var b bytes.Buffer
+	plan.Render(&b)
+	if !strings.HasPrefix(b.String(), "No changes.") {
+		t.Fatalf("Render() = %q", b.String())
+	}
+}
+
+func TestBuildPlanRichRuleSpelling(t *testing.T) {
+	desired, err := Parse([]byte("zones:\n  public:\n    rich_rules:\n      - rule family=ipv4 source address=10.0.0.0/8 service name=ssh accept\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	current := &Current{Zones: map[string]*firewalld.Zone{"public": {
+		Name:      "public",
+		RichRules: []string{`rule family="ipv4" source address="10.0.0.0/8" service name="ssh" accept`},
+	}}}
+	plan, err := BuildPlan(desired, current)
+	if err != nil {
+		t.Fatalf("BuildPlan() error = %v", err)
+	}
+	if !plan.Empty() {
+		t.Fatalf("plan = %+v, want empty for the same rule spelled differently", plan.Changes)
+	}
+}
+
+func TestBuildPlanIPSetWithoutType(t *testing.T) {
+	desired, err := Parse([]byte("ipsets:\n  allow:\n    entries: [192.0.2.1]\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, err := BuildPlan(desired, &Current{}); err == nil {
+		t.Fatalf("expected error for missing ipset type")
+	}
+}
+
+func TestRender(t *testing.T) {
+	plan := &Plan{Changes: []Change{
+		{Action: ActionCreate, Object: "zone", Name: "dmz"},
+		{Action: ActionAdd, Object: "zone", Name: "dmz", Kind: "service", Value: "http"},
+		{Action: ActionSet, Object: "zone", Name: "public", Kind: "masquerade", Value: "true", Old: "false"},
+		{Action: ActionRemove, Object: "zone", Name: "public", Kind: "port", Value: "8080/tcp"},
+	}}
+	var b bytes.Buffer
+	plan.Render(&b)
+	want := `+ zone "dmz"
+    + service http
+
+~ zone "public"
+    ~ masquerade: false -> true
+    - port 8080/tcp
+
+Plan: 2 to add, 1 to change, 1 to remove.
+`
+	if b.String() != want {
+		t.Fatalf("Render() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestRunStepsRollsBack(t *testing.T) {
+	var log []string
+	mk := func(name string, fail bool) step {
+		return step{
+			change: Change{Action: ActionAdd, Object: "zone", Name: "public", Kind: "service", Value: name},
+			do: func() error {
+				if fail {
+					return errors.New("boom")
+				}
+				log = append(log, "do "+name)
+				return nil
+			},
+			undo: func() error {
+				log = append(log, "undo "+name)
+				return nil
+			},
+		}
+	}
+	steps := []step{mk("a", false), mk("b", false), mk("c", true), mk("d", false)}
+
+	var out bytes.Buffer
+	applied, err := runSteps(steps, &out)
+	if err == nil || applied != 2 {
+		t.Fatalf("runSteps() = %d, %v, want 2 and an error", applied, err)
+	}
+	if err := undoSteps(steps[:applied], &out); err != nil {
+		t.Fatalf("undoSteps() error = %v", err)
+	}
+	if got := strings.Join(log, ","); got != "do a,do b,undo b,undo a" {
+		t.Fatalf("log = %s", got)
+	}
+}
+
+func TestBuildStepsUnsupportedChange(t *testing.T) {
+	plan := &Plan{Changes: []Change{{Action: ActionAdd, Object: "zone", Name: "public", Kind: "bogus", Value: "x"}}}
+	if _, err := buildSteps(&firewalld.Client{}, plan); err == nil {
+		t.Fatalf("expected error for unsupported change")
+	}
+}
+
+func TestChangeLockout(t *testing.T) {
+	tests := []struct {
+		name   string
+		change Change
+		want   lockout.Change
+		wantOK bool
+	}{
+		{
+			name:   "remove service",
+			change: Change{Action: ActionRemove, Object: "zone", Name: "public", Kind: "service", Value: "ssh"},
+			want:   lockout.Change{Kind: lockout.RemoveService, Zone: "public", Value: "ssh"},
+			wantOK: true,
+		},
+		{
+			name:   "remove port",
+			change: Change{Action: ActionRemove, Object: "zone", Name: "public", Kind: "port", Value: "22/tcp"},
+			want:   lockout.Change{Kind: lockout.RemovePort, Zone: "public", Value: "22/tcp", Port: firewalld.Port{Port: "22", Protocol: "tcp"}},
+			wantOK: true,
+		},
+		{
+			name:   "remove source",
+			change: Change{Action: ActionRemove, Object: "zone", Name: "trusted", Kind: "source", Value: "10.0.0.0/8"},
+			want:   lockout.Change{Kind: lockout.RemoveSource, Zone: "trusted", Value: "10.0.0.0/8"},
+			wantOK: true,
+		},
+		{
+			name:   "add service",
+			change: Change{Action: ActionAdd, Object: "zone", Name: "public", Kind: "service", Value: "ssh"},
+		},
+		{
+			name:   "remove masquerade",
+			change: Change{Action: ActionSet, Object: "zone", Name: "public", Kind: "masquerade", Value: "false"},
+		},
+		{
+			name:   "ipset entry",
+			change: Change{Action: ActionRemove, Object: "ipset", Name: "blocklist", Kind: "entry", Value: "192.0.2.10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.change.Lockout()
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("Lockout() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+// Package templates defines zone templates: sets of services, ports, rich
+// rules, sources, ipsets, masquerade and a target that are added to a zone
+// in one step. Templates come built in, from the config file and from
+// template files, and may take ${PARAMETERS} filled in when applied.
+package templates
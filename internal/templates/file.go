@@ -0,0 +1,64 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Dir returns the template files directory next to the config file at
+// configPath.
+func Dir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "templates")
+}
+
+// Parse reads a template file: the keys of one template at the top level.
+// The name defaults to the file name without .toml.
+func Parse(path, raw string) (Template, error) {
+	var t Template
+	md, err := toml.Decode(raw, &t)
+	if err != nil {
+		// Drop the "toml: " prefix; the error starts with the line.
+		return Template{}, errors.New(strings.TrimPrefix(err.Error(), "toml: "))
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return Template{}, fmt.Errorf("unknown key %q", undecoded[0].String())
+	}
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), ".toml")
+	}
+	t.Origin = path
+	if err := t.Validate(); err != nil {
+		return Template{}, err
+	}
+	return t, nil
+}
+
+// LoadDir reads the *.toml template files in dir, in name order. Files
+// that fail to parse are skipped and reported; a missing dir is empty.
+func LoadDir(dir string) ([]Template, []error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.toml"))
+	if err != nil {
+		return nil, []error{err}
+	}
+	var list []Template
+	var errs []error
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		t, err := Parse(path, string(data))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("template %s: %w", path, err))
+			continue
+		}
+		list = append(list, t)
+	}
+	return list, errs
+}
@@ -0,0 +1,250 @@
+package templates
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"lazyfirewall/internal/richrule"
+)
+
+// Param is a value asked for when a template is applied and substituted
+// for ${Name} in its fields.
+type Param struct {
+	Name    string `toml:"name"`
+	Prompt  string `toml:"prompt"`
+	Default string `toml:"default"`
+}
+
+// Template is a set of zone elements to add. Ports are "80/tcp" or
+// "6000-6010/udp"; sources are addresses, networks or MAC addresses; IPSets
+// are added as ipset:NAME sources. Target is only applied in permanent
+// mode, as firewalld has no runtime zone target.
+type Template struct {
+	Name        string   `toml:"name"`
+	Description string   `toml:"description"`
+	Params      []Param  `toml:"params"`
+	Services    []string `toml:"services"`
+	Ports       []string `toml:"ports"`
+	RichRules   []string `toml:"rich_rules"`
+	Sources     []string `toml:"sources"`
+	IPSets      []string `toml:"ipsets"`
+	Masquerade  bool     `toml:"masquerade"`
+	Target      string   `toml:"target"`
+
+	// Origin is where the template was defined: "built-in", "config" or
+	// the path of its template file.
+	Origin string `toml:"-"`
+}
+
+var builtins = []Template{
+	{
+		Name:        "Web Server",
+		Description: "Adds http and https services",
+		Services:    []string{"http", "https"},
+	},
+	{
+		Name:        "Database Server",
+		Description: "Adds postgresql and mysql services",
+		Services:    []string{"postgresql", "mysql"},
+	},
+	{
+		Name:        "SSH Only",
+		Description: "Adds ssh service (does not remove others)",
+		Services:    []string{"ssh"},
+	},
+	{
+		Name:        "Workstation",
+		Description: "Adds common desktop services",
+		Services:    []string{"ssh", "mdns", "samba-client", "ipp-client", "dhcpv6-client"},
+	},
+}
+
+// Builtin returns the templates shipped with lazyfirewall.
+func Builtin() []Template {
+	list := make([]Template, len(builtins))
+	for i, t := range builtins {
+		t.Origin = "built-in"
+		list[i] = t
+	}
+	return list
+}
+
+// Merge joins template lists; a template replaces an earlier one of the
+// same name in place, new names are appended.
+func Merge(lists ...[]Template) []Template {
+	var merged []Template
+	for _, list := range lists {
+		for _, t := range list {
+			i := slices.IndexFunc(merged, func(o Template) bool { return o.Name == t.Name })
+			if i >= 0 {
+				merged[i] = t
+				continue
+			}
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
+
+var (
+	refRe     = regexp.MustCompile(`\$\{([^}]*)\}`)
+	paramRe   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	portRe    = regexp.MustCompile(`^(\d+)(?:-(\d+))?/(tcp|udp|sctp|dccp)$`)
+	serviceRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.+-]*$`)
+	ipsetRe   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]*$`)
+)
+
+// field is one templated string of a template, e.g. "ports[0]".
+type field struct {
+	name  string
+	value *string
+}
+
+// fields returns every templated string of t.
+func (t *Template) fields() []field {
+	var out []field
+	add := func(name string, list []string) {
+		for i := range list {
+			out = append(out, field{fmt.Sprintf("%s[%d]", name, i), &list[i]})
+		}
+	}
+	add("services", t.Services)
+	add("ports", t.Ports)
+	add("rich_rules", t.RichRules)
+	add("sources", t.Sources)
+	add("ipsets", t.IPSets)
+	if t.Target != "" {
+		out = append(out, field{"target", &t.Target})
+	}
+	return out
+}
+
+// Parameters returns the declared parameters followed by ones only
+// referenced as ${NAME}, in order of first use, which are prompted for by
+// name.
+func (t Template) Parameters() []Param {
+	params := slices.Clone(t.Params)
+	for _, f := range t.fields() {
+		for _, m := range refRe.FindAllStringSubmatch(*f.value, -1) {
+			name := m[1]
+			if !slices.ContainsFunc(params, func(p Param) bool { return p.Name == name }) {
+				params = append(params, Param{Name: name})
+			}
+		}
+	}
+	return params
+}
+
+// Validate checks the template's structure, and the values of every field
+// that does not depend on a parameter.
+func (t Template) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("template has no name")
+	}
+	seen := make(map[string]bool)
+	for _, p := range t.Params {
+		if !paramRe.MatchString(p.Name) {
+			return fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("parameter %s is declared twice", p.Name)
+		}
+		seen[p.Name] = true
+	}
+	for _, f := range t.fields() {
+		refs := refRe.FindAllStringSubmatch(*f.value, -1)
+		for _, m := range refs {
+			if !paramRe.MatchString(m[1]) {
+				return fmt.Errorf("%s: invalid parameter reference %q", f.name, m[0])
+			}
+		}
+		if len(refs) > 0 {
+			continue
+		}
+		if err := checkField(f.name, *f.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Expand substitutes values for the template's parameters and checks the
+// result. Every parameter needs a non-empty value.
+func (t Template) Expand(values map[string]string) (Template, error) {
+	for _, p := range t.Parameters() {
+		if strings.TrimSpace(values[p.Name]) == "" {
+			return Template{}, fmt.Errorf("parameter %s has no value", p.Name)
+		}
+	}
+	out := t
+	out.Params = nil
+	out.Services = slices.Clone(t.Services)
+	out.Ports = slices.Clone(t.Ports)
+	out.RichRules = slices.Clone(t.RichRules)
+	out.Sources = slices.Clone(t.Sources)
+	out.IPSets = slices.Clone(t.IPSets)
+	for _, f := range out.fields() {
+		*f.value = refRe.ReplaceAllStringFunc(*f.value, func(ref string) string {
+			return strings.TrimSpace(values[ref[2:len(ref)-1]])
+		})
+		if err := checkField(f.name, *f.value); err != nil {
+			return Template{}, err
+		}
+	}
+	return out, nil
+}
+
+// checkField validates one value of the field called name ("ports[0]").
+func checkField(name, value string) error {
+	field, _, _ := strings.Cut(name, "[")
+	var ok bool
+	switch field {
+	case "services":
+		ok = serviceRe.MatchString(value)
+	case "ports":
+		ok = validPort(value)
+	case "rich_rules":
+		if _, err := richrule.Parse(value); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		ok = true
+	case "sources":
+		ok = validSource(value)
+	case "ipsets":
+		ok = ipsetRe.MatchString(value)
+	case "target":
+		ok = slices.Contains([]string{"DEFAULT", "ACCEPT", "DROP", "REJECT", "%%REJECT%%"}, strings.ToUpper(value))
+	}
+	if !ok {
+		return fmt.Errorf("%s: invalid value %q", name, value)
+	}
+	return nil
+}
+
+func validPort(value string) bool {
+	m := portRe.FindStringSubmatch(value)
+	if m == nil {
+		return false
+	}
+	from, _ := strconv.Atoi(m[1])
+	to := from
+	if m[2] != "" {
+		to, _ = strconv.Atoi(m[2])
+	}
+	return from >= 1 && to <= 65535 && from <= to
+}
+
+func validSource(value string) bool {
+	if net.ParseIP(value) != nil {
+		return true
+	}
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return true
+	}
+	_, err := net.ParseMAC(value)
+	return err == nil
+}
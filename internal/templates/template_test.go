@@ -0,0 +1,110 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	tpl := Template{
+		Name:      "Admin",
+		Params:    []Param{{Name: "ADMIN_NET", Prompt: "Admin network", Default: "10.0.0.0/8"}},
+		Services:  []string{"ssh"},
+		Ports:     []string{"${PORT}/tcp"},
+		RichRules: []string{`rule family="ipv4" source address="${ADMIN_NET}" service name="ssh" accept`},
+		Sources:   []string{"${ADMIN_NET}"},
+		IPSets:    []string{"admins"},
+		Target:    "drop",
+	}
+	if err := tpl.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	var names []string
+	for _, p := range tpl.Parameters() {
+		names = append(names, p.Name)
+	}
+	if !slices.Equal(names, []string{"ADMIN_NET", "PORT"}) {
+		t.Fatalf("Parameters() = %v", names)
+	}
+
+	if _, err := tpl.Expand(map[string]string{"ADMIN_NET": "10.1.0.0/16"}); err == nil || !strings.Contains(err.Error(), "PORT") {
+		t.Fatalf("Expand() without PORT error = %v", err)
+	}
+	got, err := tpl.Expand(map[string]string{"ADMIN_NET": "10.1.0.0/16", "PORT": "2222"})
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if got.Ports[0] != "2222/tcp" || got.Sources[0] != "10.1.0.0/16" || !strings.Contains(got.RichRules[0], `"10.1.0.0/16"`) {
+		t.Fatalf("Expand() = %+v", got)
+	}
+	if tpl.Sources[0] != "${ADMIN_NET}" {
+		t.Fatalf("Expand() changed the template")
+	}
+	if _, err := tpl.Expand(map[string]string{"ADMIN_NET": "not-a-net", "PORT": "22"}); err == nil || !strings.Contains(err.Error(), "rich_rules[0]") {
+		t.Fatalf("Expand() with a bad network error = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for want, tpl := range map[string]Template{
+		"no name":                     {},
+		"invalid parameter name":      {Name: "x", Params: []Param{{Name: "1X"}}},
+		"declared twice":              {Name: "x", Params: []Param{{Name: "X"}, {Name: "X"}}},
+		"invalid parameter reference": {Name: "x", Sources: []string{"${bad name}"}},
+		`ports[1]: invalid value`:     {Name: "x", Ports: []string{"80/tcp", "70000/tcp"}},
+		`sources[0]: invalid value`:   {Name: "x", Sources: []string{"example.com"}},
+		`target: invalid value`:       {Name: "x", Target: "allow"},
+		`rich_rules[0]`:               {Name: "x", RichRules: []string{"rule accept please"}},
+	} {
+		if err := tpl.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Validate(%+v) error = %v, want %q", tpl, err, want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	user := []Template{
+		{Name: "SSH Only", Services: []string{"ssh"}, Sources: []string{"${ADMIN_NET}"}},
+		{Name: "Mail"},
+	}
+	merged := Merge(Builtin(), user)
+	if len(merged) != len(builtins)+1 || merged[2].Sources == nil || merged[len(merged)-1].Name != "Mail" {
+		t.Fatalf("Merge() = %+v", merged)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"mail.toml": `
+description = "SMTP and IMAP"
+services = ["smtp", "imaps"]
+masquerade = true
+[[params]]
+name = "RELAY"
+`,
+		"broken.toml": `services = "smtp"`,
+		"notes.txt":   `ignored`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, errs := LoadDir(dir)
+	if len(list) != 1 || list[0].Name != "mail" || !list[0].Masquerade || list[0].Params[0].Name != "RELAY" {
+		t.Fatalf("LoadDir() = %+v", list)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken.toml") {
+		t.Fatalf("LoadDir() errors = %v", errs)
+	}
+	if list, errs := LoadDir(filepath.Join(dir, "missing")); len(list) != 0 || len(errs) != 0 {
+		t.Fatalf("missing dir should be empty, got %v, %v", list, errs)
+	}
+	if got := Dir("/home/u/.config/lazyfirewall/config.toml"); got != "/home/u/.config/lazyfirewall/templates" {
+		t.Fatalf("Dir() = %q", got)
+	}
+}
@@ -0,0 +1,3 @@
+// Package theme defines the color palettes of the terminal interface: the
+// built-in themes and user theme files.
+package theme
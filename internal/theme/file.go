@@ -0,0 +1,102 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var nameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidName reports whether name can name a theme: lower-case letters,
+// digits, '-' and '_', so it never escapes the themes directory.
+func ValidName(name string) bool {
+	return nameRe.MatchString(name)
+}
+
+// Dir returns the user themes directory next to the config file at
+// configPath.
+func Dir(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "themes")
+}
+
+// Load returns the theme called name: dir/name.toml when it exists,
+// otherwise the built-in theme of that name.
+func Load(name, dir string) (Theme, error) {
+	if !ValidName(name) {
+		return Theme{}, fmt.Errorf("invalid theme name %q", name)
+	}
+	if dir != "" {
+		path := filepath.Join(dir, name+".toml")
+		data, err := os.ReadFile(path)
+		if err == nil {
+			t, err := Parse(name, string(data))
+			if err != nil {
+				return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+			}
+			return t, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return Theme{}, err
+		}
+	}
+	t, ok := Builtin(name)
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (built-in: %s)", name, strings.Join(Names(), ", "))
+	}
+	return t, nil
+}
+
+// Parse reads a theme file: a TOML document of key = "color" pairs, see
+// FromValues.
+//
+//	base = "solarized-light"
+//	added = "#008700"
+func Parse(name, raw string) (Theme, error) {
+	var values map[string]string
+	if _, err := toml.Decode(raw, &values); err != nil {
+		// Drop the "toml: " prefix; the error starts with the line.
+		return Theme{}, errors.New(strings.TrimPrefix(err.Error(), "toml: "))
+	}
+	return FromValues(name, values)
+}
+
+// FromValues builds a theme from color keys, as found in a theme file or
+// a [themes.<name>] table of the config file. An optional base key picks
+// the built-in theme to start from (default otherwise), so only the
+// colors that change need listing.
+func FromValues(name string, values map[string]string) (Theme, error) {
+	t, _ := Builtin(Default)
+	if base, ok := values["base"]; ok {
+		if t, ok = Builtin(base); !ok {
+			return Theme{}, fmt.Errorf("unknown base theme %q", base)
+		}
+	}
+	fields := t.colors()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if key == "base" {
+			continue
+		}
+		field, ok := fields[key]
+		if !ok {
+			return Theme{}, fmt.Errorf("unknown color %q", key)
+		}
+		if !ValidColor(values[key]) {
+			return Theme{}, fmt.Errorf("%s: invalid color %q (use #rrggbb, #rgb or 0-255)", key, values[key])
+		}
+		*field = values[key]
+	}
+	t.Name = name
+	return t, nil
+}
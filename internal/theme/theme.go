@@ -0,0 +1,136 @@
+package theme
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// Default is the theme used when none is configured.
+const Default = "default"
+
+// Theme is a palette. Colors are "#rgb", "#rrggbb" or an ANSI color number
+// (0-255); an empty color leaves the terminal's own. A theme without an
+// Accent shows selections in reverse video and one without Muted
+// underlines unfocused selections, so monochrome themes stay usable.
+type Theme struct {
+	Name string
+
+	// Accent is the background of selections, the active tab and the
+	// status bar; AccentText is the text on it.
+	Accent     string
+	AccentText string
+	// Muted is the background of unfocused selections and inactive tabs.
+	Muted     string
+	MutedText string
+	// StatusText and StatusKey color hints and key names in the status bar.
+	StatusText string
+	StatusKey  string
+
+	Dim   string
+	Error string
+	Warn  string
+	Input string
+	Match string
+
+	// Added and Removed color the split view diff: entries only in
+	// runtime and only in permanent configuration.
+	Added   string
+	Removed string
+
+	PanicText string
+	PanicBg   string
+}
+
+var builtins = map[string]Theme{
+	"default": {
+		Accent: "#88c0d0", AccentText: "#2e3440",
+		Muted: "#4c566a", MutedText: "#d8dee9",
+		StatusText: "#3b4252", StatusKey: "#eceff4",
+		Dim: "240", Error: "1", Warn: "214", Input: "229", Match: "226",
+		Added: "2", Removed: "1",
+		PanicText: "15", PanicBg: "1",
+	},
+	"nord": {
+		Accent: "#88c0d0", AccentText: "#2e3440",
+		Muted: "#434c5e", MutedText: "#e5e9f0",
+		StatusText: "#3b4252", StatusKey: "#eceff4",
+		Dim: "#616e88", Error: "#bf616a", Warn: "#d08770", Input: "#ebcb8b", Match: "#ebcb8b",
+		Added: "#a3be8c", Removed: "#bf616a",
+		PanicText: "#eceff4", PanicBg: "#bf616a",
+	},
+	"solarized-dark": {
+		Accent: "#268bd2", AccentText: "#fdf6e3",
+		Muted: "#073642", MutedText: "#93a1a1",
+		StatusText: "#eee8d5", StatusKey: "#fdf6e3",
+		Dim: "#586e75", Error: "#dc322f", Warn: "#cb4b16", Input: "#b58900", Match: "#b58900",
+		Added: "#859900", Removed: "#dc322f",
+		PanicText: "#fdf6e3", PanicBg: "#dc322f",
+	},
+	"solarized-light": {
+		Accent: "#268bd2", AccentText: "#fdf6e3",
+		Muted: "#eee8d5", MutedText: "#586e75",
+		StatusText: "#eee8d5", StatusKey: "#fdf6e3",
+		Dim: "#93a1a1", Error: "#dc322f", Warn: "#cb4b16", Input: "#6c71c4", Match: "#d33682",
+		Added: "#859900", Removed: "#dc322f",
+		PanicText: "#fdf6e3", PanicBg: "#dc322f",
+	},
+	"high-contrast": {
+		Accent: "11", AccentText: "0",
+		Muted: "15", MutedText: "0",
+		StatusText: "0", StatusKey: "0",
+		Dim: "7", Error: "9", Warn: "11", Input: "15", Match: "14",
+		Added: "10", Removed: "9",
+		PanicText: "15", PanicBg: "1",
+	},
+	"monochrome": {},
+}
+
+// Names returns the built-in theme names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Builtin returns the built-in theme called name.
+func Builtin(name string) (Theme, bool) {
+	t, ok := builtins[name]
+	t.Name = name
+	return t, ok
+}
+
+// colors maps theme file keys to the colors they set.
+func (t *Theme) colors() map[string]*string {
+	return map[string]*string{
+		"accent":      &t.Accent,
+		"accent_text": &t.AccentText,
+		"muted":       &t.Muted,
+		"muted_text":  &t.MutedText,
+		"status_text": &t.StatusText,
+		"status_key":  &t.StatusKey,
+		"dim":         &t.Dim,
+		"error":       &t.Error,
+		"warn":        &t.Warn,
+		"input":       &t.Input,
+		"match":       &t.Match,
+		"added":       &t.Added,
+		"removed":     &t.Removed,
+		"panic_text":  &t.PanicText,
+		"panic_bg":    &t.PanicBg,
+	}
+}
+
+var hexColorRe = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ValidColor reports whether c is a color a theme accepts.
+func ValidColor(c string) bool {
+	if c == "" || hexColorRe.MatchString(c) {
+		return true
+	}
+	n, err := strconv.Atoi(c)
+	return err == nil && n >= 0 && n <= 255 && strconv.Itoa(n) == c
+}
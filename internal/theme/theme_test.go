@@ -0,0 +1,77 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinThemesUseValidColors(t *testing.T) {
+	for _, name := range Names() {
+		th, ok := Builtin(name)
+		if !ok || th.Name != name {
+			t.Fatalf("Builtin(%q) = %+v, %v", name, th, ok)
+		}
+		for key, c := range th.colors() {
+			if !ValidColor(*c) {
+				t.Fatalf("%s.%s = %q is not a valid color", name, key, *c)
+			}
+		}
+	}
+	if _, ok := Builtin("nope"); ok {
+		t.Fatalf("unknown built-in theme should not be found")
+	}
+}
+
+func TestParse(t *testing.T) {
+	th, err := Parse("projector", `# bright diff colors
+base = "solarized-light"
+added = "#008700"   # green
+removed = "160"
+`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	light, _ := Builtin("solarized-light")
+	if th.Name != "projector" || th.Added != "#008700" || th.Removed != "160" || th.Accent != light.Accent {
+		t.Fatalf("Parse() = %+v", th)
+	}
+
+	for raw, want := range map[string]string{
+		`accent = "blue"`:     "invalid color",
+		`accent = "256"`:      "invalid color",
+		`border = "#fff"`:     "unknown color",
+		`base = "missing"`:    "unknown base theme",
+		`accent = #fff`:       "line 1",
+		`accent = 5`:          "incompatible types",
+		"accent = \"1\"\ndim": "expected key separator",
+	} {
+		if _, err := Parse("x", raw); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("Parse(%q) error = %v, want %q", raw, err, want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "nord.toml"), []byte(`match = "#ff0000"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	th, err := Load("nord", dir)
+	if err != nil || th.Match != "#ff0000" {
+		t.Fatalf("user file should shadow the built-in theme, got %+v, %v", th, err)
+	}
+	if th, err := Load("high-contrast", dir); err != nil || th.Accent != "11" {
+		t.Fatalf("Load(high-contrast) = %+v, %v", th, err)
+	}
+	if _, err := Load("missing", dir); err == nil || !strings.Contains(err.Error(), "monochrome") {
+		t.Fatalf("unknown theme error should list built-in themes, got %v", err)
+	}
+	if _, err := Load("../etc/passwd", dir); err == nil {
+		t.Fatalf("theme names with path separators should be rejected")
+	}
+	if got := Dir("/home/u/.config/lazyfirewall/config.toml"); got != "/home/u/.config/lazyfirewall/themes" {
+		t.Fatalf("Dir() = %q", got)
+	}
+}
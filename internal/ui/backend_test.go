@@ -0,0 +1,63 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+// fakeBackend implements the methods a test needs; anything else panics on
+// the nil embedded interface.
+type fakeBackend struct {
+	firewalld.Backend
+	readOnly  bool
+	zones     []string
+	permanent map[string][]string
+	runtime   map[string][]string
+}
+
+func (f *fakeBackend) ReadOnly() bool { return f.readOnly }
+
+func (f *fakeBackend) ListZones() ([]string, error) { return f.zones, nil }
+
+func (f *fakeBackend) AddServicePermanent(zone, service string) error {
+	f.permanent[zone] = append(f.permanent[zone], service)
+	return nil
+}
+
+func (f *fakeBackend) AddServiceRuntime(zone, service string) error {
+	f.runtime[zone] = append(f.runtime[zone], service)
+	return nil
+}
+
+func TestModelUsesBackend(t *testing.T) {
+	fake := &fakeBackend{
+		readOnly:  true,
+		zones:     []string{"public", "work"},
+		permanent: map[string][]string{},
+		runtime:   map[string][]string{},
+	}
+	m := NewModel(fake, Options{})
+	if !m.readOnly {
+		t.Fatalf("NewModel() readOnly = false, want backend's read-only state")
+	}
+
+	msg, ok := fetchZonesCmd(fake)().(zonesMsg)
+	if !ok || msg.err != nil || !slices.Equal(msg.zones, fake.zones) {
+		t.Fatalf("fetchZonesCmd() = %#v, want zones %v", msg, fake.zones)
+	}
+
+	for _, permanent := range []bool{false, true} {
+		res, ok := addServiceCmd(fake, "public", "http", permanent, nil, recordNone, false)().(mutationMsg)
+		if !ok || res.err != nil || res.zone != "public" {
+			t.Fatalf("addServiceCmd(permanent=%v) = %#v", permanent, res)
+		}
+	}
+	if !slices.Equal(fake.runtime["public"], []string{"http"}) || !slices.Equal(fake.permanent["public"], []string{"http"}) {
+		t.Fatalf("backend runtime = %v, permanent = %v", fake.runtime, fake.permanent)
+	}
+}
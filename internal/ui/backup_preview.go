@@ -18,7 +18,7 @@ func buildBackupPreview(path string, current *firewalld.Zone) (string, error) {
 	}
 
 	lines := []string{
-		fmt.Sprintf("Backup contains: services %d, ports %d, interfaces %d, sources %d", len(backupZone.Services), len(backupZone.Ports), len(backupZone.Interfaces), len(backupZone.Sources)),
+		fmt.Sprintf("Backup contains: services %d, ports %d, interfaces %d, sources %d, rich rules %d", len(backupZone.Services), len(backupZone.Ports), len(backupZone.Interfaces), len(backupZone.Sources), len(backupZone.RichRules)),
 	}
 
 	if current == nil {
@@ -37,6 +37,9 @@ func buildBackupPreview(path string, current *firewalld.Zone) (string, error) {
 	add, del = diffStringCounts(current.Sources, backupZone.Sources)
 	lines = append(lines, fmt.Sprintf("Sources: +%d  -%d", add, del))
 
+	add, del = diffStringCounts(current.RichRules, backupZone.RichRules)
+	lines = append(lines, fmt.Sprintf("Rich rules: +%d  -%d", add, del))
+
 	if current.Masquerade != backupZone.Masquerade {
 		lines = append(lines, fmt.Sprintf("Masquerade: %s -> %s", onOff(current.Masquerade), onOff(backupZone.Masquerade)))
 	}
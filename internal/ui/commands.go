@@ -122,12 +122,37 @@ type ipsetMutationMsg struct {
 	err  error
 }
 
+type icmpTypesMsg struct {
+	types []string
+	err   error
+}
+
+type policiesMsg struct {
+	names     []string
+	permanent bool
+	err       error
+}
+
+type policyMsg struct {
+	name      string
+	policy    *firewalld.Policy
+	permanent bool
+	err       error
+}
+
+type policyMutationMsg struct {
+	name string
+	err  error
+}
+
 type mutationMsg struct {
 	zone      string
 	err       error
 	action    *undoAction
 	record    recordKind
 	clearRedo bool
+	expiryKey string
+	expiry    time.Duration
 }
 
 type defaultZoneMsg struct {
@@ -141,6 +166,83 @@ type serviceDetailsMsg struct {
 	err     error
 }
 
+type icmpTypeInfoMsg struct {
+	name string
+	info *firewalld.IcmpType
+	err  error
+}
+
+type icmpTypeSavedMsg struct {
+	name    string
+	deleted bool
+	err     error
+}
+
+type helpersMsg struct {
+	helpers []string
+	err     error
+}
+
+type helperInfoMsg struct {
+	name string
+	info *firewalld.Helper
+	err  error
+}
+
+type helperSavedMsg struct {
+	name    string
+	deleted bool
+	err     error
+}
+
+type directMsg struct {
+	runtime   *firewalld.DirectConfig
+	permanent *firewalld.DirectConfig
+	err       error
+}
+
+type directMutationMsg struct {
+	err error
+}
+
+type lockdownMsg struct {
+	enabled bool
+	err     error
+}
+
+type lockdownToggleMsg struct {
+	enabled bool
+	err     error
+}
+
+type whitelistMsg struct {
+	runtime   *firewalld.LockdownWhitelist
+	permanent *firewalld.LockdownWhitelist
+	err       error
+}
+
+type whitelistMutationMsg struct {
+	err error
+}
+
+type settingsMsg struct {
+	logDenied string
+	settings  map[string]string
+	err       error
+}
+
+type settingMutationMsg struct {
+	name  string
+	value string
+	err   error
+}
+
+type serviceSavedMsg struct {
+	name    string
+	deleted bool
+	err     error
+}
+
 type serviceCatalogMsg struct {
 	services []string
 	err      error
@@ -168,28 +270,31 @@ const (
 
 const maxImportFileSize = 10 << 20 // 10 MiB
 
+// undoAction is one recorded change. id is assigned when the change is first
+// recorded and identifies its entry on the undo and redo stacks.
 type undoAction struct {
+	id    int
 	label string
 	zone  string
 	undo  tea.Cmd
 	redo  tea.Cmd
 }
 
-func fetchZonesCmd(client *firewalld.Client) tea.Cmd {
+func fetchZonesCmd(client firewalld.Backend) tea.Cmd {
 	return func() tea.Msg {
 		zones, err := client.ListZones()
 		return zonesMsg{zones: zones, err: err}
 	}
 }
 
-func fetchActiveZonesCmd(client *firewalld.Client) tea.Cmd {
+func fetchActiveZonesCmd(client firewalld.Backend) tea.Cmd {
 	return func() tea.Msg {
 		zones, err := client.GetActiveZones()
 		return activeZonesMsg{zones: zones, err: err}
 	}
 }
 
-func subscribeSignalsCmd(client *firewalld.Client) tea.Cmd {
+func subscribeSignalsCmd(client firewalld.Backend) tea.Cmd {
 	return func() tea.Msg {
 		ch, cancel, err := client.SubscribeSignals()
 		return signalsReadyMsg{ch: ch, cancel: cancel, err: err}
@@ -209,28 +314,28 @@ func listenSignalsCmd(ch <-chan firewalld.SignalEvent) tea.Cmd {
 	}
 }
 
-func fetchDefaultZoneCmd(client *firewalld.Client) tea.Cmd {
+func fetchDefaultZoneCmd(client firewalld.Backend) tea.Cmd {
 	return func() tea.Msg {
 		zone, err := client.GetDefaultZone()
 		return defaultZoneMsg{zone: zone, err: err}
 	}
 }
 
-func fetchPanicModeCmd(client *firewalld.Client) tea.Cmd {
+func fetchPanicModeCmd(client firewalld.Backend) tea.Cmd {
 	return func() tea.Msg {
 		enabled, err := client.QueryPanicMode()
 		return panicModeMsg{enabled: enabled, err: err}
 	}
 }
 
-func enablePanicModeCmd(client *firewalld.Client) tea.Cmd {
+func enablePanicModeCmd(client firewalld.Backend) tea.Cmd {
 	return func() tea.Msg {
 		err := client.EnablePanicMode()
 		return panicToggleMsg{enabled: true, err: err}
 	}
 }
 
-func disablePanicModeCmd(client *firewalld.Client) tea.Cmd {
+func disablePanicModeCmd(client firewalld.Backend) tea.Cmd {
 	return func() tea.Msg {
 		err := client.DisablePanicMode()
 		return panicToggleMsg{enabled: false, err: err}
@@ -277,55 +382,10 @@ func previewBackupCmd(zone, path string, current *firewalld.Zone) tea.Cmd {
 	}
 }
 
-func restoreBackupCmd(client *firewalld.Client, zone string, item backup.Backup) tea.Cmd {
+func restoreBackupCmd(client firewalld.Backend, zone string, item backup.Backup) tea.Cmd {
 	return func() tea.Msg {
-		if err := validation.IsValidZoneName(zone); err != nil {
-			return backupRestoreMsg{zone: zone, err: fmt.Errorf("invalid zone name: %w", err)}
-		}
-
-		slog.Info("restoring backup", "zone", zone, "backup", item.Path)
-		if err := backup.RestoreZoneBackup(zone, item); err != nil {
-			return backupRestoreMsg{zone: zone, err: fmt.Errorf("restore failed: %w", err)}
-		}
-		if err := client.Reload(); err != nil {
-			slog.Error("reload failed after restore, attempting rollback", "zone", zone, "error", err)
-
-			preRestorePath, pathErr := backup.GetPreRestoreBackupPath(zone)
-			if pathErr != nil {
-				return backupRestoreMsg{
-					zone: zone,
-					err:  fmt.Errorf("restore failed and rollback path lookup failed: %w (path error: %v)", err, pathErr),
-				}
-			}
-			if preRestorePath == "" {
-				return backupRestoreMsg{zone: zone, err: fmt.Errorf("restore failed: %w", err)}
-			}
-
-			destPath, destErr := backup.ZoneDestinationPath(zone)
-			if destErr != nil {
-				return backupRestoreMsg{
-					zone: zone,
-					err:  fmt.Errorf("restore failed and rollback destination failed: %w (destination error: %v)", err, destErr),
-				}
-			}
-			if rollbackErr := backup.CopyFile(preRestorePath, destPath); rollbackErr != nil {
-				slog.Error("critical rollback failure", "zone", zone, "error", rollbackErr)
-				return backupRestoreMsg{
-					zone: zone,
-					err:  fmt.Errorf("restore failed and rollback failed: %w (rollback: %v)", err, rollbackErr),
-				}
-			}
-
-			if reloadErr := client.Reload(); reloadErr != nil {
-				slog.Error("reload failed after rollback", "zone", zone, "error", reloadErr)
-			}
-			return backupRestoreMsg{zone: zone, err: fmt.Errorf("restore failed, previous state restored: %w", err)}
-		}
-
-		if cleanupErr := backup.CleanupPreRestoreBackup(zone); cleanupErr != nil {
-			slog.Warn("failed to cleanup pre-restore backup", "zone", zone, "error", cleanupErr)
-		}
-		return backupRestoreMsg{zone: zone, err: nil}
+		err := backup.RestoreZoneBackupAndReload(zone, item, client.Reload)
+		return backupRestoreMsg{zone: zone, err: err}
 	}
 }
 
@@ -358,7 +418,7 @@ func exportZoneCmd(path string, data *firewalld.Zone) tea.Cmd {
 	}
 }
 
-func importZoneCmd(client *firewalld.Client, zone, path string) tea.Cmd {
+func importZoneCmd(client firewalld.Backend, zone, path string) tea.Cmd {
 	return func() tea.Msg {
 		if err := validation.IsValidZoneName(zone); err != nil {
 			return importMsg{zone: zone, err: fmt.Errorf("invalid zone name: %w", err)}
@@ -452,42 +512,42 @@ func mutationCmd(zone string, action *undoAction, record recordKind, clearRedo b
 	}
 }
 
-func fetchZoneSettingsCmd(client *firewalld.Client, zone string, permanent bool) tea.Cmd {
+func fetchZoneSettingsCmd(client firewalld.Backend, zone string, permanent bool) tea.Cmd {
 	return func() tea.Msg {
 		settings, err := client.GetZoneSettings(zone, permanent)
 		return zoneSettingsMsg{zone: settings, zoneName: zone, permanent: permanent, err: err}
 	}
 }
 
-func fetchIPSetsCmd(client *firewalld.Client, permanent bool) tea.Cmd {
+func fetchIPSetsCmd(client firewalld.Backend, permanent bool) tea.Cmd {
 	return func() tea.Msg {
 		sets, err := client.ListIPSets(permanent)
 		return ipsetsMsg{sets: sets, permanent: permanent, err: err}
 	}
 }
 
-func fetchIPSetEntriesCmd(client *firewalld.Client, name string, permanent bool) tea.Cmd {
+func fetchIPSetEntriesCmd(client firewalld.Backend, name string, permanent bool) tea.Cmd {
 	return func() tea.Msg {
 		entries, err := client.GetIPSetEntries(name, permanent)
 		return ipsetEntriesMsg{name: name, entries: entries, permanent: permanent, err: err}
 	}
 }
 
-func addIPSetCmd(client *firewalld.Client, name, ipsetType string) tea.Cmd {
+func addIPSetCmd(client firewalld.Backend, name, ipsetType string) tea.Cmd {
 	return func() tea.Msg {
 		err := client.AddIPSetPermanent(name, ipsetType)
 		return ipsetMutationMsg{name: name, err: err}
 	}
 }
 
-func removeIPSetCmd(client *firewalld.Client, name string) tea.Cmd {
+func removeIPSetCmd(client firewalld.Backend, name string) tea.Cmd {
 	return func() tea.Msg {
 		err := client.RemoveIPSetPermanent(name)
 		return ipsetMutationMsg{name: name, err: err}
 	}
 }
 
-func addIPSetEntryCmd(client *firewalld.Client, name, entry string, permanent bool) tea.Cmd {
+func addIPSetEntryCmd(client firewalld.Backend, name, entry string, permanent bool) tea.Cmd {
 	return func() tea.Msg {
 		var err error
 		if permanent {
@@ -499,7 +559,7 @@ func addIPSetEntryCmd(client *firewalld.Client, name, entry string, permanent bo
 	}
 }
 
-func removeIPSetEntryCmd(client *firewalld.Client, name, entry string, permanent bool) tea.Cmd {
+func removeIPSetEntryCmd(client firewalld.Backend, name, entry string, permanent bool) tea.Cmd {
 	return func() tea.Msg {
 		var err error
 		if permanent {
@@ -511,7 +571,42 @@ func removeIPSetEntryCmd(client *firewalld.Client, name, entry string, permanent
 	}
 }
 
-func addServiceCmd(client *firewalld.Client, zone, service string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func fetchPoliciesCmd(client firewalld.Backend, permanent bool) tea.Cmd {
+	return func() tea.Msg {
+		names, err := client.ListPolicies(permanent)
+		return policiesMsg{names: names, permanent: permanent, err: err}
+	}
+}
+
+func fetchPolicyCmd(client firewalld.Backend, name string, permanent bool) tea.Cmd {
+	return func() tea.Msg {
+		policy, err := client.GetPolicySettings(name, permanent)
+		return policyMsg{name: name, policy: policy, permanent: permanent, err: err}
+	}
+}
+
+func addPolicyCmd(client firewalld.Backend, policy *firewalld.Policy) tea.Cmd {
+	return func() tea.Msg {
+		err := client.AddPolicyPermanent(policy)
+		return policyMutationMsg{name: policy.Name, err: err}
+	}
+}
+
+func updatePolicyCmd(client firewalld.Backend, policy *firewalld.Policy, permanent bool) tea.Cmd {
+	return func() tea.Msg {
+		err := client.UpdatePolicy(policy, permanent)
+		return policyMutationMsg{name: policy.Name, err: err}
+	}
+}
+
+func removePolicyCmd(client firewalld.Backend, name string) tea.Cmd {
+	return func() tea.Msg {
+		err := client.RemovePolicyPermanent(name)
+		return policyMutationMsg{name: name, err: err}
+	}
+}
+
+func addServiceCmd(client firewalld.Backend, zone, service string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			return client.AddServicePermanent(zone, service)
@@ -520,7 +615,13 @@ func addServiceCmd(client *firewalld.Client, zone, service string, permanent boo
 	})
 }
 
-func removeServiceCmd(client *firewalld.Client, zone, service string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func addServiceTimedCmd(client firewalld.Backend, zone, service string, timeout time.Duration, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+	return mutationCmd(zone, action, record, clearRedo, func() error {
+		return client.AddServiceRuntimeTimeout(zone, service, timeout)
+	})
+}
+
+func removeServiceCmd(client firewalld.Backend, zone, service string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			return client.RemoveServicePermanent(zone, service)
@@ -529,7 +630,7 @@ func removeServiceCmd(client *firewalld.Client, zone, service string, permanent
 	})
 }
 
-func addPortCmd(client *firewalld.Client, zone string, port firewalld.Port, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func addPortCmd(client firewalld.Backend, zone string, port firewalld.Port, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			return client.AddPortPermanent(zone, port)
@@ -538,7 +639,13 @@ func addPortCmd(client *firewalld.Client, zone string, port firewalld.Port, perm
 	})
 }
 
-func removePortCmd(client *firewalld.Client, zone string, port firewalld.Port, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func addPortTimedCmd(client firewalld.Backend, zone string, port firewalld.Port, timeout time.Duration, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+	return mutationCmd(zone, action, record, clearRedo, func() error {
+		return client.AddPortRuntimeTimeout(zone, port, timeout)
+	})
+}
+
+func removePortCmd(client firewalld.Backend, zone string, port firewalld.Port, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			return client.RemovePortPermanent(zone, port)
@@ -547,7 +654,7 @@ func removePortCmd(client *firewalld.Client, zone string, port firewalld.Port, p
 	})
 }
 
-func addRichRuleCmd(client *firewalld.Client, zone, rule string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func addRichRuleCmd(client firewalld.Backend, zone, rule string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			return client.AddRichRulePermanent(zone, rule)
@@ -556,7 +663,13 @@ func addRichRuleCmd(client *firewalld.Client, zone, rule string, permanent bool,
 	})
 }
 
-func removeRichRuleCmd(client *firewalld.Client, zone, rule string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func addRichRuleTimedCmd(client firewalld.Backend, zone, rule string, timeout time.Duration, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+	return mutationCmd(zone, action, record, clearRedo, func() error {
+		return client.AddRichRuleRuntimeTimeout(zone, rule, timeout)
+	})
+}
+
+func removeRichRuleCmd(client firewalld.Backend, zone, rule string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			return client.RemoveRichRulePermanent(zone, rule)
@@ -604,7 +717,7 @@ func updateRichRuleCmd(client richRuleUpdater, zone, oldRule, newRule string, pe
 	})
 }
 
-func addInterfaceCmd(client *firewalld.Client, zone, iface string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func addInterfaceCmd(client firewalld.Backend, zone, iface string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			return client.AddInterfacePermanent(zone, iface)
@@ -613,7 +726,7 @@ func addInterfaceCmd(client *firewalld.Client, zone, iface string, permanent boo
 	})
 }
 
-func removeInterfaceCmd(client *firewalld.Client, zone, iface string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func removeInterfaceCmd(client firewalld.Backend, zone, iface string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			return client.RemoveInterfacePermanent(zone, iface)
@@ -622,7 +735,7 @@ func removeInterfaceCmd(client *firewalld.Client, zone, iface string, permanent
 	})
 }
 
-func addSourceCmd(client *firewalld.Client, zone, source string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func addSourceCmd(client firewalld.Backend, zone, source string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			return client.AddSourcePermanent(zone, source)
@@ -631,7 +744,7 @@ func addSourceCmd(client *firewalld.Client, zone, source string, permanent bool,
 	})
 }
 
-func removeSourceCmd(client *firewalld.Client, zone, source string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func removeSourceCmd(client firewalld.Backend, zone, source string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			return client.RemoveSourcePermanent(zone, source)
@@ -640,7 +753,25 @@ func removeSourceCmd(client *firewalld.Client, zone, source string, permanent bo
 	})
 }
 
-func setMasqueradeCmd(client *firewalld.Client, zone string, enabled, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func addForwardPortCmd(client firewalld.Backend, zone string, fp firewalld.ForwardPort, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+	return mutationCmd(zone, action, record, clearRedo, func() error {
+		if permanent {
+			return client.AddForwardPortPermanent(zone, fp)
+		}
+		return client.AddForwardPortRuntime(zone, fp)
+	})
+}
+
+func removeForwardPortCmd(client firewalld.Backend, zone string, fp firewalld.ForwardPort, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+	return mutationCmd(zone, action, record, clearRedo, func() error {
+		if permanent {
+			return client.RemoveForwardPortPermanent(zone, fp)
+		}
+		return client.RemoveForwardPortRuntime(zone, fp)
+	})
+}
+
+func setMasqueradeCmd(client firewalld.Backend, zone string, enabled, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		if permanent {
 			if enabled {
@@ -655,19 +786,65 @@ func setMasqueradeCmd(client *firewalld.Client, zone string, enabled, permanent
 	})
 }
 
-func commitRuntimeCmd(client *firewalld.Client, zone string, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func setTargetCmd(client firewalld.Backend, zone, target string, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+	return mutationCmd(zone, action, record, clearRedo, func() error {
+		return client.SetTargetPermanent(zone, target)
+	})
+}
+
+func addIcmpBlockCmd(client firewalld.Backend, zone, icmpType string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+	return mutationCmd(zone, action, record, clearRedo, func() error {
+		if permanent {
+			return client.AddIcmpBlockPermanent(zone, icmpType)
+		}
+		return client.AddIcmpBlockRuntime(zone, icmpType)
+	})
+}
+
+func removeIcmpBlockCmd(client firewalld.Backend, zone, icmpType string, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+	return mutationCmd(zone, action, record, clearRedo, func() error {
+		if permanent {
+			return client.RemoveIcmpBlockPermanent(zone, icmpType)
+		}
+		return client.RemoveIcmpBlockRuntime(zone, icmpType)
+	})
+}
+
+func setIcmpInversionCmd(client firewalld.Backend, zone string, enabled, permanent bool, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+	return mutationCmd(zone, action, record, clearRedo, func() error {
+		if permanent {
+			if enabled {
+				return client.EnableIcmpBlockInversionPermanent(zone)
+			}
+			return client.DisableIcmpBlockInversionPermanent(zone)
+		}
+		if enabled {
+			return client.EnableIcmpBlockInversionRuntime(zone)
+		}
+		return client.DisableIcmpBlockInversionRuntime(zone)
+	})
+}
+
+func fetchIcmpTypesCmd(client firewalld.Backend) tea.Cmd {
+	return func() tea.Msg {
+		types, err := client.ListIcmpTypes()
+		return icmpTypesMsg{types: types, err: err}
+	}
+}
+
+func commitRuntimeCmd(client firewalld.Backend, zone string, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		return client.RuntimeToPermanent()
 	})
 }
 
-func reloadCmd(client *firewalld.Client, zone string, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
+func reloadCmd(client firewalld.Backend, zone string, action *undoAction, record recordKind, clearRedo bool) tea.Cmd {
 	return mutationCmd(zone, action, record, clearRedo, func() error {
 		return client.Reload()
 	})
 }
 
-func addZoneCmd(client *firewalld.Client, zone string) tea.Cmd {
+func addZoneCmd(client firewalld.Backend, zone string) tea.Cmd {
 	return func() tea.Msg {
 		if err := validation.IsValidZoneName(zone); err != nil {
 			return zonesMsg{err: fmt.Errorf("invalid zone name: %w", err)}
@@ -680,7 +857,7 @@ func addZoneCmd(client *firewalld.Client, zone string) tea.Cmd {
 	}
 }
 
-func removeZoneCmd(client *firewalld.Client, zone string) tea.Cmd {
+func removeZoneCmd(client firewalld.Backend, zone string) tea.Cmd {
 	return func() tea.Msg {
 		if err := validation.IsValidZoneName(zone); err != nil {
 			return zonesMsg{err: fmt.Errorf("invalid zone name: %w", err)}
@@ -693,7 +870,7 @@ func removeZoneCmd(client *firewalld.Client, zone string) tea.Cmd {
 	}
 }
 
-func setDefaultZoneCmd(client *firewalld.Client, zone string) tea.Cmd {
+func setDefaultZoneCmd(client firewalld.Backend, zone string) tea.Cmd {
 	return func() tea.Msg {
 		if err := client.SetDefaultZone(zone); err != nil {
 			return defaultZoneMsg{err: err}
@@ -706,20 +883,186 @@ func setDefaultZoneCmd(client *firewalld.Client, zone string) tea.Cmd {
 	}
 }
 
-func fetchServiceDetailsCmd(client *firewalld.Client, service string) tea.Cmd {
+func fetchServiceDetailsCmd(client firewalld.Backend, service string) tea.Cmd {
 	return func() tea.Msg {
 		info, err := client.GetServiceDetails(service)
 		return serviceDetailsMsg{service: service, info: info, err: err}
 	}
 }
 
-func fetchServiceCatalogCmd(client *firewalld.Client) tea.Cmd {
+func fetchServiceCatalogCmd(client firewalld.Backend) tea.Cmd {
 	return func() tea.Msg {
 		services, err := client.ListServiceNames()
 		return serviceCatalogMsg{services: services, err: err}
 	}
 }
 
+func fetchIcmpTypeInfoCmd(client firewalld.Backend, name string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := client.GetIcmpType(name)
+		return icmpTypeInfoMsg{name: name, info: info, err: err}
+	}
+}
+
+func createIcmpTypeCmd(client firewalld.Backend, t *firewalld.IcmpType) tea.Cmd {
+	return func() tea.Msg {
+		err := client.CreateIcmpType(t)
+		return icmpTypeSavedMsg{name: t.Name, err: err}
+	}
+}
+
+func deleteIcmpTypeCmd(client firewalld.Backend, name string) tea.Cmd {
+	return func() tea.Msg {
+		err := client.DeleteIcmpType(name)
+		return icmpTypeSavedMsg{name: name, deleted: true, err: err}
+	}
+}
+
+func fetchHelpersCmd(client firewalld.Backend) tea.Cmd {
+	return func() tea.Msg {
+		helpers, err := client.ListHelpers()
+		return helpersMsg{helpers: helpers, err: err}
+	}
+}
+
+func fetchHelperInfoCmd(client firewalld.Backend, name string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := client.GetHelper(name)
+		return helperInfoMsg{name: name, info: info, err: err}
+	}
+}
+
+func createHelperCmd(client firewalld.Backend, h *firewalld.Helper) tea.Cmd {
+	return func() tea.Msg {
+		err := client.CreateHelper(h)
+		return helperSavedMsg{name: h.Name, err: err}
+	}
+}
+
+func deleteHelperCmd(client firewalld.Backend, name string) tea.Cmd {
+	return func() tea.Msg {
+		err := client.DeleteHelper(name)
+		return helperSavedMsg{name: name, deleted: true, err: err}
+	}
+}
+
+// fetchDirectCmd loads both modes so the tab can mark runtime-only items and
+// the split view can diff them.
+func fetchDirectCmd(client firewalld.Backend) tea.Cmd {
+	return func() tea.Msg {
+		runtime, err := client.GetDirectSettings(false)
+		if err != nil {
+			return directMsg{err: err}
+		}
+		permanent, err := client.GetDirectSettings(true)
+		if err != nil {
+			return directMsg{err: err}
+		}
+		return directMsg{runtime: runtime, permanent: permanent}
+	}
+}
+
+func directMutationCmd(client firewalld.Backend, item directItem, remove, permanent bool) tea.Cmd {
+	return func() tea.Msg {
+		return directMutationMsg{err: applyDirectItem(client, item, remove, permanent)}
+	}
+}
+
+func fetchLockdownCmd(client firewalld.Backend) tea.Cmd {
+	return func() tea.Msg {
+		enabled, err := client.QueryLockdown()
+		return lockdownMsg{enabled: enabled, err: err}
+	}
+}
+
+func setLockdownCmd(client firewalld.Backend, enabled bool) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if enabled {
+			err = client.EnableLockdown()
+		} else {
+			err = client.DisableLockdown()
+		}
+		return lockdownToggleMsg{enabled: enabled, err: err}
+	}
+}
+
+func fetchWhitelistCmd(client firewalld.Backend) tea.Cmd {
+	return func() tea.Msg {
+		runtime, err := client.GetLockdownWhitelist(false)
+		if err != nil {
+			return whitelistMsg{err: err}
+		}
+		permanent, err := client.GetLockdownWhitelist(true)
+		if err != nil {
+			return whitelistMsg{err: err}
+		}
+		return whitelistMsg{runtime: runtime, permanent: permanent}
+	}
+}
+
+func whitelistMutationCmd(client firewalld.Backend, e firewalld.LockdownEntry, remove, permanent bool) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		switch {
+		case remove && permanent:
+			err = client.RemoveLockdownWhitelistPermanent(e)
+		case remove:
+			err = client.RemoveLockdownWhitelistRuntime(e)
+		case permanent:
+			err = client.AddLockdownWhitelistPermanent(e)
+		default:
+			err = client.AddLockdownWhitelistRuntime(e)
+		}
+		return whitelistMutationMsg{err: err}
+	}
+}
+
+func fetchSettingsCmd(client firewalld.Backend) tea.Cmd {
+	return func() tea.Msg {
+		logDenied, err := client.GetLogDenied()
+		if err != nil {
+			return settingsMsg{err: err}
+		}
+		settings, err := client.GetDaemonSettings()
+		if err != nil {
+			return settingsMsg{err: err}
+		}
+		return settingsMsg{logDenied: logDenied, settings: settings}
+	}
+}
+
+func setSettingCmd(client firewalld.Backend, name, value string) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if name == "LogDenied" {
+			err = client.SetLogDenied(value)
+		} else {
+			err = client.SetDaemonSetting(name, value)
+		}
+		return settingMutationMsg{name: name, value: value, err: err}
+	}
+}
+
+func saveServiceCmd(client firewalld.Backend, info *firewalld.ServiceInfo, create bool) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if create {
+			err = client.CreateService(info)
+		} else {
+			err = client.UpdateService(info)
+		}
+		return serviceSavedMsg{name: info.Name, err: err}
+	}
+}
+
+func deleteServiceCmd(client firewalld.Backend, name string) tea.Cmd {
+	return func() tea.Msg {
+		err := client.DeleteService(name)
+		return serviceSavedMsg{name: name, deleted: true, err: err}
+	}
+}
+
 func startLogStreamCmd() tea.Cmd {
 	return func() tea.Msg {
 		cmd := exec.Command("journalctl", "-f", "-n", "20", "-u", "firewalld", "+", "-k")
@@ -766,29 +1109,45 @@ func readLogLineCmd(lines <-chan string) tea.Cmd {
 	}
 }
 
-func applyTemplateCmd(client *firewalld.Client, zone string, services []string, ports []firewalld.Port, permanent bool) tea.Cmd {
+// applyTemplateCmd adds a template plan to zone, stopping at the first
+// error.
+func applyTemplateCmd(client firewalld.Backend, zone string, plan templatePlan, permanent bool) tea.Cmd {
 	return func() tea.Msg {
+		addService, addPort := client.AddServiceRuntime, client.AddPortRuntime
+		addRich, addSource := client.AddRichRuleRuntime, client.AddSourceRuntime
+		masquerade := client.EnableMasqueradeRuntime
 		if permanent {
-			for _, s := range services {
-				if err := client.AddServicePermanent(zone, s); err != nil {
-					return mutationMsg{zone: zone, err: err}
-				}
+			addService, addPort = client.AddServicePermanent, client.AddPortPermanent
+			addRich, addSource = client.AddRichRulePermanent, client.AddSourcePermanent
+			masquerade = client.EnableMasqueradePermanent
+		}
+		for _, s := range plan.services {
+			if err := addService(zone, s); err != nil {
+				return mutationMsg{zone: zone, err: err}
 			}
-			for _, p := range ports {
-				if err := client.AddPortPermanent(zone, p); err != nil {
-					return mutationMsg{zone: zone, err: err}
-				}
+		}
+		for _, p := range plan.ports {
+			if err := addPort(zone, p); err != nil {
+				return mutationMsg{zone: zone, err: err}
 			}
-			return mutationMsg{zone: zone, err: nil}
 		}
-
-		for _, s := range services {
-			if err := client.AddServiceRuntime(zone, s); err != nil {
+		for _, r := range plan.richRules {
+			if err := addRich(zone, r); err != nil {
+				return mutationMsg{zone: zone, err: err}
+			}
+		}
+		for _, s := range plan.sources {
+			if err := addSource(zone, s); err != nil {
+				return mutationMsg{zone: zone, err: err}
+			}
+		}
+		if plan.masquerade {
+			if err := masquerade(zone); err != nil {
 				return mutationMsg{zone: zone, err: err}
 			}
 		}
-		for _, p := range ports {
-			if err := client.AddPortRuntime(zone, p); err != nil {
+		if plan.target != "" && permanent {
+			if err := client.SetTargetPermanent(zone, plan.target); err != nil {
 				return mutationMsg{zone: zone, err: err}
 			}
 		}
@@ -0,0 +1,342 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/validation"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startHelperBrowser opens the helper list from the service details view,
+// marking the helpers the shown service loads.
+func (m *Model) startHelperBrowser() tea.Cmd {
+	m.err = nil
+	m.helperMode = true
+	m.helperIndex = 0
+	m.helperInfoErr = nil
+	if m.details != nil {
+		switch {
+		case len(m.details.Helpers) > 0:
+			m.pendingHelper = m.details.Helpers[0]
+		case len(m.details.Modules) > 0:
+			m.pendingHelper = strings.TrimPrefix(m.details.Modules[0], "nf_conntrack_")
+		}
+	}
+	m.helpersLoading = true
+	m.helpersErr = nil
+	return fetchHelpersCmd(m.client)
+}
+
+func (m *Model) closeHelperBrowser() {
+	m.helperMode = false
+	m.helperIndex = 0
+	m.helperInfoErr = nil
+	m.pendingHelper = ""
+}
+
+func (m Model) selectedHelper() string {
+	if m.helperIndex < 0 || m.helperIndex >= len(m.helpers) {
+		return ""
+	}
+	return m.helpers[m.helperIndex]
+}
+
+// fetchHelperInfos loads the definitions not cached yet. All of them are
+// needed to link the service's modules to helpers.
+func (m *Model) fetchHelperInfos() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, name := range m.helpers {
+		if m.helperInfo[name] == nil {
+			cmds = append(cmds, fetchHelperInfoCmd(m.client, name))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// serviceUsesHelper reports whether the service in the details view loads
+// helper name. Before the helper is loaded only its name is compared.
+func (m Model) serviceUsesHelper(name string) bool {
+	if m.details == nil {
+		return false
+	}
+	if info := m.helperInfo[name]; info != nil {
+		return m.details.UsesHelper(info)
+	}
+	return m.details.UsesHelper(&firewalld.Helper{Name: name, Module: "nf_conntrack_" + name})
+}
+
+func (m *Model) startAddHelper() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue("")
+	m.input.Placeholder = "name module [ipv4|ipv6] [port/proto ...]"
+	m.inputMode = inputAddHelper
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) startDeleteHelper() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	name := m.selectedHelper()
+	if name == "" {
+		m.err = fmt.Errorf("no helper selected")
+		return nil
+	}
+	if info := m.helperInfo[name]; info != nil && info.Builtin {
+		m.err = fmt.Errorf("helper %s is shipped with firewalld and cannot be deleted", name)
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue("")
+	m.input.Placeholder = "type helper name to delete"
+	m.inputMode = inputDeleteHelper
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+// parseNewHelperInput reads "name module [ipv4|ipv6] [port/proto ...]". The
+// nf_conntrack_ prefix of the module may be left out.
+func parseNewHelperInput(value string, known []string) (*firewalld.Helper, error) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("usage: name module [ipv4|ipv6] [port/proto ...]")
+	}
+	name := fields[0]
+	if err := validation.IsValidZoneName(name); err != nil {
+		return nil, fmt.Errorf("invalid helper name: %w", err)
+	}
+	if slices.Contains(known, name) {
+		return nil, fmt.Errorf("helper %s already exists", name)
+	}
+	h := &firewalld.Helper{Name: name, Module: fields[1]}
+	if !strings.HasPrefix(h.Module, "nf_conntrack_") {
+		h.Module = "nf_conntrack_" + h.Module
+	}
+	rest := fields[2:]
+	if len(rest) > 0 && (rest[0] == "ipv4" || rest[0] == "ipv6") {
+		h.Family = rest[0]
+		rest = rest[1:]
+	}
+	ports, err := parseServicePorts(strings.Join(rest, " "))
+	if err != nil {
+		return nil, err
+	}
+	h.Ports = ports
+	if err := firewalld.CheckHelper(h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (m *Model) submitHelperInput(value string) tea.Cmd {
+	switch m.inputMode {
+	case inputAddHelper:
+		h, err := parseNewHelperInput(value, m.helpers)
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		m.inputMode = inputNone
+		m.input.Blur()
+		m.err = nil
+		m.notice = ""
+		if m.dryRun {
+			m.setDryRunNotice(fmt.Sprintf("create helper %s (%s)", h.Name, h.Module))
+			return nil
+		}
+		m.helpersLoading = true
+		return createHelperCmd(m.client, h)
+	case inputDeleteHelper:
+		name := m.selectedHelper()
+		if name == "" {
+			m.err = fmt.Errorf("no helper selected")
+			return nil
+		}
+		if value != name {
+			m.err = fmt.Errorf("type helper name to confirm deletion")
+			return nil
+		}
+		m.inputMode = inputNone
+		m.input.Blur()
+		m.err = nil
+		m.notice = ""
+		if m.dryRun {
+			m.setDryRunNotice(fmt.Sprintf("delete helper %s", name))
+			return nil
+		}
+		m.helpersLoading = true
+		return deleteHelperCmd(m.client, name)
+	}
+	return nil
+}
+
+func (m Model) handleHelpers(msg helpersMsg) (Model, tea.Cmd) {
+	m.helpersLoading = false
+	m.helpersErr = msg.err
+	if msg.err != nil {
+		return m, nil
+	}
+	m.helpers = msg.helpers
+	if idx := slices.Index(m.helpers, m.pendingHelper); idx >= 0 {
+		m.helperIndex = idx
+	} else if m.helperIndex >= len(m.helpers) {
+		m.helperIndex = max(len(m.helpers)-1, 0)
+	}
+	m.pendingHelper = ""
+	if !m.helperMode {
+		return m, nil
+	}
+	return m, m.fetchHelperInfos()
+}
+
+func (m Model) handleHelperInfo(msg helperInfoMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		if msg.name == m.selectedHelper() {
+			m.helperInfoErr = msg.err
+		}
+		return m, nil
+	}
+	if m.helperInfo == nil {
+		m.helperInfo = make(map[string]*firewalld.Helper)
+	}
+	m.helperInfo[msg.name] = msg.info
+	return m, nil
+}
+
+func (m Model) handleHelperSaved(msg helperSavedMsg) (Model, tea.Cmd) {
+	m.helpersLoading = false
+	if msg.err != nil {
+		m.err = msg.err
+		return m, nil
+	}
+	m.err = nil
+	delete(m.helperInfo, msg.name)
+	if msg.deleted {
+		m.notice = fmt.Sprintf("Helper %s deleted", msg.name)
+	} else {
+		m.notice = fmt.Sprintf("Helper %s created", msg.name)
+		if m.offlineRoot == "" {
+			m.notice += " (reload to use it at runtime)"
+		}
+		m.pendingHelper = msg.name
+	}
+	m.helpersLoading = true
+	m.helpersErr = nil
+	return m, fetchHelpersCmd(m.client)
+}
+
+func (m Model) handleHelperMode(msg tea.Msg) (Model, tea.Cmd, bool) {
+	if !m.helperMode {
+		return m, nil, false
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, false
+	}
+
+	if step := m.listStep(key.String()); step != 0 {
+		if next := m.helperIndex + step; next >= 0 && next < len(m.helpers) {
+			m.helperIndex = next
+			m.helperInfoErr = nil
+		}
+		return m, nil, true
+	}
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit, true
+	case "esc", "q", "H":
+		m.closeHelperBrowser()
+		return m, nil, true
+	case "n":
+		return m, m.startAddHelper(), true
+	case "D":
+		return m, m.startDeleteHelper(), true
+	}
+	return m, nil, true
+}
+
+func renderHelperBrowser(b *strings.Builder, m Model) {
+	header := "Helpers"
+	if m.details != nil {
+		header += " (service " + m.details.Name + ")"
+	}
+	if m.helpersLoading {
+		header += " " + m.spinner.View()
+	}
+	b.WriteString(titleStyle.Render(header))
+	b.WriteString("\n\n")
+	if m.helpersErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + m.helpersErr.Error()))
+		b.WriteString("\n")
+		return
+	}
+	if len(m.helpers) == 0 {
+		if !m.helpersLoading {
+			b.WriteString(dimStyle.Render("  (no helpers)"))
+			b.WriteString("\n")
+		}
+		return
+	}
+
+	for i, name := range m.helpers {
+		line := "  " + name
+		if info := m.helperInfo[name]; info != nil && !info.Builtin {
+			line += " (custom)"
+		}
+		if m.serviceUsesHelper(name) {
+			line += " (used by " + m.details.Name + ")"
+		}
+		if i == m.helperIndex {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n")
+	info := m.helperInfo[m.selectedHelper()]
+	switch {
+	case m.helperInfoErr != nil:
+		b.WriteString(errorStyle.Render("Error: " + m.helperInfoErr.Error()))
+		b.WriteString("\n")
+	case info == nil:
+		b.WriteString(dimStyle.Render("Loading... " + m.spinner.View()))
+		b.WriteString("\n")
+	default:
+		if info.Short != "" {
+			b.WriteString("Short: " + info.Short + "\n")
+		}
+		if info.Description != "" {
+			b.WriteString("Description: " + info.Description + "\n")
+		}
+		b.WriteString("Module: " + info.Module + "\n")
+		family := "ipv4, ipv6"
+		if info.Family != "" {
+			family = info.Family
+		}
+		b.WriteString("Family: " + family + "\n")
+		if len(info.Ports) > 0 {
+			b.WriteString("Ports: " + formatPortList(info.Ports) + "\n")
+		}
+		if info.Builtin {
+			b.WriteString(dimStyle.Render("Shipped with firewalld") + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("n new helper, D delete custom helper, Esc back to service"))
+}
@@ -0,0 +1,64 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseNewHelperInput(t *testing.T) {
+	got, err := parseNewHelperInput("ftp-alt ftp ipv4 2121/tcp", []string{"ftp"})
+	if err != nil {
+		t.Fatalf("parseNewHelperInput() error = %v", err)
+	}
+	if got.Module != "nf_conntrack_ftp" || got.Family != "ipv4" || len(got.Ports) != 1 || got.Ports[0].Port != "2121" {
+		t.Fatalf("parseNewHelperInput() = %+v", got)
+	}
+	for _, input := range []string{"", "only-name", "ftp ftp", "x ftp 2121", "x nf_conntrack_"} {
+		if _, err := parseNewHelperInput(input, []string{"ftp"}); err == nil {
+			t.Fatalf("parseNewHelperInput(%q) should fail", input)
+		}
+	}
+}
+
+func TestHelperBrowserLinksService(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{DryRun: true})
+	m.detailsMode = true
+	m.details = &firewalld.ServiceInfo{Name: "ftp", Modules: []string{"nf_conntrack_ftp"}}
+	m.startHelperBrowser()
+	if !m.helperMode || m.pendingHelper != "ftp" {
+		t.Fatalf("browser should open on the service's helper, pending = %q", m.pendingHelper)
+	}
+
+	m, _ = m.handleHelpers(helpersMsg{helpers: []string{"amanda", "ftp", "sip"}})
+	if m.selectedHelper() != "ftp" {
+		t.Fatalf("selectedHelper() = %q, want ftp", m.selectedHelper())
+	}
+	m, _ = m.handleHelperInfo(helperInfoMsg{name: "sip", info: &firewalld.Helper{Name: "sip", Module: "nf_conntrack_sip", Builtin: true}})
+	if !m.serviceUsesHelper("ftp") || m.serviceUsesHelper("sip") {
+		t.Fatalf("only ftp should be linked to the ftp service")
+	}
+
+	m, _, _ = m.handleHelperMode(tea.KeyMsg{Type: tea.KeyDown})
+	m, _, _ = m.handleHelperMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+	if m.inputMode == inputDeleteHelper || m.err == nil {
+		t.Fatalf("shipped helpers should not be deletable")
+	}
+
+	m, _, _ = m.handleHelperMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m.input.SetValue("sip-alt sip 5070/udp")
+	m.submitInput()
+	if m.inputMode != inputNone || m.notice == "" {
+		t.Fatalf("creating a helper should be reported in dry run, err = %v", m.err)
+	}
+
+	m, _, _ = m.handleHelperMode(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.helperMode || !m.detailsMode {
+		t.Fatalf("Esc should return to the service details")
+	}
+}
@@ -0,0 +1,331 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// directItem is one line of the Direct tab: a chain, rule or passthrough.
+type directItem struct {
+	kind        string
+	chain       firewalld.DirectChain
+	rule        firewalld.DirectRule
+	passthrough firewalld.DirectPassthrough
+}
+
+// value formats the item without its kind, as listed under its heading.
+func (it directItem) value() string {
+	switch it.kind {
+	case "chain":
+		return it.chain.String()
+	case "rule":
+		return it.rule.String()
+	}
+	return it.passthrough.String()
+}
+
+// String formats the item the way the Direct tab prompt accepts it.
+func (it directItem) String() string {
+	return it.kind + " " + it.value()
+}
+
+func directItems(cfg *firewalld.DirectConfig) []directItem {
+	if cfg == nil {
+		return nil
+	}
+	items := make([]directItem, 0, len(cfg.Chains)+len(cfg.Rules)+len(cfg.Passthroughs))
+	for _, ch := range cfg.Chains {
+		items = append(items, directItem{kind: "chain", chain: ch})
+	}
+	for _, r := range cfg.Rules {
+		items = append(items, directItem{kind: "rule", rule: r})
+	}
+	for _, p := range cfg.Passthroughs {
+		items = append(items, directItem{kind: "passthrough", passthrough: p})
+	}
+	return items
+}
+
+func directItemSet(cfg *firewalld.DirectConfig) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, it := range directItems(cfg) {
+		set[it.String()] = struct{}{}
+	}
+	return set
+}
+
+// parseDirectInput parses the Direct tab prompt, e.g.
+// "chain ipv4 filter MYCHAIN", "rule ipv4 filter INPUT 0 -p tcp --dport 22 -j ACCEPT"
+// or "passthrough ipv4 -t filter -I INPUT -j ACCEPT". Arguments are split
+// like a shell would, so quoted comments survive.
+func parseDirectInput(input string) (directItem, error) {
+	args, err := firewalld.SplitDirectArgs(input)
+	if err != nil {
+		return directItem{}, err
+	}
+	if len(args) == 0 {
+		return directItem{}, fmt.Errorf("expected: chain|rule|passthrough ipv ...")
+	}
+	it := directItem{kind: strings.ToLower(args[0])}
+	args = args[1:]
+	switch it.kind {
+	case "chain":
+		if len(args) != 3 {
+			return directItem{}, fmt.Errorf("expected: chain ipv table chain")
+		}
+		it.chain = firewalld.DirectChain{IPV: args[0], Table: args[1], Chain: args[2]}
+		err = firewalld.CheckDirectChain(it.chain)
+	case "rule":
+		if len(args) < 5 {
+			return directItem{}, fmt.Errorf("expected: rule ipv table chain priority args...")
+		}
+		priority, perr := strconv.ParseInt(args[3], 10, 32)
+		if perr != nil {
+			return directItem{}, fmt.Errorf("invalid priority: %s", args[3])
+		}
+		it.rule = firewalld.DirectRule{IPV: args[0], Table: args[1], Chain: args[2], Priority: int32(priority), Args: args[4:]}
+		err = firewalld.CheckDirectRule(it.rule)
+	case "passthrough":
+		if len(args) < 2 {
+			return directItem{}, fmt.Errorf("expected: passthrough ipv args...")
+		}
+		it.passthrough = firewalld.DirectPassthrough{IPV: args[0], Args: args[1:]}
+		err = firewalld.CheckDirectPassthrough(it.passthrough)
+	default:
+		return directItem{}, fmt.Errorf("unknown direct item: %s (use chain, rule or passthrough)", args[0])
+	}
+	if err != nil {
+		return directItem{}, err
+	}
+	return it, nil
+}
+
+func applyDirectItem(client firewalld.Backend, it directItem, remove, permanent bool) error {
+	switch it.kind {
+	case "chain":
+		switch {
+		case remove && permanent:
+			return client.RemoveDirectChainPermanent(it.chain)
+		case remove:
+			return client.RemoveDirectChainRuntime(it.chain)
+		case permanent:
+			return client.AddDirectChainPermanent(it.chain)
+		}
+		return client.AddDirectChainRuntime(it.chain)
+	case "rule":
+		switch {
+		case remove && permanent:
+			return client.RemoveDirectRulePermanent(it.rule)
+		case remove:
+			return client.RemoveDirectRuleRuntime(it.rule)
+		case permanent:
+			return client.AddDirectRulePermanent(it.rule)
+		}
+		return client.AddDirectRuleRuntime(it.rule)
+	}
+	switch {
+	case remove && permanent:
+		return client.RemoveDirectPassthroughPermanent(it.passthrough)
+	case remove:
+		return client.RemoveDirectPassthroughRuntime(it.passthrough)
+	case permanent:
+		return client.AddDirectPassthroughPermanent(it.passthrough)
+	}
+	return client.AddDirectPassthroughRuntime(it.passthrough)
+}
+
+func (m Model) currentDirect() *firewalld.DirectConfig {
+	if m.permanent {
+		return m.directPermanent
+	}
+	return m.directRuntime
+}
+
+func (m Model) selectedDirectItem() (directItem, bool) {
+	items := directItems(m.currentDirect())
+	if m.directIndex < 0 || m.directIndex >= len(items) {
+		return directItem{}, false
+	}
+	return items[m.directIndex], true
+}
+
+func (m *Model) refreshDirect() tea.Cmd {
+	m.directLoading = true
+	return fetchDirectCmd(m.client)
+}
+
+// startEditDirect opens the Direct tab prompt; a leading "-" removes the
+// item instead of adding it.
+func (m *Model) startEditDirect(prefix string) tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue(prefix)
+	m.input.Placeholder = "chain ipv4 filter NAME | rule ipv4 filter INPUT 0 -j ACCEPT | passthrough ipv4 -t filter ..."
+	m.inputMode = inputEditDirect
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) startRemoveDirect() tea.Cmd {
+	it, ok := m.selectedDirectItem()
+	if !ok {
+		m.err = fmt.Errorf("no direct item selected")
+		return nil
+	}
+	return m.startEditDirect("-" + it.String())
+}
+
+func (m *Model) submitDirectInput(value string) tea.Cmd {
+	remove := strings.HasPrefix(value, "-")
+	value = strings.TrimSpace(strings.TrimPrefix(value, "-"))
+	it, err := parseDirectInput(value)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.inputMode = inputNone
+	m.input.Blur()
+	m.err = nil
+	m.notice = ""
+	if m.dryRun {
+		verb := "add"
+		if remove {
+			verb = "remove"
+		}
+		m.setDryRunNotice(fmt.Sprintf("%s direct %s (%s)", verb, it, modeLabel(m.permanent)))
+		return nil
+	}
+	m.directLoading = true
+	return directMutationCmd(m.client, it, remove, m.permanent)
+}
+
+func (m Model) handleDirect(msg directMsg) (Model, tea.Cmd) {
+	m.directLoading = false
+	if msg.err != nil {
+		m.directErr = msg.err
+		m.directDenied = errors.Is(msg.err, firewalld.ErrPermissionDenied)
+		m.directRuntime = nil
+		m.directPermanent = nil
+		return m, nil
+	}
+	m.directErr = nil
+	m.directDenied = false
+	m.directRuntime = msg.runtime
+	m.directPermanent = msg.permanent
+	if n := len(directItems(m.currentDirect())); m.directIndex >= n {
+		m.directIndex = max(n-1, 0)
+	}
+	return m, nil
+}
+
+func (m Model) handleDirectMutation(msg directMutationMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.directLoading = false
+		m.err = msg.err
+		return m, nil
+	}
+	m.err = nil
+	m.notice = ""
+	return m, m.refreshDirect()
+}
+
+func renderDirectView(b *strings.Builder, m Model) {
+	if m.directDenied {
+		b.WriteString(warnStyle.Render("No permission to read direct rules. Run with sudo."))
+		return
+	}
+	if m.directLoading && m.currentDirect() == nil {
+		b.WriteString(dimStyle.Render("Loading direct rules..."))
+		return
+	}
+	if m.directErr != nil {
+		b.WriteString(warnStyle.Render(fmt.Sprintf("Error: %v", m.directErr)))
+		return
+	}
+	items := directItems(m.currentDirect())
+	if len(items) == 0 {
+		b.WriteString(dimStyle.Render("  (none)"))
+		return
+	}
+
+	var permanentSet map[string]struct{}
+	if !m.permanent && m.directPermanent != nil {
+		permanentSet = directItemSet(m.directPermanent)
+	}
+	headings := map[string]string{"chain": "Chains:", "rule": "Rules:", "passthrough": "Passthroughs:"}
+	kind := ""
+	for i, it := range items {
+		if it.kind != kind {
+			if kind != "" {
+				b.WriteString("\n")
+			}
+			kind = it.kind
+			b.WriteString(headings[kind] + "\n")
+		}
+		line := highlightMatch(it.value(), m.searchQuery)
+		if permanentSet != nil {
+			if _, ok := permanentSet[it.String()]; !ok {
+				line += " *"
+			}
+		}
+		if i == m.directIndex {
+			if m.focus == focusMain {
+				line = selectedStyle.Render("  " + line)
+			} else {
+				line = selectedDimStyle.Render("  " + line)
+			}
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+}
+
+func diffDirect(runtime, permanent *firewalld.DirectConfig) ([]string, []string) {
+	if runtime == nil || permanent == nil {
+		return []string{dimStyle.Render("(loading)")}, []string{dimStyle.Render("(loading)")}
+	}
+
+	permanentSet := directItemSet(permanent)
+	runtimeSet := directItemSet(runtime)
+
+	left := []string{}
+	for _, it := range directItems(runtime) {
+		prefix := "  "
+		if _, ok := permanentSet[it.String()]; !ok {
+			prefix = "+ "
+		}
+		left = append(left, prefix+it.String())
+	}
+
+	right := []string{}
+	for _, it := range directItems(permanent) {
+		prefix := "  "
+		if _, ok := runtimeSet[it.String()]; !ok {
+			prefix = "- "
+		}
+		right = append(right, prefix+it.String())
+	}
+
+	if len(left) == 0 {
+		left = []string{dimStyle.Render("(none)")}
+	}
+	if len(right) == 0 {
+		right = []string{dimStyle.Render("(none)")}
+	}
+
+	return left, right
+}
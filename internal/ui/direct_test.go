@@ -0,0 +1,76 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+func TestParseDirectInput(t *testing.T) {
+	it, err := parseDirectInput(`rule ipv4 filter INPUT 0 -p tcp --dport 22 -m comment --comment "allow ssh" -j ACCEPT`)
+	if err != nil {
+		t.Fatalf("parseDirectInput() error = %v", err)
+	}
+	want := []string{"-p", "tcp", "--dport", "22", "-m", "comment", "--comment", "allow ssh", "-j", "ACCEPT"}
+	if it.kind != "rule" || it.rule.Chain != "INPUT" || it.rule.Priority != 0 || !slices.Equal(it.rule.Args, want) {
+		t.Fatalf("parseDirectInput() = %+v", it)
+	}
+	if again, err := parseDirectInput(it.String()); err != nil || !again.rule.Equal(it.rule) {
+		t.Fatalf("String() does not round-trip: %q", it.String())
+	}
+
+	for _, input := range []string{
+		"",
+		"chain ipv4 filter",
+		"chain ipv5 filter X",
+		"chain ipv4 bogus X",
+		"rule ipv4 filter INPUT x -j ACCEPT",
+		"rule ipv4 filter INPUT 0",
+		"passthrough ipv4",
+		"table ipv4 filter",
+	} {
+		if _, err := parseDirectInput(input); err == nil {
+			t.Fatalf("parseDirectInput(%q) should fail", input)
+		}
+	}
+}
+
+func TestDirectTab(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{DryRun: true})
+	m.tab = tabDirect
+	m.focus = focusMain
+	rule := firewalld.DirectRule{IPV: "ipv4", Table: "filter", Chain: "INPUT", Args: []string{"-j", "ACCEPT"}}
+	m, _ = m.handleDirect(directMsg{
+		runtime: &firewalld.DirectConfig{
+			Chains: []firewalld.DirectChain{{IPV: "ipv4", Table: "filter", Chain: "blacklist"}},
+			Rules:  []firewalld.DirectRule{rule},
+		},
+		permanent: &firewalld.DirectConfig{Rules: []firewalld.DirectRule{rule}},
+	})
+
+	var b strings.Builder
+	renderDirectView(&b, m)
+	if out := b.String(); !strings.Contains(out, "ipv4 filter blacklist *") || strings.Contains(out, "ACCEPT *") {
+		t.Fatalf("only the chain is runtime-only:\n%s", out)
+	}
+
+	left, right := diffDirect(m.directRuntime, m.directPermanent)
+	if len(left) != 2 || left[0] != "+ chain ipv4 filter blacklist" || len(right) != 1 || strings.HasPrefix(right[0], "- ") {
+		t.Fatalf("diffDirect() = %q, %q", left, right)
+	}
+
+	m.moveMainSelection(1)
+	m.removeSelected()
+	if m.inputMode != inputEditDirect || m.input.Value() != "-rule ipv4 filter INPUT 0 -j ACCEPT" {
+		t.Fatalf("removeSelected() prompt = %q", m.input.Value())
+	}
+	m.submitInput()
+	if m.inputMode != inputNone || !strings.Contains(m.notice, "remove direct rule") {
+		t.Fatalf("removal should be reported in dry run, notice = %q, err = %v", m.notice, m.err)
+	}
+}
@@ -3,7 +3,11 @@
 
 package ui
 
-import "testing"
+import (
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+)
 
 func TestParsePortInput(t *testing.T) {
 	tests := []struct {
@@ -13,6 +17,7 @@ func TestParsePortInput(t *testing.T) {
 	}{
 		{name: "slash format", input: "80/tcp", wantErr: false},
 		{name: "space format", input: "53 udp", wantErr: false},
+		{name: "range", input: "6000-6010/udp", wantErr: false},
 		{name: "bad port", input: "0/tcp", wantErr: true},
 		{name: "bad protocol", input: "80/icmp", wantErr: true},
 		{name: "empty", input: "", wantErr: true},
@@ -28,6 +33,47 @@ func TestParsePortInput(t *testing.T) {
 	}
 }
 
+func TestParseForwardPortInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    firewalld.ForwardPort
+		wantErr bool
+	}{
+		{name: "firewall-cmd format", input: "port=80:proto=tcp:toport=8080:toaddr=10.0.0.5", want: firewalld.ForwardPort{Port: "80", Protocol: "tcp", ToPort: "8080", ToAddr: "10.0.0.5"}},
+		{name: "shorthand", input: "443/TCP 8443", want: firewalld.ForwardPort{Port: "443", Protocol: "tcp", ToPort: "8443"}},
+		{name: "shorthand with addr", input: "2222/tcp 22 192.168.1.10", want: firewalld.ForwardPort{Port: "2222", Protocol: "tcp", ToPort: "22", ToAddr: "192.168.1.10"}},
+		{name: "range", input: "port=1000-1010:proto=udp:toaddr=fd00::1", want: firewalld.ForwardPort{Port: "1000-1010", Protocol: "udp", ToAddr: "fd00::1"}},
+		{name: "no destination", input: "port=80:proto=tcp", wantErr: true},
+		{name: "bad port", input: "0/tcp 80", wantErr: true},
+		{name: "bad range", input: "port=90-80:proto=tcp:toport=8080", wantErr: true},
+		{name: "bad protocol", input: "80/icmp 8080", wantErr: true},
+		{name: "bad addr", input: "80/tcp 8080 not-an-ip", wantErr: true},
+		{name: "unknown option", input: "port=80:proto=tcp:to=1", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseForwardPortInput(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseForwardPortInput(%q) error = %v, wantErr = %v", tt.input, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Fatalf("parseForwardPortInput(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatForwardPortRoundTrip(t *testing.T) {
+	fp := firewalld.ForwardPort{Port: "80", Protocol: "tcp", ToAddr: "10.0.0.5"}
+	parsed, err := parseForwardPortInput(firewalld.FormatForwardPort(fp))
+	if err != nil || parsed != fp {
+		t.Fatalf("parseForwardPortInput(FormatForwardPort()) = %+v, %v, want %+v", parsed, err, fp)
+	}
+}
+
 func TestParseIPSetInput(t *testing.T) {
 	tests := []struct {
 		name     string
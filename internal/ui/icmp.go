@@ -0,0 +1,514 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/keymap"
+	"lazyfirewall/internal/lockout"
+	"lazyfirewall/internal/validation"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (m *Model) startIcmpPicker() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	if m.currentData() == nil {
+		m.err = fmt.Errorf("no data loaded")
+		return nil
+	}
+	m.err = nil
+	m.icmpPickerMode = true
+	m.icmpPickerIndex = 0
+	m.input.SetValue("")
+	m.input.Placeholder = "filter icmp types"
+	m.input.CursorEnd()
+	m.input.Focus()
+	if m.icmpTypes != nil || m.icmpTypesLoading {
+		return nil
+	}
+	m.icmpTypesLoading = true
+	m.icmpTypesErr = nil
+	return fetchIcmpTypesCmd(m.client)
+}
+
+func (m *Model) closeIcmpPicker() {
+	m.icmpPickerMode = false
+	m.icmpPickerIndex = 0
+	m.input.SetValue("")
+	m.input.Blur()
+}
+
+// filteredIcmpTypes returns the known ICMP types containing the picker filter.
+func (m Model) filteredIcmpTypes() []string {
+	filter := strings.ToLower(strings.TrimSpace(m.input.Value()))
+	if filter == "" {
+		return m.icmpTypes
+	}
+	out := make([]string, 0, len(m.icmpTypes))
+	for _, name := range m.icmpTypes {
+		if strings.Contains(strings.ToLower(name), filter) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+func (m *Model) chooseIcmpType() tea.Cmd {
+	types := m.filteredIcmpTypes()
+	if len(types) == 0 || m.icmpPickerIndex >= len(types) {
+		return nil
+	}
+	icmpType := types[m.icmpPickerIndex]
+	if !m.canBlockIcmpType(icmpType) {
+		return nil
+	}
+	m.closeIcmpPicker()
+	return m.blockIcmpType(icmpType)
+}
+
+func (m *Model) canBlockIcmpType(icmpType string) bool {
+	current := m.currentData()
+	if current == nil || len(m.zones) == 0 {
+		m.err = fmt.Errorf("no data loaded")
+		return false
+	}
+	if slices.Contains(current.IcmpBlocks, icmpType) {
+		m.err = fmt.Errorf("icmp type %s already blocked", icmpType)
+		return false
+	}
+	return true
+}
+
+// blockIcmpType adds icmpType to the ICMP blocks of the selected zone; the
+// caller has checked canBlockIcmpType.
+func (m *Model) blockIcmpType(icmpType string) tea.Cmd {
+	zone := m.zones[m.selected]
+	m.err = nil
+	if m.dryRun {
+		m.setDryRunNotice(fmt.Sprintf("add icmp block %s to zone %s (%s)", icmpType, zone, modeLabel(m.permanent)))
+		return nil
+	}
+	return m.safeMutation(zone, "block icmp "+icmpType, m.permanent, false, m.actionAddIcmpBlock(zone, icmpType, m.permanent))
+}
+
+func (m Model) handleIcmpPickerMode(msg tea.Msg) (Model, tea.Cmd, bool) {
+	if !m.icmpPickerMode {
+		return m, nil, false
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, false
+	}
+
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit, true
+	case "esc":
+		m.closeIcmpPicker()
+		return m, nil, true
+	case "up", "ctrl+p":
+		if m.icmpPickerIndex > 0 {
+			m.icmpPickerIndex--
+		}
+		return m, nil, true
+	case "down", "ctrl+n":
+		if m.icmpPickerIndex < len(m.filteredIcmpTypes())-1 {
+			m.icmpPickerIndex++
+		}
+		return m, nil, true
+	case "enter":
+		return m, m.chooseIcmpType(), true
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(key)
+	m.icmpPickerIndex = 0
+	return m, cmd, true
+}
+
+func (m *Model) startEditTarget() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	if !m.permanent {
+		m.err = fmt.Errorf("zone target is permanent-only (press P)")
+		return nil
+	}
+	current := m.currentData()
+	if current == nil {
+		m.err = fmt.Errorf("no data loaded")
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue(current.Target)
+	m.input.Placeholder = strings.Join(firewalld.ZoneTargets, " | ")
+	m.inputMode = inputSetTarget
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) submitSetTarget(value string) tea.Cmd {
+	target, err := firewalld.NormalizeTarget(value)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	current := m.currentData()
+	if current == nil || len(m.zones) == 0 {
+		m.err = fmt.Errorf("no data loaded")
+		return nil
+	}
+	zone := m.zones[m.selected]
+	old := current.Target
+	m.inputMode = inputNone
+	m.input.Blur()
+	m.err = nil
+	if target == old {
+		m.notice = "Target unchanged"
+		return nil
+	}
+	if m.dryRun {
+		m.setDryRunNotice(fmt.Sprintf("set target of zone %s to %s (permanent)", zone, target))
+		return nil
+	}
+	return m.guardLockout(lockout.Change{Kind: lockout.SetTarget, Zone: zone, Value: target}, func(m *Model) tea.Cmd {
+		return m.safeMutation(zone, "set target "+target, true, false, m.actionSetTarget(zone, old, target))
+	})
+}
+
+func renderIcmpPicker(b *strings.Builder, m Model) {
+	b.WriteString(titleStyle.Render("Block ICMP type (" + modeLabel(m.permanent) + ")"))
+	b.WriteString("\n\n")
+	b.WriteString(inputStyle.Render("Filter: ") + m.input.View())
+	b.WriteString("\n\n")
+	if m.icmpTypesErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + m.icmpTypesErr.Error()))
+		b.WriteString("\n")
+		return
+	}
+	if m.icmpTypesLoading {
+		b.WriteString(dimStyle.Render("Loading... " + m.spinner.View()))
+		b.WriteString("\n")
+		return
+	}
+	types := m.filteredIcmpTypes()
+	if len(types) == 0 {
+		b.WriteString(dimStyle.Render("  (no matching icmp types)"))
+		b.WriteString("\n")
+	}
+	var blocked []string
+	if current := m.currentData(); current != nil {
+		blocked = current.IcmpBlocks
+	}
+	for i, name := range types {
+		line := "  " + name
+		if slices.Contains(blocked, name) {
+			line += " (blocked)"
+		}
+		if i == m.icmpPickerIndex {
+			line = selectedStyle.Render(line)
+		} else if slices.Contains(blocked, name) {
+			line = dimStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("Type to filter, Enter to block, Esc to cancel"))
+}
+
+func (m *Model) startIcmpBrowser() tea.Cmd {
+	m.err = nil
+	m.icmpBrowserMode = true
+	m.icmpBrowserIndex = 0
+	if m.icmpTypes == nil && !m.icmpTypesLoading {
+		m.icmpTypesLoading = true
+		m.icmpTypesErr = nil
+		return fetchIcmpTypesCmd(m.client)
+	}
+	return m.fetchSelectedIcmpTypeInfo()
+}
+
+func (m *Model) closeIcmpBrowser() {
+	m.icmpBrowserMode = false
+	m.icmpBrowserIndex = 0
+	m.icmpTypeInfoErr = nil
+}
+
+func (m Model) selectedIcmpBrowserType() string {
+	if m.icmpBrowserIndex < 0 || m.icmpBrowserIndex >= len(m.icmpTypes) {
+		return ""
+	}
+	return m.icmpTypes[m.icmpBrowserIndex]
+}
+
+// fetchSelectedIcmpTypeInfo loads the definition of the selected type
+// unless it is cached already.
+func (m *Model) fetchSelectedIcmpTypeInfo() tea.Cmd {
+	name := m.selectedIcmpBrowserType()
+	m.icmpTypeInfoErr = nil
+	if name == "" || m.icmpTypeInfo[name] != nil {
+		return nil
+	}
+	return fetchIcmpTypeInfoCmd(m.client, name)
+}
+
+func (m *Model) moveIcmpBrowser(delta int) tea.Cmd {
+	next := m.icmpBrowserIndex + delta
+	if next < 0 || next >= len(m.icmpTypes) {
+		return nil
+	}
+	m.icmpBrowserIndex = next
+	return m.fetchSelectedIcmpTypeInfo()
+}
+
+// blockBrowserIcmpType blocks the selected type in the current zone, like
+// choosing it in the picker.
+func (m *Model) blockBrowserIcmpType() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	name := m.selectedIcmpBrowserType()
+	if name == "" || !m.canBlockIcmpType(name) {
+		return nil
+	}
+	m.closeIcmpBrowser()
+	return m.blockIcmpType(name)
+}
+
+func (m *Model) startAddIcmpType() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue("")
+	m.input.Placeholder = "name [ipv4|ipv6] [short description]"
+	m.inputMode = inputAddIcmpType
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) startDeleteIcmpType() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	name := m.selectedIcmpBrowserType()
+	if name == "" {
+		m.err = fmt.Errorf("no icmp type selected")
+		return nil
+	}
+	if info := m.icmpTypeInfo[name]; info != nil && info.Builtin {
+		m.err = fmt.Errorf("icmp type %s is shipped with firewalld and cannot be deleted", name)
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue("")
+	m.input.Placeholder = "type icmp type name to delete"
+	m.inputMode = inputDeleteIcmpType
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+// parseNewIcmpTypeInput reads "name [ipv4|ipv6] [short description]". Without
+// a family the type applies to both.
+func parseNewIcmpTypeInput(value string, known []string) (*firewalld.IcmpType, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("usage: name [ipv4|ipv6] [short description]")
+	}
+	name := fields[0]
+	if err := validation.IsValidZoneName(name); err != nil {
+		return nil, fmt.Errorf("invalid icmp type name: %w", err)
+	}
+	if slices.Contains(known, name) {
+		return nil, fmt.Errorf("icmp type %s already exists", name)
+	}
+	t := &firewalld.IcmpType{Name: name}
+	rest := fields[1:]
+	if len(rest) > 0 && slices.Contains(firewalld.IcmpFamilies, strings.ToLower(rest[0])) {
+		t.Destinations = []string{strings.ToLower(rest[0])}
+		rest = rest[1:]
+	}
+	t.Short = strings.Join(rest, " ")
+	return t, nil
+}
+
+func (m *Model) submitIcmpTypeInput(value string) tea.Cmd {
+	switch m.inputMode {
+	case inputAddIcmpType:
+		t, err := parseNewIcmpTypeInput(value, m.icmpTypes)
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		m.inputMode = inputNone
+		m.input.Blur()
+		m.err = nil
+		m.notice = ""
+		if m.dryRun {
+			m.setDryRunNotice(fmt.Sprintf("create icmp type %s", t.Name))
+			return nil
+		}
+		m.icmpTypesLoading = true
+		return createIcmpTypeCmd(m.client, t)
+	case inputDeleteIcmpType:
+		name := m.selectedIcmpBrowserType()
+		if name == "" {
+			m.err = fmt.Errorf("no icmp type selected")
+			return nil
+		}
+		if value != name {
+			m.err = fmt.Errorf("type icmp type name to confirm deletion")
+			return nil
+		}
+		m.inputMode = inputNone
+		m.input.Blur()
+		m.err = nil
+		m.notice = ""
+		if m.dryRun {
+			m.setDryRunNotice(fmt.Sprintf("delete icmp type %s", name))
+			return nil
+		}
+		m.icmpTypesLoading = true
+		return deleteIcmpTypeCmd(m.client, name)
+	}
+	return nil
+}
+
+func (m Model) handleIcmpTypeSaved(msg icmpTypeSavedMsg) (Model, tea.Cmd) {
+	m.icmpTypesLoading = false
+	if msg.err != nil {
+		m.err = msg.err
+		return m, nil
+	}
+	m.err = nil
+	delete(m.icmpTypeInfo, msg.name)
+	if msg.deleted {
+		m.notice = fmt.Sprintf("ICMP type %s deleted", msg.name)
+	} else {
+		m.notice = fmt.Sprintf("ICMP type %s created", msg.name)
+		if m.offlineRoot == "" {
+			m.notice += " (reload to use it at runtime)"
+		}
+	}
+	m.pendingIcmpType = msg.name
+	m.icmpTypesLoading = true
+	m.icmpTypesErr = nil
+	return m, fetchIcmpTypesCmd(m.client)
+}
+
+func (m Model) handleIcmpBrowserMode(msg tea.Msg) (Model, tea.Cmd, bool) {
+	if !m.icmpBrowserMode {
+		return m, nil, false
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, false
+	}
+
+	if step := m.listStep(key.String()); step != 0 {
+		return m, m.moveIcmpBrowser(step), true
+	}
+	if m.keys.Action(key.String()) == keymap.IcmpTypes {
+		m.closeIcmpBrowser()
+		return m, nil, true
+	}
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit, true
+	case "esc", "q":
+		m.closeIcmpBrowser()
+		return m, nil, true
+	case "enter":
+		return m, m.blockBrowserIcmpType(), true
+	case "n":
+		return m, m.startAddIcmpType(), true
+	case "D":
+		return m, m.startDeleteIcmpType(), true
+	}
+	return m, nil, true
+}
+
+func renderIcmpBrowser(b *strings.Builder, m Model) {
+	header := "ICMP Types"
+	if m.icmpTypesLoading {
+		header += " " + m.spinner.View()
+	}
+	b.WriteString(titleStyle.Render(header))
+	b.WriteString("\n\n")
+	if m.icmpTypesErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + m.icmpTypesErr.Error()))
+		b.WriteString("\n")
+		return
+	}
+	if len(m.icmpTypes) == 0 {
+		if !m.icmpTypesLoading {
+			b.WriteString(dimStyle.Render("  (no icmp types)"))
+			b.WriteString("\n")
+		}
+		return
+	}
+
+	var blocked []string
+	if current := m.currentData(); current != nil {
+		blocked = current.IcmpBlocks
+	}
+	for i, name := range m.icmpTypes {
+		line := "  " + name
+		if info := m.icmpTypeInfo[name]; info != nil && !info.Builtin {
+			line += " (custom)"
+		}
+		if slices.Contains(blocked, name) {
+			line += " (blocked)"
+		}
+		if i == m.icmpBrowserIndex {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n")
+	name := m.selectedIcmpBrowserType()
+	info := m.icmpTypeInfo[name]
+	switch {
+	case m.icmpTypeInfoErr != nil:
+		b.WriteString(errorStyle.Render("Error: " + m.icmpTypeInfoErr.Error()))
+		b.WriteString("\n")
+	case info == nil:
+		b.WriteString(dimStyle.Render("Loading... " + m.spinner.View()))
+		b.WriteString("\n")
+	default:
+		if info.Short != "" {
+			b.WriteString("Short: " + info.Short + "\n")
+		}
+		if info.Description != "" {
+			b.WriteString("Description: " + info.Description + "\n")
+		}
+		destinations := "ipv4, ipv6"
+		if len(info.Destinations) > 0 {
+			destinations = strings.Join(info.Destinations, ", ")
+		}
+		b.WriteString("Destinations: " + destinations + "\n")
+		if info.Builtin {
+			b.WriteString(dimStyle.Render("Shipped with firewalld") + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("Enter block in zone (" + modeLabel(m.permanent) + "), n new type, D delete custom type, Esc close"))
+}
@@ -0,0 +1,143 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func icmpTestModel() Model {
+	m := NewModel(&firewalld.Client{}, Options{DryRun: true})
+	m.zones = []string{"public"}
+	m.tab = tabInfo
+	m.focus = focusMain
+	m.runtimeData = &firewalld.Zone{Name: "public", Target: "default", IcmpBlocks: []string{"echo-request"}}
+	m.icmpTypes = []string{"echo-reply", "echo-request", "timestamp-request"}
+	return m
+}
+
+func TestIcmpPickerFilterAndChoose(t *testing.T) {
+	m := icmpTestModel()
+	if cmd := m.startIcmpPicker(); cmd != nil || !m.icmpPickerMode {
+		t.Fatalf("picker should open without refetching cached types")
+	}
+
+	for _, r := range "echo" {
+		next, _, handled := m.handleIcmpPickerMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		if !handled {
+			t.Fatalf("picker should handle typed keys")
+		}
+		m = next
+	}
+	if got := m.filteredIcmpTypes(); len(got) != 2 {
+		t.Fatalf("filteredIcmpTypes() = %v, want 2 echo types", got)
+	}
+
+	m, _, _ = m.handleIcmpPickerMode(tea.KeyMsg{Type: tea.KeyDown})
+	m, _, _ = m.handleIcmpPickerMode(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.icmpPickerMode || m.err == nil {
+		t.Fatalf("already blocked type should be rejected")
+	}
+
+	m, _, _ = m.handleIcmpPickerMode(tea.KeyMsg{Type: tea.KeyUp})
+	m, _, _ = m.handleIcmpPickerMode(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.icmpPickerMode || m.notice == "" {
+		t.Fatalf("choosing a type should close the picker, notice = %q", m.notice)
+	}
+}
+
+func TestRemoveSelectedIcmpBlock(t *testing.T) {
+	m := icmpTestModel()
+	m.removeSelected()
+	if m.err != nil || m.notice == "" {
+		t.Fatalf("removeSelected() on Info should unblock the icmp type, err = %v", m.err)
+	}
+}
+
+func TestEditTargetRequiresPermanent(t *testing.T) {
+	m := icmpTestModel()
+	m.startEditTarget()
+	if m.err == nil || m.inputMode == inputSetTarget {
+		t.Fatalf("target editing should be refused in runtime mode")
+	}
+
+	m.permanent = true
+	m.permanentData = m.runtimeData
+	m.startEditTarget()
+	if m.inputMode != inputSetTarget {
+		t.Fatalf("inputMode = %v, want inputSetTarget", m.inputMode)
+	}
+	m.input.SetValue("bogus")
+	m.submitInput()
+	if m.err == nil || m.inputMode != inputSetTarget {
+		t.Fatalf("invalid target should keep the input open")
+	}
+	m.input.SetValue("reject")
+	m.submitInput()
+	if m.inputMode != inputNone || m.notice == "" {
+		t.Fatalf("valid target should be applied, notice = %q", m.notice)
+	}
+}
+
+func TestParseNewIcmpTypeInput(t *testing.T) {
+	got, err := parseNewIcmpTypeInput("mld-query IPv6 MLD query", []string{"echo-request"})
+	if err != nil {
+		t.Fatalf("parseNewIcmpTypeInput() error = %v", err)
+	}
+	if got.Name != "mld-query" || got.Short != "MLD query" || len(got.Destinations) != 1 || got.Destinations[0] != "ipv6" {
+		t.Fatalf("parseNewIcmpTypeInput() = %+v", got)
+	}
+	if got, err := parseNewIcmpTypeInput("custom", nil); err != nil || got.Destinations != nil {
+		t.Fatalf("type without family = %+v, %v", got, err)
+	}
+	for _, input := range []string{"", "echo-request", "bad/name"} {
+		if _, err := parseNewIcmpTypeInput(input, []string{"echo-request"}); err == nil {
+			t.Fatalf("parseNewIcmpTypeInput(%q) should fail", input)
+		}
+	}
+}
+
+func TestIcmpBrowser(t *testing.T) {
+	m := icmpTestModel()
+	m.icmpTypeInfo = map[string]*firewalld.IcmpType{
+		"echo-reply":   {Name: "echo-reply", Builtin: true},
+		"echo-request": {Name: "echo-request", Builtin: true},
+	}
+	if cmd := m.startIcmpBrowser(); cmd != nil || !m.icmpBrowserMode {
+		t.Fatalf("browser should open on cached types and info")
+	}
+
+	m, _, _ = m.handleIcmpBrowserMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'D'}})
+	if m.inputMode == inputDeleteIcmpType || m.err == nil {
+		t.Fatalf("shipped icmp types should not be deletable")
+	}
+
+	m, _, _ = m.handleIcmpBrowserMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if m.inputMode != inputAddIcmpType {
+		t.Fatalf("inputMode = %v, want inputAddIcmpType", m.inputMode)
+	}
+	m.input.SetValue("mld-query ipv6")
+	m.submitInput()
+	if m.inputMode != inputNone || m.notice == "" {
+		t.Fatalf("creating a type should be reported in dry run, err = %v", m.err)
+	}
+
+	m, _, _ = m.handleIcmpBrowserMode(tea.KeyMsg{Type: tea.KeyDown})
+	m, _, _ = m.handleIcmpBrowserMode(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.icmpBrowserMode || m.err == nil {
+		t.Fatalf("already blocked type should be rejected")
+	}
+	m, cmd, _ := m.handleIcmpBrowserMode(tea.KeyMsg{Type: tea.KeyUp})
+	if cmd != nil || m.icmpBrowserIndex != 0 {
+		t.Fatalf("moving up should select the cached first type")
+	}
+	m, _, _ = m.handleIcmpBrowserMode(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.icmpBrowserMode || m.notice == "" {
+		t.Fatalf("Enter should block the type and close the browser, notice = %q", m.notice)
+	}
+}
@@ -0,0 +1,54 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/keymap"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRemappedKeys(t *testing.T) {
+	keys, err := keymap.New(map[string][]string{
+		keymap.Import:    {"ctrl+o"},
+		keymap.Down:      {"n", "down"},
+		keymap.NewOrNext: {"+"},
+	})
+	if err != nil {
+		t.Fatalf("keymap.New() error = %v", err)
+	}
+	m := NewModel(&firewalld.Client{}, Options{Keymap: keys})
+	m.zones = []string{"public", "work"}
+	m.focus = focusZones
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'i'}, Alt: true})
+	m = next.(Model)
+	if m.inputMode == inputImportZone {
+		t.Fatalf("alt+i should no longer open the import prompt")
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = next.(Model)
+	if m.selected != 1 || m.inputMode != inputNone {
+		t.Fatalf("n should move down, selected = %d, input mode = %v", m.selected, m.inputMode)
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = next.(Model)
+	if m.inputMode != inputImportZone {
+		t.Fatalf("ctrl+o should open the import prompt, input mode = %v", m.inputMode)
+	}
+
+	var b strings.Builder
+	renderHelp(&b, m)
+	out := b.String()
+	if !strings.Contains(out, "Ctrl+O") || strings.Contains(out, "Alt+I") {
+		t.Fatalf("help should list the active keys:\n%s", out)
+	}
+	if !strings.Contains(out, "n/Down") {
+		t.Fatalf("help should list remapped navigation keys:\n%s", out)
+	}
+}
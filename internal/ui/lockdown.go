@@ -0,0 +1,386 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/keymap"
+	"lazyfirewall/internal/offline"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lockdownCaller identifies this process the way firewalld matches it
+// against the lockdown whitelist.
+type lockdownCaller struct {
+	uid     int
+	user    string
+	command string
+}
+
+func currentCaller() lockdownCaller {
+	c := lockdownCaller{uid: os.Getuid(), command: strings.Join(os.Args, " ")}
+	if u, err := user.Current(); err == nil {
+		c.user = u.Username
+	}
+	return c
+}
+
+func (c lockdownCaller) String() string {
+	if c.user == "" {
+		return fmt.Sprintf("uid %d", c.uid)
+	}
+	return fmt.Sprintf("uid %d, user %s", c.uid, c.user)
+}
+
+func (c lockdownCaller) allowedBy(wl *firewalld.LockdownWhitelist) bool {
+	return wl.Allows(c.uid, c.user, c.command)
+}
+
+// matches reports whether entry e on its own lets the caller in.
+func (c lockdownCaller) matches(e firewalld.LockdownEntry) bool {
+	return c.allowedBy(whitelistOf([]firewalld.LockdownEntry{e}))
+}
+
+func whitelistOf(entries []firewalld.LockdownEntry) *firewalld.LockdownWhitelist {
+	out := &firewalld.LockdownWhitelist{}
+	for _, e := range entries {
+		switch e.Kind {
+		case "command":
+			out.Commands = append(out.Commands, e.Value)
+		case "context":
+			out.Contexts = append(out.Contexts, e.Value)
+		case "user":
+			out.Users = append(out.Users, e.Value)
+		case "uid":
+			uid, _ := strconv.Atoi(e.Value)
+			out.UIDs = append(out.UIDs, int32(uid))
+		}
+	}
+	return out
+}
+
+// whitelistWithout returns a copy of wl without entry e.
+func whitelistWithout(wl *firewalld.LockdownWhitelist, e firewalld.LockdownEntry) *firewalld.LockdownWhitelist {
+	return whitelistOf(slices.DeleteFunc(wl.Entries(), func(entry firewalld.LockdownEntry) bool {
+		return entry == e
+	}))
+}
+
+// parseWhitelistInput parses "kind value", e.g. "uid 1000", "user admin" or
+// "command /usr/bin/lazyfirewall*".
+func parseWhitelistInput(input string) (firewalld.LockdownEntry, error) {
+	kind, value, _ := strings.Cut(strings.TrimSpace(input), " ")
+	e := firewalld.LockdownEntry{Kind: strings.ToLower(kind), Value: strings.TrimSpace(value)}
+	if e.Kind == "" || e.Value == "" {
+		return firewalld.LockdownEntry{}, fmt.Errorf("expected: %s value", strings.Join(firewalld.LockdownKinds, "|"))
+	}
+	if err := firewalld.CheckLockdownEntry(e); err != nil {
+		return firewalld.LockdownEntry{}, err
+	}
+	return e, nil
+}
+
+func (m Model) currentWhitelist() *firewalld.LockdownWhitelist {
+	if m.permanent {
+		return m.whitelistPermanent
+	}
+	return m.whitelistRuntime
+}
+
+func (m Model) selectedWhitelistEntry() (firewalld.LockdownEntry, bool) {
+	entries := m.currentWhitelist().Entries()
+	if m.lockdownIndex < 0 || m.lockdownIndex >= len(entries) {
+		return firewalld.LockdownEntry{}, false
+	}
+	return entries[m.lockdownIndex], true
+}
+
+func (m *Model) startLockdownScreen() tea.Cmd {
+	m.err = nil
+	m.lockdownMode = true
+	m.lockdownIndex = 0
+	return m.refreshLockdown()
+}
+
+func (m *Model) closeLockdownScreen() {
+	m.lockdownMode = false
+	m.lockdownIndex = 0
+	m.whitelistErr = nil
+}
+
+func (m *Model) refreshLockdown() tea.Cmd {
+	m.whitelistLoading = true
+	m.whitelistErr = nil
+	return tea.Batch(fetchLockdownCmd(m.client), fetchWhitelistCmd(m.client))
+}
+
+// confirmLockdown asks to type YES before running next, showing reason.
+func (m *Model) confirmLockdown(reason string, next func(*Model) tea.Cmd) {
+	m.pendingLockdown = next
+	m.lockdownReason = reason
+	m.inputMode = inputLockdownConfirm
+	m.input.SetValue("")
+	m.input.Placeholder = "type YES to confirm"
+	m.input.CursorEnd()
+	m.input.Focus()
+}
+
+// toggleLockdown disables lockdown right away; enabling it needs YES, since
+// callers not on the runtime whitelist, possibly this one, can no longer
+// change firewalld afterwards.
+func (m *Model) toggleLockdown() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	if m.offlineRoot != "" {
+		m.err = offline.ErrRuntime
+		return nil
+	}
+	m.err = nil
+	if m.dryRun {
+		if m.lockdown {
+			m.setDryRunNotice("disable lockdown")
+		} else {
+			m.setDryRunNotice("enable lockdown")
+		}
+		return nil
+	}
+	if m.lockdown {
+		return setLockdownCmd(m.client, false)
+	}
+	reason := "Only callers on the runtime whitelist will be able to change firewalld."
+	if m.whitelistRuntime != nil && !m.caller.allowedBy(m.whitelistRuntime) {
+		reason += fmt.Sprintf(" This session (%s) is not whitelisted and will lose write access.", m.caller)
+	}
+	m.confirmLockdown(reason, func(m *Model) tea.Cmd {
+		return setLockdownCmd(m.client, true)
+	})
+	return nil
+}
+
+func (m *Model) startAddWhitelist() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue("")
+	m.input.Placeholder = "uid 1000 | user admin | command /usr/bin/lazyfirewall* | context system_u:..."
+	m.inputMode = inputAddWhitelist
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) submitWhitelistInput(value string) tea.Cmd {
+	e, err := parseWhitelistInput(value)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.inputMode = inputNone
+	m.input.Blur()
+	m.err = nil
+	m.notice = ""
+	if m.dryRun {
+		m.setDryRunNotice(fmt.Sprintf("add lockdown whitelist %s (%s)", e, modeLabel(m.permanent)))
+		return nil
+	}
+	m.whitelistLoading = true
+	return whitelistMutationCmd(m.client, e, false, m.permanent)
+}
+
+// removeWhitelistEntry removes the selected entry. While lockdown is on,
+// dropping the entry that lets this session in needs YES.
+func (m *Model) removeWhitelistEntry() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	e, ok := m.selectedWhitelistEntry()
+	if !ok {
+		m.err = fmt.Errorf("no whitelist entry selected")
+		return nil
+	}
+	m.err = nil
+	m.notice = ""
+	if m.dryRun {
+		m.setDryRunNotice(fmt.Sprintf("remove lockdown whitelist %s (%s)", e, modeLabel(m.permanent)))
+		return nil
+	}
+	permanent := m.permanent
+	remove := func(m *Model) tea.Cmd {
+		m.whitelistLoading = true
+		return whitelistMutationCmd(m.client, e, true, permanent)
+	}
+	wl := m.currentWhitelist()
+	if !permanent && m.lockdown && m.caller.allowedBy(wl) && !m.caller.allowedBy(whitelistWithout(wl, e)) {
+		m.confirmLockdown(fmt.Sprintf("Lockdown is on and %s is what lets this session (%s) change firewalld.", e, m.caller), remove)
+		return nil
+	}
+	return remove(m)
+}
+
+func (m *Model) submitLockdownConfirm(value string) tea.Cmd {
+	if !strings.EqualFold(value, "YES") {
+		m.err = fmt.Errorf("type YES to confirm")
+		return nil
+	}
+	next := m.pendingLockdown
+	m.pendingLockdown = nil
+	m.lockdownReason = ""
+	m.inputMode = inputNone
+	m.input.Blur()
+	m.err = nil
+	if next == nil {
+		return nil
+	}
+	return next(m)
+}
+
+func (m Model) handleLockdown(msg lockdownMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		if errors.Is(msg.err, firewalld.ErrPermissionDenied) || errors.Is(msg.err, firewalld.ErrUnsupportedAPI) {
+			return m, nil
+		}
+		m.err = msg.err
+		return m, nil
+	}
+	m.lockdown = msg.enabled
+	return m, nil
+}
+
+func (m Model) handleLockdownToggle(msg lockdownToggleMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		return m, nil
+	}
+	m.err = nil
+	m.lockdown = msg.enabled
+	return m, nil
+}
+
+func (m Model) handleWhitelist(msg whitelistMsg) (Model, tea.Cmd) {
+	m.whitelistLoading = false
+	if msg.err != nil {
+		m.whitelistErr = msg.err
+		return m, nil
+	}
+	m.whitelistErr = nil
+	m.whitelistRuntime = msg.runtime
+	m.whitelistPermanent = msg.permanent
+	if n := len(m.currentWhitelist().Entries()); m.lockdownIndex >= n {
+		m.lockdownIndex = max(n-1, 0)
+	}
+	return m, nil
+}
+
+func (m Model) handleWhitelistMutation(msg whitelistMutationMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.whitelistLoading = false
+		m.err = msg.err
+		return m, nil
+	}
+	m.err = nil
+	return m, fetchWhitelistCmd(m.client)
+}
+
+func (m Model) handleLockdownMode(msg tea.Msg) (Model, tea.Cmd, bool) {
+	if !m.lockdownMode {
+		return m, nil, false
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, false
+	}
+
+	if step := m.listStep(key.String()); step != 0 {
+		m.lockdownIndex = max(min(m.lockdownIndex+step, len(m.currentWhitelist().Entries())-1), 0)
+		return m, nil, true
+	}
+	switch m.keys.Action(key.String()) {
+	case keymap.Lockdown:
+		m.closeLockdownScreen()
+		return m, nil, true
+	case keymap.TogglePermanent:
+		// The global handler switches modes; both whitelists are loaded.
+		m.lockdownIndex = 0
+		return m, nil, false
+	}
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit, true
+	case "esc", "q":
+		m.closeLockdownScreen()
+		return m, nil, true
+	case "L":
+		return m, m.toggleLockdown(), true
+	case "a":
+		return m, m.startAddWhitelist(), true
+	case "d":
+		return m, m.removeWhitelistEntry(), true
+	case "r":
+		return m, m.refreshLockdown(), true
+	}
+	return m, nil, true
+}
+
+func renderLockdownScreen(b *strings.Builder, m Model) {
+	header := "Lockdown"
+	if m.whitelistLoading {
+		header += " " + m.spinner.View()
+	}
+	b.WriteString(titleStyle.Render(header))
+	b.WriteString("\n\n")
+	if m.lockdown {
+		b.WriteString("Status: " + warnStyle.Render("enabled") + "\n")
+	} else {
+		b.WriteString("Status: disabled\n")
+	}
+	b.WriteString(dimStyle.Render("This session: " + m.caller.String()))
+	b.WriteString("\n\n")
+
+	b.WriteString("Whitelist (" + modeLabel(m.permanent) + "):\n")
+	if m.whitelistErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + m.whitelistErr.Error()))
+		b.WriteString("\n")
+		return
+	}
+	wl := m.currentWhitelist()
+	entries := wl.Entries()
+	if len(entries) == 0 {
+		if !m.whitelistLoading {
+			b.WriteString(dimStyle.Render("  (empty)"))
+			b.WriteString("\n")
+		}
+		return
+	}
+	var permanentEntries []firewalld.LockdownEntry
+	if !m.permanent && m.whitelistPermanent != nil {
+		permanentEntries = m.whitelistPermanent.Entries()
+	}
+	for i, e := range entries {
+		line := fmt.Sprintf("  %-8s %s", e.Kind, e.Value)
+		if m.caller.matches(e) {
+			line += " (this session)"
+		}
+		if permanentEntries != nil && !slices.Contains(permanentEntries, e) {
+			line += " *"
+		}
+		if i == m.lockdownIndex {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+}
@@ -0,0 +1,71 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseWhitelistInput(t *testing.T) {
+	e, err := parseWhitelistInput("command /usr/bin/python3 -s /usr/bin/firewall-cmd*")
+	if err != nil || e.Kind != "command" || e.Value != "/usr/bin/python3 -s /usr/bin/firewall-cmd*" {
+		t.Fatalf("parseWhitelistInput() = %+v, %v", e, err)
+	}
+	for _, input := range []string{"", "uid", "uid x", "group wheel"} {
+		if _, err := parseWhitelistInput(input); err == nil {
+			t.Fatalf("parseWhitelistInput(%q) should fail", input)
+		}
+	}
+}
+
+func TestLockdownEnableNeedsConfirm(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{})
+	m.caller = lockdownCaller{uid: 1000, user: "alice", command: "lazyfirewall"}
+	m, _ = m.handleWhitelist(whitelistMsg{
+		runtime:   &firewalld.LockdownWhitelist{UIDs: []int32{0}},
+		permanent: &firewalld.LockdownWhitelist{UIDs: []int32{0}},
+	})
+	m.lockdownMode = true
+
+	m, _, _ = m.handleLockdownMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'L'}})
+	if m.inputMode != inputLockdownConfirm || !strings.Contains(m.lockdownReason, "uid 1000, user alice") {
+		t.Fatalf("enabling lockdown should warn about this session, reason = %q", m.lockdownReason)
+	}
+	m.input.SetValue("no")
+	if cmd := m.submitInput(); cmd != nil || m.inputMode != inputLockdownConfirm {
+		t.Fatalf("only YES should confirm")
+	}
+	m.input.SetValue("YES")
+	if cmd := m.submitInput(); cmd == nil || m.inputMode != inputNone || m.pendingLockdown != nil {
+		t.Fatalf("YES should run the pending change")
+	}
+}
+
+func TestLockdownRemoveOwnEntryNeedsConfirm(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{})
+	m.caller = lockdownCaller{uid: 1000, user: "alice"}
+	m.lockdown = true
+	wl := &firewalld.LockdownWhitelist{Users: []string{"alice"}, UIDs: []int32{0}}
+	m, _ = m.handleWhitelist(whitelistMsg{runtime: wl, permanent: &firewalld.LockdownWhitelist{}})
+
+	var b strings.Builder
+	renderLockdownScreen(&b, m)
+	if out := b.String(); !strings.Contains(out, "alice (this session) *") || strings.Contains(out, "0 (this session)") {
+		t.Fatalf("only alice should be marked as this session:\n%s", out)
+	}
+
+	m.lockdownIndex = 1 // uid 0
+	if cmd := m.removeWhitelistEntry(); cmd == nil || m.inputMode == inputLockdownConfirm {
+		t.Fatalf("removing someone else's entry should not need confirmation")
+	}
+	m.lockdownIndex = 0 // user alice
+	if cmd := m.removeWhitelistEntry(); cmd != nil || m.inputMode != inputLockdownConfirm {
+		t.Fatalf("removing this session's entry should need confirmation")
+	}
+}
@@ -0,0 +1,76 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"log/slog"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/lockout"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type lockoutMsg struct {
+	result  lockout.Result
+	err     error
+	proceed func(*Model) tea.Cmd
+}
+
+// guardLockout runs proceed straight away unless lazyfirewall is running over
+// SSH; then it first checks whether the change would block the session and
+// asks for explicit confirmation if so.
+func (m *Model) guardLockout(change lockout.Change, proceed func(*Model) tea.Cmd) tea.Cmd {
+	if m.ssh == nil {
+		return proceed(m)
+	}
+	return lockoutCheckCmd(m.client, m.ssh, change, m.permanent, proceed)
+}
+
+// guardEditRichRule replaces oldRule with newRule once the lockout check
+// passes; the edit removes the old rule, which may be what accepts SSH.
+func (m *Model) guardEditRichRule(zone, label, oldRule, newRule string) tea.Cmd {
+	permanent := m.permanent
+	change := lockout.Change{Kind: lockout.RemoveRichRule, Zone: zone, Value: oldRule, Replace: newRule}
+	return m.guardLockout(change, func(m *Model) tea.Cmd {
+		return m.safeMutation(zone, label, permanent, false, m.actionEditRichRule(zone, oldRule, newRule, permanent))
+	})
+}
+
+// guardSetDefaultZone changes the default zone once the lockout check passes;
+// a session no source or interface binding claims moves with it.
+func (m *Model) guardSetDefaultZone(zone string) tea.Cmd {
+	return m.guardLockout(lockout.Change{Kind: lockout.SetDefaultZone, Value: zone}, func(m *Model) tea.Cmd {
+		return setDefaultZoneCmd(m.client, zone)
+	})
+}
+
+func lockoutCheckCmd(client firewalld.Backend, session *lockout.Session, change lockout.Change, permanent bool, proceed func(*Model) tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		res, err := lockout.Check(client, session, change, permanent)
+		return lockoutMsg{result: res, err: err, proceed: proceed}
+	}
+}
+
+func (m Model) handleLockoutResult(msg lockoutMsg) (tea.Model, tea.Cmd) {
+	reason := msg.result.Reason
+	switch {
+	case msg.err != nil:
+		slog.Warn("lockout analysis failed", "error", msg.err)
+		reason = "could not check whether it does: " + msg.err.Error()
+	case !msg.result.Blocked:
+		return m, msg.proceed(&m)
+	default:
+		slog.Warn("change would block the ssh session", "reason", reason)
+	}
+	m.err = nil
+	m.pendingLockout = msg.proceed
+	m.lockoutReason = reason
+	m.inputMode = inputLockoutConfirm
+	m.input.SetValue("")
+	m.input.Placeholder = "type YES to apply anyway"
+	m.input.CursorEnd()
+	m.input.Focus()
+	return m, nil
+}
@@ -0,0 +1,158 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/lockout"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestGuardLockoutWithoutSession(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{})
+	m.ssh = nil
+	called := false
+	m.guardLockout(lockout.Change{Kind: lockout.RemoveService, Zone: "public", Value: "ssh"}, func(*Model) tea.Cmd {
+		called = true
+		return nil
+	})
+	if !called {
+		t.Fatalf("guardLockout() should proceed immediately outside SSH")
+	}
+}
+
+func TestHandleLockoutResult(t *testing.T) {
+	proceeded := 0
+	proceed := func(*Model) tea.Cmd {
+		proceeded++
+		return nil
+	}
+
+	m := NewModel(&firewalld.Client{}, Options{})
+	next, _ := m.Update(lockoutMsg{result: lockout.Result{Zone: "public"}, proceed: proceed})
+	m = next.(Model)
+	if proceeded != 1 || m.inputMode != inputNone {
+		t.Fatalf("safe change should proceed without confirmation")
+	}
+
+	next, _ = m.Update(lockoutMsg{err: errors.New("dbus"), proceed: proceed})
+	m = next.(Model)
+	if proceeded != 1 || m.inputMode != inputLockoutConfirm || !strings.Contains(m.lockoutReason, "dbus") {
+		t.Fatalf("analysis errors should wait for confirmation, mode = %v, reason = %q", m.inputMode, m.lockoutReason)
+	}
+	m, _, _ = m.handleInputMode(tea.KeyMsg{Type: tea.KeyEsc})
+
+	next, _ = m.Update(lockoutMsg{result: lockout.Result{Zone: "public", Blocked: true, Reason: "no ssh"}, proceed: proceed})
+	m = next.(Model)
+	if proceeded != 1 || m.inputMode != inputLockoutConfirm || m.lockoutReason != "no ssh" {
+		t.Fatalf("blocking change should wait for confirmation, mode = %v", m.inputMode)
+	}
+
+	m.input.SetValue("no")
+	m.submitInput()
+	if proceeded != 1 || m.err == nil {
+		t.Fatalf("anything but YES should be rejected")
+	}
+
+	m.input.SetValue("yes")
+	m.submitInput()
+	if proceeded != 2 || m.inputMode != inputNone || m.pendingLockout != nil {
+		t.Fatalf("YES should apply the change")
+	}
+}
+
+func TestLockoutConfirmEscCancels(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{})
+	m.inputMode = inputLockoutConfirm
+	m.pendingLockout = func(*Model) tea.Cmd { return nil }
+	m.lockoutReason = "no ssh"
+
+	next, _, handled := m.handleInputMode(tea.KeyMsg{Type: tea.KeyEsc})
+	if !handled {
+		t.Fatalf("expected input mode to handle esc")
+	}
+	if next.pendingLockout != nil || next.inputMode != inputNone {
+		t.Fatalf("esc should drop the pending change")
+	}
+}
+
+func TestEditRichRuleGuardsLockout(t *testing.T) {
+	accept := `rule family="ipv4" source address="192.0.2.0/24" port port="22" protocol="tcp" accept`
+	zone := &firewalld.Zone{Name: "public", RichRules: []string{accept}}
+	m := NewModel(&lockoutBackend{zone: zone}, Options{})
+	m.ssh = &lockout.Session{Client: net.ParseIP("192.0.2.10"), Port: 22, Interface: "eth0"}
+	m.loading = false
+
+	moved := `rule priority="-1" family="ipv4" source address="192.0.2.0/24" port port="22" protocol="tcp" accept`
+	msg, ok := m.guardEditRichRule("public", "move rich rule up", accept, moved)().(lockoutMsg)
+	if !ok {
+		t.Fatalf("guardEditRichRule() did not run the lockout check")
+	}
+	next, cmd := m.Update(msg)
+	if got := next.(Model); got.inputMode != inputNone || cmd == nil {
+		t.Fatalf("an edit that still accepts SSH should proceed, mode = %v", got.inputMode)
+	}
+
+	drop := `rule family="ipv4" source address="192.0.2.0/24" port port="22" protocol="tcp" drop`
+	msg, ok = m.guardEditRichRule("public", "edit rich rule", accept, drop)().(lockoutMsg)
+	if !ok {
+		t.Fatalf("guardEditRichRule() did not run the lockout check")
+	}
+	next, _ = m.Update(msg)
+	m = next.(Model)
+	if m.inputMode != inputLockoutConfirm || !strings.Contains(m.lockoutReason, "editing the rich rule") {
+		t.Fatalf("input mode = %v, reason = %q, want lockout confirmation", m.inputMode, m.lockoutReason)
+	}
+}
+
+func TestDeleteZoneGuardsLockout(t *testing.T) {
+	zone := &firewalld.Zone{Name: "public", Ports: []firewalld.Port{{Port: "22", Protocol: "tcp"}}}
+	m := NewModel(&lockoutBackend{zone: zone}, Options{})
+	m.ssh = &lockout.Session{Client: net.ParseIP("192.0.2.10"), Port: 22, Interface: "eth0"}
+	m.loading = false
+	m.zones = []string{"public"}
+	m.inputMode = inputDeleteZone
+	m.input.SetValue("public")
+
+	cmd := m.submitInput()
+	if cmd == nil || m.loading {
+		t.Fatalf("deleting a zone should wait for the lockout check, loading = %v", m.loading)
+	}
+	msg, ok := cmd().(lockoutMsg)
+	if !ok {
+		t.Fatalf("deleting a zone did not run the lockout check")
+	}
+
+	next, _ := m.Update(msg)
+	m = next.(Model)
+	if m.inputMode != inputLockoutConfirm || !strings.Contains(m.lockoutReason, "deleting zone public") {
+		t.Fatalf("input mode = %v, reason = %q, want lockout confirmation", m.inputMode, m.lockoutReason)
+	}
+}
+
+func TestSetDefaultZoneGuardsLockout(t *testing.T) {
+	backend := &lockoutBackend{zones: map[string]*firewalld.Zone{
+		"public": {Name: "public", Ports: []firewalld.Port{{Port: "22", Protocol: "tcp"}}},
+		"block":  {Name: "block", Target: "%%REJECT%%"},
+	}}
+	m := NewModel(backend, Options{})
+	m.ssh = &lockout.Session{Client: net.ParseIP("192.0.2.10"), Port: 22}
+	m.loading = false
+
+	msg, ok := m.guardSetDefaultZone("block")().(lockoutMsg)
+	if !ok {
+		t.Fatalf("guardSetDefaultZone() did not run the lockout check")
+	}
+	next, _ := m.Update(msg)
+	m = next.(Model)
+	if m.inputMode != inputLockoutConfirm || !strings.Contains(m.lockoutReason, "setting default zone block") {
+		t.Fatalf("input mode = %v, reason = %q, want lockout confirmation", m.inputMode, m.lockoutReason)
+	}
+}
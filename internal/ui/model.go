@@ -4,11 +4,16 @@
 package ui
 
 import (
+	"log/slog"
 	"sync"
 	"time"
 
 	"lazyfirewall/internal/backup"
 	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/keymap"
+	"lazyfirewall/internal/lockout"
+	"lazyfirewall/internal/templates"
+	"lazyfirewall/internal/theme"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -31,6 +36,8 @@ const (
 	tabNetwork
 	tabIPSets
 	tabInfo
+	tabPolicies
+	tabDirect
 )
 
 type inputMode int
@@ -43,9 +50,12 @@ const (
 	inputEditRich
 	inputAddInterface
 	inputAddSource
+	inputAddForwardPort
 	inputAddZone
 	inputDeleteZone
 	inputPanicConfirm
+	inputLockoutConfirm
+	inputSetTarget
 	inputExportZone
 	inputImportZone
 	inputSearch
@@ -54,30 +64,53 @@ const (
 	inputRemoveIPSetEntry
 	inputDeleteIPSet
 	inputManualBackup
+	inputAddPolicy
+	inputEditPolicy
+	inputDeletePolicy
+	inputDeleteService
+	inputAddIcmpType
+	inputDeleteIcmpType
+	inputAddHelper
+	inputDeleteHelper
+	inputEditDirect
+	inputAddWhitelist
+	inputLockdownConfirm
+	inputSetting
+	inputTemplateParam
 )
 
 type networkItem struct {
-	kind  string
-	value string
+	kind    string
+	value   string
+	forward firewalld.ForwardPort
 }
 
 type Model struct {
-	client    *firewalld.Client
+	client    firewalld.Backend
 	zones     []string
 	selected  int
 	focus     focusArea
 	permanent bool
 	dryRun    bool
+	// offlineRoot is the configuration directory being edited when there is
+	// no running firewalld; empty otherwise.
+	offlineRoot string
 
 	tab                 mainTab
 	serviceIndex        int
 	portIndex           int
 	richIndex           int
+	richFollow          string
 	networkIndex        int
+	icmpIndex           int
 	splitView           bool
 	searchQuery         string
 	templateMode        bool
 	templateIndex       int
+	templates           []templates.Template
+	templateParams      []templates.Param
+	templateValues      map[string]string
+	templatePlan        *templatePlan
 	helpMode            bool
 	readOnly            bool
 	runtimeDenied       bool
@@ -89,10 +122,22 @@ type Model struct {
 	signals             <-chan firewalld.SignalEvent
 	signalsCancel       func()
 	signalRefresh       bool
+	keys                *keymap.Keymap
+	polling             bool
+	pollInterval        time.Duration
 	panicMode           bool
 	panicCountdown      int
 	panicAutoDur        time.Duration
 	panicAutoArmed      bool
+	ssh                 *lockout.Session
+	sshWarning          string
+	confirmTimeout      time.Duration
+	safety              *safetyState
+	safetySeq           int
+	pendingLockout      func(*Model) tea.Cmd
+	lockoutReason       string
+	pendingLockdown     func(*Model) tea.Cmd
+	lockdownReason      string
 	backupMode          bool
 	backupItems         []backup.Backup
 	backupIndex         int
@@ -101,8 +146,11 @@ type Model struct {
 	backupDone          map[string]bool
 	pendingMutation     tea.Cmd
 	notice              string
+	undoSeq             int
 	undoStack           []undoAction
 	redoStack           []undoAction
+	expiries            map[string]time.Time
+	expiryTicking       bool
 	ipsets              []string
 	ipsetIndex          int
 	ipsetEntries        []string
@@ -112,6 +160,32 @@ type Model struct {
 	ipsetErr            error
 	ipsetEntriesErr     error
 	ipsetDenied         bool
+	policies            []string
+	policyIndex         int
+	policy              *firewalld.Policy
+	policyName          string
+	pendingPolicy       string
+	policiesLoading     bool
+	policyLoading       bool
+	policiesErr         error
+	policyErr           error
+	policiesDenied      bool
+	directRuntime       *firewalld.DirectConfig
+	directPermanent     *firewalld.DirectConfig
+	directIndex         int
+	directLoading       bool
+	directErr           error
+	directDenied        bool
+	icmpPickerMode      bool
+	icmpPickerIndex     int
+	icmpTypes           []string
+	icmpTypesLoading    bool
+	icmpTypesErr        error
+	icmpBrowserMode     bool
+	icmpBrowserIndex    int
+	icmpTypeInfo        map[string]*firewalld.IcmpType
+	icmpTypeInfoErr     error
+	pendingIcmpType     string
 	availableServices   []string
 	servicesLoading     bool
 	servicesErr         error
@@ -128,6 +202,34 @@ type Model struct {
 	detailsName    string
 	details        *firewalld.ServiceInfo
 	detailsErr     error
+	serviceEdit    *serviceEditor
+	ruleBuilder    *ruleBuilder
+	helperMode     bool
+	helperIndex    int
+	helpers        []string
+	helpersLoading bool
+	helpersErr     error
+	helperInfo     map[string]*firewalld.Helper
+	helperInfoErr  error
+	pendingHelper  string
+	serviceSaving  bool
+
+	lockdown           bool
+	lockdownMode       bool
+	lockdownIndex      int
+	whitelistRuntime   *firewalld.LockdownWhitelist
+	whitelistPermanent *firewalld.LockdownWhitelist
+	whitelistLoading   bool
+	whitelistErr       error
+	caller             lockdownCaller
+
+	settingsMode    bool
+	settingsIndex   int
+	settings        map[string]string
+	logDenied       string
+	settingsLoading bool
+	settingsErr     error
+	editSetting     string
 
 	runtimeData   *firewalld.Zone
 	permanentData *firewalld.Zone
@@ -146,9 +248,25 @@ type Options struct {
 	DryRun           bool
 	NoColor          bool
 	DefaultPermanent bool
+	ConfirmTimeout   time.Duration
+	// AutoRefresh polls firewalld at this interval; 0 polls only when
+	// signals are unavailable.
+	AutoRefresh time.Duration
+	// Keymap binds keys to actions; nil is the default keymap.
+	Keymap *keymap.Keymap
+	// Theme colors the interface; the zero value keeps the default theme.
+	Theme theme.Theme
+	// Templates are the zone templates offered; nil offers the built-in
+	// ones.
+	Templates []templates.Template
+	// OfflineRoot is set when client edits a configuration directory
+	// offline. Only permanent configuration exists then, and backups,
+	// imports, lockout checks and the confirm timer, which all act on the
+	// host's firewall, are disabled.
+	OfflineRoot string
 }
 
-func NewModel(client *firewalld.Client, opts Options) Model {
+func NewModel(client firewalld.Backend, opts Options) Model {
 	sp := spinner.New()
 	sp.Spinner = spinner.Line
 
@@ -157,6 +275,20 @@ func NewModel(client *firewalld.Client, opts Options) Model {
 	ti.Width = 32
 	ti.Prompt = ""
 
+	ssh, sshErr := lockout.DetectSession()
+	confirmTimeout := opts.ConfirmTimeout
+	permanent := opts.DefaultPermanent
+	if opts.OfflineRoot != "" {
+		ssh, sshErr = nil, nil
+		confirmTimeout = 0
+		permanent = true
+	}
+	sshWarning := ""
+	if sshErr != nil {
+		slog.Warn("ssh session not detected", "error", sshErr)
+		sshWarning = "SSH session not detected (" + sshErr.Error() + "); lockout checks and the confirm timer are off"
+	}
+
 	return Model{
 		client:          client,
 		focus:           focusZones,
@@ -165,12 +297,23 @@ func NewModel(client *firewalld.Client, opts Options) Model {
 		spinner:         sp,
 		input:           ti,
 		inputMode:       inputNone,
-		permanent:       opts.DefaultPermanent,
+		permanent:       permanent,
 		readOnly:        client.ReadOnly(),
 		dryRun:          opts.DryRun,
 		panicAutoDur:    10 * time.Minute,
+		ssh:             ssh,
+		sshWarning:      sshWarning,
+		confirmTimeout:  confirmTimeout,
+		keys:            opts.Keymap,
+		templates:       opts.Templates,
+		polling:         opts.AutoRefresh > 0,
+		pollInterval:    opts.AutoRefresh,
+		offlineRoot:     opts.OfflineRoot,
 		backupDone:      make(map[string]bool),
 		ipsetLoading:    true,
+		policiesLoading: true,
+		directLoading:   true,
+		caller:          currentCaller(),
 		servicesLoading: true,
 		logLinesStore:   &logLinesStore{},
 	}
@@ -221,12 +364,15 @@ func (m *Model) networkItems() []networkItem {
 	if current == nil {
 		return nil
 	}
-	items := make([]networkItem, 0, len(current.Interfaces)+len(current.Sources))
+	items := make([]networkItem, 0, len(current.Interfaces)+len(current.Sources)+len(current.ForwardPorts))
 	for _, iface := range current.Interfaces {
 		items = append(items, networkItem{kind: "iface", value: iface})
 	}
 	for _, src := range current.Sources {
 		items = append(items, networkItem{kind: "source", value: src})
 	}
+	for _, fp := range current.ForwardPorts {
+		items = append(items, networkItem{kind: "forward", value: firewalld.FormatForwardPort(fp), forward: fp})
+	}
 	return items
 }
@@ -4,9 +4,14 @@
 package ui
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/offline"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 func TestNewModelDefaults(t *testing.T) {
@@ -74,3 +79,29 @@ func TestEnsureLogLinesStoreInitializes(t *testing.T) {
 		t.Fatalf("getLogLines() = %#v, want [line]", lines)
 	}
 }
+
+func TestNewModelOffline(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{OfflineRoot: "/mnt/etc/firewalld", ConfirmTimeout: 30 * time.Second})
+	if !m.permanent || m.ssh != nil || m.confirmTimeout != 0 {
+		t.Fatalf("offline model = permanent %v, ssh %v, confirmTimeout %v", m.permanent, m.ssh, m.confirmTimeout)
+	}
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+	m = next.(Model)
+	if !m.permanent || !errors.Is(m.err, offline.ErrRuntime) {
+		t.Fatalf("P offline: permanent = %v, err = %v", m.permanent, m.err)
+	}
+
+	m.zones = []string{"public"}
+	m.err = nil
+	m.startManualBackup()
+	if !errors.Is(m.err, errOfflineBackup) {
+		t.Fatalf("startManualBackup() offline err = %v", m.err)
+	}
+	called := false
+	cmd := m.maybeBackup("public", true, func() tea.Msg { called = true; return nil })
+	cmd()
+	if !called {
+		t.Fatalf("maybeBackup() offline should run the change without a backup")
+	}
+}
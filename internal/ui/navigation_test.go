@@ -18,8 +18,20 @@ func TestTabsCycle(t *testing.T) {
 
 	m.tab = tabServices
 	m.prevTab()
-	if m.tab != tabInfo {
-		t.Fatalf("prevTab from services = %v, want %v", m.tab, tabInfo)
+	if m.tab != tabDirect {
+		t.Fatalf("prevTab from services = %v, want %v", m.tab, tabDirect)
+	}
+
+	m.tab = tabInfo
+	m.nextTab()
+	if m.tab != tabPolicies {
+		t.Fatalf("nextTab from info = %v, want %v", m.tab, tabPolicies)
+	}
+
+	m.nextTab()
+	m.nextTab()
+	if m.tab != tabServices {
+		t.Fatalf("nextTab from direct = %v, want %v", m.tab, tabServices)
 	}
 }
 
@@ -0,0 +1,343 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/richrule"
+	"lazyfirewall/internal/validation"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var policyTargets = []string{"CONTINUE", "ACCEPT", "DROP", "REJECT"}
+
+type policyEdit struct {
+	remove bool
+	kind   string
+	value  string
+}
+
+// parsePolicyEdit parses the Policies tab edit prompt: "[-]kind value", e.g.
+// "service http", "-port 8080/tcp", "ingress internal", "target ACCEPT".
+func parsePolicyEdit(input string) (policyEdit, error) {
+	input = strings.TrimSpace(input)
+	var e policyEdit
+	if strings.HasPrefix(input, "-") {
+		e.remove = true
+		input = strings.TrimSpace(input[1:])
+	} else if strings.HasPrefix(input, "+") {
+		input = strings.TrimSpace(input[1:])
+	}
+	kind, value, _ := strings.Cut(input, " ")
+	e.kind = strings.ToLower(kind)
+	e.value = strings.TrimSpace(value)
+	if e.kind == "" || e.value == "" {
+		return policyEdit{}, fmt.Errorf("expected: [-]kind value (e.g. service http, -port 80/tcp, ingress internal)")
+	}
+	switch e.kind {
+	case "service", "port", "protocol", "rule", "ingress", "egress", "icmp-block":
+	case "target", "priority", "masquerade", "short", "description":
+		if e.remove {
+			return policyEdit{}, fmt.Errorf("%s cannot be removed, set a new value instead", e.kind)
+		}
+	default:
+		return policyEdit{}, fmt.Errorf("unknown policy field: %s", e.kind)
+	}
+	return e, nil
+}
+
+// applyPolicyEdit returns a copy of p with the edit applied.
+func applyPolicyEdit(p *firewalld.Policy, e policyEdit) (*firewalld.Policy, error) {
+	next := clonePolicy(p)
+	switch e.kind {
+	case "service":
+		next.Services = editStringList(next.Services, e.value, e.remove)
+	case "protocol":
+		next.Protocols = editStringList(next.Protocols, e.value, e.remove)
+	case "icmp-block":
+		next.IcmpBlocks = editStringList(next.IcmpBlocks, e.value, e.remove)
+	case "ingress":
+		next.IngressZones = editStringList(next.IngressZones, e.value, e.remove)
+	case "egress":
+		next.EgressZones = editStringList(next.EgressZones, e.value, e.remove)
+	case "rule":
+		// parsePolicyEdit split off the leading "rule" keyword.
+		rule := "rule " + e.value
+		if err := validateRichRule(rule); err != nil {
+			return nil, err
+		}
+		next.RichRules = editRichRules(next.RichRules, rule, e.remove)
+	case "port":
+		port, err := parsePortInput(e.value)
+		if err != nil {
+			return nil, err
+		}
+		ports := make([]firewalld.Port, 0, len(next.Ports)+1)
+		found := false
+		for _, existing := range next.Ports {
+			if existing == port {
+				found = true
+				if e.remove {
+					continue
+				}
+			}
+			ports = append(ports, existing)
+		}
+		if !found && !e.remove {
+			ports = append(ports, port)
+		}
+		next.Ports = ports
+	case "target":
+		target := strings.ToUpper(e.value)
+		if !slices.Contains(policyTargets, target) {
+			return nil, fmt.Errorf("invalid target: %s (use %s)", e.value, strings.Join(policyTargets, ", "))
+		}
+		next.Target = target
+	case "priority":
+		priority, err := strconv.Atoi(e.value)
+		if err != nil || priority == 0 || priority < -32768 || priority > 32767 {
+			return nil, fmt.Errorf("invalid priority: %s (non-zero, -32768..32767)", e.value)
+		}
+		next.Priority = priority
+	case "masquerade":
+		switch strings.ToLower(e.value) {
+		case "on", "yes", "true":
+			next.Masquerade = true
+		case "off", "no", "false":
+			next.Masquerade = false
+		default:
+			return nil, fmt.Errorf("masquerade expects on or off")
+		}
+	case "short":
+		next.Short = e.value
+	case "description":
+		next.Description = e.value
+	}
+	return next, nil
+}
+
+// parseNewPolicyInput parses "name ingress egress [target]".
+func parseNewPolicyInput(input string) (*firewalld.Policy, error) {
+	fields := strings.Fields(input)
+	if len(fields) < 3 || len(fields) > 4 {
+		return nil, fmt.Errorf("expected: name ingress-zone egress-zone [target]")
+	}
+	if err := validation.IsValidZoneName(fields[0]); err != nil {
+		return nil, fmt.Errorf("invalid policy name: %w", err)
+	}
+	p := &firewalld.Policy{
+		Name:         fields[0],
+		IngressZones: []string{fields[1]},
+		EgressZones:  []string{fields[2]},
+		Target:       "CONTINUE",
+		Priority:     -1,
+	}
+	if len(fields) == 4 {
+		target := strings.ToUpper(fields[3])
+		if !slices.Contains(policyTargets, target) {
+			return nil, fmt.Errorf("invalid target: %s (use %s)", fields[3], strings.Join(policyTargets, ", "))
+		}
+		p.Target = target
+	}
+	return p, nil
+}
+
+func clonePolicy(p *firewalld.Policy) *firewalld.Policy {
+	next := *p
+	next.IngressZones = append([]string(nil), p.IngressZones...)
+	next.EgressZones = append([]string(nil), p.EgressZones...)
+	next.Services = append([]string(nil), p.Services...)
+	next.Ports = append([]firewalld.Port(nil), p.Ports...)
+	next.Protocols = append([]string(nil), p.Protocols...)
+	next.SourcePorts = append([]firewalld.Port(nil), p.SourcePorts...)
+	next.ForwardPorts = append([]firewalld.ForwardPort(nil), p.ForwardPorts...)
+	next.IcmpBlocks = append([]string(nil), p.IcmpBlocks...)
+	next.RichRules = append([]string(nil), p.RichRules...)
+	return &next
+}
+
+func editStringList(items []string, value string, remove bool) []string {
+	idx := slices.Index(items, value)
+	if remove {
+		if idx < 0 {
+			return items
+		}
+		return append(items[:idx], items[idx+1:]...)
+	}
+	if idx >= 0 {
+		return items
+	}
+	return append(items, value)
+}
+
+// editRichRules is editStringList for rich rules, matching them in
+// firewalld's normal form.
+func editRichRules(rules []string, rule string, remove bool) []string {
+	idx := slices.IndexFunc(rules, func(r string) bool {
+		return richrule.Normalize(r) == richrule.Normalize(rule)
+	})
+	if remove {
+		if idx < 0 {
+			return rules
+		}
+		return append(rules[:idx], rules[idx+1:]...)
+	}
+	if idx >= 0 {
+		return rules
+	}
+	return append(rules, rule)
+}
+
+func (m *Model) currentPolicyName() string {
+	if m.policyIndex < 0 || m.policyIndex >= len(m.policies) {
+		return ""
+	}
+	return m.policies[m.policyIndex]
+}
+
+func (m *Model) fetchCurrentPolicy() tea.Cmd {
+	if m.tab != tabPolicies {
+		return nil
+	}
+	name := m.currentPolicyName()
+	if name == "" {
+		return nil
+	}
+	m.policyLoading = true
+	m.policyErr = nil
+	m.policyName = name
+	return fetchPolicyCmd(m.client, name, m.permanent)
+}
+
+func (m *Model) startAddPolicy() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	if !m.permanent {
+		m.err = fmt.Errorf("policy creation is permanent-only (press P)")
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue("")
+	m.input.Placeholder = "name ingress-zone egress-zone [target]"
+	m.inputMode = inputAddPolicy
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) startEditPolicy(prefix string) tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	if m.policy == nil || m.policy.Name != m.currentPolicyName() {
+		m.err = fmt.Errorf("no policy selected")
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue(prefix)
+	m.input.Placeholder = "service http | -port 80/tcp | rule ... | ingress z | egress z | target ACCEPT | priority -10"
+	m.inputMode = inputEditPolicy
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) startDeletePolicy() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	if !m.permanent {
+		m.err = fmt.Errorf("policy deletion is permanent-only (press P)")
+		return nil
+	}
+	if m.currentPolicyName() == "" {
+		m.err = fmt.Errorf("no policy selected")
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue("")
+	m.input.Placeholder = "type policy name to delete"
+	m.inputMode = inputDeletePolicy
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) submitPolicyInput(value string) tea.Cmd {
+	switch m.inputMode {
+	case inputAddPolicy:
+		p, err := parseNewPolicyInput(value)
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		m.inputMode = inputNone
+		m.input.Blur()
+		m.err = nil
+		m.notice = ""
+		if m.dryRun {
+			m.setDryRunNotice(fmt.Sprintf("add policy %s (%s -> %s)", p.Name, p.IngressZones[0], p.EgressZones[0]))
+			return nil
+		}
+		m.policiesLoading = true
+		m.pendingPolicy = p.Name
+		return addPolicyCmd(m.client, p)
+	case inputEditPolicy:
+		if m.policy == nil {
+			m.err = fmt.Errorf("no policy selected")
+			return nil
+		}
+		edit, err := parsePolicyEdit(value)
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		next, err := applyPolicyEdit(m.policy, edit)
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		m.inputMode = inputNone
+		m.input.Blur()
+		m.err = nil
+		m.notice = ""
+		if m.dryRun {
+			m.setDryRunNotice(fmt.Sprintf("edit policy %s: %s (%s)", next.Name, value, modeLabel(m.permanent)))
+			return nil
+		}
+		m.policyLoading = true
+		return updatePolicyCmd(m.client, next, m.permanent)
+	case inputDeletePolicy:
+		name := m.currentPolicyName()
+		if name == "" {
+			m.err = fmt.Errorf("no policy selected")
+			return nil
+		}
+		if value != name {
+			m.err = fmt.Errorf("type policy name to confirm deletion")
+			return nil
+		}
+		m.inputMode = inputNone
+		m.input.Blur()
+		m.err = nil
+		m.notice = ""
+		if m.dryRun {
+			m.setDryRunNotice(fmt.Sprintf("delete policy %s", name))
+			return nil
+		}
+		m.policiesLoading = true
+		return removePolicyCmd(m.client, name)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+func TestParsePolicyEdit(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    policyEdit
+		wantErr bool
+	}{
+		{name: "add service", input: "service http", want: policyEdit{kind: "service", value: "http"}},
+		{name: "remove port", input: "-port 80/tcp", want: policyEdit{remove: true, kind: "port", value: "80/tcp"}},
+		{name: "rich rule", input: `rule service name="ssh" accept`, want: policyEdit{kind: "rule", value: `service name="ssh" accept`}},
+		{name: "target", input: "target ACCEPT", want: policyEdit{kind: "target", value: "ACCEPT"}},
+		{name: "remove target", input: "-target ACCEPT", wantErr: true},
+		{name: "unknown", input: "bogus x", wantErr: true},
+		{name: "missing value", input: "service", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePolicyEdit(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePolicyEdit(%q) error = %v, wantErr = %v", tt.input, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Fatalf("parsePolicyEdit(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPolicyEdit(t *testing.T) {
+	orig := &firewalld.Policy{
+		Name:         "int-dmz",
+		IngressZones: []string{"internal"},
+		EgressZones:  []string{"dmz"},
+		Services:     []string{"http"},
+		Ports:        []firewalld.Port{{Port: "80", Protocol: "tcp"}},
+		Target:       "CONTINUE",
+		Priority:     -1,
+	}
+
+	next, err := applyPolicyEdit(orig, policyEdit{kind: "service", value: "https"})
+	if err != nil {
+		t.Fatalf("applyPolicyEdit() error = %v", err)
+	}
+	if len(next.Services) != 2 || len(orig.Services) != 1 {
+		t.Fatalf("services = %v (orig %v), want copy with https added", next.Services, orig.Services)
+	}
+
+	next, err = applyPolicyEdit(orig, policyEdit{remove: true, kind: "port", value: "80/tcp"})
+	if err != nil {
+		t.Fatalf("applyPolicyEdit() error = %v", err)
+	}
+	if len(next.Ports) != 0 || len(orig.Ports) != 1 {
+		t.Fatalf("ports = %v (orig %v), want port removed from copy", next.Ports, orig.Ports)
+	}
+
+	next, err = applyPolicyEdit(orig, policyEdit{kind: "target", value: "accept"})
+	if err != nil || next.Target != "ACCEPT" {
+		t.Fatalf("applyPolicyEdit(target) = %v, %v, want ACCEPT", next, err)
+	}
+
+	if _, err := applyPolicyEdit(orig, policyEdit{kind: "target", value: "bogus"}); err == nil {
+		t.Fatalf("expected invalid target error")
+	}
+	if _, err := applyPolicyEdit(orig, policyEdit{kind: "priority", value: "0"}); err == nil {
+		t.Fatalf("expected invalid priority error")
+	}
+	if _, err := applyPolicyEdit(orig, policyEdit{kind: "rule", value: "accept"}); err == nil {
+		t.Fatalf("expected invalid rich rule error")
+	}
+}
+
+func TestApplyPolicyEditRichRule(t *testing.T) {
+	orig := &firewalld.Policy{Name: "int-dmz"}
+
+	e, err := parsePolicyEdit(`rule family="ipv4" source address="10.0.0.0/8" service name="ssh" accept`)
+	if err != nil {
+		t.Fatalf("parsePolicyEdit() error = %v", err)
+	}
+	next, err := applyPolicyEdit(orig, e)
+	if err != nil {
+		t.Fatalf("applyPolicyEdit(add rule) error = %v", err)
+	}
+	want := `rule family="ipv4" source address="10.0.0.0/8" service name="ssh" accept`
+	if len(next.RichRules) != 1 || next.RichRules[0] != want {
+		t.Fatalf("RichRules = %q, want [%s]", next.RichRules, want)
+	}
+
+	e, err = parsePolicyEdit(`-rule family=ipv4 source address=10.0.0.0/8 service name=ssh accept`)
+	if err != nil {
+		t.Fatalf("parsePolicyEdit() error = %v", err)
+	}
+	next, err = applyPolicyEdit(next, e)
+	if err != nil {
+		t.Fatalf("applyPolicyEdit(remove rule) error = %v", err)
+	}
+	if len(next.RichRules) != 0 {
+		t.Fatalf("RichRules = %q, want the rule removed", next.RichRules)
+	}
+}
+
+func TestParseNewPolicyInput(t *testing.T) {
+	p, err := parseNewPolicyInput("int-dmz internal dmz accept")
+	if err != nil {
+		t.Fatalf("parseNewPolicyInput() error = %v", err)
+	}
+	if p.Name != "int-dmz" || p.IngressZones[0] != "internal" || p.EgressZones[0] != "dmz" || p.Target != "ACCEPT" {
+		t.Fatalf("parseNewPolicyInput() = %+v, unexpected", p)
+	}
+
+	for _, input := range []string{"only-name", "a b c d e", "../bad internal dmz", "p internal dmz bogus"} {
+		if _, err := parseNewPolicyInput(input); err == nil {
+			t.Fatalf("parseNewPolicyInput(%q) expected error", input)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fallbackRefreshInterval is how often the view polls firewalld when its
+// signals are unavailable and no auto refresh interval is configured.
+const fallbackRefreshInterval = 5 * time.Second
+
+type autoRefreshMsg struct{}
+
+func autoRefreshCmd(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return autoRefreshMsg{}
+	})
+}
+
+// refreshFromBackend re-reads zones, the default and active zones, panic
+// mode and direct rules. The selected zone is kept, and its settings are
+// fetched again once the zones arrive without resetting the selections.
+func (m *Model) refreshFromBackend() tea.Cmd {
+	m.loading = true
+	m.signalRefresh = true
+	if len(m.zones) > 0 && m.selected < len(m.zones) {
+		m.pendingZone = m.zones[m.selected]
+	}
+	return tea.Batch(
+		fetchZonesCmd(m.client),
+		fetchDefaultZoneCmd(m.client),
+		fetchActiveZonesCmd(m.client),
+		fetchPanicModeCmd(m.client),
+		m.refreshDirect(),
+	)
+}
+
+// startPolling starts the refresh loop unless one is already running,
+// using the configured interval or the fallback.
+func (m *Model) startPolling() tea.Cmd {
+	if m.polling {
+		return nil
+	}
+	m.polling = true
+	if m.pollInterval <= 0 {
+		m.pollInterval = fallbackRefreshInterval
+	}
+	return autoRefreshCmd(m.pollInterval)
+}
+
+// pollInsteadOfSignals switches to polling when firewalld's signals cannot
+// be received, so the view does not go stale.
+func (m *Model) pollInsteadOfSignals(err error) tea.Cmd {
+	m.signals = nil
+	m.signalsCancel = nil
+	cmd := m.startPolling()
+	reason := "signal subscription closed"
+	if err != nil {
+		reason = err.Error()
+	}
+	slog.Warn("firewalld signals unavailable, polling instead", "reason", reason, "interval", m.pollInterval)
+	m.notice = fmt.Sprintf("Live updates unavailable (%s); refreshing every %s", reason, m.pollInterval)
+	return cmd
+}
+
+// handleAutoRefresh runs one polling round. Rounds are skipped while a
+// load is still in flight so slow buses do not pile up requests.
+func (m Model) handleAutoRefresh() (Model, tea.Cmd) {
+	next := autoRefreshCmd(m.pollInterval)
+	if m.loading {
+		return m, next
+	}
+	return m, tea.Batch(m.refreshFromBackend(), fetchLockdownCmd(m.client), next)
+}
@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"lazyfirewall/internal/firewalld"
+)
+
+func TestSignalFailureFallsBackToPolling(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{})
+	if m.polling {
+		t.Fatalf("polling should be off without auto_refresh_interval")
+	}
+	next, cmd := m.Update(signalsReadyMsg{err: errors.New("access denied")})
+	m = next.(Model)
+	if cmd == nil || !m.polling || m.pollInterval != fallbackRefreshInterval {
+		t.Fatalf("signal failure should start polling, polling = %v interval = %s", m.polling, m.pollInterval)
+	}
+	if m.err != nil || !strings.Contains(m.notice, "refreshing every 5s") {
+		t.Fatalf("err = %v, notice = %q", m.err, m.notice)
+	}
+	if _, cmd := m.Update(signalsClosedMsg{}); cmd != nil {
+		t.Fatalf("a running poll loop should not be started twice")
+	}
+
+	m = NewModel(&firewalld.Client{}, Options{AutoRefresh: 30 * time.Second})
+	next, _ = m.Update(signalsReadyMsg{err: errors.New("access denied")})
+	if m = next.(Model); m.pollInterval != 30*time.Second {
+		t.Fatalf("configured interval should be kept, got %s", m.pollInterval)
+	}
+}
+
+func TestAutoRefreshKeepsSelection(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{AutoRefresh: time.Second})
+	m.zones = []string{"home", "public", "work"}
+	m.selected = 1
+	m.tab = tabServices
+	m.runtimeData = &firewalld.Zone{Services: []string{"dhcp", "http", "ssh"}}
+	m.serviceIndex = 2
+	m.loading = false
+
+	next, cmd := m.Update(autoRefreshMsg{})
+	m = next.(Model)
+	if cmd == nil || !m.loading || m.pendingZone != "public" {
+		t.Fatalf("refresh should reload keeping zone public, loading = %v pending = %q", m.loading, m.pendingZone)
+	}
+	if _, cmd := m.Update(autoRefreshMsg{}); cmd == nil {
+		t.Fatalf("a skipped round should still schedule the next one")
+	}
+
+	next, _ = m.Update(zonesMsg{zones: []string{"block", "home", "public", "work"}})
+	m = next.(Model)
+	if m.zones[m.selected] != "public" || m.runtimeData == nil || m.serviceIndex != 2 {
+		t.Fatalf("refresh should keep zone, data and selection: zone %q data %v index %d", m.zones[m.selected], m.runtimeData, m.serviceIndex)
+	}
+}
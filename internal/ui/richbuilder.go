@@ -0,0 +1,484 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/richrule"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type ruleField int
+
+const (
+	fieldRuleFamily ruleField = iota
+	fieldRulePriority
+	fieldRuleSource
+	fieldRuleDestination
+	fieldRuleElement
+	fieldRuleLogPrefix
+	fieldRuleLogLevel
+	fieldRuleLogLimit
+	fieldRuleAudit
+	fieldRuleAction
+	fieldRuleActionLimit
+	ruleFieldCount
+)
+
+var ruleFieldLabels = [ruleFieldCount]string{
+	"Family",
+	"Priority",
+	"Source",
+	"Destination",
+	"Element",
+	"Log prefix",
+	"Log level",
+	"Log limit",
+	"Audit",
+	"Action",
+	"Action limit",
+}
+
+var ruleFieldHints = [ruleFieldCount]string{
+	"ipv4, ipv6 or empty for both",
+	"0, or -32768..32767 (lower runs first)",
+	"10.0.0.0/8, not 10.0.0.0/8, ipset:name, mac:00:11:22:33:44:55",
+	"192.0.2.1, not 192.0.2.0/24, ipset:name",
+	"service ssh | port 8080/tcp | source-port 53/udp | protocol gre | icmp-type echo-request | icmp-block echo-request | masquerade | forward-port 80/tcp 8080 [to-addr] | tcp-mss-clamp [pmtu|size]",
+	"text logged before each packet",
+	strings.Join(richrule.LogLevels, ", "),
+	"3/m, optionally burst=5",
+	"yes, or a limit such as 1/m",
+	"accept | drop | reject [type] | mark 0x1[/mask]",
+	"3/m, optionally burst=5",
+}
+
+// ruleBuilder holds the rich rule form. oldRule is the rule being edited,
+// empty for a new one; the focused field lives in Model.input.
+type ruleBuilder struct {
+	oldRule string
+	field   ruleField
+	values  [ruleFieldCount]string
+}
+
+// newRuleBuilder fills the form from r, which may be nil. nflog rules have
+// no form fields and are edited as text.
+func newRuleBuilder(r *richrule.Rule, oldRule string) (*ruleBuilder, error) {
+	b := &ruleBuilder{oldRule: oldRule}
+	if r == nil {
+		return b, nil
+	}
+	if r.Log != nil && r.Log.NFLog {
+		return nil, fmt.Errorf("nflog rules can only be edited as text (e)")
+	}
+	b.values[fieldRuleFamily] = r.Family
+	if r.Priority != 0 {
+		b.values[fieldRulePriority] = strconv.Itoa(r.Priority)
+	}
+	b.values[fieldRuleSource] = formatRuleAddress(r.Source)
+	b.values[fieldRuleDestination] = formatRuleAddress(r.Destination)
+	b.values[fieldRuleElement] = formatRuleElement(r.Element)
+	if r.Log != nil {
+		b.values[fieldRuleLogPrefix] = r.Log.Prefix
+		b.values[fieldRuleLogLevel] = r.Log.Level
+		b.values[fieldRuleLogLimit] = formatRuleLimit(r.Log.Limit)
+	}
+	if r.Audit != nil {
+		b.values[fieldRuleAudit] = "yes"
+		if r.Audit.Limit != nil {
+			b.values[fieldRuleAudit] = formatRuleLimit(r.Audit.Limit)
+		}
+	}
+	if a := r.Action; a != nil {
+		b.values[fieldRuleAction] = strings.TrimSpace(a.Kind + " " + a.Type + a.Set)
+		b.values[fieldRuleActionLimit] = formatRuleLimit(a.Limit)
+	}
+	return b, nil
+}
+
+func formatRuleAddress(a *richrule.Address) string {
+	if a == nil {
+		return ""
+	}
+	var value string
+	switch {
+	case a.IPSet != "":
+		value = "ipset:" + a.IPSet
+	case a.MAC != "":
+		value = "mac:" + a.MAC
+	default:
+		value = a.Address
+	}
+	if a.Invert {
+		return "not " + value
+	}
+	return value
+}
+
+func formatRuleElement(e *richrule.Element) string {
+	if e == nil {
+		return ""
+	}
+	switch e.Kind {
+	case "service", "icmp-block", "icmp-type":
+		return e.Kind + " " + e.Name
+	case "port", "source-port":
+		return e.Kind + " " + e.Port + "/" + e.Protocol
+	case "protocol":
+		return "protocol " + e.Protocol
+	case "forward-port":
+		return strings.TrimSpace("forward-port " + e.Port + "/" + e.Protocol + " " + e.ToPort + " " + e.ToAddr)
+	case "tcp-mss-clamp":
+		return strings.TrimSpace("tcp-mss-clamp " + e.Value)
+	}
+	return e.Kind
+}
+
+func formatRuleLimit(l *richrule.Limit) string {
+	if l == nil {
+		return ""
+	}
+	if l.Burst > 0 {
+		return fmt.Sprintf("%s burst=%d", l.Value, l.Burst)
+	}
+	return l.Value
+}
+
+// rule builds and validates the rule the form describes.
+func (b *ruleBuilder) rule() (*richrule.Rule, error) {
+	v := func(f ruleField) string { return strings.TrimSpace(b.values[f]) }
+	r := &richrule.Rule{Family: strings.ToLower(v(fieldRuleFamily))}
+	if p := v(fieldRulePriority); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("priority must be a number")
+		}
+		r.Priority = n
+	}
+	var err error
+	if r.Source, err = parseRuleAddress(v(fieldRuleSource), true); err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	if r.Destination, err = parseRuleAddress(v(fieldRuleDestination), false); err != nil {
+		return nil, fmt.Errorf("destination: %w", err)
+	}
+	if r.Element, err = parseRuleElement(v(fieldRuleElement)); err != nil {
+		return nil, err
+	}
+	if v(fieldRuleLogPrefix) != "" || v(fieldRuleLogLevel) != "" || v(fieldRuleLogLimit) != "" {
+		r.Log = &richrule.Log{Prefix: b.values[fieldRuleLogPrefix], Level: v(fieldRuleLogLevel)}
+		if r.Log.Limit, err = parseRuleLimit(v(fieldRuleLogLimit)); err != nil {
+			return nil, fmt.Errorf("log limit: %w", err)
+		}
+	}
+	switch audit := strings.ToLower(v(fieldRuleAudit)); audit {
+	case "", "no":
+	case "yes":
+		r.Audit = &richrule.Audit{}
+	default:
+		r.Audit = &richrule.Audit{}
+		if r.Audit.Limit, err = parseRuleLimit(audit); err != nil {
+			return nil, fmt.Errorf("audit: %w", err)
+		}
+	}
+	if r.Action, err = parseRuleAction(v(fieldRuleAction)); err != nil {
+		return nil, err
+	}
+	if limit := v(fieldRuleActionLimit); limit != "" {
+		if r.Action == nil {
+			return nil, fmt.Errorf("action limit needs an action")
+		}
+		if r.Action.Limit, err = parseRuleLimit(limit); err != nil {
+			return nil, fmt.Errorf("action limit: %w", err)
+		}
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parseRuleAddress(value string, allowMAC bool) (*richrule.Address, error) {
+	if value == "" {
+		return nil, nil
+	}
+	a := &richrule.Address{}
+	if len(value) > 4 && strings.EqualFold(value[:4], "not ") {
+		a.Invert = true
+		value = strings.TrimSpace(value[4:])
+	}
+	switch {
+	case strings.HasPrefix(value, "ipset:"):
+		a.IPSet = strings.TrimPrefix(value, "ipset:")
+	case strings.HasPrefix(value, "mac:"):
+		if !allowMAC {
+			return nil, fmt.Errorf("only the source takes a mac")
+		}
+		a.MAC = strings.TrimPrefix(value, "mac:")
+	default:
+		a.Address = value
+	}
+	return a, nil
+}
+
+func parseRuleElement(value string) (*richrule.Element, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	e := &richrule.Element{Kind: strings.ToLower(fields[0])}
+	args := fields[1:]
+	usage := fmt.Errorf("element: use %s", ruleFieldHints[fieldRuleElement])
+	switch e.Kind {
+	case "service", "icmp-block", "icmp-type", "protocol":
+		if len(args) != 1 {
+			return nil, usage
+		}
+		if e.Kind == "protocol" {
+			e.Protocol = args[0]
+		} else {
+			e.Name = args[0]
+		}
+	case "port", "source-port", "forward-port":
+		if len(args) == 0 || (e.Kind != "forward-port" && len(args) != 1) || len(args) > 3 {
+			return nil, usage
+		}
+		port, proto, ok := strings.Cut(args[0], "/")
+		if !ok {
+			return nil, fmt.Errorf("element: use port/proto, e.g. 8080/tcp")
+		}
+		e.Port, e.Protocol = port, strings.ToLower(proto)
+		// forward-port takes a to-port, a to-addr or both, in that order.
+		for _, arg := range args[1:] {
+			if net.ParseIP(arg) != nil {
+				e.ToAddr = arg
+			} else {
+				e.ToPort = arg
+			}
+		}
+	case "masquerade":
+		if len(args) != 0 {
+			return nil, usage
+		}
+	case "tcp-mss-clamp":
+		if len(args) > 1 {
+			return nil, usage
+		}
+		if len(args) == 1 {
+			e.Value = args[0]
+		}
+	default:
+		return nil, usage
+	}
+	return e, nil
+}
+
+func parseRuleAction(value string) (*richrule.Action, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	a := &richrule.Action{Kind: strings.ToLower(fields[0])}
+	switch {
+	case (a.Kind == "accept" || a.Kind == "drop") && len(fields) == 1:
+	case a.Kind == "reject" && len(fields) <= 2:
+		if len(fields) == 2 {
+			a.Type = fields[1]
+		}
+	case a.Kind == "mark" && len(fields) == 2:
+		a.Set = fields[1]
+	default:
+		return nil, fmt.Errorf("action: use %s", ruleFieldHints[fieldRuleAction])
+	}
+	return a, nil
+}
+
+// parseRuleLimit reads "rate" or "rate burst=N".
+func parseRuleLimit(value string) (*richrule.Limit, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	l := &richrule.Limit{Value: fields[0]}
+	if len(fields) > 2 {
+		return nil, fmt.Errorf("use rate or rate burst=N")
+	}
+	if len(fields) == 2 {
+		burst, ok := strings.CutPrefix(fields[1], "burst=")
+		n, err := strconv.Atoi(burst)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("use rate or rate burst=N")
+		}
+		l.Burst = n
+	}
+	return l, nil
+}
+
+// startRuleBuilder opens the form for a new rule, or with edit set for the
+// selected rule of the current zone.
+func (m *Model) startRuleBuilder(edit bool) tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	current := m.currentData()
+	if current == nil {
+		m.err = fmt.Errorf("no zone selected")
+		return nil
+	}
+	var parsed *richrule.Rule
+	var oldRule string
+	if edit {
+		e, ok := m.selectedRichRule()
+		if !ok {
+			return nil
+		}
+		oldRule = e.rule
+		var err error
+		if parsed, err = richrule.Parse(oldRule); err != nil {
+			m.err = fmt.Errorf("cannot load rule into the builder: %w", err)
+			return nil
+		}
+	}
+	b, err := newRuleBuilder(parsed, oldRule)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.err = nil
+	m.ruleBuilder = b
+	m.loadRuleField()
+	return nil
+}
+
+func (m *Model) closeRuleBuilder() {
+	m.ruleBuilder = nil
+	m.input.SetValue("")
+	m.input.Placeholder = ""
+	m.input.Blur()
+}
+
+func (m *Model) loadRuleField() {
+	b := m.ruleBuilder
+	m.input.SetValue(b.values[b.field])
+	m.input.Placeholder = ruleFieldHints[b.field]
+	m.input.CursorEnd()
+	m.input.Focus()
+}
+
+func (m *Model) moveRuleField(delta int) {
+	b := m.ruleBuilder
+	b.values[b.field] = m.input.Value()
+	b.field = (b.field + ruleField(delta) + ruleFieldCount) % ruleFieldCount
+	m.loadRuleField()
+}
+
+// saveRuleBuilder adds the rule, or replaces the edited one, through the
+// same safety checks as typed rules.
+func (m *Model) saveRuleBuilder() tea.Cmd {
+	b := m.ruleBuilder
+	b.values[b.field] = m.input.Value()
+	r, err := b.rule()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if m.currentData() == nil || len(m.zones) == 0 {
+		m.err = fmt.Errorf("no zone selected")
+		return nil
+	}
+	zone := m.zones[m.selected]
+	rule := r.String()
+	oldRule := b.oldRule
+	m.closeRuleBuilder()
+	m.err = nil
+	m.notice = ""
+	if oldRule == "" {
+		if m.dryRun {
+			m.setDryRunNotice(fmt.Sprintf("add rich rule to zone %s (%s)", zone, modeLabel(m.permanent)))
+			return nil
+		}
+		return m.safeMutation(zone, "add rich rule", m.permanent, false, m.actionAddRichRule(zone, rule, m.permanent))
+	}
+	if oldRule == rule {
+		return nil
+	}
+	if m.dryRun {
+		m.setDryRunNotice(fmt.Sprintf("edit rich rule in zone %s (%s)", zone, modeLabel(m.permanent)))
+		return nil
+	}
+	return m.guardEditRichRule(zone, "edit rich rule", oldRule, rule)
+}
+
+func (m Model) handleRuleBuilderMode(msg tea.Msg) (Model, tea.Cmd, bool) {
+	if m.ruleBuilder == nil {
+		return m, nil, false
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, false
+	}
+
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit, true
+	case "esc":
+		m.closeRuleBuilder()
+		m.err = nil
+		return m, nil, true
+	case "tab", "down":
+		m.moveRuleField(1)
+		return m, nil, true
+	case "shift+tab", "up":
+		m.moveRuleField(-1)
+		return m, nil, true
+	case "enter":
+		return m, m.saveRuleBuilder(), true
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(key)
+	return m, cmd, true
+}
+
+func renderRuleBuilder(b *strings.Builder, m Model) {
+	rb := m.ruleBuilder
+	header := "New Rich Rule"
+	if rb.oldRule != "" {
+		header = "Edit Rich Rule"
+	}
+	b.WriteString(titleStyle.Render(header + " (" + modeLabel(m.permanent) + ")"))
+	b.WriteString("\n\n")
+
+	for field := ruleField(0); field < ruleFieldCount; field++ {
+		label := fmt.Sprintf("%-13s", ruleFieldLabels[field]+":")
+		switch {
+		case field == rb.field:
+			b.WriteString(selectedStyle.Render("> "+label) + " " + m.input.View())
+		case rb.values[field] == "":
+			b.WriteString("  " + label + " " + dimStyle.Render("-"))
+		default:
+			b.WriteString("  " + label + " " + rb.values[field])
+		}
+		b.WriteString("\n")
+	}
+
+	// Preview what the form would save, including the field being typed.
+	preview := *rb
+	preview.values[rb.field] = m.input.Value()
+	b.WriteString("\n")
+	if r, err := preview.rule(); err != nil {
+		b.WriteString(warnStyle.Render("Invalid: " + err.Error()))
+	} else {
+		b.WriteString("Rule: " + r.String())
+	}
+	b.WriteString("\n\n")
+	b.WriteString(dimStyle.Render("Tab/Shift+Tab move, Enter save, Esc cancel"))
+}
@@ -0,0 +1,91 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/richrule"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRuleBuilderRoundTrip(t *testing.T) {
+	rules := []string{
+		`rule family="ipv4" priority="-10" source NOT address="10.0.0.0/8" port port="8080" protocol="tcp" log prefix="web" level="info" limit value="3/m" reject type="icmp-port-unreachable"`,
+		`rule family="ipv6" source ipset="blocked" audit limit value="1/h" burst="5" drop`,
+		`rule family="ipv4" forward-port port="80" protocol="tcp" to-port="8080" to-addr="192.0.2.10"`,
+		`rule source mac="00:11:22:33:44:55" service name="ssh" mark set="0x1/0xff" limit value="10/s"`,
+		`rule family="ipv4" source address="10.0.0.0/8" tcp-mss-clamp value="pmtu"`,
+	}
+	for _, text := range rules {
+		r, err := richrule.Parse(text)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", text, err)
+		}
+		b, err := newRuleBuilder(r, text)
+		if err != nil {
+			t.Fatalf("newRuleBuilder(%q) error = %v", text, err)
+		}
+		built, err := b.rule()
+		if err != nil || built.String() != text {
+			t.Fatalf("builder for %q produced %v, %v", text, built, err)
+		}
+	}
+	if _, err := newRuleBuilder(&richrule.Rule{Log: &richrule.Log{NFLog: true}}, "x"); err == nil {
+		t.Fatalf("nflog rules should not load into the builder")
+	}
+}
+
+func TestRuleBuilderFormErrors(t *testing.T) {
+	b := &ruleBuilder{}
+	b.values[fieldRuleElement] = "port 8080"
+	if _, err := b.rule(); err == nil || !strings.Contains(err.Error(), "port/proto") {
+		t.Fatalf("port without protocol error = %v", err)
+	}
+	b.values[fieldRuleElement] = "service ssh"
+	b.values[fieldRuleSource] = "10.0.0.1"
+	if _, err := b.rule(); err == nil || !strings.Contains(err.Error(), "family") {
+		t.Fatalf("source address without family error = %v", err)
+	}
+	b.values[fieldRuleFamily] = "ipv4"
+	b.values[fieldRuleActionLimit] = "3/m"
+	if _, err := b.rule(); err == nil || !strings.Contains(err.Error(), "needs an action") {
+		t.Fatalf("limit without action error = %v", err)
+	}
+	b.values[fieldRuleAction] = "accept"
+	r, err := b.rule()
+	if err != nil || r.String() != `rule family="ipv4" source address="10.0.0.1" service name="ssh" accept limit value="3/m"` {
+		t.Fatalf("rule() = %v, %v", r, err)
+	}
+}
+
+func TestRuleBuilderSave(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{})
+	m.zones = []string{"public"}
+	m.runtimeData = &firewalld.Zone{RichRules: []string{`rule service name="ssh" accept`}}
+	m.tab = tabRich
+	m.focus = focusMain
+	m.dryRun = true
+
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'B'}})
+	m = next.(Model)
+	if m.ruleBuilder == nil || m.ruleBuilder.values[fieldRuleElement] != "service ssh" || m.ruleBuilder.values[fieldRuleAction] != "accept" {
+		t.Fatalf("B should load the selected rule, builder = %+v", m.ruleBuilder)
+	}
+	for m.ruleBuilder.field != fieldRuleAction {
+		m.moveRuleField(1)
+	}
+	m.input.SetValue("drop")
+	var b strings.Builder
+	renderRuleBuilder(&b, m)
+	if !strings.Contains(b.String(), `Rule: rule service name="ssh" drop`) {
+		t.Fatalf("preview should include the field being typed:\n%s", b.String())
+	}
+	if cmd := m.saveRuleBuilder(); cmd != nil || m.ruleBuilder != nil || !strings.Contains(m.notice, "edit rich rule in zone public") {
+		t.Fatalf("dry-run save should close the builder, notice = %q", m.notice)
+	}
+}
@@ -0,0 +1,157 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/richrule"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// richEntry is a rich rule as listed on the Rich Rules tab. parsed is nil
+// for rules the parser rejects; they are listed last, in D-Bus order.
+type richEntry struct {
+	rule   string
+	parsed *richrule.Rule
+}
+
+// section is the heading the entry is listed under.
+func (e richEntry) section() string {
+	if e.parsed == nil {
+		return "Unparsed"
+	}
+	s := e.parsed.Section()
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// orderRichRules sorts rules into the order firewalld evaluates them.
+// The sort is stable, so rules that tie keep the order D-Bus returned.
+func orderRichRules(rules []string) []richEntry {
+	entries := make([]richEntry, 0, len(rules))
+	for _, r := range rules {
+		parsed, _ := richrule.Parse(r)
+		entries = append(entries, richEntry{rule: r, parsed: parsed})
+	}
+	slices.SortStableFunc(entries, func(a, b richEntry) int {
+		switch {
+		case a.parsed == nil && b.parsed == nil:
+			return 0
+		case a.parsed == nil:
+			return 1
+		case b.parsed == nil:
+			return -1
+		}
+		return richrule.Compare(a.parsed, b.parsed)
+	})
+	return entries
+}
+
+// richEntries returns the current zone's rich rules in evaluation order;
+// richIndex indexes this list.
+func (m *Model) richEntries() []richEntry {
+	current := m.currentData()
+	if current == nil {
+		return nil
+	}
+	return orderRichRules(current.RichRules)
+}
+
+// selectedRichRule returns the rule under the cursor on the Rich Rules tab.
+func (m *Model) selectedRichRule() (richEntry, bool) {
+	entries := m.richEntries()
+	if m.richIndex < 0 || m.richIndex >= len(entries) {
+		return richEntry{}, false
+	}
+	return entries[m.richIndex], true
+}
+
+// movedPriority returns the priority that moves entries[index] past its
+// neighbour in direction delta (-1 up, 1 down). Rules sharing a priority
+// have no defined order, so the rule moves past all of them; a priority 0
+// rule can only leave its group by leaving priority 0.
+func movedPriority(entries []richEntry, index, delta int) (int, error) {
+	e := entries[index]
+	if e.parsed == nil {
+		return 0, fmt.Errorf("cannot move a rule that does not parse")
+	}
+	next := index + delta
+	if next < 0 {
+		return 0, fmt.Errorf("rule is already evaluated first")
+	}
+	if next >= len(entries) || entries[next].parsed == nil {
+		return 0, fmt.Errorf("rule is already evaluated last")
+	}
+	priority := entries[next].parsed.Priority + delta
+	if priority < richrule.MinPriority || priority > richrule.MaxPriority {
+		return 0, fmt.Errorf("priority %d is out of range (%d to %d)", priority, richrule.MinPriority, richrule.MaxPriority)
+	}
+	if priority == 0 {
+		// Rules that only log need a priority; step over 0 for them.
+		moved := *e.parsed
+		moved.Priority = 0
+		if moved.Validate() != nil {
+			priority += delta
+		}
+	}
+	return priority, nil
+}
+
+// moveRichRule rewrites the selected rule's priority so it is evaluated
+// before (delta -1) or after (delta 1) its neighbour. The old rule is
+// replaced in one transaction.
+func (m *Model) moveRichRule(delta int) tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	if len(m.zones) == 0 {
+		return nil
+	}
+	entries := m.richEntries()
+	if m.richIndex < 0 || m.richIndex >= len(entries) {
+		return nil
+	}
+	priority, err := movedPriority(entries, m.richIndex, delta)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	e := entries[m.richIndex]
+	moved := *e.parsed
+	moved.Priority = priority
+	newRule := moved.String()
+	zone := m.zones[m.selected]
+	direction := "up"
+	if delta > 0 {
+		direction = "down"
+	}
+	label := fmt.Sprintf("move rich rule %s to priority %d", direction, priority)
+	m.err = nil
+	if m.dryRun {
+		m.setDryRunNotice(fmt.Sprintf("%s in zone %s (%s)", label, zone, modeLabel(m.permanent)))
+		return nil
+	}
+	m.richFollow = newRule
+	return m.guardEditRichRule(zone, label, e.rule, newRule)
+}
+
+// followRichRule selects the rule a move produced once it shows up in the
+// refreshed zone.
+func (m *Model) followRichRule() {
+	if m.richFollow == "" {
+		return
+	}
+	for i, e := range m.richEntries() {
+		if e.rule == m.richFollow {
+			m.richIndex = i
+			m.richFollow = ""
+			return
+		}
+	}
+}
@@ -0,0 +1,116 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestOrderRichRules(t *testing.T) {
+	entries := orderRichRules([]string{
+		`rule service name="ssh" accept`,
+		`not a rule`,
+		`rule priority="10" service name="http" drop`,
+		`rule family="ipv4" source address="10.0.0.0/8" drop`,
+		`rule priority="-1" service name="dns" log prefix="dns"`,
+	})
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.section())
+	}
+	want := "Priority -1,Deny,Allow,Priority 10,Unparsed"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("sections = %v, want %s", got, want)
+	}
+
+	cases := []struct {
+		index, delta, want int
+	}{
+		{2, -1, -1}, // allow past deny leaves priority 0
+		{1, 1, 1},   // deny past allow
+		{1, -1, -2}, // past priority -1
+		{2, 1, 11},  // past priority 10
+		{3, -1, -1}, // priority 10 past allow
+	}
+	for _, c := range cases {
+		p, err := movedPriority(entries, c.index, c.delta)
+		if err != nil || p != c.want {
+			t.Fatalf("movedPriority(%d, %d) = %d, %v, want %d", c.index, c.delta, p, err, c.want)
+		}
+	}
+	for _, c := range []struct{ index, delta int }{{0, -1}, {3, 1}, {4, -1}} {
+		if _, err := movedPriority(entries, c.index, c.delta); err == nil {
+			t.Fatalf("movedPriority(%d, %d) should fail", c.index, c.delta)
+		}
+	}
+}
+
+func TestMovedPrioritySkipsZeroForLogOnlyRules(t *testing.T) {
+	entries := orderRichRules([]string{
+		`rule service name="ssh" accept`,
+		`rule priority="1" service name="http" accept`,
+		`rule priority="5" service name="ftp" log prefix="ftp"`,
+	})
+	if p, err := movedPriority(entries, 2, -1); err != nil || p != 0 {
+		t.Fatalf("rule with an element moves to 0, got %d, %v", p, err)
+	}
+	entries = orderRichRules([]string{
+		`rule priority="1" service name="http" accept`,
+		`rule priority="5" family="ipv4" source address="10.0.0.0/8" log prefix="lan"`,
+	})
+	if entries[1].parsed.Element != nil {
+		t.Fatalf("test rule should be log-only")
+	}
+	if p, err := movedPriority(entries, 1, -1); err != nil || p != -1 {
+		t.Fatalf("log-only rule should skip priority 0, got %d, %v", p, err)
+	}
+}
+
+func TestMoveRichRuleKeys(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{})
+	m.zones = []string{"public"}
+	m.runtimeData = &firewalld.Zone{RichRules: []string{
+		`rule service name="ssh" accept`,
+		`rule family="ipv4" source address="10.0.0.0/8" drop`,
+	}}
+	m.tab = tabRich
+	m.focus = focusMain
+	m.dryRun = true
+
+	if e, ok := m.selectedRichRule(); !ok || !strings.Contains(e.rule, "drop") {
+		t.Fatalf("first listed rule should be the deny rule, got %q", e.rule)
+	}
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'K'}})
+	m = next.(Model)
+	if m.err == nil || !strings.Contains(m.err.Error(), "first") {
+		t.Fatalf("moving the first rule up should fail, err = %v", m.err)
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'J'}})
+	m = next.(Model)
+	if !strings.Contains(m.notice, "move rich rule down to priority 1 in zone public") {
+		t.Fatalf("dry-run notice = %q", m.notice)
+	}
+
+	var b strings.Builder
+	renderRichRulesList(&b, m, m.runtimeData)
+	out := b.String()
+	if strings.Index(out, "Deny:") > strings.Index(out, "Allow:") {
+		t.Fatalf("deny rules should be listed before allow rules:\n%s", out)
+	}
+
+	m.richFollow = `rule priority="1" family="ipv4" source address="10.0.0.0/8" drop`
+	m.runtimeData = &firewalld.Zone{RichRules: []string{
+		`rule service name="ssh" accept`,
+		m.richFollow,
+	}}
+	m.clampSelections()
+	if m.richIndex != 1 || m.richFollow != "" {
+		t.Fatalf("selection should follow the moved rule, index = %d", m.richIndex)
+	}
+}
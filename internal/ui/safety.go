@@ -0,0 +1,241 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"lazyfirewall/internal/backup"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// safetyState tracks a risky change that has been applied but not yet
+// confirmed. It is reverted when the countdown runs out.
+type safetyState struct {
+	id        int
+	zone      string
+	label     string
+	permanent bool
+	backup    backup.Backup
+	hasBackup bool
+	action    *undoAction
+	remaining int
+}
+
+type safetyArmMsg struct {
+	zone      string
+	label     string
+	permanent bool
+	backup    backup.Backup
+	hasBackup bool
+	inner     tea.Msg
+}
+
+type safetyTickMsg struct {
+	id int
+}
+
+type safetyRevertedMsg struct {
+	label string
+	inner tea.Msg
+}
+
+func (m Model) isRiskyChange(zone string, affectsSSH bool) bool {
+	if m.confirmTimeout <= 0 {
+		return false
+	}
+	if affectsSSH {
+		return true
+	}
+	sshZone := m.ssh.ZoneFor(m.activeZones, m.defaultZone)
+	return sshZone != "" && sshZone == zone
+}
+
+// safeMutation backs up the zone like maybeBackup and, for changes that may
+// cut off the current SSH session, arms the confirm-or-revert timer. Changes
+// to the zone serving the session are always risky; affectsSSH flags others,
+// such as removing the ssh service or rebinding the session elsewhere.
+func (m *Model) safeMutation(zone, label string, permanent, affectsSSH bool, cmd tea.Cmd) tea.Cmd {
+	if m.isRiskyChange(zone, affectsSSH) {
+		cmd = safetyArmCmd(zone, label, permanent, cmd)
+	}
+	return m.maybeBackup(zone, true, cmd)
+}
+
+// safetyArmCmd takes a fresh backup before a permanent change so it can be
+// restored if the change is not confirmed, then runs the change.
+func safetyArmCmd(zone, label string, permanent bool, cmd tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		msg := safetyArmMsg{zone: zone, label: label, permanent: permanent}
+		if permanent {
+			b, err := backup.CreateZoneBackupWithDescription(zone, "pre-change")
+			switch {
+			case err == nil:
+				msg.backup = b
+				msg.hasBackup = true
+			case !errors.Is(err, os.ErrNotExist):
+				return mutationMsg{zone: zone, err: fmt.Errorf("pre-change backup: %w", err)}
+			}
+		}
+		msg.inner = cmd()
+		return msg
+	}
+}
+
+func safetyTickCmd(id int) tea.Cmd {
+	return tea.Tick(1*time.Second, func(time.Time) tea.Msg {
+		return safetyTickMsg{id: id}
+	})
+}
+
+func mutationErr(msg tea.Msg) error {
+	switch msg := msg.(type) {
+	case mutationMsg:
+		return msg.err
+	case zonesMsg:
+		return msg.err
+	case importMsg:
+		return msg.err
+	case backupRestoreMsg:
+		return msg.err
+	}
+	return nil
+}
+
+func (m Model) handleSafetyArm(msg safetyArmMsg) (tea.Model, tea.Cmd) {
+	next, innerCmd := m.Update(msg.inner)
+	m = next.(Model)
+	if mutationErr(msg.inner) != nil {
+		return m, innerCmd
+	}
+	m.safetySeq++
+	m.safety = &safetyState{
+		id:        m.safetySeq,
+		zone:      msg.zone,
+		label:     msg.label,
+		permanent: msg.permanent,
+		backup:    msg.backup,
+		hasBackup: msg.hasBackup,
+		remaining: int(m.confirmTimeout / time.Second),
+	}
+	if mm, ok := msg.inner.(mutationMsg); ok {
+		m.safety.action = mm.action
+	}
+	slog.Warn("risky change applied, waiting for confirmation", "zone", msg.zone, "change", msg.label, "timeout", m.confirmTimeout)
+	return m, tea.Batch(innerCmd, safetyTickCmd(m.safety.id))
+}
+
+func (m Model) handleSafetyTick(msg safetyTickMsg) (tea.Model, tea.Cmd) {
+	if m.safety == nil || m.safety.id != msg.id {
+		return m, nil
+	}
+	m.safety.remaining--
+	if m.safety.remaining > 0 {
+		return m, safetyTickCmd(msg.id)
+	}
+	return m, m.revertSafety()
+}
+
+func (m *Model) confirmSafety() {
+	if m.safety == nil {
+		return
+	}
+	slog.Info("risky change confirmed", "zone", m.safety.zone, "change", m.safety.label)
+	m.notice = "Change confirmed: " + m.safety.label
+	m.safety = nil
+}
+
+// revertSafety clears the pending change and returns the command that undoes
+// it: the pre-change backup for permanent changes, the change's undo for
+// runtime ones, falling back to a reload.
+func (m *Model) revertSafety() tea.Cmd {
+	s := m.safety
+	if s == nil {
+		return nil
+	}
+	m.safety = nil
+	slog.Warn("risky change not confirmed, reverting", "zone", s.zone, "change", s.label)
+	m.loading = true
+	m.err = nil
+	m.pendingZone = s.zone
+	cmd := m.safetyRevertCmd(s)
+	if cmd == nil {
+		m.loading = false
+		m.err = fmt.Errorf("could not revert %s automatically: no backup of zone %s", s.label, s.zone)
+		return nil
+	}
+	return func() tea.Msg {
+		return safetyRevertedMsg{label: s.label, inner: cmd()}
+	}
+}
+
+func (m *Model) safetyRevertCmd(s *safetyState) tea.Cmd {
+	switch {
+	case s.permanent && s.hasBackup:
+		return restoreBackupCmd(m.client, s.zone, s.backup)
+	case s.action != nil && s.action.undo != nil:
+		m.dropUndo(s.action.id)
+		return s.action.undo
+	case !s.permanent:
+		// A reload also drops the zone's other runtime changes, timed
+		// elements included.
+		m.clearZoneExpiries(s.zone)
+		return reloadCmd(m.client, s.zone, nil, recordNone, false)
+	}
+	return nil
+}
+
+func (m Model) handleSafetyReverted(msg safetyRevertedMsg) (tea.Model, tea.Cmd) {
+	next, cmd := m.Update(msg.inner)
+	m = next.(Model)
+	if err := mutationErr(msg.inner); err != nil {
+		m.err = fmt.Errorf("revert %s: %w", msg.label, err)
+		return m, cmd
+	}
+	m.notice = "Not confirmed, reverted: " + msg.label
+	return m, cmd
+}
+
+// revertPendingSafety runs on exit so that quitting (or losing the terminal)
+// while a change is unconfirmed does not leave it in place.
+func (m Model) revertPendingSafety() {
+	if m.safety == nil {
+		return
+	}
+	cmd := m.revertSafety()
+	if cmd == nil {
+		slog.Error("pending change could not be reverted on exit", "error", m.err)
+		return
+	}
+	if err := mutationErr(cmd().(safetyRevertedMsg).inner); err != nil {
+		slog.Error("revert on exit failed", "error", err)
+	}
+}
+
+func (m Model) handleSafetyMode(msg tea.Msg) (Model, tea.Cmd, bool) {
+	if m.safety == nil {
+		return m, nil, false
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, false
+	}
+
+	switch key.String() {
+	case "y", "Y", "enter":
+		m.confirmSafety()
+		return m, nil, true
+	case "n", "N", "esc":
+		return m, m.revertSafety(), true
+	case "ctrl+c", "q":
+		return m, tea.Quit, true
+	default:
+		return m, nil, true
+	}
+}
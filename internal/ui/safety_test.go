@@ -0,0 +1,177 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/lockout"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestIsRiskyChange(t *testing.T) {
+	m := Model{
+		ssh:            &lockout.Session{Client: net.ParseIP("192.0.2.10"), Port: 22, Interface: "eth0"},
+		activeZones:    map[string][]string{"public": {"eth0"}},
+		defaultZone:    "home",
+		confirmTimeout: 30 * time.Second,
+	}
+	if !m.isRiskyChange("public", false) {
+		t.Fatalf("change to ssh zone should be risky")
+	}
+	if m.isRiskyChange("dmz", false) {
+		t.Fatalf("change to unrelated zone should not be risky")
+	}
+	if !m.isRiskyChange("dmz", m.ssh.Binds("192.0.2.0/24")) {
+		t.Fatalf("binding the ssh client elsewhere should be risky")
+	}
+	m.confirmTimeout = 0
+	if m.isRiskyChange("public", true) {
+		t.Fatalf("confirm_timeout = 0 should disable the safety timer")
+	}
+}
+
+func TestSafetyCountdownReverts(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{ConfirmTimeout: 2 * time.Second})
+	next, cmd := m.Update(safetyArmMsg{zone: "public", label: "remove service ssh", inner: mutationMsg{zone: "public"}})
+	m = next.(Model)
+	if m.safety == nil || m.safety.remaining != 2 {
+		t.Fatalf("safety = %+v, want armed with 2s", m.safety)
+	}
+	if cmd == nil {
+		t.Fatalf("expected tick command")
+	}
+
+	next, cmd = m.Update(safetyTickMsg{id: m.safety.id + 1})
+	m = next.(Model)
+	if cmd != nil || m.safety.remaining != 2 {
+		t.Fatalf("stale tick should be ignored")
+	}
+
+	next, _ = m.Update(safetyTickMsg{id: m.safety.id})
+	m = next.(Model)
+	next, cmd = m.Update(safetyTickMsg{id: m.safety.id})
+	m = next.(Model)
+	if m.safety != nil {
+		t.Fatalf("safety should be cleared after countdown")
+	}
+	if cmd == nil {
+		t.Fatalf("expected revert command")
+	}
+
+	next, _ = m.Update(safetyRevertedMsg{label: "remove service ssh", inner: mutationMsg{zone: "public"}})
+	m = next.(Model)
+	if m.notice != "Not confirmed, reverted: remove service ssh" {
+		t.Fatalf("notice = %q", m.notice)
+	}
+}
+
+func TestSafetyArmSkippedOnError(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{ConfirmTimeout: 30 * time.Second})
+	next, _ := m.Update(safetyArmMsg{zone: "public", inner: mutationMsg{zone: "public", err: errors.New("boom")}})
+	m = next.(Model)
+	if m.safety != nil {
+		t.Fatalf("failed change should not arm the safety timer")
+	}
+	if m.err == nil {
+		t.Fatalf("expected mutation error to be shown")
+	}
+}
+
+func TestHandleSafetyMode(t *testing.T) {
+	armed := Model{safety: &safetyState{zone: "public", label: "add source 192.0.2.0/24", remaining: 10}}
+
+	next, cmd, handled := armed.handleSafetyMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	if !handled || cmd != nil || next.safety == nil {
+		t.Fatalf("other keys should be swallowed while waiting for confirmation")
+	}
+
+	next, _, handled = armed.handleSafetyMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if !handled || next.safety != nil {
+		t.Fatalf("y should confirm the change")
+	}
+
+	next, cmd, handled = armed.handleSafetyMode(tea.KeyMsg{Type: tea.KeyEsc})
+	if !handled || next.safety != nil || cmd == nil {
+		t.Fatalf("esc should revert the change")
+	}
+
+	if _, _, handled := (Model{}).handleSafetyMode(tea.KeyMsg{Type: tea.KeyEnter}); handled {
+		t.Fatalf("safety mode should not handle keys when idle")
+	}
+}
+
+func TestSafetyRevertFallsBackToUndo(t *testing.T) {
+	action := &undoAction{id: 1, label: "remove service ssh", zone: "public", undo: func() tea.Msg { return nil }}
+	m := Model{
+		undoStack: []undoAction{*action},
+		safety:    &safetyState{zone: "public", label: action.label, permanent: true, action: action},
+	}
+	if cmd := m.safetyRevertCmd(m.safety); cmd == nil {
+		t.Fatalf("expected undo command when no backup exists")
+	}
+	if len(m.undoStack) != 0 {
+		t.Fatalf("undo stack = %d entries, want reverted action popped", len(m.undoStack))
+	}
+
+	m.safety = &safetyState{zone: "public", label: "delete zone public", permanent: true}
+	if cmd := m.revertSafety(); cmd != nil || m.err == nil {
+		t.Fatalf("revertSafety() without backup or undo should report an error")
+	}
+}
+
+func TestSafetyRevertKeepsLaterUndo(t *testing.T) {
+	action := &undoAction{id: 1, label: "remove service ssh", zone: "public", undo: func() tea.Msg { return nil }}
+	later := undoAction{id: 2, label: "add service http", zone: "public"}
+	m := Model{
+		undoStack: []undoAction{{id: 3, label: "add port 80/tcp"}, *action, later},
+		safety:    &safetyState{zone: "public", label: action.label, action: action},
+	}
+	if cmd := m.safetyRevertCmd(m.safety); cmd == nil {
+		t.Fatalf("expected undo command for the reverted change")
+	}
+	if len(m.undoStack) != 2 || m.undoStack[0].id != 3 || m.undoStack[1].id != later.id {
+		t.Fatalf("undo stack = %+v, want only the reverted change removed", m.undoStack)
+	}
+}
+
+func TestSafetyRevertRuntime(t *testing.T) {
+	undone := false
+	action := &undoAction{id: 1, label: "add source 192.0.2.0/24", zone: "public", undo: func() tea.Msg {
+		undone = true
+		return mutationMsg{zone: "public"}
+	}}
+	m := Model{
+		undoStack: []undoAction{*action},
+		safety:    &safetyState{zone: "public", label: action.label, action: action},
+	}
+	m.setExpiry(expiryKey("public", expiryService, "http"), time.Minute)
+	cmd := m.safetyRevertCmd(m.safety)
+	if cmd == nil {
+		t.Fatalf("expected undo command for a runtime change")
+	}
+	cmd()
+	if !undone || len(m.undoStack) != 0 {
+		t.Fatalf("runtime revert should run and pop the change's undo, undone = %v, stack = %d", undone, len(m.undoStack))
+	}
+	if len(m.expiries) != 1 {
+		t.Fatalf("undo should leave other timed elements alone, expiries = %v", m.expiries)
+	}
+
+	m.setExpiry(expiryKey("dmz", expiryService, "http"), time.Minute)
+	if cmd := m.safetyRevertCmd(&safetyState{zone: "public", label: "add source 192.0.2.0/24"}); cmd == nil {
+		t.Fatalf("expected reload without an undo")
+	}
+	if _, ok := m.expiries[expiryKey("public", expiryService, "http")]; ok {
+		t.Fatalf("reload should forget the zone's timed elements")
+	}
+	if _, ok := m.expiries[expiryKey("dmz", expiryService, "http")]; !ok {
+		t.Fatalf("reload should keep other zones' timed elements")
+	}
+}
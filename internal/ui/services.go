@@ -0,0 +1,398 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/validation"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type serviceField int
+
+const (
+	fieldServiceName serviceField = iota
+	fieldServiceShort
+	fieldServiceDescription
+	fieldServicePorts
+	fieldServiceProtocols
+	fieldServiceSourcePorts
+	fieldServiceModules
+	fieldServiceIPv4
+	fieldServiceIPv6
+	fieldServiceIncludes
+	serviceFieldCount
+)
+
+var serviceFieldLabels = [serviceFieldCount]string{
+	"Name",
+	"Short",
+	"Description",
+	"Ports",
+	"Protocols",
+	"Source ports",
+	"Modules",
+	"IPv4 destination",
+	"IPv6 destination",
+	"Includes",
+}
+
+var serviceFieldHints = [serviceFieldCount]string{
+	"letters, digits, - and _",
+	"one-line summary",
+	"free text",
+	"8080/tcp, 9000-9010/udp",
+	"gre, igmp",
+	"53/udp",
+	"nf_conntrack_ftp",
+	"224.0.0.251 or 10.0.0.0/8",
+	"ff02::fb",
+	"other service names",
+}
+
+// serviceEditor holds the form for a custom service. Lists are edited as
+// comma-separated text; the focused field lives in Model.input.
+type serviceEditor struct {
+	create  bool
+	field   serviceField
+	values  [serviceFieldCount]string
+	helpers []string
+}
+
+func newServiceEditor(info *firewalld.ServiceInfo) *serviceEditor {
+	e := &serviceEditor{create: info == nil, field: fieldServiceShort}
+	if info == nil {
+		e.field = fieldServiceName
+		return e
+	}
+	e.values[fieldServiceName] = info.Name
+	e.values[fieldServiceShort] = info.Short
+	e.values[fieldServiceDescription] = info.Description
+	e.values[fieldServicePorts] = formatPortList(info.Ports)
+	e.values[fieldServiceProtocols] = strings.Join(info.Protocols, ", ")
+	e.values[fieldServiceSourcePorts] = formatPortList(info.SourcePorts)
+	e.values[fieldServiceModules] = strings.Join(info.Modules, ", ")
+	e.values[fieldServiceIPv4] = info.Destinations["ipv4"]
+	e.values[fieldServiceIPv6] = info.Destinations["ipv6"]
+	e.values[fieldServiceIncludes] = strings.Join(info.Includes, ", ")
+	e.helpers = info.Helpers
+	return e
+}
+
+func formatPortList(ports []firewalld.Port) string {
+	items := make([]string, 0, len(ports))
+	for _, p := range ports {
+		items = append(items, p.Port+"/"+p.Protocol)
+	}
+	return strings.Join(items, ", ")
+}
+
+// splitServiceList splits comma or whitespace separated values.
+func splitServiceList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// parseServicePorts accepts port/proto items where port may be a range.
+func parseServicePorts(value string) ([]firewalld.Port, error) {
+	var ports []firewalld.Port
+	for _, item := range splitServiceList(value) {
+		port, err := firewalld.ParsePort(item)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+func parseServiceDestination(value, family string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		var err error
+		ip, _, err = net.ParseCIDR(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s destination: %s", family, value)
+		}
+	}
+	if (ip.To4() != nil) != (family == "ipv4") {
+		return "", fmt.Errorf("%s is not an %s address", value, family)
+	}
+	return value, nil
+}
+
+// serviceInfo validates the form and builds the service definition. known
+// is the service catalog used to check includes; it may be nil.
+func (e *serviceEditor) serviceInfo(known []string) (*firewalld.ServiceInfo, error) {
+	name := strings.TrimSpace(e.values[fieldServiceName])
+	if err := validation.IsValidZoneName(name); err != nil {
+		return nil, fmt.Errorf("invalid service name: %w", err)
+	}
+	if e.create && slices.Contains(known, name) {
+		return nil, fmt.Errorf("service %s already exists", name)
+	}
+	info := &firewalld.ServiceInfo{
+		Name:        name,
+		Short:       strings.TrimSpace(e.values[fieldServiceShort]),
+		Description: strings.TrimSpace(e.values[fieldServiceDescription]),
+		Protocols:   splitServiceList(strings.ToLower(e.values[fieldServiceProtocols])),
+		Modules:     splitServiceList(e.values[fieldServiceModules]),
+		Includes:    splitServiceList(e.values[fieldServiceIncludes]),
+		Helpers:     e.helpers,
+	}
+	var err error
+	if info.Ports, err = parseServicePorts(e.values[fieldServicePorts]); err != nil {
+		return nil, err
+	}
+	if info.SourcePorts, err = parseServicePorts(e.values[fieldServiceSourcePorts]); err != nil {
+		return nil, fmt.Errorf("source port: %w", err)
+	}
+	for _, family := range []struct {
+		field serviceField
+		name  string
+	}{{fieldServiceIPv4, "ipv4"}, {fieldServiceIPv6, "ipv6"}} {
+		addr, err := parseServiceDestination(e.values[family.field], family.name)
+		if err != nil {
+			return nil, err
+		}
+		if addr != "" {
+			if info.Destinations == nil {
+				info.Destinations = make(map[string]string)
+			}
+			info.Destinations[family.name] = addr
+		}
+	}
+	for _, inc := range info.Includes {
+		if inc == name {
+			return nil, fmt.Errorf("service %s cannot include itself", name)
+		}
+		if known != nil && !slices.Contains(known, inc) {
+			return nil, fmt.Errorf("unknown service in includes: %s", inc)
+		}
+	}
+	return info, nil
+}
+
+func (m *Model) startServiceEditor(create bool) tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	var info *firewalld.ServiceInfo
+	if !create {
+		if m.details == nil || m.detailsLoading {
+			m.err = fmt.Errorf("service details not loaded")
+			return nil
+		}
+		info = m.details
+	}
+	m.err = nil
+	m.serviceEdit = newServiceEditor(info)
+	m.loadServiceField()
+	return nil
+}
+
+func (m *Model) closeServiceEditor() {
+	m.serviceEdit = nil
+	m.serviceSaving = false
+	m.input.SetValue("")
+	m.input.Placeholder = ""
+	m.input.Blur()
+}
+
+func (m *Model) loadServiceField() {
+	e := m.serviceEdit
+	m.input.SetValue(e.values[e.field])
+	m.input.Placeholder = serviceFieldHints[e.field]
+	m.input.CursorEnd()
+	m.input.Focus()
+}
+
+// moveServiceField stores the focused value and focuses the next or
+// previous field, wrapping around. The name is fixed once a service exists.
+func (m *Model) moveServiceField(delta int) {
+	e := m.serviceEdit
+	e.values[e.field] = m.input.Value()
+	for {
+		e.field = (e.field + serviceField(delta) + serviceFieldCount) % serviceFieldCount
+		if e.create || e.field != fieldServiceName {
+			break
+		}
+	}
+	m.loadServiceField()
+}
+
+func (m *Model) saveService() tea.Cmd {
+	e := m.serviceEdit
+	e.values[e.field] = m.input.Value()
+	info, err := e.serviceInfo(m.availableServices)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.err = nil
+	m.notice = ""
+	if m.dryRun {
+		verb := "update"
+		if e.create {
+			verb = "create"
+		}
+		m.closeServiceEditor()
+		m.setDryRunNotice(fmt.Sprintf("%s service %s", verb, info.Name))
+		return nil
+	}
+	m.serviceSaving = true
+	return saveServiceCmd(m.client, info, e.create)
+}
+
+func (m *Model) startDeleteService() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	if m.details == nil || m.detailsLoading {
+		m.err = fmt.Errorf("service details not loaded")
+		return nil
+	}
+	if m.details.Builtin {
+		m.err = fmt.Errorf("service %s is shipped with firewalld and cannot be deleted", m.details.Name)
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue("")
+	m.input.Placeholder = "type service name to delete"
+	m.inputMode = inputDeleteService
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) submitDeleteService(value string) tea.Cmd {
+	name := m.detailsName
+	if value != name {
+		m.err = fmt.Errorf("type service name to confirm deletion")
+		return nil
+	}
+	m.inputMode = inputNone
+	m.input.Blur()
+	m.err = nil
+	m.notice = ""
+	if m.dryRun {
+		m.setDryRunNotice(fmt.Sprintf("delete service %s", name))
+		return nil
+	}
+	m.detailsLoading = true
+	return deleteServiceCmd(m.client, name)
+}
+
+func (m Model) handleServiceSaved(msg serviceSavedMsg) (Model, tea.Cmd) {
+	m.serviceSaving = false
+	m.detailsLoading = false
+	if msg.err != nil {
+		m.err = msg.err
+		return m, nil
+	}
+	m.err = nil
+	m.servicesLoading = true
+	if msg.deleted {
+		m.notice = fmt.Sprintf("Service %s deleted", msg.name)
+		m.detailsMode = false
+		m.detailsName = ""
+		m.details = nil
+		return m, fetchServiceCatalogCmd(m.client)
+	}
+	m.closeServiceEditor()
+	m.notice = fmt.Sprintf("Service %s saved", msg.name)
+	if m.offlineRoot == "" {
+		m.notice += " (reload to use it at runtime)"
+	}
+	m.detailsMode = true
+	m.detailsLoading = true
+	m.detailsErr = nil
+	m.detailsName = msg.name
+	return m, tea.Batch(fetchServiceCatalogCmd(m.client), fetchServiceDetailsCmd(m.client, msg.name))
+}
+
+func (m Model) handleServiceEditorMode(msg tea.Msg) (Model, tea.Cmd, bool) {
+	if m.serviceEdit == nil {
+		return m, nil, false
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, false
+	}
+	if m.serviceSaving {
+		if key.String() == "ctrl+c" {
+			return m, tea.Quit, true
+		}
+		return m, nil, true
+	}
+
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit, true
+	case "esc":
+		m.closeServiceEditor()
+		m.err = nil
+		return m, nil, true
+	case "tab", "down":
+		m.moveServiceField(1)
+		return m, nil, true
+	case "shift+tab", "up":
+		m.moveServiceField(-1)
+		return m, nil, true
+	case "enter":
+		return m, m.saveService(), true
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(key)
+	return m, cmd, true
+}
+
+func renderServiceEditor(b *strings.Builder, m Model) {
+	e := m.serviceEdit
+	header := "Edit Service: " + e.values[fieldServiceName]
+	if e.create {
+		header = "New Service"
+	}
+	if m.serviceSaving {
+		header += " " + m.spinner.View()
+	}
+	b.WriteString(titleStyle.Render(header))
+	b.WriteString("\n\n")
+
+	for field := serviceField(0); field < serviceFieldCount; field++ {
+		label := fmt.Sprintf("%-17s", serviceFieldLabels[field]+":")
+		switch {
+		case field == e.field:
+			b.WriteString(selectedStyle.Render("> "+label) + " " + m.input.View())
+		case field == fieldServiceName && !e.create:
+			b.WriteString("  " + label + " " + dimStyle.Render(e.values[field]))
+		case e.values[field] == "":
+			b.WriteString("  " + label + " " + dimStyle.Render("-"))
+		default:
+			b.WriteString("  " + label + " " + e.values[field])
+		}
+		b.WriteString("\n")
+	}
+	if len(e.helpers) > 0 {
+		b.WriteString(dimStyle.Render("  Helpers are kept: " + strings.Join(e.helpers, ", ")))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("Tab/Shift+Tab move, Enter save, Esc cancel"))
+}
@@ -0,0 +1,110 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseServicePorts(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []firewalld.Port
+		wantErr bool
+	}{
+		{input: "", want: nil},
+		{input: "80/tcp, 443/TCP", want: []firewalld.Port{{Port: "80", Protocol: "tcp"}, {Port: "443", Protocol: "tcp"}}},
+		{input: "60000-61000/udp", want: []firewalld.Port{{Port: "60000-61000", Protocol: "udp"}}},
+		{input: "80", wantErr: true},
+		{input: "0/tcp", wantErr: true},
+		{input: "90-80/tcp", wantErr: true},
+		{input: "80/icmp", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseServicePorts(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseServicePorts(%q) error = %v, wantErr = %v", tt.input, err, tt.wantErr)
+		}
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("parseServicePorts(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestServiceEditorRoundTrip(t *testing.T) {
+	info := &firewalld.ServiceInfo{
+		Name:         "myapp",
+		Short:        "My App",
+		Ports:        []firewalld.Port{{Port: "8080", Protocol: "tcp"}},
+		Protocols:    []string{"gre"},
+		Modules:      []string{"nf_conntrack_ftp"},
+		Destinations: map[string]string{"ipv6": "ff02::fb"},
+		Includes:     []string{"ssh"},
+		Helpers:      []string{"ftp"},
+	}
+	got, err := newServiceEditor(info).serviceInfo([]string{"myapp", "ssh"})
+	if err != nil {
+		t.Fatalf("serviceInfo() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, info) {
+		t.Fatalf("serviceInfo() = %+v, want %+v", got, info)
+	}
+}
+
+func TestServiceEditorValidation(t *testing.T) {
+	known := []string{"http", "ssh"}
+	tests := []struct {
+		name   string
+		create bool
+		values map[serviceField]string
+	}{
+		{name: "bad name", create: true, values: map[serviceField]string{fieldServiceName: "a/b"}},
+		{name: "existing name", create: true, values: map[serviceField]string{fieldServiceName: "ssh"}},
+		{name: "ipv6 in ipv4", values: map[serviceField]string{fieldServiceName: "x", fieldServiceIPv4: "::1"}},
+		{name: "unknown include", values: map[serviceField]string{fieldServiceName: "x", fieldServiceIncludes: "gopher"}},
+		{name: "self include", values: map[serviceField]string{fieldServiceName: "x", fieldServiceIncludes: "x"}},
+		{name: "bad source port", values: map[serviceField]string{fieldServiceName: "x", fieldServiceSourcePorts: "53"}},
+	}
+	for _, tt := range tests {
+		e := &serviceEditor{create: tt.create}
+		for field, value := range tt.values {
+			e.values[field] = value
+		}
+		if _, err := e.serviceInfo(known); err == nil {
+			t.Fatalf("%s: serviceInfo() should fail", tt.name)
+		}
+	}
+}
+
+func TestServiceEditorKeys(t *testing.T) {
+	m := NewModel(&fakeBackend{}, Options{})
+	m.detailsMode = true
+	m.detailsName = "ssh"
+	m.details = &firewalld.ServiceInfo{Name: "ssh", Short: "SSH", Builtin: true}
+
+	next, _, handled := m.handleDetailsMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	if !handled || next.inputMode != inputNone || next.err == nil {
+		t.Fatalf("deleting a shipped service should be refused, err = %v", next.err)
+	}
+
+	next, _, _ = m.handleDetailsMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if next.serviceEdit == nil || next.serviceEdit.create || next.serviceEdit.field != fieldServiceShort {
+		t.Fatalf("e should open the editor on the short field, got %+v", next.serviceEdit)
+	}
+	next, _, _ = next.handleServiceEditorMode(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if next.serviceEdit.field != fieldServiceIncludes {
+		t.Fatalf("shift+tab should skip the fixed name, field = %d", next.serviceEdit.field)
+	}
+
+	next.dryRun = true
+	next, cmd, _ := next.handleServiceEditorMode(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd != nil || next.serviceEdit != nil || next.notice == "" {
+		t.Fatalf("dry-run save should close the editor with a notice, notice = %q", next.notice)
+	}
+}
@@ -0,0 +1,220 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/keymap"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// settingNames lists the rows of the settings screen: the default zone,
+// LogDenied, then the other DaemonSettings this firewalld reports.
+func (m Model) settingNames() []string {
+	names := []string{"DefaultZone", "LogDenied"}
+	for _, s := range firewalld.DaemonSettings {
+		if _, ok := m.settings[s.Name]; ok && s.Name != "LogDenied" {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+func (m Model) settingValue(name string) string {
+	switch name {
+	case "DefaultZone":
+		return m.defaultZone
+	case "LogDenied":
+		if m.logDenied != "" {
+			return m.logDenied
+		}
+	}
+	return m.settings[name]
+}
+
+// settingChoices returns the values name accepts, for the input placeholder.
+func (m Model) settingChoices(name string) []string {
+	if name == "DefaultZone" {
+		return m.zones
+	}
+	s, _ := firewalld.LookupDaemonSetting(name)
+	return s.Values
+}
+
+func (m *Model) startSettingsScreen() tea.Cmd {
+	m.err = nil
+	m.settingsMode = true
+	m.settingsIndex = 0
+	return m.refreshSettings()
+}
+
+func (m *Model) closeSettingsScreen() {
+	m.settingsMode = false
+	m.settingsIndex = 0
+	m.settingsErr = nil
+}
+
+func (m *Model) refreshSettings() tea.Cmd {
+	m.settingsLoading = true
+	m.settingsErr = nil
+	return tea.Batch(fetchSettingsCmd(m.client), fetchDefaultZoneCmd(m.client))
+}
+
+func (m *Model) startEditSetting() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	names := m.settingNames()
+	if m.settingsIndex >= len(names) {
+		return nil
+	}
+	name := names[m.settingsIndex]
+	m.err = nil
+	m.editSetting = name
+	m.input.SetValue(m.settingValue(name))
+	m.input.Placeholder = strings.Join(m.settingChoices(name), " | ")
+	m.inputMode = inputSetting
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) submitSettingInput(value string) tea.Cmd {
+	name := m.editSetting
+	if name == "DefaultZone" {
+		if !slices.Contains(m.zones, value) {
+			m.err = fmt.Errorf("unknown zone %q", value)
+			return nil
+		}
+	} else if err := firewalld.CheckDaemonSetting(name, value); err != nil {
+		m.err = err
+		return nil
+	}
+	m.inputMode = inputNone
+	m.input.Blur()
+	m.editSetting = ""
+	m.err = nil
+	m.notice = ""
+	if value == m.settingValue(name) {
+		m.notice = name + " is already " + value
+		return nil
+	}
+	if m.dryRun {
+		m.setDryRunNotice(fmt.Sprintf("set %s=%s", name, value))
+		return nil
+	}
+	if name == "DefaultZone" {
+		return m.guardSetDefaultZone(value)
+	}
+	m.settingsLoading = true
+	return setSettingCmd(m.client, name, value)
+}
+
+func (m Model) handleSettings(msg settingsMsg) (Model, tea.Cmd) {
+	m.settingsLoading = false
+	if msg.err != nil {
+		m.settingsErr = msg.err
+		return m, nil
+	}
+	m.settingsErr = nil
+	m.logDenied = msg.logDenied
+	m.settings = msg.settings
+	if n := len(m.settingNames()); m.settingsIndex >= n {
+		m.settingsIndex = n - 1
+	}
+	return m, nil
+}
+
+// handleSettingMutation reports where a change takes effect: firewalld
+// reloads itself for LogDenied, the rest waits for the next reload.
+func (m Model) handleSettingMutation(msg settingMutationMsg) (Model, tea.Cmd) {
+	if msg.err != nil {
+		m.settingsLoading = false
+		m.err = msg.err
+		return m, nil
+	}
+	m.err = nil
+	switch {
+	case msg.name == "LogDenied":
+		m.logDenied = msg.value
+		m.notice = "LogDenied set to " + msg.value
+	case m.offlineRoot != "":
+		m.notice = msg.name + "=" + msg.value + " saved to firewalld.conf"
+	default:
+		m.notice = msg.name + "=" + msg.value + " saved to firewalld.conf; reload (u) to apply"
+	}
+	return m, fetchSettingsCmd(m.client)
+}
+
+func (m Model) handleSettingsMode(msg tea.Msg) (Model, tea.Cmd, bool) {
+	if !m.settingsMode {
+		return m, nil, false
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil, false
+	}
+
+	if step := m.listStep(key.String()); step != 0 {
+		m.settingsIndex = max(min(m.settingsIndex+step, len(m.settingNames())-1), 0)
+		return m, nil, true
+	}
+	if m.keys.Action(key.String()) == keymap.Settings {
+		m.closeSettingsScreen()
+		return m, nil, true
+	}
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit, true
+	case "esc", "q":
+		m.closeSettingsScreen()
+		return m, nil, true
+	case "enter", "e":
+		return m, m.startEditSetting(), true
+	case "r":
+		return m, m.refreshSettings(), true
+	}
+	return m, nil, true
+}
+
+func renderSettingsScreen(b *strings.Builder, m Model) {
+	header := "Settings"
+	if m.settingsLoading {
+		header += " " + m.spinner.View()
+	}
+	b.WriteString(titleStyle.Render(header))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("firewalld.conf: LogDenied and DefaultZone apply at once, the rest on reload"))
+	b.WriteString("\n\n")
+	if m.settingsErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + m.settingsErr.Error()))
+		b.WriteString("\n")
+		return
+	}
+	names := m.settingNames()
+	for i, name := range names {
+		value := m.settingValue(name)
+		if value == "" {
+			value = "-"
+		}
+		line := fmt.Sprintf("  %-22s %s", name, value)
+		if i == m.settingsIndex {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	if m.settingsIndex < len(names) {
+		if choices := m.settingChoices(names[m.settingsIndex]); len(choices) > 0 {
+			b.WriteString("\n")
+			b.WriteString(dimStyle.Render("Values: " + strings.Join(choices, ", ")))
+			b.WriteString("\n")
+		}
+	}
+}
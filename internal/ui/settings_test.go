@@ -0,0 +1,81 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSettingsScreenRowsAndEdit(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{})
+	m.zones = []string{"drop", "public"}
+	m.defaultZone = "public"
+	m.settingsMode = true
+	m, _ = m.handleSettings(settingsMsg{
+		logDenied: "off",
+		settings:  map[string]string{"LogDenied": "off", "CleanupOnExit": "yes", "FirewallBackend": "nftables"},
+	})
+	if got := m.settingNames(); !slices.Equal(got, []string{"DefaultZone", "LogDenied", "FirewallBackend", "CleanupOnExit"}) {
+		t.Fatalf("settingNames() = %v", got)
+	}
+
+	m, _, _ = m.handleSettingsMode(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	m, _, _ = m.handleSettingsMode(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.inputMode != inputSetting || m.editSetting != "LogDenied" || m.input.Value() != "off" {
+		t.Fatalf("Enter should edit LogDenied, got mode %v setting %q value %q", m.inputMode, m.editSetting, m.input.Value())
+	}
+	m.input.SetValue("some")
+	if cmd := m.submitInput(); cmd != nil || m.inputMode != inputSetting || m.err == nil {
+		t.Fatalf("an invalid LogDenied value should be rejected")
+	}
+	m.input.SetValue("unicast")
+	if cmd := m.submitInput(); cmd == nil || m.inputMode != inputNone {
+		t.Fatalf("a valid LogDenied value should be applied")
+	}
+
+	m.dryRun = true
+	m.editSetting = "DefaultZone"
+	m.inputMode = inputSetting
+	if cmd := m.submitSettingInput("lab"); cmd != nil || m.err == nil {
+		t.Fatalf("an unknown default zone should be rejected")
+	}
+	if cmd := m.submitSettingInput("drop"); cmd != nil || !strings.Contains(m.notice, "DefaultZone=drop") {
+		t.Fatalf("dry run should only report the change, notice = %q", m.notice)
+	}
+}
+
+func TestSettingMutationNotice(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{})
+	m.logDenied = "off"
+	m, _ = m.handleSettingMutation(settingMutationMsg{name: "LogDenied", value: "all"})
+	if m.logDenied != "all" {
+		t.Fatalf("logDenied = %q, want all", m.logDenied)
+	}
+	m, _ = m.handleSettingMutation(settingMutationMsg{name: "CleanupOnExit", value: "no"})
+	if !strings.Contains(m.notice, "reload") {
+		t.Fatalf("other settings should mention the reload, notice = %q", m.notice)
+	}
+}
+
+func TestLogsViewHintsAtLogDenied(t *testing.T) {
+	m := NewModel(&firewalld.Client{}, Options{})
+	m.logDenied = "off"
+	var b strings.Builder
+	renderLogsView(&b, m)
+	if !strings.Contains(b.String(), "Alt+S") {
+		t.Fatalf("log view should point at the settings screen:\n%s", b.String())
+	}
+	m.logDenied = "all"
+	b.Reset()
+	renderLogsView(&b, m)
+	if strings.Contains(b.String(), "LogDenied") {
+		t.Fatalf("no hint expected once LogDenied is on:\n%s", b.String())
+	}
+}
@@ -3,34 +3,279 @@
 
 package ui
 
-import "lazyfirewall/internal/firewalld"
-
-type zoneTemplate struct {
-	Name        string
-	Description string
-	Services    []string
-	Ports       []firewalld.Port
-}
-
-var defaultTemplates = []zoneTemplate{
-	{
-		Name:        "Web Server",
-		Description: "Adds http and https services",
-		Services:    []string{"http", "https"},
-	},
-	{
-		Name:        "Database Server",
-		Description: "Adds postgresql and mysql services",
-		Services:    []string{"postgresql", "mysql"},
-	},
-	{
-		Name:        "SSH Only",
-		Description: "Adds ssh service (does not remove others)",
-		Services:    []string{"ssh"},
-	},
-	{
-		Name:        "Workstation",
-		Description: "Adds common desktop services",
-		Services:    []string{"ssh", "mdns", "samba-client", "ipp-client", "dhcpv6-client"},
-	},
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/lockout"
+	"lazyfirewall/internal/richrule"
+	"lazyfirewall/internal/templates"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// templateList returns the templates the template screen offers.
+func (m Model) templateList() []templates.Template {
+	if m.templates == nil {
+		return templates.Builtin()
+	}
+	return m.templates
+}
+
+// templatePlan is what applying a template adds to a zone: only elements
+// the zone lacks. The preview shows it and applyTemplateCmd applies
+// exactly it.
+type templatePlan struct {
+	name       string
+	services   []string
+	ports      []firewalld.Port
+	richRules  []string
+	sources    []string
+	masquerade bool
+	target     string
+	// present lists template elements the zone already has; skipped ones
+	// that cannot be applied in this mode.
+	present []string
+	skipped []string
+}
+
+func (p templatePlan) empty() bool {
+	return len(p.services) == 0 && len(p.ports) == 0 && len(p.richRules) == 0 &&
+		len(p.sources) == 0 && !p.masquerade && p.target == ""
+}
+
+// changes lists the additions in apply order.
+func (p templatePlan) changes() []string {
+	var out []string
+	for _, s := range p.services {
+		out = append(out, "service "+s)
+	}
+	for _, port := range p.ports {
+		out = append(out, "port "+port.Port+"/"+port.Protocol)
+	}
+	for _, r := range p.richRules {
+		out = append(out, "rich rule "+r)
+	}
+	for _, s := range p.sources {
+		out = append(out, "source "+s)
+	}
+	if p.masquerade {
+		out = append(out, "masquerade")
+	}
+	if p.target != "" {
+		out = append(out, "target "+p.target)
+	}
+	return out
+}
+
+// buildTemplatePlan compares an expanded template with the zone. Rich
+// rules are compared in firewalld's normal form, so quoting differences do
+// not add a rule twice.
+func buildTemplatePlan(tpl templates.Template, current *firewalld.Zone, permanent bool) (templatePlan, error) {
+	plan := templatePlan{name: tpl.Name}
+
+	plan.services = filterMissingServices(tpl.Services, current.Services)
+	for _, s := range tpl.Services {
+		if !slices.Contains(plan.services, s) {
+			plan.present = append(plan.present, "service "+s)
+		}
+	}
+
+	ports := make([]firewalld.Port, 0, len(tpl.Ports))
+	for _, value := range tpl.Ports {
+		port, proto, _ := strings.Cut(value, "/")
+		ports = append(ports, firewalld.Port{Port: port, Protocol: proto})
+	}
+	plan.ports = filterMissingPorts(ports, current.Ports)
+	for _, p := range ports {
+		if !slices.Contains(plan.ports, p) {
+			plan.present = append(plan.present, "port "+p.Port+"/"+p.Protocol)
+		}
+	}
+
+	existing := make(map[string]bool, len(current.RichRules))
+	for _, r := range current.RichRules {
+		existing[richrule.Normalize(r)] = true
+	}
+	for _, r := range tpl.RichRules {
+		if existing[richrule.Normalize(r)] {
+			plan.present = append(plan.present, "rich rule "+r)
+			continue
+		}
+		existing[richrule.Normalize(r)] = true
+		plan.richRules = append(plan.richRules, r)
+	}
+
+	sources := slices.Clone(tpl.Sources)
+	for _, name := range tpl.IPSets {
+		sources = append(sources, "ipset:"+name)
+	}
+	for _, s := range sources {
+		if slices.Contains(current.Sources, s) || slices.Contains(plan.sources, s) {
+			plan.present = append(plan.present, "source "+s)
+			continue
+		}
+		plan.sources = append(plan.sources, s)
+	}
+
+	if tpl.Masquerade {
+		if current.Masquerade {
+			plan.present = append(plan.present, "masquerade")
+		} else {
+			plan.masquerade = true
+		}
+	}
+
+	if tpl.Target != "" {
+		target, err := firewalld.NormalizeTarget(tpl.Target)
+		if err != nil {
+			return templatePlan{}, err
+		}
+		currentTarget, _ := firewalld.NormalizeTarget(current.Target)
+		switch {
+		case target == currentTarget:
+			plan.present = append(plan.present, "target "+target)
+		case !permanent:
+			plan.skipped = append(plan.skipped, "target "+target+" (permanent mode only)")
+		default:
+			plan.target = target
+		}
+	}
+	return plan, nil
+}
+
+// startTemplate asks for the selected template's parameters, then shows
+// the preview.
+func (m *Model) startTemplate() tea.Cmd {
+	list := m.templateList()
+	if m.templateIndex < 0 || m.templateIndex >= len(list) {
+		m.err = fmt.Errorf("invalid template selection")
+		return nil
+	}
+	m.err = nil
+	m.templateValues = make(map[string]string)
+	m.templateParams = list[m.templateIndex].Parameters()
+	return m.nextTemplateParam()
+}
+
+// nextTemplateParam prompts for the first parameter without a value, or
+// builds the preview once all have one.
+func (m *Model) nextTemplateParam() tea.Cmd {
+	if len(m.templateParams) == 0 {
+		m.previewTemplate()
+		return nil
+	}
+	p := m.templateParams[0]
+	m.inputMode = inputTemplateParam
+	m.input.Placeholder = p.Name
+	m.input.SetValue(p.Default)
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) submitTemplateParam(value string) tea.Cmd {
+	if len(m.templateParams) == 0 {
+		m.inputMode = inputNone
+		m.input.Blur()
+		return nil
+	}
+	m.templateValues[m.templateParams[0].Name] = value
+	m.templateParams = m.templateParams[1:]
+	m.inputMode = inputNone
+	m.input.Blur()
+	return m.nextTemplateParam()
+}
+
+// templateParamLabel is the prompt for the parameter being asked for.
+func (m Model) templateParamLabel() string {
+	if len(m.templateParams) == 0 {
+		return "Parameter: "
+	}
+	p := m.templateParams[0]
+	if p.Prompt != "" {
+		return fmt.Sprintf("%s (%s): ", p.Prompt, p.Name)
+	}
+	return p.Name + ": "
+}
+
+// previewTemplate expands the selected template with the collected values
+// and plans it against the current zone.
+func (m *Model) previewTemplate() {
+	list := m.templateList()
+	if m.templateIndex < 0 || m.templateIndex >= len(list) {
+		m.err = fmt.Errorf("invalid template selection")
+		return
+	}
+	current := m.currentData()
+	if current == nil {
+		m.err = fmt.Errorf("no data loaded")
+		return
+	}
+	expanded, err := list[m.templateIndex].Expand(m.templateValues)
+	if err != nil {
+		m.err = err
+		return
+	}
+	plan, err := buildTemplatePlan(expanded, current, m.permanent)
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.templatePlan = &plan
+}
+
+// closeTemplates leaves the preview, then the template list.
+func (m *Model) closeTemplates() {
+	if m.templatePlan != nil {
+		m.templatePlan = nil
+		return
+	}
+	m.templateMode = false
+}
+
+func (m *Model) applyTemplate() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
+		return nil
+	}
+	if len(m.zones) == 0 {
+		m.err = fmt.Errorf("no zone selected")
+		return nil
+	}
+	if m.templatePlan == nil {
+		return nil
+	}
+	plan := *m.templatePlan
+	if plan.empty() {
+		m.err = fmt.Errorf("template already applied")
+		return nil
+	}
+
+	m.templateMode = false
+	m.templatePlan = nil
+	zone := m.zones[m.selected]
+	if m.dryRun {
+		m.setDryRunNotice(fmt.Sprintf("apply template %s to zone %s (%s): %s", plan.name, zone, modeLabel(m.permanent), strings.Join(plan.changes(), ", ")))
+		return nil
+	}
+	affectsSSH := false
+	for _, s := range plan.sources {
+		affectsSSH = affectsSSH || m.ssh.Binds(s)
+	}
+	permanent := m.permanent
+	apply := func(m *Model) tea.Cmd {
+		m.loading = true
+		m.err = nil
+		m.pendingZone = zone
+		return m.safeMutation(zone, "apply template "+plan.name, permanent, affectsSSH, applyTemplateCmd(m.client, zone, plan, permanent))
+	}
+	if plan.target == "" {
+		return apply(m)
+	}
+	// A new target can stop the session being accepted, as in
+	// submitSetTarget.
+	return m.guardLockout(lockout.Change{Kind: lockout.SetTarget, Zone: zone, Value: plan.target}, apply)
 }
@@ -0,0 +1,146 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/lockout"
+	"lazyfirewall/internal/templates"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestApplyTemplateWithParameters(t *testing.T) {
+	bastion := templates.Template{
+		Name:       "Bastion",
+		Params:     []templates.Param{{Name: "ADMIN_NET", Prompt: "Admin network", Default: "10.0.0.0/8"}},
+		Services:   []string{"ssh", "https"},
+		RichRules:  []string{`rule family="ipv4" source address="${ADMIN_NET}" service name="ssh" accept`},
+		Sources:    []string{"${ADMIN_NET}"},
+		IPSets:     []string{"admins"},
+		Masquerade: true,
+		Target:     "DROP",
+		Origin:     "config",
+	}
+	m := NewModel(&firewalld.Client{}, Options{Templates: []templates.Template{bastion}})
+	m.zones = []string{"public"}
+	m.runtimeData = &firewalld.Zone{
+		Services:  []string{"ssh"},
+		RichRules: []string{`rule family=ipv4 source address=10.0.0.0/8 service name=ssh accept`},
+		Target:    "default",
+	}
+	m.dryRun = true
+
+	press := func(msg tea.KeyMsg) {
+		t.Helper()
+		next, _ := m.Update(msg)
+		m = next.(Model)
+	}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.inputMode != inputTemplateParam || m.input.Value() != "10.0.0.0/8" {
+		t.Fatalf("ADMIN_NET should be asked for with its default, mode = %v, value = %q", m.inputMode, m.input.Value())
+	}
+	if label := m.templateParamLabel(); label != "Admin network (ADMIN_NET): " {
+		t.Fatalf("prompt = %q", label)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.err != nil || m.templatePlan == nil {
+		t.Fatalf("expected a preview, err = %v", m.err)
+	}
+
+	plan := *m.templatePlan
+	want := "service https,source 10.0.0.0/8,source ipset:admins,masquerade"
+	if got := strings.Join(plan.changes(), ","); got != want {
+		t.Fatalf("changes = %s, want %s", got, want)
+	}
+	if len(plan.present) != 2 || len(plan.skipped) != 1 {
+		t.Fatalf("present = %v, skipped = %v", plan.present, plan.skipped)
+	}
+	var b strings.Builder
+	renderTemplates(&b, m)
+	out := b.String()
+	if !strings.Contains(out, "+ service https") || !strings.Contains(out, "skipped: target DROP (permanent mode only)") {
+		t.Fatalf("preview:\n%s", out)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.templateMode || !strings.Contains(m.notice, "apply template Bastion to zone public (runtime): service https, source 10.0.0.0/8") {
+		t.Fatalf("notice = %q", m.notice)
+	}
+}
+
+func TestTemplatePlanPermanentTarget(t *testing.T) {
+	tpl := templates.Template{Name: "Locked", Target: "reject", Ports: []string{"8080/tcp"}}
+	plan, err := buildTemplatePlan(tpl, &firewalld.Zone{Target: "default"}, true)
+	if err != nil {
+		t.Fatalf("buildTemplatePlan() error = %v", err)
+	}
+	if plan.target != "%%REJECT%%" || len(plan.ports) != 1 || plan.ports[0] != (firewalld.Port{Port: "8080", Protocol: "tcp"}) {
+		t.Fatalf("plan = %+v", plan)
+	}
+	plan, _ = buildTemplatePlan(tpl, &firewalld.Zone{Target: "%%REJECT%%", Ports: []firewalld.Port{{Port: "8080", Protocol: "tcp"}}}, true)
+	if !plan.empty() {
+		t.Fatalf("applied template should plan nothing, got %+v", plan)
+	}
+}
+
+// lockoutBackend answers the queries lockout.Check makes.
+type lockoutBackend struct {
+	fakeBackend
+	zone *firewalld.Zone
+	// zones, if set, answers per zone name instead of zone.
+	zones map[string]*firewalld.Zone
+}
+
+func (b *lockoutBackend) GetActiveZones() (map[string][]string, error) {
+	return map[string][]string{"public": {"eth0"}}, nil
+}
+
+func (b *lockoutBackend) GetDefaultZone() (string, error) { return "public", nil }
+
+func (b *lockoutBackend) GetZoneSettings(zone string, permanent bool) (*firewalld.Zone, error) {
+	if b.zones != nil {
+		return b.zones[zone], nil
+	}
+	return b.zone, nil
+}
+
+func (b *lockoutBackend) GetServiceDetails(name string) (*firewalld.ServiceInfo, error) {
+	return &firewalld.ServiceInfo{Name: name}, nil
+}
+
+func TestApplyTemplateTargetGuardsLockout(t *testing.T) {
+	zone := &firewalld.Zone{Name: "public", Target: "ACCEPT"}
+	m := NewModel(&lockoutBackend{zone: zone}, Options{})
+	m.zones = []string{"public"}
+	m.permanent = true
+	m.permanentData = zone
+	m.ssh = &lockout.Session{Client: net.ParseIP("192.0.2.10"), Port: 22, Interface: "eth0"}
+	m.templateMode = true
+	m.loading = false
+
+	plan, err := buildTemplatePlan(templates.Template{Name: "Locked", Target: "DROP"}, zone, true)
+	if err != nil {
+		t.Fatalf("buildTemplatePlan() error = %v", err)
+	}
+	m.templatePlan = &plan
+	cmd := m.applyTemplate()
+	if cmd == nil || m.loading {
+		t.Fatalf("a target change should wait for the lockout check, loading = %v", m.loading)
+	}
+	msg, ok := cmd().(lockoutMsg)
+	if !ok {
+		t.Fatalf("applyTemplate() did not run the lockout check")
+	}
+	next, _ := m.Update(msg)
+	m = next.(Model)
+	if m.inputMode != inputLockoutConfirm || !strings.Contains(m.lockoutReason, "setting target DROP") {
+		t.Fatalf("input mode = %v, reason = %q, want lockout confirmation", m.inputMode, m.lockoutReason)
+	}
+}
@@ -0,0 +1,100 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"strings"
+
+	"lazyfirewall/internal/theme"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Styles used across the views; applyTheme sets their colors.
+var (
+	titleStyle       lipgloss.Style
+	selectedStyle    lipgloss.Style
+	selectedDimStyle lipgloss.Style
+	dimStyle         lipgloss.Style
+	errorStyle       lipgloss.Style
+	tabActiveStyle   lipgloss.Style
+	tabInactiveStyle lipgloss.Style
+	inputStyle       lipgloss.Style
+	matchStyle       lipgloss.Style
+	statusStyle      lipgloss.Style
+	statusTextStyle  lipgloss.Style
+	statusMutedStyle lipgloss.Style
+	statusKeyStyle   lipgloss.Style
+	sidebarStyle     lipgloss.Style
+	mainStyle        lipgloss.Style
+	warnStyle        lipgloss.Style
+	panicStyle       lipgloss.Style
+	addedStyle       lipgloss.Style
+	removedStyle     lipgloss.Style
+)
+
+func init() {
+	t, _ := theme.Builtin(theme.Default)
+	applyTheme(t)
+}
+
+// applyTheme rebuilds the styles from t. Themes without an accent or muted
+// background fall back to reverse video and underlining for selections.
+func applyTheme(t theme.Theme) {
+	fg := func(c string) lipgloss.Style {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(c))
+	}
+	on := func(bg, text string) lipgloss.Style {
+		return fg(text).Background(lipgloss.Color(bg))
+	}
+
+	accent := on(t.Accent, t.AccentText)
+	if t.Accent == "" {
+		accent = accent.Reverse(true)
+	}
+	muted := on(t.Muted, t.MutedText)
+	if t.Muted == "" {
+		muted = muted.Underline(true)
+	}
+
+	titleStyle = lipgloss.NewStyle().Bold(true)
+	selectedStyle = accent
+	selectedDimStyle = muted
+	dimStyle = fg(t.Dim)
+	errorStyle = fg(t.Error)
+	tabActiveStyle = accent.Padding(0, 1)
+	tabInactiveStyle = on(t.Muted, t.MutedText).Padding(0, 1)
+	inputStyle = fg(t.Input)
+	matchStyle = fg(t.Match).Bold(true)
+	statusStyle = accent.Padding(0, 1)
+	statusTextStyle = accent.Foreground(lipgloss.Color(t.StatusText))
+	statusMutedStyle = accent.Foreground(lipgloss.Color(t.StatusText))
+	statusKeyStyle = accent.Foreground(lipgloss.Color(t.StatusKey))
+	sidebarStyle = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1)
+	mainStyle = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1)
+	warnStyle = fg(t.Warn).Bold(true)
+	panicStyle = on(t.PanicBg, t.PanicText).Padding(0, 1).Bold(true)
+	if t.PanicBg == "" {
+		panicStyle = panicStyle.Reverse(true)
+	}
+	addedStyle = fg(t.Added)
+	removedStyle = fg(t.Removed)
+}
+
+// colorDiffLines colors split view lines marked "+ " (runtime only) and
+// "- " (permanent only).
+func colorDiffLines(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+ "):
+			out[i] = addedStyle.Render(line)
+		case strings.HasPrefix(line, "- "):
+			out[i] = removedStyle.Render(line)
+		default:
+			out[i] = line
+		}
+	}
+	return out
+}
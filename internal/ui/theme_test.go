@@ -0,0 +1,35 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"testing"
+
+	"lazyfirewall/internal/theme"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestApplyTheme(t *testing.T) {
+	defer func() {
+		def, _ := theme.Builtin(theme.Default)
+		applyTheme(def)
+	}()
+
+	mono, _ := theme.Builtin("monochrome")
+	applyTheme(mono)
+	if !selectedStyle.GetReverse() || !selectedDimStyle.GetUnderline() {
+		t.Fatalf("monochrome selections should use reverse video and underline")
+	}
+
+	hc, _ := theme.Builtin("high-contrast")
+	applyTheme(hc)
+	if selectedStyle.GetReverse() || selectedStyle.GetBackground() != lipgloss.Color(hc.Accent) {
+		t.Fatalf("high-contrast selection background = %v", selectedStyle.GetBackground())
+	}
+	lines := colorDiffLines([]string{"+ ssh", "  http", "- dns"})
+	if lines[0] != addedStyle.Render("+ ssh") || lines[1] != "  http" || lines[2] != removedStyle.Render("- dns") {
+		t.Fatalf("colorDiffLines() = %q", lines)
+	}
+}
@@ -0,0 +1,204 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/richrule"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	expiryService = "service"
+	expiryPort    = "port"
+	expiryRich    = "rich"
+)
+
+type expiryTickMsg struct{}
+
+func expiryTickCmd() tea.Cmd {
+	return tea.Tick(1*time.Second, func(time.Time) tea.Msg {
+		return expiryTickMsg{}
+	})
+}
+
+// expiryKey identifies a timed element. Rich rules are keyed in firewalld's
+// normal form so a rule typed without quotes still matches the listing.
+func expiryKey(zone, kind, value string) string {
+	if kind == expiryRich {
+		value = richrule.Normalize(value)
+	}
+	return zone + "\x00" + kind + "\x00" + value
+}
+
+// parseTimedInput splits an optional trailing lifetime ("ssh 30m") from the
+// value typed into the add prompt.
+func parseTimedInput(value string) (string, time.Duration, error) {
+	value = strings.TrimSpace(value)
+	idx := strings.LastIndexAny(value, " \t")
+	if idx < 0 {
+		return value, 0, nil
+	}
+	timeout, ok := parseTimeout(value[idx+1:])
+	if !ok {
+		return value, 0, nil
+	}
+	if timeout <= 0 || timeout > firewalld.MaxRuntimeTimeout {
+		return "", 0, fmt.Errorf("invalid timeout: %s", value[idx+1:])
+	}
+	return strings.TrimSpace(value[:idx]), timeout, nil
+}
+
+func parseTimeout(value string) (time.Duration, bool) {
+	if value == "" || value[0] < '0' || value[0] > '9' {
+		return 0, false
+	}
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(days) * 24 * time.Hour, true
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+func timedModeLabel(permanent bool, timeout time.Duration) string {
+	if timeout > 0 {
+		return "runtime, expires in " + formatRemaining(timeout)
+	}
+	return modeLabel(permanent)
+}
+
+func formatRemaining(d time.Duration) string {
+	if d < time.Second {
+		return "0s"
+	}
+	d = d.Round(time.Second)
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd%02dh", int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%02ds", int(d/time.Minute), int(d%time.Minute/time.Second))
+	default:
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	}
+}
+
+// withExpiry tags a runtime mutation so that, on success, the model records
+// (timeout > 0) or forgets (timeout == 0) the lifetime of the affected item.
+func withExpiry(cmd tea.Cmd, key string, timeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		msg := cmd()
+		if mm, ok := msg.(mutationMsg); ok {
+			mm.expiryKey = key
+			mm.expiry = timeout
+			return mm
+		}
+		return msg
+	}
+}
+
+func (m *Model) setExpiry(key string, timeout time.Duration) tea.Cmd {
+	if timeout <= 0 {
+		delete(m.expiries, key)
+		return nil
+	}
+	if m.expiries == nil {
+		m.expiries = make(map[string]time.Time)
+	}
+	m.expiries[key] = time.Now().Add(timeout)
+	if m.expiryTicking {
+		return nil
+	}
+	m.expiryTicking = true
+	return expiryTickCmd()
+}
+
+func (m Model) expiryRemaining(key string) time.Duration {
+	at, ok := m.expiries[key]
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(at)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// clearZoneExpiries forgets the timed elements of zone, e.g. after a reload
+// dropped them.
+func (m *Model) clearZoneExpiries(zone string) {
+	for key := range m.expiries {
+		if strings.HasPrefix(key, zone+"\x00") {
+			delete(m.expiries, key)
+		}
+	}
+}
+
+// pruneExpiries drops elapsed entries and reports the zones they belonged to.
+func (m *Model) pruneExpiries(now time.Time) map[string]struct{} {
+	var zones map[string]struct{}
+	for key, at := range m.expiries {
+		if now.Before(at) {
+			continue
+		}
+		delete(m.expiries, key)
+		if zones == nil {
+			zones = make(map[string]struct{})
+		}
+		zone, _, _ := strings.Cut(key, "\x00")
+		zones[zone] = struct{}{}
+	}
+	return zones
+}
+
+func (m Model) expiryLabel(kind, value string) string {
+	if m.permanent || len(m.zones) == 0 || m.selected >= len(m.zones) {
+		return ""
+	}
+	remaining := m.expiryRemaining(expiryKey(m.zones[m.selected], kind, value))
+	if remaining <= 0 {
+		return ""
+	}
+	return " (expires in " + formatRemaining(remaining) + ")"
+}
+
+func (m *Model) actionAddServiceTimed(zone, service string, timeout time.Duration) tea.Cmd {
+	key := expiryKey(zone, expiryService, service)
+	action := &undoAction{label: "add service " + service + " for " + formatRemaining(timeout), zone: zone}
+	action.undo = withExpiry(removeServiceCmd(m.client, zone, service, false, action, recordRedo, false), key, 0)
+	action.redo = withExpiry(addServiceTimedCmd(m.client, zone, service, timeout, action, recordUndo, false), key, timeout)
+	return withExpiry(addServiceTimedCmd(m.client, zone, service, timeout, action, recordUndo, true), key, timeout)
+}
+
+func (m *Model) actionAddPortTimed(zone string, port firewalld.Port, timeout time.Duration) tea.Cmd {
+	label := port.Port + "/" + port.Protocol
+	key := expiryKey(zone, expiryPort, label)
+	action := &undoAction{label: "add port " + label + " for " + formatRemaining(timeout), zone: zone}
+	action.undo = withExpiry(removePortCmd(m.client, zone, port, false, action, recordRedo, false), key, 0)
+	action.redo = withExpiry(addPortTimedCmd(m.client, zone, port, timeout, action, recordUndo, false), key, timeout)
+	return withExpiry(addPortTimedCmd(m.client, zone, port, timeout, action, recordUndo, true), key, timeout)
+}
+
+func (m *Model) actionAddRichRuleTimed(zone, rule string, timeout time.Duration) tea.Cmd {
+	key := expiryKey(zone, expiryRich, rule)
+	action := &undoAction{label: "add rich rule for " + formatRemaining(timeout), zone: zone}
+	action.undo = withExpiry(removeRichRuleCmd(m.client, zone, rule, false, action, recordRedo, false), key, 0)
+	action.redo = withExpiry(addRichRuleTimedCmd(m.client, zone, rule, timeout, action, recordUndo, false), key, timeout)
+	return withExpiry(addRichRuleTimedCmd(m.client, zone, rule, timeout, action, recordUndo, true), key, timeout)
+}
@@ -0,0 +1,120 @@
+//go:build linux
+// +build linux
+
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimedInput(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantValue   string
+		wantTimeout time.Duration
+		wantErr     bool
+	}{
+		{name: "no timeout", input: "ssh", wantValue: "ssh"},
+		{name: "minutes", input: "ssh 30m", wantValue: "ssh", wantTimeout: 30 * time.Minute},
+		{name: "days", input: "8080/tcp 2d", wantValue: "8080/tcp", wantTimeout: 48 * time.Hour},
+		{name: "port with space protocol", input: "53 udp 1h30m", wantValue: "53 udp", wantTimeout: 90 * time.Minute},
+		{name: "port without unit", input: "53 udp", wantValue: "53 udp"},
+		{name: "rich rule", input: `rule family="ipv4" service name="ssh" accept 10m`, wantValue: `rule family="ipv4" service name="ssh" accept`, wantTimeout: 10 * time.Minute},
+		{name: "rich rule limit", input: `rule service name="ssh" accept limit value="1/m"`, wantValue: `rule service name="ssh" accept limit value="1/m"`},
+		{name: "zero", input: "ssh 0s", wantErr: true},
+		{name: "longer than firewalld allows", input: "ssh 25000d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, timeout, err := parseTimedInput(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimedInput(%q) error = %v, wantErr = %v", tt.input, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if value != tt.wantValue || timeout != tt.wantTimeout {
+				t.Fatalf("parseTimedInput(%q) = (%q, %v), want (%q, %v)", tt.input, value, timeout, tt.wantValue, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestFormatRemaining(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: 0, want: "0s"},
+		{in: 45 * time.Second, want: "45s"},
+		{in: 29*time.Minute + 59*time.Second, want: "29m59s"},
+		{in: 2*time.Hour + 5*time.Minute, want: "2h05m"},
+		{in: 50 * time.Hour, want: "2d02h"},
+	}
+	for _, tt := range tests {
+		if got := formatRemaining(tt.in); got != tt.want {
+			t.Fatalf("formatRemaining(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpiryTracking(t *testing.T) {
+	m := Model{zones: []string{"public"}}
+	key := expiryKey("public", expiryService, "ssh")
+
+	if cmd := m.setExpiry(key, 30*time.Minute); cmd == nil {
+		t.Fatalf("expected tick command for first expiry")
+	}
+	if cmd := m.setExpiry(expiryKey("public", expiryPort, "80/tcp"), time.Minute); cmd != nil {
+		t.Fatalf("expected no second tick command while ticking")
+	}
+	if label := m.expiryLabel(expiryService, "ssh"); label == "" {
+		t.Fatalf("expected expiry label for timed service")
+	}
+	if label := m.expiryLabel(expiryService, "http"); label != "" {
+		t.Fatalf("expiryLabel() = %q, want empty for untimed service", label)
+	}
+
+	expired := m.pruneExpiries(time.Now().Add(2 * time.Minute))
+	if _, ok := expired["public"]; !ok {
+		t.Fatalf("pruneExpiries() = %v, want public", expired)
+	}
+	if len(m.expiries) != 1 {
+		t.Fatalf("len(expiries) = %d, want 1", len(m.expiries))
+	}
+
+	m.setExpiry(key, 0)
+	if len(m.expiries) != 0 {
+		t.Fatalf("setExpiry(0) should forget the entry")
+	}
+}
+
+func TestWithExpiry(t *testing.T) {
+	cmd := withExpiry(mutationCmd("public", nil, recordNone, false, func() error { return nil }), "k", time.Minute)
+	msg, ok := cmd().(mutationMsg)
+	if !ok {
+		t.Fatalf("expected mutationMsg")
+	}
+	if msg.expiryKey != "k" || msg.expiry != time.Minute {
+		t.Fatalf("withExpiry() = (%q, %v), want (k, 1m)", msg.expiryKey, msg.expiry)
+	}
+}
+
+func TestExpiryRichRuleNormalized(t *testing.T) {
+	m := Model{zones: []string{"public"}}
+	typed := `rule family=ipv4 source address=10.0.0.0/8 service name=ssh accept`
+	listed := `rule family="ipv4" source address="10.0.0.0/8" service name="ssh" accept`
+
+	m.setExpiry(expiryKey("public", expiryRich, typed), 10*time.Minute)
+	if label := m.expiryLabel(expiryRich, listed); label == "" {
+		t.Fatalf("expected expiry label for the rule as firewalld lists it")
+	}
+
+	m.setExpiry(expiryKey("public", expiryRich, listed), 0)
+	if len(m.expiries) != 0 {
+		t.Fatalf("removing the listed rule should forget the typed rule's expiry")
+	}
+}
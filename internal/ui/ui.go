@@ -12,7 +12,10 @@ import (
 	"github.com/muesli/termenv"
 )
 
-func RunWithContext(ctx context.Context, client *firewalld.Client, opts Options) error {
+func RunWithContext(ctx context.Context, client firewalld.Backend, opts Options) error {
+	if opts.Theme.Name != "" {
+		applyTheme(opts.Theme)
+	}
 	if opts.NoColor {
 		lipgloss.SetColorProfile(termenv.Ascii)
 	}
@@ -20,6 +23,7 @@ func RunWithContext(ctx context.Context, client *firewalld.Client, opts Options)
 	program := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx))
 	m, err := program.Run()
 	if finalModel, ok := m.(Model); ok {
+		finalModel.revertPendingSafety()
 		if finalModel.logCancel != nil {
 			finalModel.logCancel()
 		}
@@ -30,6 +34,6 @@ func RunWithContext(ctx context.Context, client *firewalld.Client, opts Options)
 	return err
 }
 
-func Run(client *firewalld.Client, opts Options) error {
+func Run(client firewalld.Backend, opts Options) error {
 	return RunWithContext(context.Background(), client, opts)
 }
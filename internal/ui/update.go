@@ -6,14 +6,26 @@ package ui
 import tea "github.com/charmbracelet/bubbletea"
 
 func (m Model) Init() tea.Cmd {
+	var signals, poll tea.Cmd
+	if m.offlineRoot == "" {
+		signals = subscribeSignalsCmd(m.client)
+	}
+	if m.polling {
+		poll = autoRefreshCmd(m.pollInterval)
+	}
 	return tea.Batch(
 		m.spinner.Tick,
 		fetchZonesCmd(m.client),
 		fetchDefaultZoneCmd(m.client),
 		fetchActiveZonesCmd(m.client),
 		fetchPanicModeCmd(m.client),
+		fetchLockdownCmd(m.client),
+		fetchSettingsCmd(m.client),
 		fetchIPSetsCmd(m.client, m.permanent),
+		fetchPoliciesCmd(m.client, m.permanent),
+		fetchDirectCmd(m.client),
 		fetchServiceCatalogCmd(m.client),
-		subscribeSignalsCmd(m.client),
+		signals,
+		poll,
 	)
 }
@@ -5,14 +5,15 @@ package ui
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 
 	"lazyfirewall/internal/backup"
 	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/richrule"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -21,7 +22,7 @@ func (m *Model) startZoneLoad(zone string, reset bool) tea.Cmd {
 	m.loading = true
 	m.pendingZone = zone
 	m.ipsetLoading = true
-	if m.logMode {
+	if m.logMode && (reset || m.logZone != zone) {
 		m.logZone = zone
 		m.clearLogLines()
 		m.logErr = nil
@@ -113,6 +114,16 @@ func (m *Model) fetchCurrentIPSetEntries() tea.Cmd {
 	return fetchIPSetEntriesCmd(m.client, name, m.permanent)
 }
 
+func (m *Model) fetchTabData() tea.Cmd {
+	switch m.tab {
+	case tabIPSets:
+		return m.fetchCurrentIPSetEntries()
+	case tabPolicies:
+		return m.fetchCurrentPolicy()
+	}
+	return nil
+}
+
 func (m *Model) currentService() string {
 	current := m.currentData()
 	if current == nil || len(current.Services) == 0 {
@@ -124,40 +135,89 @@ func (m *Model) currentService() string {
 	return current.Services[m.serviceIndex]
 }
 
+// parsePortInput accepts "80/tcp" or "80 tcp"; the port may be a range
+// such as "6000-6010/udp".
 func parsePortInput(value string) (firewalld.Port, error) {
 	input := strings.TrimSpace(value)
 	if input == "" {
 		return firewalld.Port{}, fmt.Errorf("port input is empty")
 	}
-
-	var portStr string
-	var proto string
-	if strings.Contains(input, "/") {
-		parts := strings.SplitN(input, "/", 2)
-		portStr = strings.TrimSpace(parts[0])
-		proto = strings.TrimSpace(parts[1])
-	} else {
+	if !strings.Contains(input, "/") {
 		fields := strings.Fields(input)
 		if len(fields) != 2 {
 			return firewalld.Port{}, fmt.Errorf("use format port/proto or \"port proto\"")
 		}
-		portStr = fields[0]
-		proto = fields[1]
+		input = fields[0] + "/" + fields[1]
 	}
+	return firewalld.ParsePort(input)
+}
 
-	portNum, err := strconv.Atoi(portStr)
-	if err != nil || portNum < 1 || portNum > 65535 {
-		return firewalld.Port{}, fmt.Errorf("invalid port: %s", portStr)
+// parseForwardPortInput accepts firewall-cmd syntax
+// ("port=80:proto=tcp:toport=8080:toaddr=10.0.0.5") or the shorthand
+// "80/tcp 8080 [10.0.0.5]".
+func parseForwardPortInput(value string) (firewalld.ForwardPort, error) {
+	input := strings.TrimSpace(value)
+	if input == "" {
+		return firewalld.ForwardPort{}, fmt.Errorf("forward port input is empty")
 	}
 
-	proto = strings.ToLower(proto)
-	switch proto {
-	case "tcp", "udp", "sctp", "dccp":
-	default:
-		return firewalld.Port{}, fmt.Errorf("invalid protocol: %s", proto)
+	var fp firewalld.ForwardPort
+	if strings.Contains(input, "=") {
+		// toaddr comes last and may be an IPv6 address containing ':'.
+		if head, addr, ok := strings.Cut(input, "toaddr="); ok {
+			fp.ToAddr = strings.TrimSpace(addr)
+			input = strings.TrimSuffix(head, ":")
+		}
+		for _, part := range strings.Split(input, ":") {
+			key, val, ok := strings.Cut(part, "=")
+			if !ok {
+				return firewalld.ForwardPort{}, fmt.Errorf("invalid forward port option: %s", part)
+			}
+			val = strings.TrimSpace(val)
+			switch strings.TrimSpace(key) {
+			case "port":
+				fp.Port = val
+			case "proto":
+				fp.Protocol = strings.ToLower(val)
+			case "toport":
+				fp.ToPort = val
+			default:
+				return firewalld.ForwardPort{}, fmt.Errorf("unknown forward port option: %s", key)
+			}
+		}
+	} else {
+		fields := strings.Fields(input)
+		if len(fields) < 2 || len(fields) > 3 {
+			return firewalld.ForwardPort{}, fmt.Errorf("use format port/proto toport [toaddr] or port=..:proto=..:toport=..:toaddr=..")
+		}
+		port, proto, ok := strings.Cut(fields[0], "/")
+		if !ok {
+			return firewalld.ForwardPort{}, fmt.Errorf("use format port/proto toport [toaddr]")
+		}
+		fp.Port = port
+		fp.Protocol = strings.ToLower(proto)
+		fp.ToPort = fields[1]
+		if len(fields) == 3 {
+			fp.ToAddr = fields[2]
+		}
 	}
 
-	return firewalld.Port{Port: portStr, Protocol: proto}, nil
+	if !firewalld.ValidPortRange(fp.Port) {
+		return firewalld.ForwardPort{}, fmt.Errorf("invalid port: %s", fp.Port)
+	}
+	if !firewalld.ValidPortProtocol(fp.Protocol) {
+		return firewalld.ForwardPort{}, fmt.Errorf("invalid protocol: %s", fp.Protocol)
+	}
+	if fp.ToPort == "" && fp.ToAddr == "" {
+		return firewalld.ForwardPort{}, fmt.Errorf("forward port needs toport and/or toaddr")
+	}
+	if fp.ToPort != "" && !firewalld.ValidPortRange(fp.ToPort) {
+		return firewalld.ForwardPort{}, fmt.Errorf("invalid toport: %s", fp.ToPort)
+	}
+	if fp.ToAddr != "" && net.ParseIP(fp.ToAddr) == nil {
+		return firewalld.ForwardPort{}, fmt.Errorf("invalid toaddr: %s", fp.ToAddr)
+	}
+	return fp, nil
 }
 
 func parseIPSetInput(value string) (string, string, error) {
@@ -176,15 +236,11 @@ func parseIPSetInput(value string) (string, string, error) {
 	return name, ipsetType, nil
 }
 
+// validateRichRule parses value with the rich rule grammar; errors point at
+// the offending column.
 func validateRichRule(value string) error {
-	trimmed := strings.TrimSpace(value)
-	if trimmed == "" {
-		return fmt.Errorf("rich rule is empty")
-	}
-	if !strings.HasPrefix(trimmed, "rule") {
-		return fmt.Errorf("rich rule must start with 'rule'")
-	}
-	return nil
+	_, err := richrule.Parse(strings.TrimSpace(value))
+	return err
 }
 
 func logMatchesZone(line, zone string) bool {
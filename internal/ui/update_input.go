@@ -10,8 +10,10 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/lockout"
 	"lazyfirewall/internal/validation"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -23,14 +25,19 @@ func (m *Model) startAddInput() tea.Cmd {
 		return nil
 	}
 	m.err = nil
-	if m.tab == tabNetwork || m.tab == tabInfo {
-		if m.tab == tabNetwork {
-			m.err = fmt.Errorf("use i/s/m in Network tab")
-		} else {
-			m.err = fmt.Errorf("editing not implemented for this tab")
-		}
+	if m.tab == tabPolicies {
+		return m.startEditPolicy("")
+	}
+	if m.tab == tabDirect {
+		return m.startEditDirect("")
+	}
+	if m.tab == tabNetwork {
+		m.err = fmt.Errorf("use i/s/f/m in Network tab")
 		return nil
 	}
+	if m.tab == tabInfo {
+		return m.startIcmpPicker()
+	}
 	if m.tab == tabIPSets {
 		if m.currentIPSetName() == "" {
 			m.err = fmt.Errorf("no ipset selected (press n to create)")
@@ -41,10 +48,10 @@ func (m *Model) startAddInput() tea.Cmd {
 	m.editRichOld = ""
 	switch m.tab {
 	case tabServices:
-		m.input.Placeholder = "service name"
+		m.input.Placeholder = "service name [30m]"
 		m.inputMode = inputAddService
 	case tabPorts:
-		m.input.Placeholder = "port/proto (e.g. 80/tcp)"
+		m.input.Placeholder = "port/proto [30m] (e.g. 80/tcp, 6000-6010/udp)"
 		m.inputMode = inputAddPort
 	case tabRich:
 		m.input.Placeholder = "rich rule"
@@ -95,6 +102,10 @@ func (m *Model) startManualBackup() tea.Cmd {
 		m.err = fmt.Errorf("no zone selected")
 		return nil
 	}
+	if m.offlineRoot != "" {
+		m.err = errOfflineBackup
+		return nil
+	}
 	m.err = nil
 	m.input.SetValue("")
 	m.input.Placeholder = "backup description (optional)"
@@ -197,20 +208,34 @@ func (m *Model) startAddSource() tea.Cmd {
 	return nil
 }
 
-func (m *Model) startEditRich() tea.Cmd {
+func (m *Model) startAddForwardPort() tea.Cmd {
 	if m.readOnly {
 		m.err = firewalld.ErrPermissionDenied
 		return nil
 	}
-	current := m.currentData()
-	if current == nil || len(current.RichRules) == 0 {
+	if m.tab != tabNetwork {
+		return nil
+	}
+	m.err = nil
+	m.input.SetValue("")
+	m.input.Placeholder = "port/proto toport [toaddr] (e.g. 80/tcp 8080 10.0.0.5)"
+	m.inputMode = inputAddForwardPort
+	m.input.CursorEnd()
+	m.input.Focus()
+	return nil
+}
+
+func (m *Model) startEditRich() tea.Cmd {
+	if m.readOnly {
+		m.err = firewalld.ErrPermissionDenied
 		return nil
 	}
-	if m.richIndex < 0 || m.richIndex >= len(current.RichRules) {
+	e, ok := m.selectedRichRule()
+	if !ok {
 		return nil
 	}
 	m.err = nil
-	m.editRichOld = current.RichRules[m.richIndex]
+	m.editRichOld = e.rule
 	m.input.Placeholder = "rich rule"
 	m.input.SetValue(m.editRichOld)
 	m.inputMode = inputEditRich
@@ -230,6 +255,23 @@ func (m *Model) submitInput() tea.Cmd {
 		return nil
 	}
 
+	if m.inputMode == inputLockoutConfirm {
+		if !strings.EqualFold(value, "YES") {
+			m.err = fmt.Errorf("type YES to apply anyway")
+			return nil
+		}
+		proceed := m.pendingLockout
+		m.pendingLockout = nil
+		m.lockoutReason = ""
+		m.inputMode = inputNone
+		m.input.Blur()
+		m.err = nil
+		if proceed == nil {
+			return nil
+		}
+		return proceed(m)
+	}
+
 	if m.inputMode == inputPanicConfirm {
 		if m.panicCountdown > 0 {
 			m.err = fmt.Errorf("wait %ds then press Enter", m.panicCountdown)
@@ -250,6 +292,46 @@ func (m *Model) submitInput() tea.Cmd {
 		return enablePanicModeCmd(m.client)
 	}
 
+	switch m.inputMode {
+	case inputAddPolicy, inputEditPolicy, inputDeletePolicy:
+		return m.submitPolicyInput(value)
+	}
+
+	if m.inputMode == inputLockdownConfirm {
+		return m.submitLockdownConfirm(value)
+	}
+
+	if m.inputMode == inputSetting {
+		return m.submitSettingInput(value)
+	}
+	if m.inputMode == inputAddWhitelist {
+		return m.submitWhitelistInput(value)
+	}
+
+	if m.inputMode == inputEditDirect {
+		return m.submitDirectInput(value)
+	}
+
+	if m.inputMode == inputAddHelper || m.inputMode == inputDeleteHelper {
+		return m.submitHelperInput(value)
+	}
+
+	if m.inputMode == inputAddIcmpType || m.inputMode == inputDeleteIcmpType {
+		return m.submitIcmpTypeInput(value)
+	}
+
+	if m.inputMode == inputDeleteService {
+		return m.submitDeleteService(value)
+	}
+
+	if m.inputMode == inputSetTarget {
+		return m.submitSetTarget(value)
+	}
+
+	if m.inputMode == inputTemplateParam {
+		return m.submitTemplateParam(value)
+	}
+
 	if m.inputMode == inputExportZone {
 		current := m.currentData()
 		if current == nil {
@@ -277,7 +359,7 @@ func (m *Model) submitInput() tea.Cmd {
 			m.setDryRunNotice(fmt.Sprintf("import zone from %s into %s", value, zone))
 			return nil
 		}
-		return m.maybeBackup(zone, true, importZoneCmd(m.client, zone, value))
+		return m.safeMutation(zone, "import zone "+zone, true, false, importZoneCmd(m.client, zone, value))
 	}
 
 	if m.inputMode == inputAddZone {
@@ -324,11 +406,13 @@ func (m *Model) submitInput() tea.Cmd {
 			m.setDryRunNotice(fmt.Sprintf("delete zone %s", zone))
 			return nil
 		}
-		m.loading = true
-		m.err = nil
-		m.runtimeInvalid = false
-		m.pendingZone = ""
-		return m.maybeBackup(zone, true, removeZoneCmd(m.client, zone))
+		return m.guardLockout(lockout.Change{Kind: lockout.RemoveZone, Zone: zone}, func(m *Model) tea.Cmd {
+			m.loading = true
+			m.err = nil
+			m.runtimeInvalid = false
+			m.pendingZone = ""
+			return m.safeMutation(zone, "delete zone "+zone, true, false, removeZoneCmd(m.client, zone))
+		})
 	}
 
 	if m.inputMode == inputManualBackup {
@@ -438,6 +522,20 @@ func (m *Model) submitInput() tea.Cmd {
 	}
 
 	zone := m.zones[m.selected]
+	var timeout time.Duration
+	switch m.inputMode {
+	case inputAddService, inputAddPort, inputAddRich:
+		var err error
+		value, timeout, err = parseTimedInput(value)
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		if timeout > 0 && m.permanent {
+			m.err = fmt.Errorf("timeouts apply to runtime only (press P)")
+			return nil
+		}
+	}
 	switch m.tab {
 	case tabServices:
 		m.inputMode = inputNone
@@ -447,10 +545,13 @@ func (m *Model) submitInput() tea.Cmd {
 			return nil
 		}
 		if m.dryRun {
-			m.setDryRunNotice(fmt.Sprintf("add service %s to zone %s (%s)", value, zone, modeLabel(m.permanent)))
+			m.setDryRunNotice(fmt.Sprintf("add service %s to zone %s (%s)", value, zone, timedModeLabel(m.permanent, timeout)))
 			return nil
 		}
-		return m.maybeBackup(zone, true, m.actionAddService(zone, value, m.permanent))
+		if timeout > 0 {
+			return m.safeMutation(zone, "add service "+value, false, false, m.actionAddServiceTimed(zone, value, timeout))
+		}
+		return m.safeMutation(zone, "add service "+value, m.permanent, false, m.actionAddService(zone, value, m.permanent))
 	case tabPorts:
 		port, err := parsePortInput(value)
 		if err != nil {
@@ -461,10 +562,13 @@ func (m *Model) submitInput() tea.Cmd {
 		m.input.Blur()
 		if m.dryRun {
 			label := port.Port + "/" + port.Protocol
-			m.setDryRunNotice(fmt.Sprintf("add port %s to zone %s (%s)", label, zone, modeLabel(m.permanent)))
+			m.setDryRunNotice(fmt.Sprintf("add port %s to zone %s (%s)", label, zone, timedModeLabel(m.permanent, timeout)))
 			return nil
 		}
-		return m.maybeBackup(zone, true, m.actionAddPort(zone, port, m.permanent))
+		if timeout > 0 {
+			return m.safeMutation(zone, "add port "+port.Port+"/"+port.Protocol, false, false, m.actionAddPortTimed(zone, port, timeout))
+		}
+		return m.safeMutation(zone, "add port "+port.Port+"/"+port.Protocol, m.permanent, false, m.actionAddPort(zone, port, m.permanent))
 	case tabRich:
 		switch m.inputMode {
 		case inputAddRich:
@@ -475,10 +579,13 @@ func (m *Model) submitInput() tea.Cmd {
 				return nil
 			}
 			if m.dryRun {
-				m.setDryRunNotice(fmt.Sprintf("add rich rule to zone %s (%s)", zone, modeLabel(m.permanent)))
+				m.setDryRunNotice(fmt.Sprintf("add rich rule to zone %s (%s)", zone, timedModeLabel(m.permanent, timeout)))
 				return nil
 			}
-			return m.maybeBackup(zone, true, m.actionAddRichRule(zone, value, m.permanent))
+			if timeout > 0 {
+				return m.safeMutation(zone, "add rich rule", false, false, m.actionAddRichRuleTimed(zone, value, timeout))
+			}
+			return m.safeMutation(zone, "add rich rule", m.permanent, false, m.actionAddRichRule(zone, value, m.permanent))
 		case inputEditRich:
 			oldRule := m.editRichOld
 			m.editRichOld = ""
@@ -495,7 +602,7 @@ func (m *Model) submitInput() tea.Cmd {
 				m.setDryRunNotice(fmt.Sprintf("edit rich rule in zone %s (%s)", zone, modeLabel(m.permanent)))
 				return nil
 			}
-			return m.maybeBackup(zone, true, m.actionEditRichRule(zone, oldRule, value, m.permanent))
+			return m.guardEditRichRule(zone, "edit rich rule", oldRule, value)
 		}
 		return nil
 	case tabNetwork:
@@ -507,7 +614,7 @@ func (m *Model) submitInput() tea.Cmd {
 				m.setDryRunNotice(fmt.Sprintf("add interface %s to zone %s (%s)", value, zone, modeLabel(m.permanent)))
 				return nil
 			}
-			return m.maybeBackup(zone, true, m.actionAddInterface(zone, value, m.permanent))
+			return m.safeMutation(zone, "add interface "+value, m.permanent, m.ssh.Binds(value), m.actionAddInterface(zone, value, m.permanent))
 		case inputAddSource:
 			if net.ParseIP(value) == nil {
 				if _, _, err := net.ParseCIDR(value); err != nil {
@@ -521,7 +628,20 @@ func (m *Model) submitInput() tea.Cmd {
 				m.setDryRunNotice(fmt.Sprintf("add source %s to zone %s (%s)", value, zone, modeLabel(m.permanent)))
 				return nil
 			}
-			return m.maybeBackup(zone, true, m.actionAddSource(zone, value, m.permanent))
+			return m.safeMutation(zone, "add source "+value, m.permanent, m.ssh.Binds(value), m.actionAddSource(zone, value, m.permanent))
+		case inputAddForwardPort:
+			fp, err := parseForwardPortInput(value)
+			if err != nil {
+				m.err = err
+				return nil
+			}
+			m.inputMode = inputNone
+			m.input.Blur()
+			if m.dryRun {
+				m.setDryRunNotice(fmt.Sprintf("add forward port %s to zone %s (%s)", firewalld.FormatForwardPort(fp), zone, modeLabel(m.permanent)))
+				return nil
+			}
+			return m.safeMutation(zone, "add forward port "+firewalld.FormatForwardPort(fp), m.permanent, false, m.actionAddForwardPort(zone, fp, m.permanent))
 		}
 		return nil
 	default:
@@ -7,15 +7,23 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/keymap"
+	"lazyfirewall/internal/offline"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if next, cmd, handled := m.handleSafetyMode(msg); handled {
+		return next, cmd
+	}
+
 	if next, cmd, handled := m.handleHelpMode(msg); handled {
 		return next, cmd
 	}
@@ -24,6 +32,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return next, cmd
 	}
 
+	if next, cmd, handled := m.handleIcmpPickerMode(msg); handled {
+		return next, cmd
+	}
+
+	if next, cmd, handled := m.handleServiceEditorMode(msg); handled {
+		return next, cmd
+	}
+
+	if next, cmd, handled := m.handleRuleBuilderMode(msg); handled {
+		return next, cmd
+	}
+
 	if next, cmd, handled := m.handleBackupMode(msg); handled {
 		return next, cmd
 	}
@@ -32,6 +52,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return next, cmd
 	}
 
+	if next, cmd, handled := m.handleLockdownMode(msg); handled {
+		return next, cmd
+	}
+
+	if next, cmd, handled := m.handleSettingsMode(msg); handled {
+		return next, cmd
+	}
+
+	if next, cmd, handled := m.handleHelperMode(msg); handled {
+		return next, cmd
+	}
+
+	if next, cmd, handled := m.handleIcmpBrowserMode(msg); handled {
+		return next, cmd
+	}
+
 	if next, cmd, handled := m.handleDetailsMode(msg); handled {
 		return next, cmd
 	}
@@ -43,57 +79,81 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "q":
+		case "ctrl+c":
+			return m, tea.Quit
+		case "enter":
+			if m.focus == focusMain && m.tab == tabServices {
+				service := m.currentService()
+				if service == "" {
+					return m, nil
+				}
+				if m.detailsMode && m.detailsName == service {
+					m.detailsMode = false
+					return m, nil
+				}
+				m.detailsMode = true
+				m.detailsLoading = true
+				m.detailsErr = nil
+				m.detailsName = service
+				return m, fetchServiceDetailsCmd(m.client, service)
+			}
+			return m, nil
+		}
+		action := m.keys.Action(msg.String())
+		switch action {
+		case keymap.Quit:
 			return m, tea.Quit
-		case "tab":
+		case keymap.Focus:
 			if m.focus == focusZones {
 				m.focus = focusMain
 			} else {
 				m.focus = focusZones
 			}
 			return m, nil
-		case "1":
+		case keymap.TabServices:
 			m.detailsMode = false
 			m.tab = tabServices
 			return m, nil
-		case "2":
+		case keymap.TabPorts:
 			m.detailsMode = false
 			m.tab = tabPorts
 			return m, nil
-		case "3":
+		case keymap.TabRich:
 			m.detailsMode = false
 			m.tab = tabRich
 			return m, nil
-		case "4":
+		case keymap.TabNetwork:
 			m.detailsMode = false
 			m.tab = tabNetwork
 			return m, nil
-		case "5":
+		case keymap.TabIPSets:
 			m.detailsMode = false
 			m.tab = tabIPSets
 			if m.focus == focusMain {
 				return m, m.fetchCurrentIPSetEntries()
 			}
 			return m, nil
-		case "6":
+		case keymap.TabInfo:
 			m.detailsMode = false
 			m.tab = tabInfo
 			return m, nil
-		case "h", "left":
+		case keymap.TabPolicies:
 			m.detailsMode = false
-			m.prevTab()
-			if m.tab == tabIPSets {
-				return m, m.fetchCurrentIPSetEntries()
-			}
+			m.tab = tabPolicies
+			return m, m.fetchCurrentPolicy()
+		case keymap.TabDirect:
+			m.detailsMode = false
+			m.tab = tabDirect
 			return m, nil
-		case "l", "right":
+		case keymap.PrevTab:
+			m.detailsMode = false
+			m.prevTab()
+			return m, m.fetchTabData()
+		case keymap.NextTab:
 			m.detailsMode = false
 			m.nextTab()
-			if m.tab == tabIPSets {
-				return m, m.fetchCurrentIPSetEntries()
-			}
-			return m, nil
-		case "S":
+			return m, m.fetchTabData()
+		case keymap.SplitView:
 			if m.logMode {
 				m.err = fmt.Errorf("split view not available in logs")
 				return m, nil
@@ -102,11 +162,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.err = fmt.Errorf("split view not available for IPSets")
 				return m, nil
 			}
+			if m.tab == tabPolicies {
+				m.err = fmt.Errorf("split view not available for Policies")
+				return m, nil
+			}
 			m.splitView = !m.splitView
 			return m, nil
-		case "L":
+		case keymap.Logs:
 			return m, m.toggleLogs()
-		case "?":
+		case keymap.Help:
 			m.helpMode = !m.helpMode
 			if m.helpMode {
 				m.templateMode = false
@@ -115,15 +179,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.input.Blur()
 			}
 			return m, nil
-		case "t":
+		case keymap.Templates:
 			if m.readOnly {
 				m.err = firewalld.ErrPermissionDenied
 				return m, nil
 			}
 			m.templateMode = true
 			m.templateIndex = 0
+			m.templatePlan = nil
 			return m, nil
-		case "ctrl+e":
+		case keymap.Export:
 			if len(m.zones) == 0 || m.selected >= len(m.zones) {
 				return m, nil
 			}
@@ -141,11 +206,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.input.CursorEnd()
 			m.input.Focus()
 			return m, nil
-		case "alt+i", "alt+I":
+		case keymap.Import:
 			if m.readOnly {
 				m.err = firewalld.ErrPermissionDenied
 				return m, nil
 			}
+			if m.offlineRoot != "" {
+				m.err = errOfflineBackup
+				return m, nil
+			}
 			if len(m.zones) == 0 || m.selected >= len(m.zones) {
 				return m, nil
 			}
@@ -157,10 +226,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.input.CursorEnd()
 			m.input.Focus()
 			return m, nil
-		case "ctrl+r":
+		case keymap.Restore:
 			if len(m.zones) == 0 || m.selected >= len(m.zones) {
 				return m, nil
 			}
+			if m.offlineRoot != "" {
+				m.err = errOfflineBackup
+				return m, nil
+			}
 			m.err = nil
 			m.notice = ""
 			m.backupMode = true
@@ -169,7 +242,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.backupErr = nil
 			zone := m.zones[m.selected]
 			return m, fetchBackupsCmd(zone)
-		case "alt+p", "alt+P":
+		case keymap.Panic:
 			if m.readOnly {
 				m.err = firewalld.ErrPermissionDenied
 				return m, nil
@@ -194,7 +267,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.input.Focus()
 			m.panicCountdown = 5
 			return m, panicTickCmd()
-		case "/":
+		case keymap.Lockdown:
+			return m, m.startLockdownScreen()
+		case keymap.Settings:
+			return m, m.startSettingsScreen()
+		case keymap.Search:
 			if m.splitView {
 				m.err = fmt.Errorf("search disabled in split view")
 				return m, nil
@@ -206,7 +283,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.input.CursorEnd()
 			m.input.Focus()
 			return m, nil
-		case "n":
+		case keymap.NewOrNext:
 			if m.focus == focusZones {
 				if m.readOnly {
 					m.err = firewalld.ErrPermissionDenied
@@ -217,22 +294,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.focus == focusMain && m.tab == tabIPSets && m.searchQuery == "" {
 				return m, m.startAddIPSet()
 			}
+			if m.focus == focusMain && m.tab == tabPolicies && m.searchQuery == "" {
+				return m, m.startAddPolicy()
+			}
 			if m.searchQuery != "" && !m.splitView && m.focus == focusMain {
 				m.moveMatchSelection(true)
-				if m.tab == tabIPSets {
-					return m, m.fetchCurrentIPSetEntries()
-				}
+				return m, m.fetchTabData()
 			}
 			return m, nil
-		case "N":
+		case keymap.PrevMatch:
 			if m.searchQuery != "" && !m.splitView && m.focus == focusMain {
 				m.moveMatchSelection(false)
-				if m.tab == tabIPSets {
-					return m, m.fetchCurrentIPSetEntries()
-				}
+				return m, m.fetchTabData()
 			}
 			return m, nil
-		case "r":
+		case keymap.Refresh:
 			m.loading = true
 			m.err = nil
 			m.notice = ""
@@ -243,10 +319,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.permanentData = nil
 			m.editRichOld = ""
 			m.ipsetLoading = true
-			return m, tea.Batch(fetchZonesCmd(m.client), fetchDefaultZoneCmd(m.client), fetchActiveZonesCmd(m.client), fetchPanicModeCmd(m.client), fetchIPSetsCmd(m.client, m.permanent))
-		case "ctrl+b":
+			m.policiesLoading = true
+			m.directLoading = true
+			return m, tea.Batch(fetchZonesCmd(m.client), fetchDefaultZoneCmd(m.client), fetchActiveZonesCmd(m.client), fetchPanicModeCmd(m.client), fetchLockdownCmd(m.client), fetchSettingsCmd(m.client), fetchIPSetsCmd(m.client, m.permanent), fetchPoliciesCmd(m.client, m.permanent), fetchDirectCmd(m.client))
+		case keymap.Backup:
 			return m, m.startManualBackup()
-		case "c":
+		case keymap.Commit:
 			if m.readOnly {
 				m.err = firewalld.ErrPermissionDenied
 				return m, nil
@@ -264,7 +342,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.notice = ""
 			m.pendingZone = zone
 			return m, m.maybeBackup(zone, true, commitRuntimeCmd(m.client, zone, nil, recordNone, false))
-		case "u":
+		case keymap.Reload:
 			if m.readOnly {
 				m.err = firewalld.ErrPermissionDenied
 				return m, nil
@@ -282,7 +360,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.notice = ""
 			m.pendingZone = zone
 			return m, reloadCmd(m.client, zone, nil, recordNone, false)
-		case "ctrl+z":
+		case keymap.Undo:
 			if m.readOnly {
 				m.err = firewalld.ErrPermissionDenied
 				return m, nil
@@ -298,7 +376,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.notice = ""
 			m.pendingZone = action.zone
 			return m, action.undo
-		case "ctrl+y":
+		case keymap.Redo:
 			if m.readOnly {
 				m.err = firewalld.ErrPermissionDenied
 				return m, nil
@@ -314,15 +392,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.notice = ""
 			m.pendingZone = action.zone
 			return m, action.redo
-		case "P":
+		case keymap.TogglePermanent:
+			if m.offlineRoot != "" {
+				m.err = offline.ErrRuntime
+				return m, nil
+			}
 			m.permanent = !m.permanent
+			m.policiesLoading = true
 			if len(m.zones) > 0 && m.selected < len(m.zones) {
 				m.err = nil
-				return m, m.startZoneLoad(m.zones[m.selected], false)
+				return m, tea.Batch(m.startZoneLoad(m.zones[m.selected], false), fetchPoliciesCmd(m.client, m.permanent))
 			}
 			m.ipsetLoading = true
-			return m, fetchIPSetsCmd(m.client, m.permanent)
-		case "j", "down":
+			return m, tea.Batch(fetchIPSetsCmd(m.client, m.permanent), fetchPoliciesCmd(m.client, m.permanent))
+		case keymap.Down:
 			if m.focus == focusZones {
 				if len(m.zones) > 0 && m.selected < len(m.zones)-1 {
 					m.selected++
@@ -332,11 +415,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			m.moveMainSelection(1)
-			if m.tab == tabIPSets {
-				return m, m.fetchCurrentIPSetEntries()
-			}
-			return m, nil
-		case "k", "up":
+			return m, m.fetchTabData()
+		case keymap.Up:
 			if m.focus == focusZones {
 				if len(m.zones) > 0 && m.selected > 0 {
 					m.selected--
@@ -346,28 +426,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			m.moveMainSelection(-1)
-			if m.tab == tabIPSets {
-				return m, m.fetchCurrentIPSetEntries()
-			}
-			return m, nil
-		case "enter":
-			if m.focus == focusMain && m.tab == tabServices {
-				service := m.currentService()
-				if service == "" {
-					return m, nil
-				}
-				if m.detailsMode && m.detailsName == service {
-					m.detailsMode = false
-					return m, nil
-				}
-				m.detailsMode = true
-				m.detailsLoading = true
-				m.detailsErr = nil
-				m.detailsName = service
-				return m, fetchServiceDetailsCmd(m.client, service)
-			}
-			return m, nil
-		case "a":
+			return m, m.fetchTabData()
+		case keymap.Add:
 			if m.focus == focusMain {
 				if m.readOnly {
 					m.err = firewalld.ErrPermissionDenied
@@ -376,7 +436,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.startAddInput()
 			}
 			return m, nil
-		case "i":
+		case keymap.AddInterface:
 			if m.focus == focusMain && m.tab == tabNetwork {
 				if m.readOnly {
 					m.err = firewalld.ErrPermissionDenied
@@ -385,7 +445,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.startAddInterface()
 			}
 			return m, nil
-		case "s":
+		case keymap.AddSource:
 			if m.focus == focusMain && m.tab == tabNetwork {
 				if m.readOnly {
 					m.err = firewalld.ErrPermissionDenied
@@ -394,7 +454,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.startAddSource()
 			}
 			return m, nil
-		case "m":
+		case keymap.AddForwardPort:
+			if m.focus == focusMain && m.tab == tabNetwork {
+				if m.readOnly {
+					m.err = firewalld.ErrPermissionDenied
+					return m, nil
+				}
+				return m, m.startAddForwardPort()
+			}
+			return m, nil
+		case keymap.Masquerade:
 			if m.focus == focusMain && m.tab == tabNetwork {
 				if m.readOnly {
 					m.err = firewalld.ErrPermissionDenied
@@ -403,7 +472,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.toggleMasquerade()
 			}
 			return m, nil
-		case "e":
+		case keymap.IcmpInversion:
+			if m.focus == focusMain && m.tab == tabInfo {
+				if m.readOnly {
+					m.err = firewalld.ErrPermissionDenied
+					return m, nil
+				}
+				return m, m.toggleIcmpInversion()
+			}
+			return m, nil
+		case keymap.RuleBuilder, keymap.RuleBuilderEdit:
+			if m.focus == focusMain && m.tab == tabRich {
+				return m, m.startRuleBuilder(action == keymap.RuleBuilderEdit)
+			}
+			return m, nil
+		case keymap.RuleUp, keymap.RuleDown:
+			if m.focus == focusMain && m.tab == tabRich {
+				delta := -1
+				if action == keymap.RuleDown {
+					delta = 1
+				}
+				return m, m.moveRichRule(delta)
+			}
+			return m, nil
+		case keymap.IcmpTypes:
+			if m.focus == focusMain && m.tab == tabInfo {
+				return m, m.startIcmpBrowser()
+			}
+			return m, nil
+		case keymap.Edit:
 			if m.focus == focusMain && m.tab == tabRich {
 				if m.readOnly {
 					m.err = firewalld.ErrPermissionDenied
@@ -411,8 +508,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return m, m.startEditRich()
 			}
+			if m.focus == focusMain && m.tab == tabInfo {
+				return m, m.startEditTarget()
+			}
+			if m.focus == focusMain && m.tab == tabPolicies {
+				return m, m.startEditPolicy("")
+			}
 			return m, nil
-		case "d":
+		case keymap.Remove:
 			if m.focus == focusZones {
 				if m.readOnly {
 					m.err = firewalld.ErrPermissionDenied
@@ -428,7 +531,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.removeSelected()
 			}
 			return m, nil
-		case "D":
+		case keymap.DefaultOrDelete:
 			if m.focus == focusZones {
 				if m.readOnly {
 					m.err = firewalld.ErrPermissionDenied
@@ -443,11 +546,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 				m.err = nil
-				return m, setDefaultZoneCmd(m.client, zone)
+				return m, m.guardSetDefaultZone(zone)
 			}
 			if m.focus == focusMain && m.tab == tabIPSets {
 				return m, m.startDeleteIPSet()
 			}
+			if m.focus == focusMain && m.tab == tabPolicies {
+				return m, m.startDeletePolicy()
+			}
 			return m, nil
 		}
 	case zonesMsg:
@@ -478,6 +584,37 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 		}
 		return m, tea.Batch(cmds...)
+	case icmpTypesMsg:
+		m.icmpTypesLoading = false
+		m.icmpTypesErr = msg.err
+		if msg.err == nil {
+			m.icmpTypes = msg.types
+		}
+		if idx := slices.Index(m.icmpTypes, m.pendingIcmpType); idx >= 0 {
+			m.icmpBrowserIndex = idx
+		} else if m.icmpBrowserIndex >= len(m.icmpTypes) {
+			m.icmpBrowserIndex = max(len(m.icmpTypes)-1, 0)
+		}
+		m.pendingIcmpType = ""
+		if m.icmpBrowserMode {
+			return m, m.fetchSelectedIcmpTypeInfo()
+		}
+		return m, nil
+	case icmpTypeInfoMsg:
+		if msg.err != nil {
+			if msg.name == m.selectedIcmpBrowserType() {
+				m.icmpTypeInfoErr = msg.err
+			}
+			return m, nil
+		}
+		if m.icmpTypeInfo == nil {
+			m.icmpTypeInfo = make(map[string]*firewalld.IcmpType)
+		}
+		m.icmpTypeInfo[msg.name] = msg.info
+		return m, nil
+	case icmpTypeSavedMsg:
+		next, cmd := m.handleIcmpTypeSaved(msg)
+		return next, cmd
 	case activeZonesMsg:
 		if msg.err != nil {
 			if errors.Is(msg.err, firewalld.ErrPermissionDenied) || errors.Is(msg.err, firewalld.ErrUnsupportedAPI) {
@@ -490,16 +627,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case signalsReadyMsg:
 		if msg.err != nil {
-			m.err = msg.err
-			return m, nil
+			return m, m.pollInsteadOfSignals(msg.err)
 		}
 		m.signals = msg.ch
 		m.signalsCancel = msg.cancel
 		return m, listenSignalsCmd(m.signals)
 	case signalsClosedMsg:
-		m.signals = nil
-		m.signalsCancel = nil
-		return m, nil
+		return m, m.pollInsteadOfSignals(nil)
+	case autoRefreshMsg:
+		return m.handleAutoRefresh()
 	case firewalldSignalMsg:
 		if m.loading && m.signalRefresh {
 			return m, listenSignalsCmd(m.signals)
@@ -509,20 +645,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else if strings.HasSuffix(msg.event.Name, ".PanicModeDisabled") {
 			m.panicMode = false
 			m.panicAutoArmed = false
+		} else if strings.HasSuffix(msg.event.Name, ".LockdownEnabled") {
+			m.lockdown = true
+		} else if strings.HasSuffix(msg.event.Name, ".LockdownDisabled") {
+			m.lockdown = false
+		} else if strings.HasSuffix(msg.event.Name, ".LogDeniedChanged") && msg.event.Zone != "" {
+			m.logDenied = msg.event.Zone
 		}
-		m.loading = true
 		m.err = nil
-		m.signalRefresh = true
-		if len(m.zones) > 0 && m.selected < len(m.zones) {
-			m.pendingZone = m.zones[m.selected]
-		}
-		return m, tea.Batch(
-			fetchZonesCmd(m.client),
-			fetchDefaultZoneCmd(m.client),
-			fetchActiveZonesCmd(m.client),
-			fetchPanicModeCmd(m.client),
-			listenSignalsCmd(m.signals),
-		)
+		return m, tea.Batch(m.refreshFromBackend(), listenSignalsCmd(m.signals))
 	case panicModeMsg:
 		if msg.err != nil {
 			if errors.Is(msg.err, firewalld.ErrPermissionDenied) || errors.Is(msg.err, firewalld.ErrUnsupportedAPI) {
@@ -557,6 +688,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.err = nil
 		return m, nil
+	case lockoutMsg:
+		return m.handleLockoutResult(msg)
+	case safetyArmMsg:
+		return m.handleSafetyArm(msg)
+	case safetyTickMsg:
+		return m.handleSafetyTick(msg)
+	case safetyRevertedMsg:
+		return m.handleSafetyReverted(msg)
 	case panicAutoDisableMsg:
 		if !m.panicMode {
 			return m, nil
@@ -768,12 +907,78 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ipsetEntriesErr = nil
 		m.ipsetLoading = true
 		return m, fetchIPSetsCmd(m.client, m.permanent)
+	case policiesMsg:
+		if msg.permanent != m.permanent {
+			return m, nil
+		}
+		m.policiesLoading = false
+		if msg.err != nil {
+			m.policiesErr = msg.err
+			m.policiesDenied = errors.Is(msg.err, firewalld.ErrPermissionDenied)
+			m.policies = nil
+			m.policy = nil
+			return m, nil
+		}
+		m.policiesErr = nil
+		m.policiesDenied = false
+		m.policies = msg.names
+		if m.pendingPolicy != "" {
+			if idx := indexOfZone(m.policies, m.pendingPolicy); idx >= 0 {
+				m.policyIndex = idx
+			}
+			m.pendingPolicy = ""
+		}
+		if len(m.policies) == 0 {
+			m.policyIndex = 0
+			m.policy = nil
+			m.policyName = ""
+			m.policyErr = nil
+			m.policyLoading = false
+			return m, nil
+		}
+		if m.policyIndex < 0 || m.policyIndex >= len(m.policies) {
+			m.policyIndex = 0
+		}
+		return m, m.fetchCurrentPolicy()
+	case policyMsg:
+		if msg.permanent != m.permanent || msg.name != m.policyName {
+			return m, nil
+		}
+		m.policyLoading = false
+		if msg.err != nil {
+			m.policyErr = msg.err
+			m.policy = nil
+			return m, nil
+		}
+		m.policyErr = nil
+		m.policy = msg.policy
+		return m, nil
+	case policyMutationMsg:
+		if msg.err != nil {
+			m.policyLoading = false
+			m.policiesLoading = false
+			m.pendingPolicy = ""
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.notice = ""
+		m.policiesLoading = true
+		return m, fetchPoliciesCmd(m.client, m.permanent)
 	case mutationMsg:
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
 		}
+		var expiryCmd tea.Cmd
+		if msg.expiryKey != "" {
+			expiryCmd = m.setExpiry(msg.expiryKey, msg.expiry)
+		}
 		if msg.action != nil {
+			if msg.action.id == 0 {
+				m.undoSeq++
+				msg.action.id = m.undoSeq
+			}
 			switch msg.record {
 			case recordUndo:
 				m.pushUndo(*msg.action, msg.clearRedo)
@@ -801,7 +1006,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			fetchZoneSettingsCmd(m.client, msg.zone, false),
 			fetchZoneSettingsCmd(m.client, msg.zone, true),
 			fetchActiveZonesCmd(m.client),
+			expiryCmd,
 		)
+	case expiryTickMsg:
+		expired := m.pruneExpiries(time.Now())
+		var cmds []tea.Cmd
+		if len(m.zones) > 0 && m.selected < len(m.zones) {
+			if _, ok := expired[m.zones[m.selected]]; ok {
+				cmds = append(cmds, fetchZoneSettingsCmd(m.client, m.zones[m.selected], false))
+			}
+		}
+		if len(m.expiries) == 0 {
+			m.expiryTicking = false
+		} else {
+			cmds = append(cmds, expiryTickCmd())
+		}
+		return m, tea.Batch(cmds...)
 	case serviceDetailsMsg:
 		if msg.service != m.detailsName {
 			return m, nil
@@ -814,6 +1034,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.detailsErr = nil
 		m.details = msg.info
 		return m, nil
+	case serviceSavedMsg:
+		next, cmd := m.handleServiceSaved(msg)
+		return next, cmd
+	case lockdownMsg:
+		return m.handleLockdown(msg)
+	case lockdownToggleMsg:
+		return m.handleLockdownToggle(msg)
+	case whitelistMsg:
+		return m.handleWhitelist(msg)
+	case whitelistMutationMsg:
+		return m.handleWhitelistMutation(msg)
+	case settingsMsg:
+		return m.handleSettings(msg)
+	case settingMutationMsg:
+		return m.handleSettingMutation(msg)
+	case directMsg:
+		return m.handleDirect(msg)
+	case directMutationMsg:
+		return m.handleDirectMutation(msg)
+	case helpersMsg:
+		next, cmd := m.handleHelpers(msg)
+		return next, cmd
+	case helperInfoMsg:
+		next, cmd := m.handleHelperInfo(msg)
+		return next, cmd
+	case helperSavedMsg:
+		next, cmd := m.handleHelperSaved(msg)
+		return next, cmd
 	case serviceCatalogMsg:
 		m.servicesLoading = false
 		if msg.err != nil {
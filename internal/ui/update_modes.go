@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/keymap"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -20,11 +21,12 @@ func (m Model) handleHelpMode(msg tea.Msg) (Model, tea.Cmd, bool) {
 		return m, nil, false
 	}
 
-	switch key.String() {
-	case "esc", "?":
+	action := m.keys.Action(key.String())
+	switch {
+	case key.String() == "esc" || action == keymap.Help:
 		m.helpMode = false
 		return m, tea.ClearScreen, true
-	case "ctrl+c", "q":
+	case key.String() == "ctrl+c" || action == keymap.Quit:
 		return m, tea.Quit, true
 	default:
 		return m, nil, true
@@ -32,7 +34,7 @@ func (m Model) handleHelpMode(msg tea.Msg) (Model, tea.Cmd, bool) {
 }
 
 func (m Model) handleTemplateMode(msg tea.Msg) (Model, tea.Cmd, bool) {
-	if !m.templateMode {
+	if !m.templateMode || m.inputMode != inputNone {
 		return m, nil, false
 	}
 	key, ok := msg.(tea.KeyMsg)
@@ -40,22 +42,36 @@ func (m Model) handleTemplateMode(msg tea.Msg) (Model, tea.Cmd, bool) {
 		return m, nil, false
 	}
 
-	switch key.String() {
-	case "esc", "q", "t":
-		m.templateMode = false
-		return m, nil, true
-	case "j", "down":
-		if m.templateIndex < len(defaultTemplates)-1 {
-			m.templateIndex++
+	if m.templatePlan != nil {
+		if m.keys.Action(key.String()) == keymap.Templates {
+			m.closeTemplates()
+			return m, nil, true
 		}
-		return m, nil, true
-	case "k", "up":
-		if m.templateIndex > 0 {
-			m.templateIndex--
+		switch key.String() {
+		case "esc", "q":
+			m.closeTemplates()
+			return m, nil, true
+		case "enter":
+			return m, m.applyTemplate(), true
+		default:
+			return m, nil, false
 		}
+	}
+
+	if step := m.listStep(key.String()); step != 0 {
+		m.templateIndex = max(min(m.templateIndex+step, len(m.templateList())-1), 0)
+		return m, nil, true
+	}
+	if m.keys.Action(key.String()) == keymap.Templates {
+		m.templateMode = false
+		return m, nil, true
+	}
+	switch key.String() {
+	case "esc", "q":
+		m.templateMode = false
 		return m, nil, true
 	case "enter":
-		return m, m.applyTemplate(), true
+		return m, m.startTemplate(), true
 	default:
 		return m, nil, false
 	}
@@ -70,27 +86,23 @@ func (m Model) handleBackupMode(msg tea.Msg) (Model, tea.Cmd, bool) {
 		return m, nil, false
 	}
 
-	switch key.String() {
-	case "esc", "ctrl+r":
+	if step := m.listStep(key.String()); step != 0 {
+		next := m.backupIndex + step
+		if next < 0 || next >= len(m.backupItems) {
+			return m, nil, true
+		}
+		m.backupIndex = next
+		item := m.backupItems[m.backupIndex]
+		return m, previewBackupCmd(item.Zone, item.Path, m.permanentData), true
+	}
+	if key.String() == "esc" || m.keys.Action(key.String()) == keymap.Restore {
 		m.backupMode = false
 		m.backupItems = nil
 		m.backupPreview = ""
 		m.backupErr = nil
 		return m, nil, true
-	case "j", "down":
-		if len(m.backupItems) > 0 && m.backupIndex < len(m.backupItems)-1 {
-			m.backupIndex++
-			item := m.backupItems[m.backupIndex]
-			return m, previewBackupCmd(item.Zone, item.Path, m.permanentData), true
-		}
-		return m, nil, true
-	case "k", "up":
-		if len(m.backupItems) > 0 && m.backupIndex > 0 {
-			m.backupIndex--
-			item := m.backupItems[m.backupIndex]
-			return m, previewBackupCmd(item.Zone, item.Path, m.permanentData), true
-		}
-		return m, nil, true
+	}
+	switch key.String() {
 	case "enter":
 		if m.readOnly {
 			m.err = firewalld.ErrPermissionDenied
@@ -147,6 +159,21 @@ func (m Model) handleInputMode(msg tea.Msg) (Model, tea.Cmd, bool) {
 		if m.inputMode == inputPanicConfirm {
 			m.panicCountdown = 0
 		}
+		if m.inputMode == inputTemplateParam {
+			m.templateParams = nil
+		}
+		if m.inputMode == inputLockoutConfirm {
+			m.pendingLockout = nil
+			m.lockoutReason = ""
+			m.notice = "Change cancelled"
+		}
+		if m.inputMode == inputLockdownConfirm {
+			m.pendingLockdown = nil
+			m.lockdownReason = ""
+		}
+		if m.inputMode == inputSetting {
+			m.editSetting = ""
+		}
 		m.inputMode = inputNone
 		m.input.Blur()
 		return m, nil, true
@@ -191,6 +218,14 @@ func (m Model) handleDetailsMode(msg tea.Msg) (Model, tea.Cmd, bool) {
 		m.detailsLoading = false
 		m.detailsErr = nil
 		return m, nil, true
+	case "e":
+		return m, m.startServiceEditor(false), true
+	case "n":
+		return m, m.startServiceEditor(true), true
+	case "D":
+		return m, m.startDeleteService(), true
+	case "H":
+		return m, m.startHelperBrowser(), true
 	default:
 		return m, nil, false
 	}
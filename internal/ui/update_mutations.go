@@ -4,9 +4,12 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 
 	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/lockout"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -15,7 +18,7 @@ func (m *Model) maybeBackup(zone string, needsBackup bool, cmd tea.Cmd) tea.Cmd
 	if !needsBackup {
 		return cmd
 	}
-	if m.readOnly {
+	if m.readOnly || m.offlineRoot != "" {
 		return cmd
 	}
 	if zone == "" {
@@ -31,6 +34,10 @@ func (m *Model) maybeBackup(zone string, needsBackup bool, cmd tea.Cmd) tea.Cmd
 	return createBackupCmd(zone)
 }
 
+// errOfflineBackup is reported for backups and imports in offline mode: they
+// read and write the host's /etc/firewalld, not the directory being edited.
+var errOfflineBackup = errors.New("backups and imports are not available in offline mode")
+
 func (m *Model) setDryRunNotice(action string) {
 	m.err = nil
 	m.notice = "DRY RUN: would " + action
@@ -53,6 +60,20 @@ func (m *Model) pushUndo(action undoAction, clearRedo bool) {
 	}
 }
 
+// dropUndo removes the recorded change id from the undo stack, leaving the
+// changes made after it in place.
+func (m *Model) dropUndo(id int) {
+	if id == 0 {
+		return
+	}
+	for i := len(m.undoStack) - 1; i >= 0; i-- {
+		if m.undoStack[i].id == id {
+			m.undoStack = slices.Delete(m.undoStack, i, i+1)
+			return
+		}
+	}
+}
+
 func (m *Model) pushRedo(action undoAction) {
 	if len(m.redoStack) >= undoLimit {
 		m.redoStack = m.redoStack[1:]
@@ -71,7 +92,16 @@ func (m *Model) actionRemoveService(zone, service string, permanent bool) tea.Cm
 	action := &undoAction{label: "remove service " + service, zone: zone}
 	action.undo = addServiceCmd(m.client, zone, service, permanent, action, recordRedo, false)
 	action.redo = removeServiceCmd(m.client, zone, service, permanent, action, recordUndo, false)
-	return removeServiceCmd(m.client, zone, service, permanent, action, recordUndo, true)
+	cmd := removeServiceCmd(m.client, zone, service, permanent, action, recordUndo, true)
+	if permanent {
+		return cmd
+	}
+	key := expiryKey(zone, expiryService, service)
+	if remaining := m.expiryRemaining(key); remaining > 0 {
+		action.undo = withExpiry(addServiceTimedCmd(m.client, zone, service, remaining, action, recordRedo, false), key, remaining)
+	}
+	action.redo = withExpiry(action.redo, key, 0)
+	return withExpiry(cmd, key, 0)
 }
 
 func (m *Model) actionAddPort(zone string, port firewalld.Port, permanent bool) tea.Cmd {
@@ -87,7 +117,16 @@ func (m *Model) actionRemovePort(zone string, port firewalld.Port, permanent boo
 	action := &undoAction{label: "remove port " + label, zone: zone}
 	action.undo = addPortCmd(m.client, zone, port, permanent, action, recordRedo, false)
 	action.redo = removePortCmd(m.client, zone, port, permanent, action, recordUndo, false)
-	return removePortCmd(m.client, zone, port, permanent, action, recordUndo, true)
+	cmd := removePortCmd(m.client, zone, port, permanent, action, recordUndo, true)
+	if permanent {
+		return cmd
+	}
+	key := expiryKey(zone, expiryPort, label)
+	if remaining := m.expiryRemaining(key); remaining > 0 {
+		action.undo = withExpiry(addPortTimedCmd(m.client, zone, port, remaining, action, recordRedo, false), key, remaining)
+	}
+	action.redo = withExpiry(action.redo, key, 0)
+	return withExpiry(cmd, key, 0)
 }
 
 func (m *Model) actionAddRichRule(zone, rule string, permanent bool) tea.Cmd {
@@ -101,14 +140,27 @@ func (m *Model) actionRemoveRichRule(zone, rule string, permanent bool) tea.Cmd
 	action := &undoAction{label: "remove rich rule", zone: zone}
 	action.undo = addRichRuleCmd(m.client, zone, rule, permanent, action, recordRedo, false)
 	action.redo = removeRichRuleCmd(m.client, zone, rule, permanent, action, recordUndo, false)
-	return removeRichRuleCmd(m.client, zone, rule, permanent, action, recordUndo, true)
+	cmd := removeRichRuleCmd(m.client, zone, rule, permanent, action, recordUndo, true)
+	if permanent {
+		return cmd
+	}
+	key := expiryKey(zone, expiryRich, rule)
+	if remaining := m.expiryRemaining(key); remaining > 0 {
+		action.undo = withExpiry(addRichRuleTimedCmd(m.client, zone, rule, remaining, action, recordRedo, false), key, remaining)
+	}
+	action.redo = withExpiry(action.redo, key, 0)
+	return withExpiry(cmd, key, 0)
 }
 
 func (m *Model) actionEditRichRule(zone, oldRule, newRule string, permanent bool) tea.Cmd {
 	action := &undoAction{label: "edit rich rule", zone: zone}
 	action.undo = updateRichRuleCmd(m.client, zone, newRule, oldRule, permanent, action, recordRedo, false)
 	action.redo = updateRichRuleCmd(m.client, zone, oldRule, newRule, permanent, action, recordUndo, false)
-	return updateRichRuleCmd(m.client, zone, oldRule, newRule, permanent, action, recordUndo, true)
+	cmd := updateRichRuleCmd(m.client, zone, oldRule, newRule, permanent, action, recordUndo, true)
+	if permanent {
+		return cmd
+	}
+	return withExpiry(cmd, expiryKey(zone, expiryRich, oldRule), 0)
 }
 
 func (m *Model) actionAddInterface(zone, iface string, permanent bool) tea.Cmd {
@@ -139,6 +191,20 @@ func (m *Model) actionRemoveSource(zone, source string, permanent bool) tea.Cmd
 	return removeSourceCmd(m.client, zone, source, permanent, action, recordUndo, true)
 }
 
+func (m *Model) actionAddForwardPort(zone string, fp firewalld.ForwardPort, permanent bool) tea.Cmd {
+	action := &undoAction{label: "add forward port " + firewalld.FormatForwardPort(fp), zone: zone}
+	action.undo = removeForwardPortCmd(m.client, zone, fp, permanent, action, recordRedo, false)
+	action.redo = addForwardPortCmd(m.client, zone, fp, permanent, action, recordUndo, false)
+	return addForwardPortCmd(m.client, zone, fp, permanent, action, recordUndo, true)
+}
+
+func (m *Model) actionRemoveForwardPort(zone string, fp firewalld.ForwardPort, permanent bool) tea.Cmd {
+	action := &undoAction{label: "remove forward port " + firewalld.FormatForwardPort(fp), zone: zone}
+	action.undo = addForwardPortCmd(m.client, zone, fp, permanent, action, recordRedo, false)
+	action.redo = removeForwardPortCmd(m.client, zone, fp, permanent, action, recordUndo, false)
+	return removeForwardPortCmd(m.client, zone, fp, permanent, action, recordUndo, true)
+}
+
 func (m *Model) actionMasquerade(zone string, enabled, permanent bool) tea.Cmd {
 	state := "off"
 	if enabled {
@@ -150,6 +216,56 @@ func (m *Model) actionMasquerade(zone string, enabled, permanent bool) tea.Cmd {
 	return setMasqueradeCmd(m.client, zone, enabled, permanent, action, recordUndo, true)
 }
 
+func (m *Model) actionSetTarget(zone, oldTarget, newTarget string) tea.Cmd {
+	action := &undoAction{label: "set target " + newTarget, zone: zone}
+	action.undo = setTargetCmd(m.client, zone, oldTarget, action, recordRedo, false)
+	action.redo = setTargetCmd(m.client, zone, newTarget, action, recordUndo, false)
+	return setTargetCmd(m.client, zone, newTarget, action, recordUndo, true)
+}
+
+func (m *Model) actionAddIcmpBlock(zone, icmpType string, permanent bool) tea.Cmd {
+	action := &undoAction{label: "block icmp " + icmpType, zone: zone}
+	action.undo = removeIcmpBlockCmd(m.client, zone, icmpType, permanent, action, recordRedo, false)
+	action.redo = addIcmpBlockCmd(m.client, zone, icmpType, permanent, action, recordUndo, false)
+	return addIcmpBlockCmd(m.client, zone, icmpType, permanent, action, recordUndo, true)
+}
+
+func (m *Model) actionRemoveIcmpBlock(zone, icmpType string, permanent bool) tea.Cmd {
+	action := &undoAction{label: "unblock icmp " + icmpType, zone: zone}
+	action.undo = addIcmpBlockCmd(m.client, zone, icmpType, permanent, action, recordRedo, false)
+	action.redo = removeIcmpBlockCmd(m.client, zone, icmpType, permanent, action, recordUndo, false)
+	return removeIcmpBlockCmd(m.client, zone, icmpType, permanent, action, recordUndo, true)
+}
+
+func (m *Model) actionIcmpInversion(zone string, enabled, permanent bool) tea.Cmd {
+	state := "off"
+	if enabled {
+		state = "on"
+	}
+	action := &undoAction{label: "icmp inversion " + state, zone: zone}
+	action.undo = setIcmpInversionCmd(m.client, zone, !enabled, permanent, action, recordRedo, false)
+	action.redo = setIcmpInversionCmd(m.client, zone, enabled, permanent, action, recordUndo, false)
+	return setIcmpInversionCmd(m.client, zone, enabled, permanent, action, recordUndo, true)
+}
+
+func (m *Model) toggleIcmpInversion() tea.Cmd {
+	current := m.currentData()
+	if current == nil || len(m.zones) == 0 {
+		return nil
+	}
+	zone := m.zones[m.selected]
+	enabled := !current.IcmpInvert
+	if m.dryRun {
+		state := "on"
+		if !enabled {
+			state = "off"
+		}
+		m.setDryRunNotice(fmt.Sprintf("set icmp block inversion %s for zone %s (%s)", state, zone, modeLabel(m.permanent)))
+		return nil
+	}
+	return m.safeMutation(zone, "toggle icmp inversion", m.permanent, false, m.actionIcmpInversion(zone, enabled, m.permanent))
+}
+
 func (m *Model) toggleMasquerade() tea.Cmd {
 	current := m.currentData()
 	if current == nil || len(m.zones) == 0 {
@@ -165,7 +281,7 @@ func (m *Model) toggleMasquerade() tea.Cmd {
 		m.setDryRunNotice(fmt.Sprintf("set masquerade %s for zone %s (%s)", state, zone, modeLabel(m.permanent)))
 		return nil
 	}
-	return m.maybeBackup(zone, true, m.actionMasquerade(zone, enabled, m.permanent))
+	return m.safeMutation(zone, "toggle masquerade", m.permanent, false, m.actionMasquerade(zone, enabled, m.permanent))
 }
 
 func (m *Model) removeSelected() tea.Cmd {
@@ -176,11 +292,18 @@ func (m *Model) removeSelected() tea.Cmd {
 	if m.tab == tabIPSets {
 		return m.startRemoveIPSetEntry()
 	}
+	if m.tab == tabPolicies {
+		return m.startEditPolicy("-")
+	}
+	if m.tab == tabDirect {
+		return m.startRemoveDirect()
+	}
 	current := m.currentData()
 	if current == nil || len(m.zones) == 0 {
 		return nil
 	}
 	zone := m.zones[m.selected]
+	permanent := m.permanent
 
 	switch m.tab {
 	case tabServices:
@@ -192,7 +315,9 @@ func (m *Model) removeSelected() tea.Cmd {
 			m.setDryRunNotice(fmt.Sprintf("remove service %s from zone %s (%s)", service, zone, modeLabel(m.permanent)))
 			return nil
 		}
-		return m.maybeBackup(zone, true, m.actionRemoveService(zone, service, m.permanent))
+		return m.guardLockout(lockout.Change{Kind: lockout.RemoveService, Zone: zone, Value: service}, func(m *Model) tea.Cmd {
+			return m.safeMutation(zone, "remove service "+service, permanent, service == "ssh", m.actionRemoveService(zone, service, permanent))
+		})
 	case tabPorts:
 		if len(current.Ports) == 0 {
 			return nil
@@ -203,17 +328,22 @@ func (m *Model) removeSelected() tea.Cmd {
 			m.setDryRunNotice(fmt.Sprintf("remove port %s from zone %s (%s)", label, zone, modeLabel(m.permanent)))
 			return nil
 		}
-		return m.maybeBackup(zone, true, m.actionRemovePort(zone, port, m.permanent))
+		return m.guardLockout(lockout.Change{Kind: lockout.RemovePort, Zone: zone, Port: port}, func(m *Model) tea.Cmd {
+			return m.safeMutation(zone, "remove port "+port.Port+"/"+port.Protocol, permanent, false, m.actionRemovePort(zone, port, permanent))
+		})
 	case tabRich:
-		if len(current.RichRules) == 0 {
+		e, ok := m.selectedRichRule()
+		if !ok {
 			return nil
 		}
-		rule := current.RichRules[m.richIndex]
+		rule := e.rule
 		if m.dryRun {
 			m.setDryRunNotice(fmt.Sprintf("remove rich rule from zone %s (%s)", zone, modeLabel(m.permanent)))
 			return nil
 		}
-		return m.maybeBackup(zone, true, m.actionRemoveRichRule(zone, rule, m.permanent))
+		return m.guardLockout(lockout.Change{Kind: lockout.RemoveRichRule, Zone: zone, Value: rule}, func(m *Model) tea.Cmd {
+			return m.safeMutation(zone, "remove rich rule", permanent, false, m.actionRemoveRichRule(zone, rule, permanent))
+		})
 	case tabNetwork:
 		items := m.networkItems()
 		if len(items) == 0 {
@@ -229,64 +359,41 @@ func (m *Model) removeSelected() tea.Cmd {
 				m.setDryRunNotice(fmt.Sprintf("remove interface %s from zone %s (%s)", item.value, zone, modeLabel(m.permanent)))
 				return nil
 			}
-			return m.maybeBackup(zone, true, m.actionRemoveInterface(zone, item.value, m.permanent))
+			return m.guardLockout(lockout.Change{Kind: lockout.RemoveInterface, Zone: zone, Value: item.value}, func(m *Model) tea.Cmd {
+				return m.safeMutation(zone, "remove interface "+item.value, permanent, false, m.actionRemoveInterface(zone, item.value, permanent))
+			})
 		case "source":
 			if m.dryRun {
 				m.setDryRunNotice(fmt.Sprintf("remove source %s from zone %s (%s)", item.value, zone, modeLabel(m.permanent)))
 				return nil
 			}
-			return m.maybeBackup(zone, true, m.actionRemoveSource(zone, item.value, m.permanent))
+			return m.guardLockout(lockout.Change{Kind: lockout.RemoveSource, Zone: zone, Value: item.value}, func(m *Model) tea.Cmd {
+				return m.safeMutation(zone, "remove source "+item.value, permanent, false, m.actionRemoveSource(zone, item.value, permanent))
+			})
+		case "forward":
+			if m.dryRun {
+				m.setDryRunNotice(fmt.Sprintf("remove forward port %s from zone %s (%s)", item.value, zone, modeLabel(m.permanent)))
+				return nil
+			}
+			return m.safeMutation(zone, "remove forward port "+item.value, m.permanent, false, m.actionRemoveForwardPort(zone, item.forward, m.permanent))
 		default:
 			return nil
 		}
 	case tabInfo:
-		m.err = fmt.Errorf("editing not implemented for this tab")
-		return nil
+		if len(current.IcmpBlocks) == 0 || m.icmpIndex >= len(current.IcmpBlocks) {
+			return nil
+		}
+		icmpType := current.IcmpBlocks[m.icmpIndex]
+		if m.dryRun {
+			m.setDryRunNotice(fmt.Sprintf("remove icmp block %s from zone %s (%s)", icmpType, zone, modeLabel(m.permanent)))
+			return nil
+		}
+		return m.safeMutation(zone, "unblock icmp "+icmpType, m.permanent, false, m.actionRemoveIcmpBlock(zone, icmpType, m.permanent))
 	default:
 		return nil
 	}
 }
 
-func (m *Model) applyTemplate() tea.Cmd {
-	if m.readOnly {
-		m.err = firewalld.ErrPermissionDenied
-		return nil
-	}
-	if len(m.zones) == 0 {
-		m.err = fmt.Errorf("no zone selected")
-		return nil
-	}
-	current := m.currentData()
-	if current == nil {
-		m.err = fmt.Errorf("no data loaded")
-		return nil
-	}
-	if m.templateIndex < 0 || m.templateIndex >= len(defaultTemplates) {
-		m.err = fmt.Errorf("invalid template selection")
-		return nil
-	}
-
-	tpl := defaultTemplates[m.templateIndex]
-	services := filterMissingServices(tpl.Services, current.Services)
-	ports := filterMissingPorts(tpl.Ports, current.Ports)
-	if len(services) == 0 && len(ports) == 0 {
-		m.err = fmt.Errorf("template already applied")
-		return nil
-	}
-
-	m.templateMode = false
-	if m.dryRun {
-		zone := m.zones[m.selected]
-		m.setDryRunNotice(fmt.Sprintf("apply template %s to zone %s (%s)", tpl.Name, zone, modeLabel(m.permanent)))
-		return nil
-	}
-	m.loading = true
-	m.err = nil
-	zone := m.zones[m.selected]
-	m.pendingZone = zone
-	return m.maybeBackup(zone, true, applyTemplateCmd(m.client, zone, services, ports, m.permanent))
-}
-
 func filterMissingServices(template, current []string) []string {
 	currentSet := make(map[string]struct{}, len(current))
 	for _, s := range current {
@@ -5,8 +5,22 @@ package ui
 
 import (
 	"strings"
+
+	"lazyfirewall/internal/keymap"
 )
 
+// listStep returns -1 or 1 when key is bound to moving a selection up or
+// down, and 0 otherwise.
+func (m Model) listStep(key string) int {
+	switch m.keys.Action(key) {
+	case keymap.Up:
+		return -1
+	case keymap.Down:
+		return 1
+	}
+	return 0
+}
+
 func (m *Model) clampSelections() {
 	current := m.currentData()
 	if current == nil {
@@ -21,6 +35,7 @@ func (m *Model) clampSelections() {
 	if m.richIndex >= len(current.RichRules) {
 		m.richIndex = 0
 	}
+	m.followRichRule()
 	items := m.networkItems()
 	if len(items) == 0 {
 		m.networkIndex = 0
@@ -32,6 +47,15 @@ func (m *Model) clampSelections() {
 	} else if m.ipsetIndex >= len(m.ipsets) {
 		m.ipsetIndex = 0
 	}
+	if m.policyIndex >= len(m.policies) {
+		m.policyIndex = 0
+	}
+	if m.directIndex >= len(directItems(m.currentDirect())) {
+		m.directIndex = 0
+	}
+	if m.icmpIndex >= len(current.IcmpBlocks) {
+		m.icmpIndex = 0
+	}
 }
 
 func (m *Model) moveMainSelection(delta int) {
@@ -53,6 +77,35 @@ func (m *Model) moveMainSelection(delta int) {
 		m.ipsetIndex = next
 		return
 	}
+	if m.tab == tabPolicies {
+		if len(m.policies) == 0 {
+			return
+		}
+		next := m.policyIndex + delta
+		if next < 0 {
+			next = 0
+		}
+		if next >= len(m.policies) {
+			next = len(m.policies) - 1
+		}
+		m.policyIndex = next
+		return
+	}
+	if m.tab == tabDirect {
+		items := directItems(m.currentDirect())
+		if len(items) == 0 {
+			return
+		}
+		next := m.directIndex + delta
+		if next < 0 {
+			next = 0
+		}
+		if next >= len(items) {
+			next = len(items) - 1
+		}
+		m.directIndex = next
+		return
+	}
 	current := m.currentData()
 	if current == nil {
 		return
@@ -109,7 +162,17 @@ func (m *Model) moveMainSelection(delta int) {
 		m.networkIndex = next
 		return
 	case tabInfo:
-		return
+		if len(current.IcmpBlocks) == 0 {
+			return
+		}
+		next := m.icmpIndex + delta
+		if next < 0 {
+			next = 0
+		}
+		if next >= len(current.IcmpBlocks) {
+			next = len(current.IcmpBlocks) - 1
+		}
+		m.icmpIndex = next
 	}
 }
 
@@ -157,8 +220,14 @@ func (m *Model) currentIndex() int {
 	if m.tab == tabIPSets {
 		return m.ipsetIndex
 	}
+	if m.tab == tabPolicies {
+		return m.policyIndex
+	}
+	if m.tab == tabDirect {
+		return m.directIndex
+	}
 	if m.tab == tabInfo {
-		return 0
+		return m.icmpIndex
 	}
 	return m.serviceIndex
 }
@@ -180,7 +249,16 @@ func (m *Model) setCurrentIndex(index int) {
 		m.ipsetIndex = index
 		return
 	}
+	if m.tab == tabPolicies {
+		m.policyIndex = index
+		return
+	}
+	if m.tab == tabDirect {
+		m.directIndex = index
+		return
+	}
 	if m.tab == tabInfo {
+		m.icmpIndex = index
 		return
 	}
 	m.serviceIndex = index
@@ -190,6 +268,17 @@ func (m *Model) currentItems() []string {
 	if m.tab == tabIPSets {
 		return m.ipsets
 	}
+	if m.tab == tabPolicies {
+		return m.policies
+	}
+	if m.tab == tabDirect {
+		items := directItems(m.currentDirect())
+		out := make([]string, 0, len(items))
+		for _, it := range items {
+			out = append(out, it.value())
+		}
+		return out
+	}
 	current := m.currentData()
 	if current == nil {
 		return nil
@@ -202,7 +291,12 @@ func (m *Model) currentItems() []string {
 		return items
 	}
 	if m.tab == tabRich {
-		return current.RichRules
+		entries := orderRichRules(current.RichRules)
+		items := make([]string, 0, len(entries))
+		for _, e := range entries {
+			items = append(items, e.rule)
+		}
+		return items
 	}
 	if m.tab == tabNetwork {
 		items := m.networkItems()
@@ -213,7 +307,7 @@ func (m *Model) currentItems() []string {
 		return out
 	}
 	if m.tab == tabInfo {
-		return nil
+		return current.IcmpBlocks
 	}
 	return current.Services
 }
@@ -266,6 +360,10 @@ func (m *Model) nextTab() {
 	case tabIPSets:
 		m.tab = tabInfo
 	case tabInfo:
+		m.tab = tabPolicies
+	case tabPolicies:
+		m.tab = tabDirect
+	case tabDirect:
 		m.tab = tabServices
 	}
 }
@@ -273,7 +371,7 @@ func (m *Model) nextTab() {
 func (m *Model) prevTab() {
 	switch m.tab {
 	case tabServices:
-		m.tab = tabInfo
+		m.tab = tabDirect
 	case tabPorts:
 		m.tab = tabServices
 	case tabRich:
@@ -284,5 +382,9 @@ func (m *Model) prevTab() {
 		m.tab = tabNetwork
 	case tabInfo:
 		m.tab = tabIPSets
+	case tabPolicies:
+		m.tab = tabInfo
+	case tabDirect:
+		m.tab = tabPolicies
 	}
 }
@@ -9,30 +9,12 @@ import (
 
 	"lazyfirewall/internal/backup"
 	"lazyfirewall/internal/firewalld"
+	"lazyfirewall/internal/keymap"
+	"lazyfirewall/internal/templates"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	titleStyle       = lipgloss.NewStyle().Bold(true)
-	selectedStyle    = lipgloss.NewStyle().Background(lipgloss.Color("#88c0d0")).Foreground(lipgloss.Color("#2e3440"))
-	selectedDimStyle = lipgloss.NewStyle().Background(lipgloss.Color("#4c566a")).Foreground(lipgloss.Color("#d8dee9"))
-	dimStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
-	errorStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
-	tabActiveStyle   = lipgloss.NewStyle().Background(lipgloss.Color("#88c0d0")).Foreground(lipgloss.Color("#2e3440")).Padding(0, 1)
-	tabInactiveStyle = lipgloss.NewStyle().Background(lipgloss.Color("#4c566a")).Foreground(lipgloss.Color("#d8dee9")).Padding(0, 1)
-	inputStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("229"))
-	matchStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("226")).Bold(true)
-	statusStyle      = lipgloss.NewStyle().Background(lipgloss.Color("#88c0d0")).Foreground(lipgloss.Color("#2e3440")).Padding(0, 1)
-	statusTextStyle  = lipgloss.NewStyle().Background(lipgloss.Color("#88c0d0")).Foreground(lipgloss.Color("#3b4252"))
-	statusMutedStyle = lipgloss.NewStyle().Background(lipgloss.Color("#88c0d0")).Foreground(lipgloss.Color("#3b4252"))
-	statusKeyStyle   = lipgloss.NewStyle().Background(lipgloss.Color("#88c0d0")).Foreground(lipgloss.Color("#eceff4"))
-	sidebarStyle     = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1)
-	mainStyle        = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1)
-	warnStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
-	panicStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("15")).Background(lipgloss.Color("1")).Padding(0, 1).Bold(true)
-)
-
 func (m Model) View() string {
 	sidebarWidth := 24
 	if m.width > 0 {
@@ -132,10 +114,24 @@ func renderMain(m Model, width int) string {
 		b.WriteString(warnStyle.Render("[RO] Read-Only Mode - Run with sudo for editing"))
 		b.WriteString("\n\n")
 	}
+	if m.sshWarning != "" {
+		b.WriteString(warnStyle.Render("[SSH?] " + m.sshWarning))
+		b.WriteString("\n\n")
+	}
+	if m.offlineRoot != "" {
+		b.WriteString(warnStyle.Render("[OFFLINE] Editing " + m.offlineRoot + " - permanent configuration only"))
+		b.WriteString("\n\n")
+	}
 	if m.panicMode {
 		b.WriteString(panicStyle.Render("[PANIC] MODE ACTIVE - ALL CONNECTIONS DROPPED"))
 		b.WriteString("\n\n")
 	}
+	if m.safety != nil {
+		b.WriteString(panicStyle.Render(fmt.Sprintf("Keep change? %s (zone %s) reverts in %ds", m.safety.label, m.safety.zone, m.safety.remaining)))
+		b.WriteString("\n")
+		b.WriteString(dimStyle.Render("This change may affect your SSH session. Press y/Enter to keep it, n/Esc to revert now."))
+		b.WriteString("\n\n")
+	}
 
 	if m.helpMode {
 		renderHelp(&b, m)
@@ -163,11 +159,25 @@ func renderMain(m Model, width int) string {
 	b.WriteString("\n")
 	b.WriteString(renderTabs(m))
 	b.WriteString("\n\n")
-	if m.splitView && m.tab != tabIPSets {
+	if m.serviceEdit != nil {
+		renderServiceEditor(&b, m)
+	} else if m.ruleBuilder != nil {
+		renderRuleBuilder(&b, m)
+	} else if m.lockdownMode {
+		renderLockdownScreen(&b, m)
+	} else if m.settingsMode {
+		renderSettingsScreen(&b, m)
+	} else if m.helperMode {
+		renderHelperBrowser(&b, m)
+	} else if m.icmpBrowserMode {
+		renderIcmpBrowser(&b, m)
+	} else if m.splitView && m.tab != tabIPSets && m.tab != tabPolicies {
 		b.WriteString(renderSplitView(m, width))
 	} else {
 		if m.logMode {
 			renderLogsView(&b, m)
+		} else if m.icmpPickerMode {
+			renderIcmpPicker(&b, m)
 		} else if m.templateMode {
 			renderTemplates(&b, m)
 		} else if m.detailsMode && m.tab == tabServices {
@@ -186,11 +196,27 @@ func renderMain(m Model, width int) string {
 				renderIPSetsView(&b, m)
 			case tabInfo:
 				renderInfoView(&b, m, current)
+			case tabPolicies:
+				renderPoliciesView(&b, m)
+			case tabDirect:
+				renderDirectView(&b, m)
 			}
 		}
 	}
 
 	if m.inputMode != inputNone {
+		if m.inputMode == inputLockoutConfirm {
+			b.WriteString(warnStyle.Render("This change may lock out your SSH session:"))
+			b.WriteString("\n")
+			b.WriteString(dimStyle.Render(m.lockoutReason))
+			b.WriteString("\n")
+		}
+		if m.inputMode == inputLockdownConfirm {
+			b.WriteString(warnStyle.Render("Lockdown restricts who can change firewalld:"))
+			b.WriteString("\n")
+			b.WriteString(dimStyle.Render(m.lockdownReason))
+			b.WriteString("\n")
+		}
 		if m.inputMode == inputPanicConfirm {
 			b.WriteString(warnStyle.Render("This will DROP ALL network connections immediately."))
 			b.WriteString("\n")
@@ -209,57 +235,28 @@ func renderMain(m Model, width int) string {
 }
 
 func renderTabs(m Model) string {
-	serviceLabel := " Services "
-	portLabel := " Ports "
-	richLabel := " Rich Rules "
-	networkLabel := " Network "
-	ipsetLabel := " IPSets "
-	infoLabel := " Info "
-	switch m.tab {
-	case tabServices:
-		serviceLabel = tabActiveStyle.Render(serviceLabel)
-		portLabel = tabInactiveStyle.Render(portLabel)
-		richLabel = tabInactiveStyle.Render(richLabel)
-		networkLabel = tabInactiveStyle.Render(networkLabel)
-		ipsetLabel = tabInactiveStyle.Render(ipsetLabel)
-		infoLabel = tabInactiveStyle.Render(infoLabel)
-	case tabPorts:
-		serviceLabel = tabInactiveStyle.Render(serviceLabel)
-		portLabel = tabActiveStyle.Render(portLabel)
-		richLabel = tabInactiveStyle.Render(richLabel)
-		networkLabel = tabInactiveStyle.Render(networkLabel)
-		ipsetLabel = tabInactiveStyle.Render(ipsetLabel)
-		infoLabel = tabInactiveStyle.Render(infoLabel)
-	case tabRich:
-		serviceLabel = tabInactiveStyle.Render(serviceLabel)
-		portLabel = tabInactiveStyle.Render(portLabel)
-		richLabel = tabActiveStyle.Render(richLabel)
-		networkLabel = tabInactiveStyle.Render(networkLabel)
-		ipsetLabel = tabInactiveStyle.Render(ipsetLabel)
-		infoLabel = tabInactiveStyle.Render(infoLabel)
-	case tabNetwork:
-		serviceLabel = tabInactiveStyle.Render(serviceLabel)
-		portLabel = tabInactiveStyle.Render(portLabel)
-		richLabel = tabInactiveStyle.Render(richLabel)
-		networkLabel = tabActiveStyle.Render(networkLabel)
-		ipsetLabel = tabInactiveStyle.Render(ipsetLabel)
-		infoLabel = tabInactiveStyle.Render(infoLabel)
-	case tabIPSets:
-		serviceLabel = tabInactiveStyle.Render(serviceLabel)
-		portLabel = tabInactiveStyle.Render(portLabel)
-		richLabel = tabInactiveStyle.Render(richLabel)
-		networkLabel = tabInactiveStyle.Render(networkLabel)
-		ipsetLabel = tabActiveStyle.Render(ipsetLabel)
-		infoLabel = tabInactiveStyle.Render(infoLabel)
-	case tabInfo:
-		serviceLabel = tabInactiveStyle.Render(serviceLabel)
-		portLabel = tabInactiveStyle.Render(portLabel)
-		richLabel = tabInactiveStyle.Render(richLabel)
-		networkLabel = tabInactiveStyle.Render(networkLabel)
-		ipsetLabel = tabInactiveStyle.Render(ipsetLabel)
-		infoLabel = tabActiveStyle.Render(infoLabel)
-	}
-	return serviceLabel + portLabel + richLabel + networkLabel + ipsetLabel + infoLabel
+	tabs := []struct {
+		tab   mainTab
+		label string
+	}{
+		{tabServices, " Services "},
+		{tabPorts, " Ports "},
+		{tabRich, " Rich Rules "},
+		{tabNetwork, " Network "},
+		{tabIPSets, " IPSets "},
+		{tabInfo, " Info "},
+		{tabPolicies, " Policies "},
+		{tabDirect, " Direct "},
+	}
+	var b strings.Builder
+	for _, t := range tabs {
+		if t.tab == m.tab {
+			b.WriteString(tabActiveStyle.Render(t.label))
+		} else {
+			b.WriteString(tabInactiveStyle.Render(t.label))
+		}
+	}
+	return b.String()
 }
 
 func renderSplitView(m Model, width int) string {
@@ -274,6 +271,7 @@ func renderSplitView(m Model, width int) string {
 	}
 
 	leftLines, rightLines := splitLines(m)
+	leftLines, rightLines = colorDiffLines(leftLines), colorDiffLines(rightLines)
 	left := titleStyle.Render("Runtime") + "\n" + strings.Join(leftLines, "\n")
 	right := titleStyle.Render("Permanent") + "\n" + strings.Join(rightLines, "\n")
 
@@ -304,6 +302,8 @@ func splitLines(m Model) ([]string, []string) {
 		return []string{dimStyle.Render("(split view not available)")}, []string{dimStyle.Render("(split view not available)")}
 	case tabInfo:
 		return diffInfo(m.runtimeData, m.permanentData)
+	case tabDirect:
+		return diffDirect(m.directRuntime, m.directPermanent)
 	default:
 		return []string{""}, []string{""}
 	}
@@ -541,6 +541,37 @@ func diffNetwork(runtime, permanent *firewalld.Zone) ([]string, []string) {
 		right = append(right, dimStyle.Render("(none)"))
 	}
 
+	left = append(left, "", "Forward ports:")
+	right = append(right, "", "Forward ports:")
+	permanentForwards := make(map[firewalld.ForwardPort]struct{}, len(permanent.ForwardPorts))
+	for _, fp := range permanent.ForwardPorts {
+		permanentForwards[fp] = struct{}{}
+	}
+	runtimeForwards := make(map[firewalld.ForwardPort]struct{}, len(runtime.ForwardPorts))
+	for _, fp := range runtime.ForwardPorts {
+		runtimeForwards[fp] = struct{}{}
+	}
+	for _, fp := range runtime.ForwardPorts {
+		prefix := "  "
+		if _, ok := permanentForwards[fp]; !ok {
+			prefix = "+ "
+		}
+		left = append(left, prefix+firewalld.FormatForwardPort(fp))
+	}
+	for _, fp := range permanent.ForwardPorts {
+		prefix := "  "
+		if _, ok := runtimeForwards[fp]; !ok {
+			prefix = "- "
+		}
+		right = append(right, prefix+firewalld.FormatForwardPort(fp))
+	}
+	if len(runtime.ForwardPorts) == 0 {
+		left = append(left, dimStyle.Render("(none)"))
+	}
+	if len(permanent.ForwardPorts) == 0 {
+		right = append(right, dimStyle.Render("(none)"))
+	}
+
 	return left, right
 }
 
@@ -678,6 +709,7 @@ func renderServicesList(b *strings.Builder, m Model, current *firewalld.Zone) {
 				line = line + " *"
 			}
 		}
+		line += m.expiryLabel(expiryService, s)
 		if i == m.serviceIndex {
 			if m.focus == focusMain {
 				line = selectedStyle.Render("  " + line)
@@ -706,6 +738,7 @@ func renderPortsList(b *strings.Builder, m Model, current *firewalld.Zone) {
 				line = line + " " + mark
 			}
 		}
+		line += m.expiryLabel(expiryPort, base)
 		if i == m.portIndex {
 			if m.focus == focusMain {
 				line = selectedStyle.Render("  " + line)
@@ -732,13 +765,23 @@ func renderRichRulesList(b *strings.Builder, m Model, current *firewalld.Zone) {
 		}
 	}
 
-	for i, r := range current.RichRules {
+	section := ""
+	for i, e := range orderRichRules(current.RichRules) {
+		if s := e.section(); s != section {
+			if section != "" {
+				b.WriteString("\n")
+			}
+			section = s
+			b.WriteString(section + ":\n")
+		}
+		r := e.rule
 		line := highlightMatch(r, m.searchQuery)
 		if !m.permanent && m.permanentData != nil {
 			if _, ok := permanentSet[r]; !ok {
 				line = line + " *"
 			}
 		}
+		line += m.expiryLabel(expiryRich, r)
 		if i == m.richIndex {
 			if m.focus == focusMain {
 				line = selectedStyle.Render("  " + line)
@@ -802,6 +845,38 @@ func renderNetworkView(b *strings.Builder, m Model, current *firewalld.Zone) {
 		}
 	}
 
+	b.WriteString("\nForward ports:\n")
+	if len(current.ForwardPorts) == 0 {
+		b.WriteString(dimStyle.Render("  (none)"))
+		b.WriteString("\n")
+	} else {
+		permanentSet := make(map[firewalld.ForwardPort]struct{})
+		if !m.permanent && m.permanentData != nil {
+			for _, fp := range m.permanentData.ForwardPorts {
+				permanentSet[fp] = struct{}{}
+			}
+		}
+		for _, fp := range current.ForwardPorts {
+			line := highlightMatch(firewalld.FormatForwardPort(fp), m.searchQuery)
+			if !m.permanent && m.permanentData != nil {
+				if _, ok := permanentSet[fp]; !ok {
+					line = line + " *"
+				}
+			}
+			if index == m.networkIndex {
+				if m.focus == focusMain {
+					line = selectedStyle.Render("  " + line)
+				} else {
+					line = selectedDimStyle.Render("  " + line)
+				}
+			} else {
+				line = "  " + line
+			}
+			b.WriteString(line + "\n")
+			index++
+		}
+	}
+
 	b.WriteString("\n")
 }
 
@@ -813,7 +888,12 @@ func renderLogsView(b *strings.Builder, m Model) {
 	b.WriteString(titleStyle.Render("Logs"))
 	b.WriteString("\n")
 	b.WriteString(dimStyle.Render("Filter: firewalld/iptables, zone " + zone + " (best effort)"))
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	if m.logDenied == "off" {
+		b.WriteString(warnStyle.Render("LogDenied is off, so denied packets are not logged. Alt+S to change it."))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
 	if m.logLoading {
 		b.WriteString(dimStyle.Render("Starting log stream..."))
@@ -892,6 +972,94 @@ func renderIPSetsView(b *strings.Builder, m Model) {
 	}
 }
 
+func renderPoliciesView(b *strings.Builder, m Model) {
+	if m.policiesDenied {
+		b.WriteString(warnStyle.Render("No permission to read policies. Run with sudo."))
+		return
+	}
+	if m.policiesLoading {
+		b.WriteString(dimStyle.Render("Loading policies..."))
+		return
+	}
+	if m.policiesErr != nil {
+		b.WriteString(warnStyle.Render(fmt.Sprintf("Error: %v", m.policiesErr)))
+		return
+	}
+	if len(m.policies) == 0 {
+		b.WriteString(dimStyle.Render("  (none)"))
+		return
+	}
+
+	b.WriteString("Policies:\n")
+	for i, name := range m.policies {
+		line := highlightMatch(name, m.searchQuery)
+		if i == m.policyIndex {
+			if m.focus == focusMain {
+				line = selectedStyle.Render("  " + line)
+			} else {
+				line = selectedDimStyle.Render("  " + line)
+			}
+		} else {
+			line = "  " + line
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\nSettings")
+	if m.policyName != "" {
+		b.WriteString(" (" + m.policyName + ")")
+	}
+	b.WriteString(":\n")
+	if m.policyLoading {
+		b.WriteString(dimStyle.Render("  (loading)"))
+		return
+	}
+	if m.policyErr != nil {
+		b.WriteString(warnStyle.Render(fmt.Sprintf("  Error: %v", m.policyErr)))
+		return
+	}
+	p := m.policy
+	if p == nil {
+		b.WriteString(dimStyle.Render("  (none)"))
+		return
+	}
+
+	b.WriteString(fmt.Sprintf("  Zones: %s -> %s\n", joinOrNone(p.IngressZones), joinOrNone(p.EgressZones)))
+	b.WriteString("  Target: " + emptyAsNone(p.Target) + "\n")
+	b.WriteString(fmt.Sprintf("  Priority: %d\n", p.Priority))
+	b.WriteString("  Masquerade: " + onOff(p.Masquerade) + "\n")
+	b.WriteString("  Services: " + joinOrNone(p.Services) + "\n")
+	ports := make([]string, 0, len(p.Ports))
+	for _, port := range p.Ports {
+		ports = append(ports, port.Port+"/"+port.Protocol)
+	}
+	b.WriteString("  Ports: " + joinOrNone(ports) + "\n")
+	if len(p.Protocols) > 0 {
+		b.WriteString("  Protocols: " + strings.Join(p.Protocols, ", ") + "\n")
+	}
+	if len(p.IcmpBlocks) > 0 {
+		b.WriteString("  ICMP Blocks: " + strings.Join(p.IcmpBlocks, ", ") + "\n")
+	}
+	b.WriteString("  Rich Rules:\n")
+	if len(p.RichRules) == 0 {
+		b.WriteString(dimStyle.Render("    (none)"))
+		b.WriteString("\n")
+	}
+	for _, r := range p.RichRules {
+		b.WriteString("    - " + r + "\n")
+	}
+	if p.Description != "" {
+		b.WriteString("  Description: " + p.Description + "\n")
+	}
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "(none)"
+	}
+	return strings.Join(items, ", ")
+}
+
 func attachedIPSets(zone *firewalld.Zone) map[string]struct{} {
 	if zone == nil {
 		return nil
@@ -919,22 +1087,30 @@ func renderInfoView(b *strings.Builder, m Model, current *firewalld.Zone) {
 		desc = "(none)"
 	}
 
-	b.WriteString("Target: " + highlightMatch(target, m.searchQuery) + "\n")
+	b.WriteString("Target: " + highlightMatch(target, m.searchQuery) + " (e to edit)\n")
 	b.WriteString("ICMP Inversion: ")
 	if current.IcmpInvert {
-		b.WriteString("ON\n")
+		b.WriteString("ON")
 	} else {
-		b.WriteString("OFF\n")
+		b.WriteString("OFF")
 	}
+	b.WriteString(" (v to toggle)\n")
 
 	b.WriteString("\nICMP Blocks:\n")
 	if len(current.IcmpBlocks) == 0 {
 		b.WriteString(dimStyle.Render("  (none)"))
 		b.WriteString("\n")
 	} else {
-		for _, r := range current.IcmpBlocks {
-			line := highlightMatch(r, m.searchQuery)
-			b.WriteString("  - " + line + "\n")
+		for i, r := range current.IcmpBlocks {
+			line := "  - " + highlightMatch(r, m.searchQuery)
+			if i == m.icmpIndex {
+				if m.focus == focusMain {
+					line = selectedStyle.Render(line)
+				} else {
+					line = selectedDimStyle.Render(line)
+				}
+			}
+			b.WriteString(line + "\n")
 		}
 	}
 
@@ -1002,99 +1178,171 @@ func renderServiceDetails(b *strings.Builder, m Model) {
 		}
 	}
 
+	if len(info.Protocols) > 0 {
+		b.WriteString("\nProtocols: " + strings.Join(info.Protocols, ", ") + "\n")
+	}
+	if len(info.SourcePorts) > 0 {
+		b.WriteString("\nSource ports: " + formatPortList(info.SourcePorts) + "\n")
+	}
+	for _, family := range []string{"ipv4", "ipv6"} {
+		if addr := info.Destinations[family]; addr != "" {
+			b.WriteString("\nDestination (" + family + "): " + addr + "\n")
+		}
+	}
+	if len(info.Includes) > 0 {
+		b.WriteString("\nIncludes: " + strings.Join(info.Includes, ", ") + "\n")
+	}
+	if len(info.Helpers) > 0 {
+		b.WriteString("\nHelpers: " + strings.Join(info.Helpers, ", ") + "\n")
+	}
+	if info.Builtin {
+		b.WriteString("\n" + dimStyle.Render("Shipped with firewalld; edits are saved as a local override.") + "\n")
+	}
+
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("Press Enter or Esc to close"))
+	b.WriteString(dimStyle.Render("e edit, n new service, D delete custom service, H helpers, Enter or Esc to close"))
 }
 
 func renderHelp(b *strings.Builder, m Model) {
 	b.WriteString(titleStyle.Render("Help"))
 	b.WriteString("\n\n")
 
-	b.WriteString("Global:\n")
-	b.WriteString("  ?           Toggle help\n")
-	b.WriteString("  q / Ctrl+C  Quit\n\n")
-	b.WriteString("  --dry-run/-n  Start in dry-run mode\n\n")
+	b.WriteString("Command line:\n")
+	b.WriteString("  --dry-run/-n  Start in dry-run mode\n")
 	b.WriteString("  --log-level   Set log level (debug|info|warn|error)\n")
-	b.WriteString("  --no-color    Disable color output\n\n")
-	b.WriteString("  Docs: README.md\n\n")
-
-	b.WriteString("Navigation:\n")
-	b.WriteString("  Tab         Switch focus\n")
-	b.WriteString("  j/k         Move selection\n")
-	b.WriteString("  1-6         Switch tabs\n")
-	b.WriteString("  h/l         Prev/next tab\n\n")
-
-	b.WriteString("View:\n")
-	b.WriteString("  P           Toggle runtime/permanent\n")
-	b.WriteString("  S           Split diff view\n")
-	b.WriteString("  L           Toggle logs\n")
-	b.WriteString("  r           Refresh data\n\n")
-
-	b.WriteString("Actions:\n")
-	b.WriteString("  n (zones)   New zone\n")
-	b.WriteString("  d (zones)   Delete zone\n")
-	b.WriteString("  D (zones)   Set default zone\n")
-	b.WriteString("  a (main)    Add service/port\n")
-	b.WriteString("  d (main)    Remove service/port\n")
-	b.WriteString("  e           Edit rich rule\n")
-	b.WriteString("  m           Toggle masquerade\n")
-	b.WriteString("  i           Add interface\n")
-	b.WriteString("  s           Add source\n")
-	b.WriteString("  c           Commit runtime -> permanent\n")
-	b.WriteString("  u           Reload (revert runtime)\n")
-	b.WriteString("  t           Apply template\n")
-	b.WriteString("  Alt+P       Panic mode (type YES)\n")
-	b.WriteString("  Ctrl+R      Backup restore menu\n")
-	b.WriteString("  Ctrl+B      Create backup\n")
-	b.WriteString("  Ctrl+E      Export zone (JSON/XML)\n")
-	b.WriteString("  Alt+I       Import zone (JSON/XML)\n")
-	b.WriteString("  Ctrl+Z/Y    Undo / Redo\n")
-	b.WriteString("  Tab         Autocomplete (export/import/service)\n")
-	b.WriteString("  Enter       Service details\n\n")
-	b.WriteString("  n (ipsets)  New IPSet (permanent)\n")
-	b.WriteString("  a (ipsets)  Add entry\n")
-	b.WriteString("  d (ipsets)  Remove entry\n\n")
-	b.WriteString("  D (ipsets)  Delete IPSet\n\n")
-
-	b.WriteString("Search:\n")
-	b.WriteString("  /           Search current tab\n")
-	b.WriteString("  n / N       Next/prev match\n\n")
-
-	b.WriteString("Indicators:\n")
+	b.WriteString("  --no-color    Disable color output\n")
+	b.WriteString("  Docs: README.md\n")
+
+	// Configurable keys, from the active keymap ([keys] in config.toml).
+	section := ""
+	for _, binding := range m.keys.Bindings() {
+		if binding.Section != section {
+			section = binding.Section
+			b.WriteString("\n" + section + ":\n")
+		}
+		writeHelpLine(b, m.keys.Label(binding.Action), binding.Help)
+	}
+
+	key := m.keys.Label
+	b.WriteString("\nScreens and prompts:\n")
+	writeHelpLine(b, "Enter", "Service details; confirm prompts")
+	writeHelpLine(b, "Esc", "Close screen or prompt")
+	writeHelpLine(b, "Tab", "Autocomplete (export/import/service)")
+	writeHelpLine(b, "y / n", "Keep / revert a change to the SSH zone")
+	writeHelpLine(b, "e (details)", "Edit service definition")
+	writeHelpLine(b, "n (details)", "New custom service")
+	writeHelpLine(b, "D (details)", "Delete custom service")
+	writeHelpLine(b, "H (details)", "Conntrack helpers (n new, D delete custom)")
+	writeHelpLine(b, key(keymap.Add)+" (policies)", "Edit: service http, -port 80/tcp, ingress z, target ACCEPT")
+	writeHelpLine(b, key(keymap.Add)+" (direct)", "Add: chain ipv4 filter X, rule ipv4 filter INPUT 0 ..., passthrough ipv4 ...")
+	writeHelpLine(b, key(keymap.Remove)+" (direct)", "Remove selected (prefills -)")
+	writeHelpLine(b, key(keymap.IcmpTypes)+" (info)", "ICMP type browser: Enter block, n new, D delete custom")
+
+	b.WriteString("\nIndicators:\n")
 	b.WriteString("  [A]         Active zone\n")
 	b.WriteString("  [D]         Default zone\n")
 	b.WriteString("  *           Runtime-only item\n")
 	b.WriteString("  ~           Modified item\n")
-	b.WriteString("  + / -       Added/removed (split view)\n\n")
+	b.WriteString("  + / -       Added/removed (split view)\n")
 	b.WriteString("  [Z]         IPSet attached to zone\n\n")
 
-	b.WriteString(dimStyle.Render("Press Esc or ? to close"))
+	b.WriteString(dimStyle.Render(fmt.Sprintf("Press Esc or %s to close", key(keymap.Help))))
+}
+
+func writeHelpLine(b *strings.Builder, keys, text string) {
+	fmt.Fprintf(b, "  %-11s %s\n", keys, text)
 }
 
 func renderTemplates(b *strings.Builder, m Model) {
+	if m.templatePlan != nil {
+		renderTemplatePreview(b, m, *m.templatePlan)
+		return
+	}
 	b.WriteString(titleStyle.Render("Apply Template"))
 	b.WriteString("\n\n")
-	for i, tpl := range defaultTemplates {
+	list := m.templateList()
+	for i, tpl := range list {
 		line := tpl.Name
+		if tpl.Origin != "built-in" {
+			line += dimStyle.Render("  (" + tpl.Origin + ")")
+		}
 		if i == m.templateIndex {
-			line = selectedStyle.Render("  " + line)
+			line = selectedStyle.Render("  "+tpl.Name) + strings.TrimPrefix(line, tpl.Name)
 		} else {
 			line = "  " + line
 		}
 		b.WriteString(line + "\n")
 	}
 
-	if m.templateIndex >= 0 && m.templateIndex < len(defaultTemplates) {
-		desc := defaultTemplates[m.templateIndex].Description
-		if desc != "" {
+	if m.templateIndex >= 0 && m.templateIndex < len(list) {
+		tpl := list[m.templateIndex]
+		if tpl.Description != "" {
 			b.WriteString("\n")
-			b.WriteString(dimStyle.Render(desc))
+			b.WriteString(dimStyle.Render(tpl.Description))
 			b.WriteString("\n")
 		}
+		b.WriteString("\n")
+		for _, line := range templateContents(tpl) {
+			b.WriteString("  " + line + "\n")
+		}
 	}
 
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("Enter to apply, Esc to cancel"))
+	b.WriteString(dimStyle.Render("Enter to preview, Esc to cancel"))
+}
+
+// templateContents lists a template's elements, parameters unexpanded.
+func templateContents(tpl templates.Template) []string {
+	var lines []string
+	add := func(label string, items []string) {
+		if len(items) > 0 {
+			lines = append(lines, label+": "+strings.Join(items, ", "))
+		}
+	}
+	var params []string
+	for _, p := range tpl.Parameters() {
+		params = append(params, "${"+p.Name+"}")
+	}
+	add("Parameters", params)
+	add("Services", tpl.Services)
+	add("Ports", tpl.Ports)
+	for _, r := range tpl.RichRules {
+		lines = append(lines, "Rich rule: "+r)
+	}
+	add("Sources", tpl.Sources)
+	add("IPSets", tpl.IPSets)
+	if tpl.Masquerade {
+		lines = append(lines, "Masquerade: on")
+	}
+	if tpl.Target != "" {
+		lines = append(lines, "Target: "+tpl.Target)
+	}
+	return lines
+}
+
+func renderTemplatePreview(b *strings.Builder, m Model, plan templatePlan) {
+	zone := ""
+	if len(m.zones) > 0 && m.selected < len(m.zones) {
+		zone = m.zones[m.selected]
+	}
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Apply %s to %s (%s)", plan.name, zone, modeLabel(m.permanent))))
+	b.WriteString("\n\n")
+	changes := plan.changes()
+	if len(changes) == 0 {
+		b.WriteString(dimStyle.Render("Nothing to add: the zone already has everything in this template."))
+		b.WriteString("\n")
+	}
+	for _, c := range changes {
+		b.WriteString(addedStyle.Render("+ "+c) + "\n")
+	}
+	for _, p := range plan.present {
+		b.WriteString(dimStyle.Render("  "+p+" (already present)") + "\n")
+	}
+	for _, s := range plan.skipped {
+		b.WriteString(warnStyle.Render("  skipped: "+s) + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("Enter to apply, Esc to go back"))
 }
 
 func renderBackupView(b *strings.Builder, m Model) {
@@ -1207,12 +1455,20 @@ func renderInput(m Model) string {
 		label = "Add interface (" + mode + "): "
 	case inputAddSource:
 		label = "Add source (" + mode + "): "
+	case inputAddForwardPort:
+		label = "Add forward port (" + mode + "): "
 	case inputAddZone:
 		label = "Add zone: "
 	case inputDeleteZone:
 		label = "Delete zone (type name): "
 	case inputPanicConfirm:
 		label = "PANIC confirm: "
+	case inputLockoutConfirm:
+		label = "Apply anyway: "
+	case inputSetTarget:
+		label = "Set target (permanent): "
+	case inputTemplateParam:
+		label = m.templateParamLabel()
 	case inputExportZone:
 		label = "Export path: "
 	case inputImportZone:
@@ -1229,6 +1485,30 @@ func renderInput(m Model) string {
 		label = "Delete IPSet: "
 	case inputManualBackup:
 		label = "Backup description: "
+	case inputAddPolicy:
+		label = "Add policy: "
+	case inputEditPolicy:
+		label = "Edit policy (" + mode + "): "
+	case inputDeletePolicy:
+		label = "Delete policy: "
+	case inputDeleteService:
+		label = "Delete service: "
+	case inputAddIcmpType:
+		label = "New ICMP type (permanent): "
+	case inputDeleteIcmpType:
+		label = "Delete ICMP type: "
+	case inputAddHelper:
+		label = "New helper (permanent): "
+	case inputDeleteHelper:
+		label = "Delete helper: "
+	case inputEditDirect:
+		label = "Direct (" + mode + "): "
+	case inputAddWhitelist:
+		label = "Add whitelist entry (" + mode + "): "
+	case inputLockdownConfirm:
+		label = "Lockdown confirm: "
+	case inputSetting:
+		label = m.editSetting + " (firewalld.conf): "
 	}
 	return inputStyle.Render(label) + m.input.View()
 }
@@ -1241,43 +1521,75 @@ func renderStatus(m Model) string {
 		shortMode = "Perm"
 	}
 
+	key := m.keys.Label
 	contextHints := []statusHint{
-		{key: "a", label: "add"},
-		{key: "d", label: "delete"},
-		{key: "c", label: "commit"},
-		{key: "u", label: "revert"},
+		{key: key(keymap.Add), label: "add"},
+		{key: key(keymap.Remove), label: "delete"},
+		{key: key(keymap.Commit), label: "commit"},
+		{key: key(keymap.Reload), label: "revert"},
 	}
 	if m.focus == focusZones {
 		contextHints = []statusHint{
-			{key: "n", label: "new zone"},
-			{key: "d", label: "delete zone"},
-			{key: "D", label: "default"},
+			{key: key(keymap.NewOrNext), label: "new zone"},
+			{key: key(keymap.Remove), label: "delete zone"},
+			{key: key(keymap.DefaultOrDelete), label: "default"},
 		}
 	} else if m.tab == tabIPSets {
 		contextHints = []statusHint{
-			{key: "n", label: "new ipset"},
-			{key: "a", label: "add entry"},
-			{key: "d", label: "remove entry"},
-			{key: "D", label: "delete ipset"},
+			{key: key(keymap.NewOrNext), label: "new ipset"},
+			{key: key(keymap.Add), label: "add entry"},
+			{key: key(keymap.Remove), label: "remove entry"},
+			{key: key(keymap.DefaultOrDelete), label: "delete ipset"},
+		}
+	} else if m.tab == tabInfo {
+		contextHints = []statusHint{
+			{key: key(keymap.Add), label: "block icmp"},
+			{key: key(keymap.Remove), label: "unblock"},
+			{key: key(keymap.Edit), label: "target"},
+			{key: key(keymap.IcmpInversion), label: "inversion"},
+			{key: key(keymap.IcmpTypes), label: "icmp types"},
+		}
+	} else if m.tab == tabPolicies {
+		contextHints = []statusHint{
+			{key: key(keymap.NewOrNext), label: "new policy"},
+			{key: key(keymap.Add) + "/" + key(keymap.Edit), label: "edit"},
+			{key: key(keymap.Remove), label: "remove item"},
+			{key: key(keymap.DefaultOrDelete), label: "delete policy"},
+		}
+	} else if m.tab == tabDirect {
+		contextHints = []statusHint{
+			{key: key(keymap.Add), label: "add"},
+			{key: key(keymap.Remove), label: "remove"},
+			{key: key(keymap.Commit), label: "commit"},
+			{key: key(keymap.Reload), label: "revert"},
 		}
 	}
 
 	rightHints := []statusHint{
-		{key: "/", label: "search"},
-		{key: "?", label: "help"},
-		{key: "q", label: "quit"},
+		{key: key(keymap.Search), label: "search"},
+		{key: key(keymap.Help), label: "help"},
+		{key: key(keymap.Quit), label: "quit"},
 	}
 	if m.searchQuery != "" {
-		rightHints = append([]statusHint{{key: "n/N", label: "next"}}, rightHints...)
+		rightHints = append([]statusHint{{key: key(keymap.NewOrNext) + "/" + key(keymap.PrevMatch), label: "next"}}, rightHints...)
 	}
 
 	badges := []string{}
 	if m.panicMode {
 		badges = append(badges, statusKeyStyle.Render("[PANIC]"))
 	}
+	if m.lockdown {
+		badges = append(badges, statusKeyStyle.Render("[LOCKDOWN]"))
+	}
+	if m.safety != nil {
+		badges = append(badges, statusKeyStyle.Render(fmt.Sprintf("[CONFIRM %ds]", m.safety.remaining)))
+	}
 	if m.dryRun {
 		badges = append(badges, statusKeyStyle.Render("[DRY]"))
 	}
+	if m.offlineRoot != "" {
+		badges = append(badges, statusKeyStyle.Render("[OFFLINE]"))
+	}
 	if m.readOnly {
 		badges = append(badges, statusKeyStyle.Render("[RO]"))
 	}
@@ -1304,9 +1616,9 @@ func renderStatus(m Model) string {
 		}
 		if includeTemplate {
 			if m.readOnly {
-				parts = append(parts, statusMutedStyle.Render("t: templates [RO]"))
+				parts = append(parts, statusMutedStyle.Render(key(keymap.Templates)+": templates [RO]"))
 			} else {
-				parts = append(parts, renderStatusHints([]statusHint{{key: "t", label: "templates"}}, false))
+				parts = append(parts, renderStatusHints([]statusHint{{key: key(keymap.Templates), label: "templates"}}, false))
 			}
 		}
 		return joinStatusSegments(parts)
@@ -1368,7 +1680,7 @@ func renderStatus(m Model) string {
 	}
 
 	legendParts := []string{}
-	if m.tab != tabIPSets {
+	if m.tab != tabIPSets && m.tab != tabPolicies {
 		if m.splitView {
 			legendParts = append(legendParts, statusMutedStyle.Render("Legend: + added  - removed  ~ modified"))
 		} else if !m.permanent {
@@ -1379,6 +1691,7 @@ func renderStatus(m Model) string {
 		legendParts = append(legendParts, renderStatusHints([]statusHint{
 			{key: "i", label: "add interface"},
 			{key: "s", label: "add source"},
+			{key: "f", label: "add forward port"},
 			{key: "d", label: "remove selected"},
 		}, false))
 	}